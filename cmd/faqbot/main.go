@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/bot"
+)
+
+func main() {
+	// The config file, .env and the environment are merged and validated
+	// before anything else so LOG_* settings apply to the logger
+	config, err := bot.LoadConfig()
+	if err != nil {
+		bot.SetupLogger("").WithError(err).Fatal("Invalid configuration")
+	}
+
+	logger := bot.SetupLogger(config.Logging.Level)
+	bot.SetupLogFile(logger, config)
+
+	if config.File() != "" {
+		logger.WithField("file", config.File()).Info("Loaded config file")
+	}
+
+	botToken := config.Telegram.Token
+	if botToken == "" {
+		logger.Fatal("TELEGRAM_BOT_TOKEN (telegram.token) is required")
+	}
+
+	if config.Telegram.AdminID == 0 {
+		logger.Fatal("ADMIN_ID (telegram.admin_id) is required")
+	}
+
+	api, err := tgbotapi.NewBotAPI(botToken)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to create bot API instance")
+	}
+
+	api.Debug = false
+
+	flushSentry, err := bot.SetupSentry(logger, config)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to initialize Sentry")
+	}
+	defer flushSentry()
+
+	shutdownTracing, err := bot.SetupTracing(context.Background(), config)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to set up tracing")
+	}
+	defer shutdownTracing(context.Background())
+
+	faqBot, err := bot.New(api, config, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to set up bot")
+	}
+
+	if err := faqBot.Run(); err != nil {
+		logger.WithError(err).Fatal("Bot stopped")
+	}
+}
@@ -0,0 +1,6 @@
+// Package faqbotpb contains the gRPC API served by the bot when GRPC_ADDR is
+// set. Other Go services can import it to list open tickets, stream new
+// questions and send answers.
+package faqbotpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative faqbot.proto
@@ -0,0 +1,217 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: faqbot.proto
+
+package faqbotpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	FAQBot_ListSessions_FullMethodName   = "/faqbot.v1.FAQBot/ListSessions"
+	FAQBot_WatchQuestions_FullMethodName = "/faqbot.v1.FAQBot/WatchQuestions"
+	FAQBot_SendAnswer_FullMethodName     = "/faqbot.v1.FAQBot/SendAnswer"
+)
+
+// FAQBotClient is the client API for FAQBot service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FAQBotClient interface {
+	// ListSessions returns every open ticket, urgent first, then oldest first.
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	// WatchQuestions streams tickets as they are created.
+	WatchQuestions(ctx context.Context, in *WatchQuestionsRequest, opts ...grpc.CallOption) (FAQBot_WatchQuestionsClient, error)
+	// SendAnswer delivers an answer to the user and closes the ticket.
+	SendAnswer(ctx context.Context, in *SendAnswerRequest, opts ...grpc.CallOption) (*SendAnswerResponse, error)
+}
+
+type fAQBotClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFAQBotClient(cc grpc.ClientConnInterface) FAQBotClient {
+	return &fAQBotClient{cc}
+}
+
+func (c *fAQBotClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, FAQBot_ListSessions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fAQBotClient) WatchQuestions(ctx context.Context, in *WatchQuestionsRequest, opts ...grpc.CallOption) (FAQBot_WatchQuestionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &FAQBot_ServiceDesc.Streams[0], FAQBot_WatchQuestions_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &fAQBotWatchQuestionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type FAQBot_WatchQuestionsClient interface {
+	Recv() (*Session, error)
+	grpc.ClientStream
+}
+
+type fAQBotWatchQuestionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *fAQBotWatchQuestionsClient) Recv() (*Session, error) {
+	m := new(Session)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *fAQBotClient) SendAnswer(ctx context.Context, in *SendAnswerRequest, opts ...grpc.CallOption) (*SendAnswerResponse, error) {
+	out := new(SendAnswerResponse)
+	err := c.cc.Invoke(ctx, FAQBot_SendAnswer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// FAQBotServer is the server API for FAQBot service.
+// All implementations must embed UnimplementedFAQBotServer
+// for forward compatibility
+type FAQBotServer interface {
+	// ListSessions returns every open ticket, urgent first, then oldest first.
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	// WatchQuestions streams tickets as they are created.
+	WatchQuestions(*WatchQuestionsRequest, FAQBot_WatchQuestionsServer) error
+	// SendAnswer delivers an answer to the user and closes the ticket.
+	SendAnswer(context.Context, *SendAnswerRequest) (*SendAnswerResponse, error)
+	mustEmbedUnimplementedFAQBotServer()
+}
+
+// UnimplementedFAQBotServer must be embedded to have forward compatible implementations.
+type UnimplementedFAQBotServer struct {
+}
+
+func (UnimplementedFAQBotServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedFAQBotServer) WatchQuestions(*WatchQuestionsRequest, FAQBot_WatchQuestionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchQuestions not implemented")
+}
+func (UnimplementedFAQBotServer) SendAnswer(context.Context, *SendAnswerRequest) (*SendAnswerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendAnswer not implemented")
+}
+func (UnimplementedFAQBotServer) mustEmbedUnimplementedFAQBotServer() {}
+
+// UnsafeFAQBotServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FAQBotServer will
+// result in compilation errors.
+type UnsafeFAQBotServer interface {
+	mustEmbedUnimplementedFAQBotServer()
+}
+
+func RegisterFAQBotServer(s grpc.ServiceRegistrar, srv FAQBotServer) {
+	s.RegisterService(&FAQBot_ServiceDesc, srv)
+}
+
+func _FAQBot_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FAQBotServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FAQBot_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FAQBotServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FAQBot_WatchQuestions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchQuestionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FAQBotServer).WatchQuestions(m, &fAQBotWatchQuestionsServer{stream})
+}
+
+type FAQBot_WatchQuestionsServer interface {
+	Send(*Session) error
+	grpc.ServerStream
+}
+
+type fAQBotWatchQuestionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *fAQBotWatchQuestionsServer) Send(m *Session) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _FAQBot_SendAnswer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendAnswerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FAQBotServer).SendAnswer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FAQBot_SendAnswer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FAQBotServer).SendAnswer(ctx, req.(*SendAnswerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FAQBot_ServiceDesc is the grpc.ServiceDesc for FAQBot service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FAQBot_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "faqbot.v1.FAQBot",
+	HandlerType: (*FAQBotServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSessions",
+			Handler:    _FAQBot_ListSessions_Handler,
+		},
+		{
+			MethodName: "SendAnswer",
+			Handler:    _FAQBot_SendAnswer_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchQuestions",
+			Handler:       _FAQBot_WatchQuestions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "faqbot.proto",
+}
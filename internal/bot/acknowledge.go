@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+func acknowledgeButton(ticketID int) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData("👀 Acknowledge", fmt.Sprintf("ack:%d", ticketID))
+}
+
+// handleAcknowledgeCallback processes the "ack:<ticket_id>" button on admin
+// notifications: the admin claims the ticket, it is marked 👀 in /sessions
+// and, with ACKNOWLEDGE_NOTIFY_USER, the user is told their question is
+// being looked at. Only the first press counts.
+func (b *Bot) handleAcknowledgeCallback(callback *tgbotapi.CallbackQuery) {
+	ticketID, err := strconv.Atoi(strings.TrimPrefix(callback.Data, "ack:"))
+	if err != nil {
+		b.logger.WithError(err).WithField("callback_data", callback.Data).Error("Malformed acknowledge callback")
+		return
+	}
+
+	var reply string
+	session := b.findOpenTicket(fmt.Sprintf("#%d", ticketID))
+	switch {
+	case session == nil:
+		reply = fmt.Sprintf("Ticket #%d is already closed", ticketID)
+	case !session.AcknowledgedAt.IsZero():
+		reply = fmt.Sprintf("Ticket #%d was already acknowledged on %s", ticketID, session.AcknowledgedAt.Format("2006-01-02 15:04"))
+	default:
+		reply = b.acknowledge(session)
+	}
+
+	msg := tgbotapi.NewMessage(b.adminID, reply)
+	if callback.Message != nil {
+		msg.ReplyToMessageID = callback.Message.MessageID
+	}
+	_, err = b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send acknowledge confirmation")
+	}
+}
+
+func (b *Bot) acknowledge(session *UserSession) string {
+	session.AcknowledgedAt = time.Now()
+	if err := b.store.AcknowledgeTicket(session.TicketID, session.AcknowledgedAt); err != nil {
+		b.logger.WithError(err).WithField("ticket_id", session.TicketID).Error("Failed to persist acknowledged ticket")
+	}
+
+	b.audit.Record(AuditTicketAcknowledged, b.adminID, logrus.Fields{
+		"user_id":   session.UserID,
+		"ticket_id": session.TicketID,
+	})
+
+	// Group questions are answered in the group, a private message about
+	// them would come out of nowhere
+	if !b.acknowledgeNotify || session.Group != nil {
+		return fmt.Sprintf("👀 Ticket #%d acknowledged", session.TicketID)
+	}
+
+	msg := telegram.NewMarkdownMessage(session.UserID, b.trMarkdown(session.UserID, "question_seen", map[string]interface{}{"TicketID": session.TicketID}))
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.WithError(err).WithField("user_id", session.UserID).Error("Failed to tell user their question was seen")
+		return fmt.Sprintf("👀 Ticket #%d acknowledged, but the user could not be told: %s", session.TicketID, telegram.DeliveryFailure(err))
+	}
+
+	return fmt.Sprintf("👀 Ticket #%d acknowledged, the user was told their question is being looked at", session.TicketID)
+}
@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+const (
+	ticketStatusOpen     = "open"
+	ticketStatusAnswered = "answered"
+	// ticketStatusExpired marks an unanswered ticket that is no longer open
+	// because the admin archived it, so it can no longer be answered.
+	ticketStatusExpired = "expired"
+)
+
+// apiTicket is the JSON representation of a ticket served by the REST API.
+type apiTicket struct {
+	storage.TicketRecord
+	Status string `json:"status"`
+	Urgent bool   `json:"urgent,omitempty"`
+}
+
+func (b *Bot) apiTicketFromRecord(ticket storage.TicketRecord) apiTicket {
+	view := apiTicket{TicketRecord: ticket, Status: ticketStatusExpired}
+	if session, exists := b.tickets[ticket.ID]; exists {
+		view.Status = ticketStatusOpen
+		view.Urgent = session.Urgent
+	} else if !ticket.AnsweredAt.IsZero() {
+		view.Status = ticketStatusAnswered
+	}
+
+	return view
+}
+
+// startAPIServer serves the ticket management API on API_ADDR. Every request
+// must carry "Authorization: Bearer <API_TOKEN>".
+//
+//	GET  /api/tickets?status=open|answered|expired|all
+//	GET  /api/tickets/{id}
+//	POST /api/tickets/{id}/answer  {"answer": "..."}
+func (b *Bot) startAPIServer() {
+	addr := b.config.Features.API.Addr
+	if addr == "" {
+		return
+	}
+
+	token := b.config.Features.API.Token
+	if token == "" {
+		b.logger.Fatal("API_TOKEN is required when API_ADDR is set")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tickets", b.apiListTickets)
+	mux.HandleFunc("GET /api/tickets/{id}", b.apiGetTicket)
+	mux.HandleFunc("POST /api/tickets/{id}/answer", b.apiAnswerTicket)
+
+	go func() {
+		err := http.ListenAndServe(addr, requireBearerToken(token, mux))
+		if err != nil {
+			b.logger.WithError(err).WithField("addr", addr).Error("API server stopped")
+		}
+	}()
+}
+
+func requireBearerToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			writeAPIError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func (b *Bot) apiListTickets(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = ticketStatusOpen
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tickets := []apiTicket{}
+	for _, ticket := range b.store.Tickets() {
+		view := b.apiTicketFromRecord(ticket)
+		if status == "all" || view.Status == status {
+			tickets = append(tickets, view)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, tickets)
+}
+
+func (b *Bot) apiGetTicket(w http.ResponseWriter, r *http.Request) {
+	ticketID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid ticket id")
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ticket, exists := b.store.Ticket(ticketID)
+	if !exists {
+		writeAPIError(w, http.StatusNotFound, "ticket not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, b.apiTicketFromRecord(ticket))
+}
+
+func (b *Bot) apiAnswerTicket(w http.ResponseWriter, r *http.Request) {
+	ticketID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid ticket id")
+		return
+	}
+
+	var request struct {
+		Answer string `json:"answer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || strings.TrimSpace(request.Answer) == "" {
+		writeAPIError(w, http.StatusBadRequest, `body must be {"answer": "<non-empty text>"}`)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	session, exists := b.tickets[ticketID]
+	if !exists {
+		if _, known := b.store.Ticket(ticketID); known {
+			writeAPIError(w, http.StatusConflict, "ticket is not open")
+		} else {
+			writeAPIError(w, http.StatusNotFound, "ticket not found")
+		}
+		return
+	}
+
+	b.deliverAnswer(session, request.Answer)
+	if _, stillOpen := b.tickets[ticketID]; stillOpen {
+		writeAPIError(w, http.StatusBadGateway, "failed to deliver the answer through Telegram")
+		return
+	}
+
+	ticket, _ := b.store.Ticket(ticketID)
+	writeJSON(w, http.StatusOK, b.apiTicketFromRecord(ticket))
+}
@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const archivedListLimit = 20
+
+// parseTicketID parses a ticket ID such as "42" or "#42".
+func parseTicketID(value string) (int, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(value), "#"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid ticket ID %q", value)
+	}
+
+	return id, nil
+}
+
+// archiveTicket handles /archive <ticket_id>. An archived ticket leaves
+// /sessions and the queue, even if it is still unanswered, but stays in the
+// history and is never purged by retention.
+func (b *Bot) archiveTicket(args string) {
+	ticketID, err := parseTicketID(args)
+	if err != nil {
+		b.sendArchiveReply(fmt.Sprintf("❌ %v\n\nUsage: /archive <ticket_id>", err))
+		return
+	}
+
+	var reply string
+	ticket, archived, err := b.store.ArchiveTicket(ticketID, time.Now())
+	switch {
+	case err != nil:
+		b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to archive ticket")
+		reply = fmt.Sprintf("❌ Failed to archive ticket #%d: %v", ticketID, err)
+	case ticket.ID == 0:
+		reply = fmt.Sprintf("❌ Ticket #%d not found", ticketID)
+	case !archived:
+		reply = fmt.Sprintf("Ticket #%d is already archived", ticketID)
+	default:
+		if session, open := b.tickets[ticketID]; open {
+			b.closeSession(session)
+		}
+		b.audit.Record(AuditTicketArchived, b.adminID, logrus.Fields{
+			"user_id":   ticket.UserID,
+			"ticket_id": ticketID,
+		})
+
+		reply = fmt.Sprintf("🗄 Ticket #%d archived. See /archived, or bring it back with /unarchive %d", ticketID, ticketID)
+		if ticket.AnsweredAt.IsZero() {
+			reply = fmt.Sprintf("🗄 Ticket #%d archived without an answer, it no longer shows in /sessions. Bring it back with /unarchive %d", ticketID, ticketID)
+		}
+	}
+
+	b.sendArchiveReply(reply)
+}
+
+// unarchiveTicket handles /unarchive <ticket_id>. An unanswered ticket is
+// open again and can be answered with /reply.
+func (b *Bot) unarchiveTicket(args string) {
+	ticketID, err := parseTicketID(args)
+	if err != nil {
+		b.sendArchiveReply(fmt.Sprintf("❌ %v\n\nUsage: /unarchive <ticket_id>", err))
+		return
+	}
+
+	var reply string
+	ticket, restored, err := b.store.UnarchiveTicket(ticketID)
+	switch {
+	case err != nil:
+		b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to unarchive ticket")
+		reply = fmt.Sprintf("❌ Failed to unarchive ticket #%d: %v", ticketID, err)
+	case ticket.ID == 0:
+		reply = fmt.Sprintf("❌ Ticket #%d not found", ticketID)
+	case !restored:
+		reply = fmt.Sprintf("Ticket #%d is not archived", ticketID)
+	case ticket.AnsweredAt.IsZero():
+		b.trackOpenTicket(sessionFromTicket(ticket))
+		b.updateQueuePositions()
+		reply = fmt.Sprintf("📂 Ticket #%d is open again, answer it with /reply %d <text>", ticketID, ticketID)
+	default:
+		reply = fmt.Sprintf("📂 Ticket #%d is no longer archived", ticketID)
+	}
+
+	b.sendArchiveReply(reply)
+}
+
+// showArchivedTickets lists the most recently archived tickets for
+// /archived.
+func (b *Bot) showArchivedTickets() {
+	archived := b.store.ArchivedTickets()
+	if len(archived) == 0 {
+		b.sendArchiveReply("🗄 No archived tickets. Archive one with /archive <ticket_id>")
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🗄 %d archived ticket(s):\n\n", len(archived)))
+	for _, ticket := range archived[:min(archivedListLimit, len(archived))] {
+		who := fmt.Sprintf("User ID %d", ticket.UserID)
+		if ticket.Username != "" {
+			who = "@" + ticket.Username
+		}
+		text.WriteString(fmt.Sprintf("#%d %s, archived %s\n❓ %s\n",
+			ticket.ID, who, ticket.ArchivedAt.Format("2006-01-02"), truncateText(ticket.Question, 150)))
+		if ticket.AnsweredAt.IsZero() {
+			text.WriteString("⏳ Not answered\n\n")
+		} else {
+			text.WriteString(fmt.Sprintf("💬 %s\n\n", truncateText(ticket.Answer, 200)))
+		}
+	}
+	if len(archived) > archivedListLimit {
+		text.WriteString(fmt.Sprintf("… and %d older ones\n\n", len(archived)-archivedListLimit))
+	}
+	text.WriteString("Bring a ticket back with /unarchive <ticket_id>")
+
+	b.sendArchiveReply(text.String())
+}
+
+func (b *Bot) sendArchiveReply(text string) {
+	_, err := b.api.SendLong(b.adminID, text, nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send archive reply")
+	}
+}
@@ -0,0 +1,350 @@
+package bot
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+// Reviewer is a team member the admin can assign tickets to. Reviewers get
+// the assigned tickets and their SLA reminders in their own chat and answer
+// by replying there; everything else stays with the admin.
+type Reviewer struct {
+	Name string
+	ID   int64
+}
+
+// reviewersFromConfig reads REVIEWERS, a comma-separated list of name:user_id
+// pairs such as "alice:123456,bob:789012", sorted by name.
+func reviewersFromConfig(config *Config, adminID int64) ([]Reviewer, error) {
+	names := slices.Sorted(maps.Keys(config.Team.Reviewers))
+
+	var reviewers []Reviewer
+	for _, configured := range names {
+		id := config.Team.Reviewers[configured]
+		entry := fmt.Sprintf("%s:%d", configured, id)
+		name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(configured), "@"))
+		if name == "" || id <= 0 {
+			return nil, fmt.Errorf("%q is not name:user_id", entry)
+		}
+		if id == adminID || name == "me" {
+			return nil, fmt.Errorf("%q: the admin is not a reviewer", entry)
+		}
+		for _, other := range reviewers {
+			if other.Name == name || other.ID == id {
+				return nil, fmt.Errorf("%q is listed twice", entry)
+			}
+		}
+
+		reviewers = append(reviewers, Reviewer{Name: name, ID: id})
+	}
+
+	return reviewers, nil
+}
+
+func (b *Bot) isReviewer(userID int64) bool {
+	_, exists := b.reviewerByID(userID)
+	return exists
+}
+
+func (b *Bot) reviewerByID(userID int64) (Reviewer, bool) {
+	for _, reviewer := range b.reviewers {
+		if reviewer.ID == userID {
+			return reviewer, true
+		}
+	}
+
+	return Reviewer{}, false
+}
+
+// assigneeName names who handles a ticket, e.g. "@alice" or "the admin".
+func (b *Bot) assigneeName(userID int64) string {
+	if userID == 0 || userID == b.adminID {
+		return "the admin"
+	}
+	if reviewer, exists := b.reviewerByID(userID); exists {
+		return "@" + reviewer.Name
+	}
+
+	return fmt.Sprintf("user ID %d", userID)
+}
+
+// notificationKeyboard is the markup of the admin's ticket notifications.
+// CV reviews also get the 📋 Review form.
+func (b *Bot) notificationKeyboard(session *UserSession) tgbotapi.InlineKeyboardMarkup {
+	ticketID := session.TicketID
+	keyboard := snoozeKeyboard(ticketID)
+	keyboard.InlineKeyboard = append([][]tgbotapi.InlineKeyboardButton{{acknowledgeButton(ticketID)}}, keyboard.InlineKeyboard...)
+	if session.State == StateCVReview {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(reviewFormButton(ticketID)))
+	}
+	if len(b.reviewers) > 0 {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👤 Assign", fmt.Sprintf("assign:%d", ticketID)),
+		))
+	}
+
+	return keyboard
+}
+
+// handleAssignCommand handles /assign <ticket_id> <@reviewer|me>, or shows
+// the assignments of a ticket for /assign <ticket_id>.
+func (b *Bot) handleAssignCommand(args string) {
+	fields := strings.Fields(args)
+	var reply string
+	ticketID, err := 0, error(nil)
+	if len(fields) > 0 {
+		ticketID, err = parseTicketID(fields[0])
+	}
+
+	switch {
+	case len(fields) == 0 || len(fields) > 2 || err != nil:
+		reply = "Usage: /assign <ticket_id> <@reviewer|me>\n\n/assign <ticket_id> shows who the ticket was assigned to."
+		if names := b.reviewerNames(); names != "" {
+			reply += "\n\nReviewers: " + names
+		}
+	case len(fields) == 1:
+		reply = b.describeAssignments(ticketID)
+	default:
+		assignee := int64(0)
+		name := strings.ToLower(strings.TrimPrefix(fields[1], "@"))
+		if name != "me" {
+			reviewer, found := b.reviewerByName(name)
+			if !found {
+				reply = fmt.Sprintf("❌ Unknown reviewer %q. Reviewers: %s", fields[1], b.reviewerNames())
+				break
+			}
+			assignee = reviewer.ID
+		}
+
+		session := b.findOpenTicket(fmt.Sprintf("#%d", ticketID))
+		if session == nil {
+			reply = fmt.Sprintf("❌ No open ticket #%d", ticketID)
+			break
+		}
+		reply = b.assignTicket(session, assignee)
+	}
+
+	_, err = b.api.SendLong(b.adminID, reply, nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send assign command reply")
+	}
+}
+
+func (b *Bot) reviewerByName(name string) (Reviewer, bool) {
+	for _, reviewer := range b.reviewers {
+		if reviewer.Name == name {
+			return reviewer, true
+		}
+	}
+
+	return Reviewer{}, false
+}
+
+// reviewerNames lists the reviewers for the admin, e.g. "@alice, @bob".
+func (b *Bot) reviewerNames() string {
+	names := make([]string, len(b.reviewers))
+	for i, reviewer := range b.reviewers {
+		names[i] = "@" + reviewer.Name
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// describeAssignments lists who a ticket was assigned to over time.
+func (b *Bot) describeAssignments(ticketID int) string {
+	ticket, exists := b.store.Ticket(ticketID)
+	if !exists {
+		return fmt.Sprintf("❌ Ticket #%d not found", ticketID)
+	}
+	if len(ticket.Assignments) == 0 {
+		return fmt.Sprintf("Ticket #%d was never assigned, the admin handles it", ticketID)
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("👤 Assignments of ticket #%d:\n\n", ticketID))
+	for _, assignment := range ticket.Assignments {
+		text.WriteString(fmt.Sprintf("• %s: %s\n", assignment.At.Format("2006-01-02 15:04"), b.assigneeName(assignment.To)))
+	}
+
+	return text.String()
+}
+
+// assignTicket hands an open ticket to a reviewer, or back to the admin
+// with a zero assignee, and returns the reply for the admin. The reviewer
+// gets the ticket in their chat; from then on only they are reminded about
+// it.
+func (b *Bot) assignTicket(session *UserSession, assignee int64) string {
+	if session.AssignedTo == assignee {
+		return fmt.Sprintf("Ticket #%d is already assigned to %s", session.TicketID, b.assigneeName(assignee))
+	}
+
+	var messageIDs []int
+	if assignee != 0 {
+		sent, err := b.api.SendLong(assignee, b.assignmentText(session), nil)
+		if err != nil {
+			b.logger.WithError(err).WithField("reviewer_id", assignee).Error("Failed to forward assigned ticket")
+			return fmt.Sprintf("❌ Could not send ticket #%d to %s, they must start a chat with the bot first: %v",
+				session.TicketID, b.assigneeName(assignee), err)
+		}
+		for _, message := range sent {
+			messageIDs = append(messageIDs, message.MessageID)
+		}
+	}
+	var note string
+	if assignee != 0 && b.anonymizesAuthor(session) {
+		note = b.shareAnonymizedCV(assignee, session)
+	}
+
+	logged := assignee
+	if logged == 0 {
+		logged = b.adminID
+	}
+	err := b.store.AssignTicket(session.TicketID, assignee, messageIDs, storage.TicketAssignment{To: logged, At: time.Now()})
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", session.TicketID).Error("Failed to persist ticket assignment")
+	}
+
+	previous := session.AssignedTo
+	session.AssignedTo = assignee
+	// The new assignee is reminded from the first threshold on
+	session.SLALevel = 0
+
+	if previous != 0 {
+		msg := tgbotapi.NewMessage(previous, fmt.Sprintf("👤 Ticket #%d was reassigned to %s, you no longer need to answer it",
+			session.TicketID, b.assigneeName(assignee)))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("reviewer_id", previous).Error("Failed to tell reviewer about reassignment")
+		}
+	}
+
+	b.audit.Record(AuditTicketAssigned, b.adminID, logrus.Fields{
+		"ticket_id": session.TicketID,
+		"from":      previous,
+		"to":        assignee,
+	})
+
+	reply := fmt.Sprintf("👤 Ticket #%d assigned to %s", session.TicketID, b.assigneeName(assignee))
+	if note != "" {
+		reply += "\n" + note
+	}
+
+	return reply
+}
+
+// assignmentText is the ticket as forwarded to a reviewer.
+func (b *Bot) assignmentText(session *UserSession) string {
+	from := fmt.Sprintf("user ID %d", session.UserID)
+	switch {
+	case b.anonymizesAuthor(session):
+		from = fmt.Sprintf("the author of ticket #%d", session.TicketID)
+	case session.Username != "":
+		from = fmt.Sprintf("@%s (ID: %d)", session.Username, session.UserID)
+	}
+
+	if comments := b.ticketCommentLines(session.TicketID); comments != "" {
+		from += "\n" + comments
+	}
+
+	return fmt.Sprintf("👤 Ticket #%d was assigned to you\n\nFrom %s:\n\n%s\n\n💡 Reply to this message to answer the user, or add an internal comment with /comment %d <text>",
+		session.TicketID, from, b.reviewerText(session, session.LastQuestion), session.TicketID)
+}
+
+// handleAssignCallback processes the "assign:<ticket_id>" button on admin
+// notifications, which offers the reviewers, and the
+// "assign:<ticket_id>:<user_id>" buttons that pick one.
+func (b *Bot) handleAssignCallback(callback *tgbotapi.CallbackQuery) {
+	idArg, assigneeArg, picked := strings.Cut(strings.TrimPrefix(callback.Data, "assign:"), ":")
+	ticketID, err := strconv.Atoi(idArg)
+	if err != nil {
+		b.logger.WithError(err).WithField("callback_data", callback.Data).Error("Malformed assign callback")
+		return
+	}
+
+	session := b.findOpenTicket(fmt.Sprintf("#%d", ticketID))
+	if session == nil {
+		msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("Ticket #%d is already closed", ticketID))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).Error("Failed to send closed ticket message")
+		}
+		return
+	}
+
+	if !picked {
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, reviewer := range b.reviewers {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("@"+reviewer.Name, fmt.Sprintf("assign:%d:%d", ticketID, reviewer.ID)),
+			))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Me", fmt.Sprintf("assign:%d:0", ticketID)),
+		))
+
+		msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("👤 Assign ticket #%d to:", ticketID))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).Error("Failed to send reviewer picker")
+		}
+		return
+	}
+
+	assignee, err := strconv.ParseInt(assigneeArg, 10, 64)
+	if err != nil || (assignee != 0 && !b.isReviewer(assignee)) {
+		b.logger.WithField("callback_data", callback.Data).Error("Malformed assign callback")
+		return
+	}
+
+	reply := b.assignTicket(session, assignee)
+	if callback.Message != nil {
+		edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, reply)
+		if _, err := b.api.Request(edit); err != nil {
+			b.logger.WithError(err).Error("Failed to update reviewer picker")
+		}
+		return
+	}
+	if _, err := b.api.Send(tgbotapi.NewMessage(b.adminID, reply)); err != nil {
+		b.logger.WithError(err).Error("Failed to send assign reply")
+	}
+}
+
+// handleReviewerMessage answers the ticket a reviewer replied to, or adds
+// their /comment to it.
+func (b *Bot) handleReviewerMessage(message *tgbotapi.Message) {
+	reviewerID := message.From.ID
+
+	var reply string
+	command, args, _ := strings.Cut(strings.TrimSpace(message.Text), " ")
+	if command == "/comment" {
+		reply = b.commentOnTicket(reviewerID, args)
+	} else if message.ReplyToMessage == nil || strings.TrimSpace(message.Text) == "" {
+		reply = "👋 You are a reviewer of this bot. Reply to a ticket assigned to you to answer it, or comment on it with /comment <ticket_id> <text>."
+	} else if ticket, exists := b.store.AssignedTicket(reviewerID, message.ReplyToMessage.MessageID); !exists {
+		reply = "❌ This message is not an open ticket assigned to you"
+	} else if session := b.findOpenTicket(fmt.Sprintf("#%d", ticket.ID)); session == nil {
+		reply = fmt.Sprintf("❌ Ticket #%d is already closed", ticket.ID)
+	} else {
+		b.deliverAnswer(session, message.Text)
+		reply = fmt.Sprintf("✅ Answer to ticket #%d sent", ticket.ID)
+		if session.AnsweredAt.IsZero() {
+			reply = fmt.Sprintf("❌ The answer to ticket #%d could not be delivered, the admin was told", ticket.ID)
+		}
+		b.logger.WithFields(logrus.Fields{
+			"ticket_id":   ticket.ID,
+			"reviewer_id": reviewerID,
+		}).Info("Reviewer answered ticket")
+	}
+
+	msg := tgbotapi.NewMessage(reviewerID, reply)
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.WithError(err).WithField("reviewer_id", reviewerID).Error("Failed to send reviewer reply")
+	}
+}
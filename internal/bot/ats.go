@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ATSKeywords maps target roles to the keywords applicant tracking systems
+// look for in their CVs.
+//
+//	{
+//	  "backend developer": ["Go", "SQL", "Docker", "REST API"],
+//	  "data analyst": ["SQL", "Python", "Tableau", "A/B testing"]
+//	}
+type ATSKeywords struct {
+	roles map[string][]string
+}
+
+// atsKeywordsFromConfig loads ATS_KEYWORDS_FILE. Without it CVs are not
+// analyzed.
+func atsKeywordsFromConfig(config *Config) (*ATSKeywords, error) {
+	path := config.Texts.ATSKeywordsFile
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var roles map[string][]string
+	if err := json.Unmarshal(data, &roles); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	keywords := &ATSKeywords{roles: make(map[string][]string, len(roles))}
+	for role, list := range roles {
+		if len(faqWords(role)) == 0 || len(list) == 0 {
+			return nil, fmt.Errorf("%s: role %q needs a name and keywords", path, role)
+		}
+		keywords.roles[strings.Join(faqWords(role), " ")] = list
+	}
+
+	return keywords, nil
+}
+
+// ForRole returns the keywords of the role the user is aiming for: the
+// longest configured role mentioned in their answer, so "Senior backend
+// developer" picks "backend developer" over "developer".
+func (k *ATSKeywords) ForRole(targetRole string) (string, []string, bool) {
+	text := " " + strings.Join(faqWords(targetRole), " ") + " "
+
+	best := ""
+	for role := range k.roles {
+		if strings.Contains(text, " "+role+" ") && (len(role) > len(best) || len(role) == len(best) && role < best) {
+			best = role
+		}
+	}
+
+	return best, k.roles[best], best != ""
+}
+
+// missingKeywords returns the keywords that do not appear in the CV text,
+// in their configured order. Keywords match whole words or phrases
+// regardless of case.
+func missingKeywords(cvText string, keywords []string) []string {
+	text := " " + strings.Join(faqWords(cvText), " ") + " "
+
+	var missing []string
+	for _, keyword := range keywords {
+		words := faqWords(keyword)
+		if len(words) > 0 && !strings.Contains(text, " "+strings.Join(words, " ")+" ") {
+			missing = append(missing, keyword)
+		}
+	}
+
+	return missing
+}
+
+// keywordGap is the result of the keyword analysis of a CV.
+type keywordGap struct {
+	Role    string
+	Missing []string
+	// Err explains why the CV could not be analyzed
+	Err error
+}
+
+// analyzeKeywords compares the CV uploaded with a review request with the
+// keywords of the target role from the intake form. It returns nil when
+// there is nothing to analyze: no keywords configured, no target role or
+// no uploaded file.
+func (b *Bot) analyzeKeywords(session *UserSession) *keywordGap {
+	intake := session.CVIntake
+	if b.atsKeywords == nil || intake == nil || intake.TargetRole == "" {
+		return nil
+	}
+
+	role, keywords, found := b.atsKeywords.ForRole(intake.TargetRole)
+	switch {
+	case !found:
+		roles := make([]string, 0, len(b.atsKeywords.roles))
+		for role := range b.atsKeywords.roles {
+			roles = append(roles, role)
+		}
+		sort.Strings(roles)
+		return &keywordGap{Err: fmt.Errorf("no keywords for the target role %q, known roles: %s", intake.TargetRole, strings.Join(roles, ", "))}
+	case intake.CVFileID == "":
+		return &keywordGap{Role: role, Err: fmt.Errorf("the CV was not uploaded as a file")}
+	}
+
+	data, err := b.api.DownloadFile(intake.CVFileID)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", session.TicketID).Error("Failed to download CV")
+		return &keywordGap{Role: role, Err: fmt.Errorf("the CV could not be downloaded")}
+	}
+	text, err := extractCVText(intake.CVFileName, data)
+	if err != nil {
+		return &keywordGap{Role: role, Err: err}
+	}
+
+	return &keywordGap{Role: role, Missing: missingKeywords(text, keywords)}
+}
+
+// AdminSummary describes the gap for the admin.
+func (g *keywordGap) AdminSummary() string {
+	switch {
+	case g.Err != nil:
+		return "🔑 Keyword analysis: " + g.Err.Error()
+	case len(g.Missing) == 0:
+		return fmt.Sprintf("🔑 The CV has all %s keywords", g.Role)
+	default:
+		return fmt.Sprintf("🔑 Missing %s keywords: %s", g.Role, strings.Join(g.Missing, ", "))
+	}
+}
@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	defaultAuditLogFile = "data/audit.log"
+	auditQueryLimit     = 20
+)
+
+type AuditEvent string
+
+const (
+	AuditUserMessage          AuditEvent = "user_message"
+	AuditUserCallback         AuditEvent = "user_callback"
+	AuditAdminMessage         AuditEvent = "admin_message"
+	AuditAdminCallback        AuditEvent = "admin_callback"
+	AuditTicketCreated        AuditEvent = "ticket_created"
+	AuditTicketEdited         AuditEvent = "ticket_edited"
+	AuditAnswerSent           AuditEvent = "answer_sent"
+	AuditAnswerEdited         AuditEvent = "answer_edited"
+	AuditAnswerUndone         AuditEvent = "answer_undone"
+	AuditTicketArchived       AuditEvent = "ticket_archived"
+	AuditTicketAssigned       AuditEvent = "ticket_assigned"
+	AuditTicketEscalated      AuditEvent = "ticket_escalated"
+	AuditTicketAcknowledged   AuditEvent = "ticket_acknowledged"
+	AuditPaymentReceived      AuditEvent = "payment_received"
+	AuditPromoRedeemed        AuditEvent = "promo_redeemed"
+	AuditSubscriptionPaid     AuditEvent = "subscription_paid"
+	AuditSubscriptionCanceled AuditEvent = "subscription_canceled"
+	AuditBookingCreated       AuditEvent = "booking_created"
+	AuditBookingCanceled      AuditEvent = "booking_canceled"
+	AuditReengagementSent     AuditEvent = "reengagement_sent"
+	AuditReengagementReturned AuditEvent = "reengagement_returned"
+	AuditReengagementOptOut   AuditEvent = "reengagement_opt_out"
+	AuditDeepLink             AuditEvent = "deep_link"
+	AuditUserVerified         AuditEvent = "user_verified"
+	AuditTermsAccepted        AuditEvent = "terms_accepted"
+	AuditSubmissionBlocked    AuditEvent = "submission_blocked"
+	AuditFileInfected         AuditEvent = "file_infected"
+	AuditBroadcast            AuditEvent = "broadcast"
+	AuditReferral             AuditEvent = "referral"
+)
+
+// AuditLogger records user and admin activity as JSON lines in its own sink,
+// independent of LOG_LEVEL, so it can be queried with /audit or jq.
+type AuditLogger struct {
+	logger *logrus.Logger
+	path   string
+}
+
+// NewAuditLogger writes to AUDIT_LOG_FILE (default data/audit.log), rotating
+// it like the main log file. AUDIT_LOG_FILE=stdout writes to standard output.
+func NewAuditLogger(config *Config) (*AuditLogger, error) {
+	path := config.Storage.AuditLogFile
+	if path == "" {
+		path = defaultAuditLogFile
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.InfoLevel)
+	logger.SetFormatter(&logrus.JSONFormatter{
+		TimestampFormat: time.RFC3339,
+		FieldMap: logrus.FieldMap{
+			logrus.FieldKeyTime: "timestamp",
+			logrus.FieldKeyMsg:  "event",
+		},
+		DisableHTMLEscape: true,
+	})
+
+	if path == "stdout" {
+		logger.SetOutput(os.Stdout)
+		return &AuditLogger{logger: logger}, nil
+	}
+
+	logger.SetOutput(&lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    intOr(config.Logging.MaxSize, defaultLogMaxSize),
+		MaxBackups: defaultLogMaxBackups,
+	})
+
+	return &AuditLogger{logger: logger, path: path}, nil
+}
+
+func (a *AuditLogger) Record(event AuditEvent, actorID int64, fields logrus.Fields) {
+	entry := a.logger.WithField("actor_id", actorID)
+	if fields != nil {
+		entry = entry.WithFields(fields)
+	}
+	entry.Info(string(event))
+}
+
+// Query returns the most recent entries whose actor or target user is
+// userID, oldest first. Only the current (non-rotated) file is searched.
+func (a *AuditLogger) Query(userID int64, limit int) ([]map[string]interface{}, error) {
+	return a.query(func(entry map[string]interface{}) bool {
+		return auditEntryMatches(entry, userID)
+	}, limit)
+}
+
+// QueryEvent returns the most recent entries of an event, oldest first.
+func (a *AuditLogger) QueryEvent(event AuditEvent, limit int) ([]map[string]interface{}, error) {
+	return a.query(func(entry map[string]interface{}) bool {
+		return entry["event"] == string(event)
+	}, limit)
+}
+
+func (a *AuditLogger) query(match func(entry map[string]interface{}) bool, limit int) ([]map[string]interface{}, error) {
+	if a.path == "" {
+		return nil, errors.New("audit log is written to stdout and cannot be queried")
+	}
+
+	file, err := os.Open(a.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var matches []map[string]interface{}
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var entry map[string]interface{}
+			if json.Unmarshal(line, &entry) == nil && match(entry) {
+				matches = append(matches, entry)
+				if len(matches) > limit {
+					matches = matches[1:]
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return matches, nil
+}
+
+func auditEntryMatches(entry map[string]interface{}, userID int64) bool {
+	for _, key := range []string{"actor_id", "user_id"} {
+		if id, ok := entry[key].(float64); ok && int64(id) == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *Bot) showAuditLog(args string) {
+	userID, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(b.adminID, "Usage: /audit <user_id>")
+		_, err = b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send audit usage")
+		}
+		return
+	}
+
+	entries, err := b.audit.Query(userID, auditQueryLimit)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to query audit log")
+	}
+
+	var text strings.Builder
+	if err != nil {
+		text.WriteString(fmt.Sprintf("❌ Failed to query audit log: %v", err))
+	} else if len(entries) == 0 {
+		text.WriteString(fmt.Sprintf("No audit entries for user ID %d", userID))
+	} else {
+		text.WriteString(fmt.Sprintf("🗂 Last %d audit entries for user ID %d:\n\n", len(entries), userID))
+		for _, entry := range entries {
+			text.WriteString(fmt.Sprintf("%v %v", entry["timestamp"], entry["event"]))
+			for _, key := range []string{"ticket_id", "text", "callback_data"} {
+				if value, ok := entry[key]; ok && value != "" {
+					text.WriteString(fmt.Sprintf(" %s=%v", key, truncateText(fmt.Sprint(value), 80)))
+				}
+			}
+			text.WriteString("\n")
+		}
+	}
+
+	_, err = b.api.SendLong(b.adminID, text.String(), nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send audit entries")
+	}
+}
@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const blockedQueryLimit = 20
+
+var (
+	// domainPattern finds domain names in text, with or without a scheme
+	domainPattern = regexp.MustCompile(`(?i)(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+[a-z][a-z0-9-]*[a-z0-9]`)
+	// linkPattern finds links with a scheme
+	linkPattern = regexp.MustCompile(`(?i)https?://\S+`)
+	// telegramLookalike matches the spellings scam sites use to pass as
+	// Telegram, e.g. "telegram-premium.xyz" or "te1egram.org"
+	telegramLookalike = regexp.MustCompile(`te[l1i]e[gq]r(?:a|4)m|te[l1i]egrarn`)
+	// telegramDomains are Telegram's own domains, which the lookalike
+	// pattern must not block
+	telegramDomains = []string{"telegram.org", "telegram.me", "telegram.dog", "t.me", "telegra.ph", "telesco.pe"}
+)
+
+// Blocklist keeps questions linking to scam or phishing sites from reaching
+// the admin. The configured domains and their subdomains are blocked
+// wherever they are mentioned; links to sites impersonating Telegram are
+// always blocked.
+type Blocklist struct {
+	domains []string
+}
+
+// blocklistFromConfig reads BLOCKED_DOMAINS, a comma-separated list of domains
+// such as "free-crypto.io,bit-gift.xyz".
+func blocklistFromConfig(config *Config) *Blocklist {
+	list := &Blocklist{}
+	for _, domain := range config.Moderation.BlockedDomains {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "*.")
+		if domain != "" && !slices.Contains(list.domains, domain) {
+			list.domains = append(list.domains, domain)
+		}
+	}
+
+	return list
+}
+
+// Domains returns the configured domains.
+func (l *Blocklist) Domains() []string {
+	return l.domains
+}
+
+// Blocked returns the first blocked domain mentioned in text or linked to
+// by links.
+func (l *Blocklist) Blocked(text string, links []string) (string, bool) {
+	var linked []string
+	for _, link := range links {
+		if !strings.Contains(link, "://") {
+			link = "http://" + link
+		}
+		if parsed, err := url.Parse(link); err == nil && parsed.Hostname() != "" {
+			linked = append(linked, strings.ToLower(parsed.Hostname()))
+		}
+	}
+
+	for _, host := range append(domainPattern.FindAllString(text, -1), linked...) {
+		host = strings.ToLower(host)
+		for _, domain := range l.domains {
+			if matchesDomain(host, domain) {
+				return host, true
+			}
+		}
+	}
+
+	for _, host := range linked {
+		if telegramLookalike.MatchString(host) && !slices.ContainsFunc(telegramDomains, func(domain string) bool {
+			return matchesDomain(host, domain)
+		}) {
+			return host, true
+		}
+	}
+
+	return "", false
+}
+
+// matchesDomain reports whether host is domain or one of its subdomains.
+func matchesDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// messageLinks returns the links of a message: those Telegram detected,
+// including links hidden behind text, and those written with a scheme.
+func messageLinks(message *tgbotapi.Message) []string {
+	links := append(linkPattern.FindAllString(message.Text, -1), linkPattern.FindAllString(message.Caption, -1)...)
+	links = append(links, entityLinks(message.Text, message.Entities)...)
+	links = append(links, entityLinks(message.Caption, message.CaptionEntities)...)
+
+	return links
+}
+
+// entityLinks returns the links of url and text_link entities. Entity
+// offsets count UTF-16 code units.
+func entityLinks(text string, entities []tgbotapi.MessageEntity) []string {
+	var links []string
+	units := utf16.Encode([]rune(text))
+	for _, entity := range entities {
+		switch entity.Type {
+		case "text_link":
+			links = append(links, entity.URL)
+		case "url":
+			if entity.Offset >= 0 && entity.Offset+entity.Length <= len(units) {
+				links = append(links, string(utf16.Decode(units[entity.Offset:entity.Offset+entity.Length])))
+			}
+		}
+	}
+
+	return links
+}
+
+// rejectBlockedLink tells the sender and logs it for review when message
+// links to a blocked domain. It reports whether the message was rejected.
+func (b *Bot) rejectBlockedLink(message *tgbotapi.Message) bool {
+	domain, blocked := b.blocklist.Blocked(message.Text+" "+message.Caption, messageLinks(message))
+	if !blocked {
+		return false
+	}
+
+	userID := message.From.ID
+	b.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"domain":  domain,
+	}).Warn("Blocked a message linking to a blocked domain")
+	b.audit.Record(AuditSubmissionBlocked, userID, logrus.Fields{
+		"username": message.From.UserName,
+		"domain":   domain,
+		"text":     questionText(message),
+	})
+
+	msg := telegram.NewMarkdownMessage(message.Chat.ID, b.trMarkdown(userID, "submission_blocked"))
+	msg.ReplyToMessageID = message.MessageID
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send blocked link notice")
+	}
+
+	return true
+}
+
+// showBlockedAttempts lists the configured domains and the latest blocked
+// messages for /blocked.
+func (b *Bot) showBlockedAttempts() {
+	var text strings.Builder
+	if domains := b.blocklist.Domains(); len(domains) > 0 {
+		text.WriteString("🚫 Blocked domains: " + strings.Join(domains, ", ") + "\n")
+	} else {
+		text.WriteString("🚫 No blocked domains configured, set BLOCKED_DOMAINS\n")
+	}
+	text.WriteString("Sites impersonating Telegram are always blocked.\n\n")
+
+	entries, err := b.audit.QueryEvent(AuditSubmissionBlocked, blockedQueryLimit)
+	switch {
+	case err != nil:
+		b.logger.WithError(err).Error("Failed to query audit log")
+		text.WriteString(fmt.Sprintf("❌ Failed to query audit log: %v", err))
+	case len(entries) == 0:
+		text.WriteString("No blocked messages")
+	default:
+		text.WriteString(fmt.Sprintf("Last %d blocked messages:\n\n", len(entries)))
+		for _, entry := range entries {
+			userID, _ := entry["actor_id"].(float64)
+			text.WriteString(fmt.Sprintf("%v user %d %v: %s\n", entry["timestamp"], int64(userID), entry["domain"],
+				truncateText(fmt.Sprint(entry["text"]), 80)))
+		}
+	}
+
+	_, err = b.api.SendLong(b.adminID, text.String(), nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send blocked messages")
+	}
+}
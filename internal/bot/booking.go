@@ -0,0 +1,644 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/flows"
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const (
+	defaultBookingSlotLength = 30 * time.Minute
+	defaultBookingDaysAhead  = 14
+	// bookingMinNotice keeps users from booking a call the admin cannot
+	// prepare for
+	bookingMinNotice     = 2 * time.Hour
+	bookingCheckInterval = time.Minute
+	bookingSlotsPerRow   = 4
+	bookingTimeFormat    = "02.01.2006 15:04"
+)
+
+var defaultBookingReminders = []time.Duration{24 * time.Hour, time.Hour}
+
+// Consultations are live CV consultation calls users book in the weekly
+// availability the admin sets with /availability.
+type Consultations struct {
+	slotLength time.Duration
+	daysAhead  int
+	// reminders are sent this long before a call, longest first
+	reminders []time.Duration
+	location  *time.Location
+}
+
+// consultationsFromConfig reads BOOKING_ENABLED, BOOKING_SLOT_LENGTH
+// (default 30m), BOOKING_DAYS_AHEAD (default 14), BOOKING_REMINDERS
+// (24h and 1h by default, "off" for none) and BOOKING_TIMEZONE (an IANA
+// name, default local time). It returns nil unless BOOKING_ENABLED is true.
+func consultationsFromConfig(config *Config) (*Consultations, error) {
+	if !enabled(config.Booking.Enabled) {
+		return nil, nil
+	}
+
+	consultations := &Consultations{
+		slotLength: durationOr(config.Booking.SlotLength, defaultBookingSlotLength),
+		daysAhead:  intOr(config.Booking.DaysAhead, defaultBookingDaysAhead),
+		location:   time.Local,
+	}
+	if consultations.slotLength < time.Minute {
+		return nil, fmt.Errorf("invalid BOOKING_SLOT_LENGTH %s, expected a duration such as 30m", consultations.slotLength)
+	}
+	if consultations.daysAhead <= 0 {
+		return nil, fmt.Errorf("invalid BOOKING_DAYS_AHEAD %d, expected a positive number of days", consultations.daysAhead)
+	}
+
+	if !config.Booking.DisableReminders {
+		consultations.reminders = slices.Clone(defaultBookingReminders)
+		if len(config.Booking.Reminders) > 0 {
+			consultations.reminders = nil
+			for _, reminder := range config.Booking.Reminders {
+				if reminder <= 0 {
+					return nil, fmt.Errorf("invalid BOOKING_REMINDERS, expected durations such as 24h,1h or off")
+				}
+				consultations.reminders = append(consultations.reminders, time.Duration(reminder))
+			}
+		}
+		sort.Slice(consultations.reminders, func(i, j int) bool {
+			return consultations.reminders[i] > consultations.reminders[j]
+		})
+	}
+
+	if zone := config.Booking.Timezone; zone != "" {
+		var err error
+		consultations.location, err = time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BOOKING_TIMEZONE: %w", err)
+		}
+	}
+
+	return consultations, nil
+}
+
+// freeSlots returns the start of every slot in the availability windows of
+// the coming days that is not booked yet, soonest first.
+func (c *Consultations) freeSlots(windows []storage.AvailabilityWindow, booked []storage.Booking, now time.Time) []time.Time {
+	earliest := now.Add(bookingMinNotice)
+	today := startOfDay(now.In(c.location))
+
+	var slots []time.Time
+	for day := 0; day < c.daysAhead; day++ {
+		date := today.AddDate(0, 0, day)
+		for _, window := range windows {
+			if window.Weekday != date.Weekday() {
+				continue
+			}
+			start, err := parseClock(window.Start)
+			if err != nil {
+				continue
+			}
+			end, err := parseClock(window.End)
+			if err != nil {
+				continue
+			}
+
+			for slot := date.Add(start); !slot.Add(c.slotLength).After(date.Add(end)); slot = slot.Add(c.slotLength) {
+				if slot.Before(earliest) || slotBooked(booked, slot, slot.Add(c.slotLength)) {
+					continue
+				}
+				slots = append(slots, slot)
+			}
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].Before(slots[j])
+	})
+
+	return slots
+}
+
+func slotBooked(booked []storage.Booking, start, end time.Time) bool {
+	for _, booking := range booked {
+		if booking.Start.Before(end) && start.Before(booking.End) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// format formats the time of a call, e.g. "20.10.2026 15:00 (Asia/Tashkent)".
+func (c *Consultations) format(t time.Time) string {
+	return fmt.Sprintf("%s (%s)", t.In(c.location).Format(bookingTimeFormat), c.location)
+}
+
+// bookingFlow lets users book a consultation call.
+func (b *Bot) bookingFlow() *flows.Flow {
+	return &flows.Flow{
+		Name:        "booking",
+		Button:      "button_book_call",
+		Commands:    []string{"/book", "/consultation", "book", "consultation", "book call"},
+		Description: "command_book",
+		Keywords:    []string{"call", "consultation"},
+		Start:       b.startBookingFlow,
+		States: map[UserState]flows.StateHandler{
+			StateBooking: func(message *tgbotapi.Message, userID int64, username string) {
+				b.showBookingDays(userID)
+			},
+		},
+	}
+}
+
+func (b *Bot) availableSlots() []time.Time {
+	now := time.Now()
+	return b.consultations.freeSlots(b.store.Availability(), b.store.UpcomingBookings(now), now)
+}
+
+// upcomingBooking returns the next call the user booked.
+func (b *Bot) upcomingBooking(userID int64) (storage.Booking, bool) {
+	for _, booking := range b.store.UpcomingBookings(time.Now()) {
+		if booking.UserID == userID {
+			return booking, true
+		}
+	}
+
+	return storage.Booking{}, false
+}
+
+// startBookingFlow shows the user's upcoming call, or the days with free
+// slots to book one. Users book one call at a time.
+func (b *Bot) startBookingFlow(userID int64) {
+	booking, exists := b.upcomingBooking(userID)
+	if !exists {
+		b.showBookingDays(userID)
+		return
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_cancel_booking"), fmt.Sprintf("booking:cancel:%d", booking.ID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_back_to_menu"), "back_to_menu"),
+		),
+	)
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "booking_existing", map[string]interface{}{
+		"Time": b.consultations.format(booking.Start),
+	}))
+	msg.ReplyMarkup = keyboard
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send upcoming booking")
+		return
+	}
+
+	b.userStates[userID] = StateWelcome
+}
+
+func (b *Bot) showBookingDays(userID int64) {
+	slots := b.availableSlots()
+	if len(slots) == 0 {
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "booking_no_slots"))
+		msg.ReplyMarkup = b.backToMenuKeyboard(userID)
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send booking notice")
+		}
+		b.userStates[userID] = StateWelcome
+		return
+	}
+
+	var days []string
+	counts := make(map[string]int)
+	for _, slot := range slots {
+		day := slot.In(b.consultations.location).Format(time.DateOnly)
+		if counts[day] == 0 {
+			days = append(days, day)
+		}
+		counts[day]++
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, day := range days {
+		date, _ := time.Parse(time.DateOnly, day)
+		label := b.tr(userID, "button_booking_day", map[string]interface{}{"Date": date.Format("02.01"), "Slots": counts[day]})
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, "booking:day:"+day))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_cancel"), "cancel"),
+	))
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "booking_pick_day", map[string]interface{}{
+		"Length":   formatDuration(b.consultations.slotLength),
+		"Timezone": b.consultations.location.String(),
+	}))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send booking days")
+		return
+	}
+
+	b.userStates[userID] = StateBooking
+}
+
+func (b *Bot) showBookingSlots(userID int64, day string) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, slot := range b.availableSlots() {
+		local := slot.In(b.consultations.location)
+		if local.Format(time.DateOnly) != day {
+			continue
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(local.Format("15:04"), fmt.Sprintf("booking:slot:%d", slot.Unix())))
+		if len(row) == bookingSlotsPerRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		b.showBookingDays(userID)
+		return
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_other_day"), "booking:days"),
+	))
+
+	date, _ := time.Parse(time.DateOnly, day)
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "booking_pick_slot", map[string]interface{}{
+		"Date":     date.Format("02.01.2006"),
+		"Timezone": b.consultations.location.String(),
+	}))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send booking slots")
+	}
+}
+
+func (b *Bot) handleBookingCallback(callback *tgbotapi.CallbackQuery) {
+	userID := callback.From.ID
+	action, value, _ := strings.Cut(strings.TrimPrefix(callback.Data, "booking:"), ":")
+
+	switch action {
+	case "cancel":
+		id, err := strconv.Atoi(value)
+		if err == nil {
+			b.cancelBookingByUser(userID, id)
+		}
+	case "day":
+		b.showBookingSlots(userID, value)
+	case "slot":
+		unix, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			b.bookSlot(userID, callback.From.UserName, time.Unix(unix, 0))
+		}
+	default:
+		b.showBookingDays(userID)
+	}
+}
+
+// bookSlot books a call if the slot is still free and tells both sides.
+// A user who already has a call, e.g. after tapping a second slot of an old
+// keyboard, is shown that call instead.
+func (b *Bot) bookSlot(userID int64, username string, start time.Time) {
+	if _, booked := b.upcomingBooking(userID); booked {
+		b.startBookingFlow(userID)
+		return
+	}
+
+	free := false
+	for _, slot := range b.availableSlots() {
+		if slot.Equal(start) {
+			free = true
+			break
+		}
+	}
+
+	var booking storage.Booking
+	err := storage.ErrSlotTaken
+	if free {
+		booking, err = b.store.AddBooking(storage.Booking{
+			UserID:    userID,
+			Username:  username,
+			Start:     start,
+			End:       start.Add(b.consultations.slotLength),
+			CreatedAt: time.Now(),
+		})
+	}
+	if errors.Is(err, storage.ErrSlotTaken) {
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "booking_slot_taken"))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send booking notice")
+		}
+		b.showBookingDays(userID)
+		return
+	}
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist booking")
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "booking_failed"))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send booking error")
+		}
+		return
+	}
+
+	b.userStates[userID] = StateWelcome
+	b.audit.Record(AuditBookingCreated, userID, logrus.Fields{
+		"booking_id": booking.ID,
+		"start":      booking.Start,
+	})
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_cancel_booking"), fmt.Sprintf("booking:cancel:%d", booking.ID)),
+		),
+	)
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "booking_confirmed", map[string]interface{}{
+		"Time":   b.consultations.format(booking.Start),
+		"Length": formatDuration(b.consultations.slotLength),
+	}))
+	msg.ReplyMarkup = keyboard
+	_, err = b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send booking confirmation")
+	}
+
+	b.notifyAdminOfBooking(fmt.Sprintf("📅 Consultation #%d booked by %s for %s", booking.ID,
+		bookingUser(booking), b.consultations.format(booking.Start)))
+}
+
+func (b *Bot) cancelBookingByUser(userID int64, id int) {
+	booking, exists := b.upcomingBooking(userID)
+	if !exists || booking.ID != id {
+		b.startBookingFlow(userID)
+		return
+	}
+
+	booking, canceled, err := b.store.CancelBooking(id, time.Now())
+	if err != nil {
+		b.logger.WithError(err).WithField("booking_id", id).Error("Failed to persist booking cancellation")
+	}
+	if !canceled {
+		return
+	}
+	b.audit.Record(AuditBookingCanceled, userID, logrus.Fields{"booking_id": id})
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "booking_canceled"))
+	msg.ReplyMarkup = b.backToMenuKeyboard(userID)
+	_, err = b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send booking cancellation")
+	}
+
+	b.notifyAdminOfBooking(fmt.Sprintf("❌ Consultation #%d on %s canceled by %s", booking.ID,
+		b.consultations.format(booking.Start), bookingUser(booking)))
+}
+
+func (b *Bot) backToMenuKeyboard(userID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_back_to_menu"), "back_to_menu"),
+		),
+	)
+}
+
+func bookingUser(booking storage.Booking) string {
+	if booking.Username != "" {
+		return fmt.Sprintf("@%s (ID %d)", booking.Username, booking.UserID)
+	}
+
+	return fmt.Sprintf("user ID %d", booking.UserID)
+}
+
+func (b *Bot) notifyAdminOfBooking(text string) {
+	_, err := b.api.Send(tgbotapi.NewMessage(b.adminID, text))
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send booking notification to admin")
+	}
+}
+
+// runBookingReminders reminds both sides of upcoming calls.
+func (b *Bot) runBookingReminders() {
+	ticker := time.NewTicker(bookingCheckInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		b.mu.Lock()
+		b.sendBookingReminders(now)
+		b.mu.Unlock()
+	}
+}
+
+// sendBookingReminders sends the reminders that are due. Reminders that
+// were due before the call was booked are skipped, and after downtime only
+// one reminder is sent for all that were missed.
+func (b *Bot) sendBookingReminders(now time.Time) {
+	for _, booking := range b.store.UpcomingBookings(now) {
+		if !booking.Start.After(now) {
+			continue
+		}
+
+		var due []string
+		for _, reminder := range b.consultations.reminders {
+			at := booking.Start.Add(-reminder)
+			if now.Before(at) || !at.After(booking.CreatedAt) || slices.Contains(booking.Reminders, reminder.String()) {
+				continue
+			}
+			due = append(due, reminder.String())
+		}
+		if len(due) == 0 {
+			continue
+		}
+
+		until := formatDuration(booking.Start.Sub(now))
+		msg := telegram.NewMarkdownMessage(booking.UserID, b.trMarkdown(booking.UserID, "booking_reminder", map[string]interface{}{
+			"In":   until,
+			"Time": b.consultations.format(booking.Start),
+		}))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("booking_id", booking.ID).Error("Failed to send booking reminder")
+		}
+		b.notifyAdminOfBooking(fmt.Sprintf("⏰ Consultation #%d with %s in %s, at %s", booking.ID,
+			bookingUser(booking), until, b.consultations.format(booking.Start)))
+
+		for _, reminder := range due {
+			if err := b.store.MarkBookingReminded(booking.ID, reminder); err != nil {
+				b.logger.WithError(err).WithField("booking_id", booking.ID).Error("Failed to persist booking reminder")
+			}
+		}
+	}
+}
+
+// showBookings lists the upcoming calls for the admin or, with
+// "cancel <id>", cancels one and tells the user.
+func (b *Bot) showBookings(args string) {
+	fields := strings.Fields(args)
+
+	var reply string
+	switch {
+	case len(fields) == 0:
+		bookings := b.store.UpcomingBookings(time.Now())
+		if len(bookings) == 0 {
+			reply = "No upcoming consultations."
+			break
+		}
+
+		var text strings.Builder
+		text.WriteString("Upcoming consultations:\n\n")
+		for _, booking := range bookings {
+			fmt.Fprintf(&text, "• #%d %s: %s\n", booking.ID, b.consultations.format(booking.Start), bookingUser(booking))
+		}
+		reply = text.String()
+
+	case len(fields) == 2 && fields[0] == "cancel":
+		id, err := strconv.Atoi(strings.TrimPrefix(fields[1], "#"))
+		if err != nil {
+			reply = fmt.Sprintf("❌ Invalid booking ID %q", fields[1])
+			break
+		}
+
+		booking, canceled, err := b.store.CancelBooking(id, time.Now())
+		if err != nil {
+			b.logger.WithError(err).WithField("booking_id", id).Error("Failed to persist booking cancellation")
+			reply = fmt.Sprintf("❌ Failed to cancel consultation: %v", err)
+			break
+		}
+		if !canceled {
+			reply = fmt.Sprintf("Consultation #%d not found", id)
+			break
+		}
+		b.audit.Record(AuditBookingCanceled, b.adminID, logrus.Fields{"booking_id": id, "user_id": booking.UserID})
+
+		msg := telegram.NewMarkdownMessage(booking.UserID, b.trMarkdown(booking.UserID, "booking_canceled_by_admin", map[string]interface{}{
+			"Time": b.consultations.format(booking.Start),
+		}))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", booking.UserID).Error("Failed to send booking cancellation")
+		}
+		reply = fmt.Sprintf("🗑 Consultation #%d with %s canceled, the user was told", id, bookingUser(booking))
+
+	default:
+		reply = "Usage:\n/bookings - List upcoming consultations\n/bookings cancel <id> - Cancel a consultation and tell the user"
+	}
+
+	_, err := b.api.SendLong(b.adminID, reply, nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send bookings reply")
+	}
+}
+
+// handleAvailabilityCommand shows or sets the weekly availability:
+//
+//	/availability
+//	/availability <days> <HH:MM-HH:MM>[,<HH:MM-HH:MM>...]
+//	/availability <days> off
+func (b *Bot) handleAvailabilityCommand(args string) {
+	fields := strings.Fields(args)
+
+	var reply string
+	switch len(fields) {
+	case 0:
+		reply = describeAvailability(b.store.Availability(), b.consultations.location)
+	case 2:
+		days, windows, err := parseAvailability(fields[0], fields[1])
+		if err != nil {
+			reply = fmt.Sprintf("❌ %v", err)
+			break
+		}
+		if err := b.store.SetAvailability(days, windows); err != nil {
+			b.logger.WithError(err).Error("Failed to save availability")
+			reply = fmt.Sprintf("❌ Failed to save availability: %v", err)
+			break
+		}
+		reply = "✅ Availability saved.\n\n" + describeAvailability(b.store.Availability(), b.consultations.location)
+	default:
+		reply = `Usage:
+/availability <days> <HH:MM-HH:MM>[,<HH:MM-HH:MM>...]
+/availability <days> off
+
+Days are like mon-fri or tue,thu. Setting days replaces their previous hours; the other days are kept.`
+	}
+
+	msg := tgbotapi.NewMessage(b.adminID, reply)
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send availability reply")
+	}
+}
+
+// parseAvailability parses "<days>" and "<HH:MM-HH:MM>[,...]" or "off".
+func parseAvailability(daysValue, hoursValue string) ([]time.Weekday, []storage.AvailabilityWindow, error) {
+	set, err := parseWeekdays(daysValue)
+	if err != nil {
+		return nil, nil, err
+	}
+	var days []time.Weekday
+	for day, included := range set {
+		if included {
+			days = append(days, time.Weekday(day))
+		}
+	}
+
+	if hoursValue == "off" {
+		return days, nil, nil
+	}
+
+	var windows []storage.AvailabilityWindow
+	for _, hours := range strings.Split(hoursValue, ",") {
+		from, to, found := strings.Cut(hours, "-")
+		start, startErr := parseClock(from)
+		end, endErr := parseClock(to)
+		if !found || startErr != nil || endErr != nil || end <= start {
+			return nil, nil, fmt.Errorf("invalid hours %q, expected HH:MM-HH:MM with the end after the start", hours)
+		}
+		for _, day := range days {
+			windows = append(windows, storage.AvailabilityWindow{Weekday: day, Start: strings.TrimSpace(from), End: strings.TrimSpace(to)})
+		}
+	}
+
+	return days, windows, nil
+}
+
+// describeAvailability formats the availability for the admin, one line
+// per day, e.g. "mon: 10:00-12:00, 15:00-17:00".
+func describeAvailability(windows []storage.AvailabilityWindow, location *time.Location) string {
+	if len(windows) == 0 {
+		return "No availability set, so no consultations can be booked. Add some with /availability mon-fri 10:00-12:00"
+	}
+
+	hours := make(map[time.Weekday][]string)
+	for _, window := range windows {
+		hours[window.Weekday] = append(hours[window.Weekday], window.Start+"-"+window.End)
+	}
+
+	lines := []string{fmt.Sprintf("Weekly availability (%s):", location)}
+	// Monday first
+	for i := 1; i <= 7; i++ {
+		day := time.Weekday(i % 7)
+		if len(hours[day]) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s", weekdayNames[day], strings.Join(hours[day], ", ")))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,1431 @@
+// Package bot implements the FAQ bot: user conversations, admin commands,
+// background jobs and the optional integrations and servers around them.
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/commands"
+	"github.com/DilmurodYangiboev/faq_bot/internal/flows"
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// UserState is the conversation state of a user, see package flows.
+type UserState = flows.State
+
+const (
+	StateWelcome   UserState = "welcome"
+	StateQuestion  UserState = "question"
+	StateCVReview  UserState = "cv_review"
+	StateWaitingCV UserState = "waiting_cv"
+
+	StateConfirmQuestion UserState = "confirm_question"
+	StateCVIntake        UserState = "cv_intake"
+	StateCVTier          UserState = "cv_tier"
+	StateBooking         UserState = "booking"
+)
+
+// TelegramClient is the part of the Bot API the bot talks to.
+// *telegram.Client implements it; tests substitute a fake.
+type TelegramClient interface {
+	Send(chattable tgbotapi.Chattable) (tgbotapi.Message, error)
+	SendLong(chatID int64, text string, markup interface{}) ([]tgbotapi.Message, error)
+	SendLongMarkdown(chatID int64, text string, markup interface{}) ([]tgbotapi.Message, error)
+	Request(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	GetFile(config tgbotapi.FileConfig) (tgbotapi.File, error)
+	DownloadFile(fileID string) ([]byte, error)
+	Self() tgbotapi.User
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+}
+
+type Bot struct {
+	// mu guards the in-memory session state shared between the update loop
+	// and background jobs
+	mu sync.Mutex
+
+	// lastHeartbeat is the UnixNano time the update loop last ticked
+	lastHeartbeat atomic.Int64
+	// traceCtx holds the context of the span currently being handled
+	traceCtx atomic.Value
+	// adminSeen is the last time the admin interacted with the bot
+	adminSeen time.Time
+	// translations holds the message catalogs, swapped on /reload
+	translations atomic.Pointer[i18n.Bundle]
+	// welcomeVariants are the welcome menu texts new users are split
+	// between, empty without an experiment
+	welcomeVariants []string
+	// menuLayout arranges the welcome menu buttons, nil for the default
+	menuLayout [][]menuButton
+	// metrics counts handled updates, see serveMetrics
+	metrics updateMetrics
+	// pipeline is the middleware chain every update goes through,
+	// resumePipeline the one for updates handled again after a scan
+	pipeline       UpdateHandler
+	resumePipeline UpdateHandler
+
+	api TelegramClient
+	// mention matches "@bot" in group messages, see groupQuestion
+	mention *regexp.Regexp
+	// config is the validated configuration the bot was built from,
+	// replaced by /reload
+	config        *Config
+	adminID       int64
+	userSessions  map[int64]*UserSession
+	adminMessages map[int]*UserSession
+	previews      map[int]*answerPreview
+	// reviewForm is the structured CV review the admin is filling in
+	reviewForm   *reviewForm
+	tickets      map[int]*UserSession
+	userStates   map[int64]UserState
+	drafts       map[int64]*UserSession
+	lastUrgent   map[int64]time.Time
+	lastActivity map[int64]time.Time
+	sessionTTL   time.Duration
+	// nudgeDelay is how long a user may stall in a flow before a reminder,
+	// nudged the activity time each reminder was sent for
+	nudgeDelay time.Duration
+	nudged     map[int64]time.Time
+	// verifyUsers holds back users until they solve a verification,
+	// verifications is the open challenge of each of them
+	verifyUsers   bool
+	verifications map[int64]*verification
+	blocklist     *Blocklist
+	// malwareScanner checks uploaded files, nil without one. scannedMessage
+	// is the message found clean that is being handled again;
+	// pendingScans lets tests wait for the scans in the background
+	malwareScanner *ClamAV
+	scannedMessage *tgbotapi.Message
+	pendingScans   sync.WaitGroup
+	// faq answers common questions before they reach the admin
+	faq *FAQ
+	// terms users accept before they start a flow, nil without a gate
+	terms *Terms
+	// atsKeywords are the role keywords CVs are checked for
+	atsKeywords *ATSKeywords
+	// anonymizeCVs removes contact details from CVs shared with reviewers
+	anonymizeCVs bool
+	// reviewers are the team members tickets can be assigned to,
+	// seniorChatID the chat tickets are escalated to
+	reviewers    []Reviewer
+	seniorChatID int64
+	// acknowledgeNotify tells users when the admin acknowledges their
+	// ticket
+	acknowledgeNotify bool
+	// onboardingTutorial walks new users through the bot on their first
+	// /start
+	onboardingTutorial bool
+	urgentCooldown     time.Duration
+	slaThresholds      []time.Duration
+	surveyDelay        time.Duration
+	officeHours        *OfficeHours
+	userRateLimit      int
+	referralThanks     bool
+	retention          *Retention
+	// fileArchive keeps uploaded files beyond Telegram, nil without one
+	fileArchive    FileArchive
+	priorityReview *PriorityReview
+	stripe         *StripeClient
+	// subscriptionPlan is nil when every flow is free
+	subscriptionPlan *SubscriptionPlan
+	// subscriptionInvoices is the latest subscription invoice payload
+	// sent to each user
+	subscriptionInvoices map[int64]string
+	// consultations is nil when booking calls is disabled
+	consultations *Consultations
+	// reengagementPeriod is 0 when abandoned flows are not followed up
+	reengagementPeriod time.Duration
+	cvForms            map[int64]*CVIntake
+	integrations       *IntegrationRegistry
+	flows              *flows.Registry
+	commands           *commands.Router
+	sheets             *SheetsClient
+	notion             *NotionClient
+	tracker            *ticketTracker
+	slack              *SlackClient
+	email              *EmailNotifier
+	webhook            *Webhook
+	rpc                *grpcService
+	// searchIndex is the FTS5 index of answered tickets for /search
+	searchIndex ticketIndex
+	store       *storage.Store
+	audit       *AuditLogger
+	logger      *logrus.Logger
+}
+
+type UserSession struct {
+	TicketID     int
+	UserID       int64
+	Username     string
+	LastQuestion string
+	MessageID    int
+	AdminMsgID   int
+	HasFile      bool
+	FileName     string
+	// FileID is the Telegram file ID of the file attached to a question
+	FileID     string
+	State      UserState
+	Category   string
+	Urgent     bool
+	AfterHours bool
+	SLALevel   int
+	Digested   bool
+	Emailed    bool
+	CVIntake   *CVIntake
+	// Payment is PaymentPaid for priority CV reviews
+	Payment PaymentStatus
+	// Subscriber is set for tickets of subscribed users
+	Subscriber bool
+	// QueuePosition is the position in the queue the user was last told
+	QueuePosition int
+	// Pinned tickets are listed first in /sessions
+	Pinned bool
+	// SnoozedUntil hides the ticket from /sessions and SLA reminders
+	SnoozedUntil time.Time
+	// AssignedTo is the reviewer the ticket is assigned to, zero for the
+	// admin
+	AssignedTo int64
+	// EscalatedAt is when the ticket went to the senior reviewer
+	EscalatedAt time.Time
+	// AcknowledgedAt is when the admin pressed 👀 Acknowledge
+	AcknowledgedAt time.Time
+	// Group is set for questions asked in a group
+	Group      *GroupOrigin
+	CreatedAt  time.Time
+	AnsweredAt time.Time
+}
+
+// Priority reports whether the ticket goes before the others: it is
+// urgent, a paid review or from a subscriber.
+func (s *UserSession) Priority() bool {
+	return s.Urgent || s.Payment == PaymentPaid || s.Subscriber
+}
+
+// ChatID is the chat the answer goes to: the group the question was asked
+// in or the user's private chat.
+func (s *UserSession) ChatID() int64 {
+	if s.Group != nil {
+		return s.Group.ChatID
+	}
+
+	return s.UserID
+}
+
+// GroupMessageID is the question the answer replies to in a group, or zero
+// for private questions.
+func (s *UserSession) GroupMessageID() int {
+	if s.Group != nil {
+		return s.MessageID
+	}
+
+	return 0
+}
+
+// SetupLogger creates the JSON logger at the given level (LOG_LEVEL).
+func SetupLogger(level string) *logrus.Logger {
+	logger := logrus.New()
+
+	logger.SetFormatter(&logrus.JSONFormatter{
+		TimestampFormat: time.RFC3339,
+		FieldMap: logrus.FieldMap{
+			logrus.FieldKeyTime:  "timestamp",
+			logrus.FieldKeyLevel: "level",
+			logrus.FieldKeyMsg:   "message",
+		},
+	})
+
+	switch strings.ToLower(level) {
+	case "debug":
+		logger.SetLevel(logrus.DebugLevel)
+	case "info":
+		logger.SetLevel(logrus.InfoLevel)
+	case "warn", "warning":
+		logger.SetLevel(logrus.WarnLevel)
+	case "error":
+		logger.SetLevel(logrus.ErrorLevel)
+	default:
+		logger.SetLevel(logrus.ErrorLevel)
+	}
+
+	return logger
+}
+
+// New builds the bot from config: storage, message catalogs, limits and
+// every optional integration. api is wrapped so that Bot API calls are
+// traced.
+func New(api *tgbotapi.BotAPI, config *Config, logger *logrus.Logger) (*Bot, error) {
+	b, err := newBot(telegram.NewClient(api, logger), config, logger)
+	if err != nil {
+		return nil, err
+	}
+	api.Client = &tracingHTTPClient{bot: b, next: api.Client}
+
+	return b, nil
+}
+
+func newBot(api TelegramClient, config *Config, logger *logrus.Logger) (*Bot, error) {
+	adminID := config.Telegram.AdminID
+
+	dataFile := config.Storage.DataFile
+	if dataFile == "" {
+		dataFile = storage.DefaultDataFile
+	}
+
+	officeHours, err := officeHoursFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid office hours configuration: %w", err)
+	}
+
+	reengagementPeriod, err := reengagementPeriodFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewers, err := reviewersFromConfig(config, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REVIEWERS: %w", err)
+	}
+
+	terms, err := termsFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TERMS_FILE: %w", err)
+	}
+
+	atsKeywords, err := atsKeywordsFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ATS_KEYWORDS_FILE: %w", err)
+	}
+
+	faq, err := faqFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FAQ_FILE: %w", err)
+	}
+
+	retention, err := retentionFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	fileArchive, err := fileArchiveFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the file archive: %w", err)
+	}
+
+	priorityReview, err := priorityReviewFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment configuration: %w", err)
+	}
+
+	stripe, err := stripeClientFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up Stripe payments: %w", err)
+	}
+
+	subscriptionPlan, err := subscriptionPlanFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription configuration: %w", err)
+	}
+
+	consultations, err := consultationsFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid booking configuration: %w", err)
+	}
+
+	store, err := storage.OpenStore(dataFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data store: %w", err)
+	}
+
+	translations, err := loadTranslations(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load translations: %w", err)
+	}
+
+	welcomeVariants, err := welcomeVariantsFromConfig(config, translations)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WELCOME_VARIANTS: %w", err)
+	}
+
+	audit, err := NewAuditLogger(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up audit log: %w", err)
+	}
+
+	sheets, err := sheetsClientFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up Google Sheets sync: %w", err)
+	}
+
+	tracker, err := trackerFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up issue tracker: %w", err)
+	}
+
+	slack, err := slackClientFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up Slack mirror: %w", err)
+	}
+
+	email, err := emailNotifierFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up email fallback: %w", err)
+	}
+
+	webhook, err := webhookFromConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up webhook: %w", err)
+	}
+
+	b := &Bot{
+		api:                  api,
+		mention:              mentionPattern(api.Self().UserName),
+		config:               config,
+		adminID:              adminID,
+		userSessions:         make(map[int64]*UserSession),
+		adminMessages:        make(map[int]*UserSession),
+		previews:             make(map[int]*answerPreview),
+		tickets:              make(map[int]*UserSession),
+		userStates:           make(map[int64]UserState),
+		drafts:               make(map[int64]*UserSession),
+		lastUrgent:           make(map[int64]time.Time),
+		lastActivity:         make(map[int64]time.Time),
+		sessionTTL:           durationOr(config.Limits.SessionTTL, defaultSessionTTL),
+		nudgeDelay:           durationOr(config.Limits.StalledFlowNudge, defaultNudgeDelay),
+		nudged:               make(map[int64]time.Time),
+		verifyUsers:          enabled(config.Features.VerifyNewUsers),
+		verifications:        make(map[int64]*verification),
+		blocklist:            blocklistFromConfig(config),
+		malwareScanner:       clamAVFromConfig(config),
+		faq:                  faq,
+		terms:                terms,
+		atsKeywords:          atsKeywords,
+		anonymizeCVs:         enabled(config.Team.AnonymizeCVs),
+		reviewers:            reviewers,
+		seniorChatID:         config.Team.SeniorReviewerChatID,
+		acknowledgeNotify:    enabled(config.Features.AcknowledgeNotifyUser),
+		onboardingTutorial:   enabled(config.Features.OnboardingTutorial),
+		welcomeVariants:      welcomeVariants,
+		reengagementPeriod:   reengagementPeriod,
+		urgentCooldown:       durationOr(config.Limits.UrgentCooldown, defaultUrgentCooldown),
+		slaThresholds:        slaThresholdsFromConfig(config),
+		surveyDelay:          durationOr(config.Limits.FollowUpSurveyDelay, defaultSurveyDelay),
+		officeHours:          officeHours,
+		userRateLimit:        intOr(config.Limits.UserRateLimit, defaultUserRateLimit),
+		referralThanks:       enabled(config.Features.ReferralThanks),
+		retention:            retention,
+		fileArchive:          fileArchive,
+		priorityReview:       priorityReview,
+		stripe:               stripe,
+		subscriptionPlan:     subscriptionPlan,
+		subscriptionInvoices: make(map[int64]string),
+		consultations:        consultations,
+		cvForms:              make(map[int64]*CVIntake),
+		integrations:         NewIntegrationRegistry(),
+		flows:                flows.NewRegistry(StateWelcome),
+		commands:             commands.NewRouter(),
+		sheets:               sheets,
+		notion:               notionClientFromConfig(config),
+		tracker:              tracker,
+		slack:                slack,
+		email:                email,
+		webhook:              webhook,
+		store:                store,
+		audit:                audit,
+		logger:               logger,
+	}
+
+	b.translations.Store(translations)
+	b.loadOpenTickets()
+	b.pipeline = b.newUpdatePipeline()
+	b.resumePipeline = b.newResumePipeline()
+	if err := b.registerFlows(); err != nil {
+		return nil, fmt.Errorf("failed to register conversation flows: %w", err)
+	}
+	// The layout refers to the flows, so it is read once they are known
+	if b.menuLayout, err = b.menuLayoutFromConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid MENU_LAYOUT: %w", err)
+	}
+	if err := b.registerCommands(); err != nil {
+		return nil, fmt.Errorf("failed to register commands: %w", err)
+	}
+
+	if sheets != nil {
+		b.integrations.Register(integrationSheets, FallbackRetry)
+	}
+	if b.notion != nil {
+		b.integrations.Register(integrationNotion, FallbackRetry)
+	}
+	if tracker != nil {
+		b.integrations.Register(tracker.Name(), FallbackRetry)
+	}
+	if slack != nil {
+		b.integrations.Register(integrationSlack, FallbackRetry)
+	}
+	if email != nil {
+		b.integrations.Register(integrationEmail, FallbackRetry)
+	}
+	if webhook != nil {
+		b.integrations.Register(integrationWebhook, FallbackRetry)
+	}
+	if fileArchive != nil {
+		b.integrations.Register(integrationFileArchive, FallbackRetry)
+	}
+
+	return b, nil
+}
+
+// Run starts the background jobs and servers, then handles updates until
+// the update channel closes.
+func (b *Bot) Run() error {
+	reportTime, reportEnabled, err := dailyReportTimeFromConfig(b.config)
+	if err != nil {
+		return fmt.Errorf("invalid DAILY_REPORT_TIME format, expected HH:MM: %w", err)
+	}
+
+	if err := b.publishCommands(); err != nil {
+		b.logger.WithError(err).Error("Failed to publish the command menu")
+	}
+
+	if b.email != nil {
+		go b.runEmailFallback()
+	}
+
+	b.notifyInterruptedScans()
+
+	go b.runIntegrationRetries()
+	go b.runOutbox()
+	go b.runJanitor()
+
+	go b.runFollowUpSurveys()
+	go b.runSLAReminders()
+	go b.runSnoozes()
+	if b.consultations != nil {
+		go b.runBookingReminders()
+	}
+	if b.reengagementPeriod > 0 {
+		go b.runReengagement()
+	}
+
+	go b.runDigest()
+
+	if b.retention != nil {
+		go b.runRetention()
+	}
+
+	if reportEnabled {
+		go b.runDailyReport(reportTime)
+	}
+
+	// Resume after the last handled update; Telegram drops everything
+	// before the offset
+	u := tgbotapi.NewUpdate(b.store.LastUpdateID() + 1)
+	u.Timeout = 60
+
+	updates := b.api.GetUpdatesChan(u)
+
+	b.beat()
+	b.startHealthServer()
+	b.startSlackEventsServer()
+	b.startStripeWebhookServer()
+	b.startAPIServer()
+	b.startGRPCServer()
+	b.startDashboard()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			b.handleUpdate(update)
+		case <-heartbeat.C:
+		}
+		b.beat()
+	}
+}
+
+func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pipeline(update)
+}
+
+func (b *Bot) handleMessage(message *tgbotapi.Message) {
+	userID := message.From.ID
+	username := message.From.UserName
+
+	if isGroupChat(message.Chat) {
+		b.handleGroupMessage(message)
+	} else if message.SuccessfulPayment != nil {
+		b.handleSuccessfulPayment(message)
+	} else if userID == b.adminID {
+		defer b.startSpan("handler.admin_message")()
+		b.handleAdminMessage(message)
+	} else if b.isReviewer(userID) {
+		b.handleReviewerMessage(message)
+	} else {
+		b.handleUserQuestion(message, userID, username)
+	}
+}
+
+func (b *Bot) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
+	userID := callback.From.ID
+
+	defer b.startSpan("handler.callback", attribute.String("telegram.callback_data", callback.Data))()
+
+	expired := b.callbackExpired(userID, callback.Data)
+	notice := ""
+	if expired {
+		notice = b.tr(userID, "menu_expired")
+	}
+
+	callbackConfig := tgbotapi.NewCallback(callback.ID, notice)
+	_, err := b.api.Request(callbackConfig)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to answer callback query")
+	}
+
+	if expired {
+		b.logger.WithFields(logrus.Fields{
+			"user_id":       userID,
+			"callback_data": callback.Data,
+		}).Debug("Expired callback received")
+		b.showCurrentMenu(userID)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "ticket:") && userID == b.adminID {
+		b.handleOpenTicketCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "answer:") && userID == b.adminID {
+		b.handleShowAnswerCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "review:") && userID == b.adminID {
+		b.handleReviewFormCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "preview:") && userID == b.adminID {
+		b.handlePreviewCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "undo:") && userID == b.adminID {
+		b.handleUndoCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "ack:") && userID == b.adminID {
+		b.handleAcknowledgeCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "snooze:") && userID == b.adminID {
+		b.handleSnoozeCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "assign:") && userID == b.adminID {
+		b.handleAssignCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "rate:") {
+		b.handleRatingCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "category:") {
+		b.handleCategoryCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "history:") {
+		b.handleHistoryCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "survey:") {
+		b.handleSurveyCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "language:") {
+		b.handleLanguageCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "cv_tier:") {
+		b.handleCVTierCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "sub:") {
+		b.handleSubscriptionCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "booking:") && b.consultations != nil {
+		b.handleBookingCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "reengage:") {
+		b.handleReengagementCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "terms:") {
+		b.handleTermsCallback(callback)
+		return
+	}
+
+	if strings.HasPrefix(callback.Data, "tutorial:") {
+		b.handleTutorialCallback(callback)
+		return
+	}
+
+	if flow, exists := b.flows.Flow(callback.Data); exists {
+		flow.Start(userID)
+		return
+	}
+
+	switch callback.Data {
+	case "help":
+		b.showUserHelp(userID)
+	case "commands":
+		b.showUserCommands(userID)
+	case "language":
+		b.showLanguagePicker(userID)
+	case "back_to_menu":
+		b.showWelcomeMenu(userID)
+	case "cancel":
+		b.cancelCurrentAction(userID)
+	case "confirm_send":
+		if b.userStates[userID] == StateConfirmQuestion {
+			b.submitQuestionDraft(userID)
+		}
+	case "cv_intake_skip":
+		if b.userStates[userID] == StateCVIntake {
+			b.recordCVIntakeAnswer(userID, "")
+		}
+	case "confirm_urgent":
+		if b.userStates[userID] == StateConfirmQuestion {
+			b.submitUrgentQuestionDraft(userID)
+		}
+	case "confirm_edit":
+		if b.userStates[userID] == StateConfirmQuestion {
+			b.editQuestionDraft(userID)
+		}
+	case "1":
+		// Handle Google Drive choice for CV upload
+		if b.userStates[userID] == StateWaitingCV {
+			b.showCVInstructions(userID)
+		}
+	case "2":
+		// Handle direct file upload choice for CV
+		if b.userStates[userID] == StateWaitingCV {
+			// Create a session with direct upload
+			b.createUserSession(userID, callback.From.UserName, "CV Review Request - File uploaded directly", 0, true, "", StateCVReview)
+		}
+	default:
+		b.logger.WithFields(logrus.Fields{
+			"user_id":       userID,
+			"callback_data": callback.Data,
+		}).Error("Unknown callback data received")
+	}
+}
+
+func (b *Bot) showUserHelp(userID int64) {
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "user_help"))
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send user help")
+	}
+}
+
+func (b *Bot) cancelCurrentAction(userID int64) {
+	b.userStates[userID] = StateWelcome
+	delete(b.drafts, userID)
+	delete(b.cvForms, userID)
+
+	// Show welcome menu with buttons
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_ask_question"), "question"),
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_cv_review"), "cv_review"),
+		),
+	)
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "action_cancelled"))
+	msg.ReplyMarkup = keyboard
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send cancel message")
+	}
+}
+
+func (b *Bot) handleUserQuestion(message *tgbotapi.Message, userID int64, username string) {
+
+	// Handle commands first
+	if b.routeCommand(message) {
+		return
+	}
+
+	currentState, exists := b.userStates[userID]
+	if !exists {
+		b.showWelcomeMenu(userID)
+		return
+	}
+
+	defer b.startSpan("state."+string(currentState), attribute.Int64("telegram.user_id", userID))()
+
+	if currentState == StateWelcome {
+		b.handleWelcomeState(message, userID, username)
+	} else if handler, exists := b.flows.StateHandler(currentState); exists {
+		handler(message, userID, username)
+	} else {
+		b.showWelcomeMenu(userID)
+	}
+}
+
+func (b *Bot) showWelcomeMenu(userID int64) {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(b.welcomeMenuRows(userID)...)
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, b.welcomeVariant(userID)))
+	msg.ReplyMarkup = keyboard
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send welcome menu")
+		return
+	}
+
+	b.userStates[userID] = StateWelcome
+}
+
+func (b *Bot) handleWelcomeState(message *tgbotapi.Message, userID int64, username string) {
+	text := strings.ToLower(strings.TrimSpace(message.Text))
+
+	if text == "/start" || text == "/menu" || text == "menu" {
+		b.showWelcomeMenu(userID)
+		return
+	}
+
+	if flow, exists := b.flows.ForWelcomeText(text); exists {
+		flow.Start(userID)
+	} else {
+		b.showWelcomeMenu(userID)
+	}
+}
+
+func (b *Bot) startQuestionFlow(userID int64) {
+	// Category picker plus a cancel button for easier navigation
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		b.categoryKeyboardRow(userID),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_back_to_menu"), "back_to_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_cancel"), "cancel"),
+		),
+	)
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "question_instructions"))
+	msg.ReplyMarkup = keyboard
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send question flow instructions")
+		return
+	}
+
+	b.drafts[userID] = &UserSession{UserID: userID, State: StateQuestion}
+	b.userStates[userID] = StateQuestion
+	b.recordFlowStart(userID, "question")
+}
+
+func (b *Bot) startCVReviewFlow(userID int64) {
+	b.cvForms[userID] = &CVIntake{}
+	b.askCVIntakeStep(userID)
+	b.recordFlowStart(userID, "cv_review")
+}
+
+func (b *Bot) showCVInstructions(userID int64) {
+	// Add navigation buttons
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_back_to_menu"), "back_to_menu"),
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_cancel"), "cancel"),
+		),
+	)
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "cv_instructions"))
+	msg.ReplyMarkup = keyboard
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send CV review flow instructions")
+		return
+	}
+
+	b.userStates[userID] = StateCVReview
+}
+
+// questionText is the question a message asks, including the name of an
+// attached file.
+func questionText(message *tgbotapi.Message) string {
+	if message.Document == nil {
+		return message.Text
+	}
+
+	if message.Caption != "" {
+		return fmt.Sprintf("[File: %s] %s", message.Document.FileName, message.Caption)
+	}
+	return fmt.Sprintf("[File: %s]", message.Document.FileName)
+}
+
+func (b *Bot) handleQuestionState(message *tgbotapi.Message, userID int64, username string) {
+	if b.rejectBlockedLink(message) || b.rejectInfectedFile(tgbotapi.Update{Message: message}) {
+		return
+	}
+
+	questionText := questionText(message)
+	var hasFile bool
+	var fileName, fileID string
+
+	if message.Document != nil {
+		hasFile = true
+		fileName = message.Document.FileName
+		fileID = message.Document.FileID
+	}
+
+	category := ""
+	if draft, exists := b.drafts[userID]; exists {
+		category = draft.Category
+	}
+
+	b.drafts[userID] = &UserSession{
+		UserID:       userID,
+		Username:     username,
+		LastQuestion: questionText,
+		MessageID:    message.MessageID,
+		HasFile:      hasFile,
+		FileName:     fileName,
+		FileID:       fileID,
+		State:        StateQuestion,
+		Category:     category,
+	}
+
+	b.suggestFAQAnswer(userID, questionText)
+	b.showQuestionConfirmation(userID)
+}
+
+func (b *Bot) showQuestionConfirmation(userID int64) {
+	draft := b.drafts[userID]
+
+	confirmText := b.trMarkdown(userID, "question_confirmation", map[string]interface{}{
+		"Category": b.userCategoryLabel(userID, draft.Category),
+		"Question": draft.LastQuestion,
+	})
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_send"), "confirm_send"),
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_send_urgent"), "confirm_urgent"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_edit"), "confirm_edit"),
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_cancel"), "cancel"),
+		),
+	)
+
+	_, err := b.api.SendLongMarkdown(userID, confirmText, keyboard)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send question confirmation")
+		return
+	}
+
+	b.userStates[userID] = StateConfirmQuestion
+}
+
+func (b *Bot) submitQuestionDraft(userID int64) {
+	draft, exists := b.drafts[userID]
+	if !exists {
+		b.showWelcomeMenu(userID)
+		return
+	}
+
+	// The draft is still in place so createUserSession can pick up the category
+	b.createUserSession(userID, draft.Username, draft.LastQuestion, draft.MessageID, draft.HasFile, draft.FileName, draft.State)
+	delete(b.drafts, userID)
+}
+
+func (b *Bot) editQuestionDraft(userID int64) {
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "edit_question_prompt"))
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send edit question prompt")
+		return
+	}
+
+	b.userStates[userID] = StateQuestion
+}
+
+func (b *Bot) handleCVReviewState(message *tgbotapi.Message, userID int64, username string) {
+	if b.rejectBlockedLink(message) || b.rejectInfectedFile(tgbotapi.Update{Message: message}) {
+		return
+	}
+
+	text := message.Text
+
+	if strings.Contains(text, "drive.google.com") || strings.Contains(text, "docs.google.com") {
+		questionText := fmt.Sprintf("CV Review Request - Google Drive Link: %s", text)
+		b.createUserSession(userID, username, questionText, message.MessageID, false, "", StateCVReview)
+	} else if message.Document != nil {
+		if intake, exists := b.cvForms[userID]; exists {
+			intake.CVFileID = message.Document.FileID
+			intake.CVFileName = message.Document.FileName
+		}
+
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "cv_file_uploaded_help"))
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send file upload help message")
+			return
+		}
+
+		b.userStates[userID] = StateWaitingCV
+	} else {
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "cv_link_retry"))
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send CV retry message")
+		}
+	}
+}
+
+func (b *Bot) handleWaitingCVState(message *tgbotapi.Message, userID int64, username string) {
+	text := strings.ToLower(strings.TrimSpace(message.Text))
+
+	if text == "1" {
+		b.showCVInstructions(userID)
+	} else if text == "2" {
+		questionText := fmt.Sprintf("CV Review Request - File uploaded directly")
+		if message.Document != nil {
+			questionText = fmt.Sprintf("CV Review Request - File: %s", message.Document.FileName)
+		}
+		b.createUserSession(userID, username, questionText, message.MessageID, true, "", StateCVReview)
+	} else {
+		b.showCVUploadChoice(userID)
+	}
+}
+
+func (b *Bot) showCVUploadChoice(userID int64) {
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_google_drive"), "1"),
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_upload_file"), "2"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_back_to_menu"), "back_to_menu"),
+		),
+	)
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "cv_choice_help"))
+	msg.ReplyMarkup = keyboard
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send CV choice help message")
+	}
+}
+
+// sendChatAction shows an action such as "typing…" in the chat until the
+// next message arrives or a few seconds pass, so slow work does not look like
+// a dead bot.
+func (b *Bot) sendChatAction(chatID int64, action string) {
+	_, err := b.api.Request(tgbotapi.NewChatAction(chatID, action))
+	if err != nil {
+		b.logger.WithError(err).WithField("chat_id", chatID).Debug("Failed to send chat action")
+	}
+}
+
+func (b *Bot) createUserSession(userID int64, username, questionText string, messageID int, hasFile bool, fileName string, state UserState) {
+	// Persisting and announcing the ticket waits on disk and Telegram
+	b.sendChatAction(userID, tgbotapi.ChatTyping)
+
+	session := &UserSession{
+		UserID:       userID,
+		Username:     username,
+		LastQuestion: questionText,
+		MessageID:    messageID,
+		HasFile:      hasFile,
+		FileName:     fileName,
+		State:        state,
+		Subscriber:   b.subscribed(userID),
+		CreatedAt:    time.Now(),
+	}
+
+	if state == StateCVReview {
+		session.CVIntake = b.cvForms[userID]
+		delete(b.cvForms, userID)
+		if _, paid := b.store.UnusedPayment(userID); paid {
+			session.Payment = PaymentPaid
+		}
+	} else {
+		session.Category = defaultCategory
+		if draft, exists := b.drafts[userID]; exists {
+			if draft.Category != "" {
+				session.Category = draft.Category
+			}
+			session.Urgent = draft.Urgent
+			if hasFile {
+				session.FileID = draft.FileID
+			}
+		}
+	}
+
+	var confirmText string
+	if state == StateCVReview {
+		confirmText = b.trMarkdown(userID, "confirmation_cv")
+	} else if hasFile {
+		confirmText = b.trMarkdown(userID, "confirmation_question_file")
+	} else {
+		confirmText = b.trMarkdown(userID, "confirmation_question")
+	}
+
+	if b.officeHours != nil && !b.officeHours.IsOpen(session.CreatedAt) {
+		session.AfterHours = true
+		confirmText += "\n\n" + b.afterHoursNotice(userID, session.CreatedAt)
+	}
+
+	session.QueuePosition = b.queuePosition(session)
+	confirmText += "\n\n" + b.queueNotice(userID, "queue_position", session.QueuePosition, b.typicalResponseTime())
+
+	confirmMsg := telegram.NewMarkdownMessage(userID, confirmText)
+	_, err := b.api.Send(confirmMsg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send confirmation message to user")
+		return
+	}
+
+	if b.openTicket(session) {
+		b.userStates[userID] = StateWelcome
+	}
+}
+
+// openTicket numbers and persists the ticket of session, notifies the admin
+// and the integrations. It reports false if the admin could not be notified.
+func (b *Bot) openTicket(session *UserSession) bool {
+	userID := session.UserID
+
+	ticketID, err := b.store.NextTicketID()
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist ticket counter")
+	}
+	session.TicketID = ticketID
+
+	if !b.digestEnabled() || session.Priority() {
+		err = b.sendAdminNotification(session)
+		if err != nil {
+			b.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":  userID,
+				"admin_id": b.adminID,
+			}).Error("Failed to send notification to admin")
+			return false
+		}
+	}
+
+	b.userSessions[userID] = session
+	b.tickets[ticketID] = session
+
+	// The payment is only tied to the ticket once the admin knows about it,
+	// a ticket that failed to open leaves it for the next attempt
+	if session.Payment == PaymentPaid {
+		used, err := b.store.UsePayment(userID, ticketID)
+		if err != nil {
+			b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to assign payment to ticket")
+		}
+		if !used {
+			session.Payment = ""
+		}
+	}
+
+	ticket := storage.TicketRecord{
+		ID:        ticketID,
+		UserID:    userID,
+		Username:  session.Username,
+		Kind:      string(session.State),
+		Category:  session.Category,
+		Question:  session.LastQuestion,
+		CreatedAt: session.CreatedAt,
+		Paid:      session.Payment == PaymentPaid,
+	}
+	if session.Group != nil {
+		ticket.GroupChatID = session.Group.ChatID
+		ticket.GroupMessageID = session.MessageID
+	}
+	err = b.store.AddTicket(ticket)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to persist ticket")
+	}
+	b.syncTicketToSheet(ticket)
+	b.syncCVReviewToNotion(ticket)
+	b.createTrackerIssue(ticket)
+	b.postTicketToSlack(ticket)
+	b.emitTicketCreatedWebhook(ticket)
+	b.archiveTicketFile(session)
+	b.publishQuestion(session)
+	b.recordReturn(userID, ticketID)
+
+	b.audit.Record(AuditTicketCreated, userID, logrus.Fields{
+		"ticket_id":   ticketID,
+		"kind":        session.State,
+		"category":    session.Category,
+		"urgent":      session.Urgent,
+		"after_hours": session.AfterHours,
+		"group":       session.Group != nil,
+	})
+
+	return true
+}
+
+// sendAdminNotification sends the ticket notification the admin replies to
+// in order to answer the user.
+func (b *Bot) sendAdminNotification(session *UserSession) error {
+	var adminNotification string
+	var icon string
+
+	switch session.State {
+	case StateCVReview:
+		icon = "📄 "
+	case StateQuestion:
+		if session.HasFile {
+			icon = "📎 "
+		} else {
+			icon = "❓ "
+		}
+	default:
+		icon = "💬 "
+	}
+
+	body := session.LastQuestion
+	if session.CVIntake != nil {
+		body += "\n\n" + session.CVIntake.Summary()
+	}
+
+	if session.State != StateCVReview {
+		icon = fmt.Sprintf("[%s] %s", categoryLabel(session.Category), icon)
+	}
+	if session.Urgent {
+		icon = "🚨 URGENT " + icon
+	}
+	if session.Payment == PaymentPaid {
+		icon = "💳 PAID PRIORITY " + icon
+	}
+	if session.Subscriber {
+		icon = "⭐ SUBSCRIBER " + icon
+	}
+	if session.AfterHours {
+		icon = "🌙 AFTER HOURS " + icon
+	}
+
+	profile := b.userProfileLine(session)
+	if profile != "" {
+		profile = "\n" + profile
+	}
+	if session.Group != nil {
+		profile += "\n" + session.Group.Describe()
+	}
+	if notes := b.userNotesLines(session.UserID); notes != "" {
+		profile += "\n" + notes
+	}
+	if comments := b.ticketCommentLines(session.TicketID); comments != "" {
+		profile += "\n" + comments
+	}
+
+	if session.Username != "" {
+		adminNotification = fmt.Sprintf("%sNew message from @%s (ID: %d, ticket #%d):%s\n\n%s\n\n💡 Simply reply to this message to answer the user",
+			icon, session.Username, session.UserID, session.TicketID, profile, body)
+	} else {
+		adminNotification = fmt.Sprintf("%sNew message from user (ID: %d, ticket #%d):%s\n\n%s\n\n💡 Simply reply to this message to answer the user",
+			icon, session.UserID, session.TicketID, profile, body)
+	}
+
+	sent, err := b.api.SendLong(b.adminID, adminNotification, b.notificationKeyboard(session))
+	if err != nil {
+		return err
+	}
+
+	// Replying to any part of a split notification answers the ticket
+	session.AdminMsgID = sent[len(sent)-1].MessageID
+	messageIDs := make([]int, 0, len(sent))
+	for _, part := range sent {
+		b.adminMessages[part.MessageID] = session
+		messageIDs = append(messageIDs, part.MessageID)
+	}
+
+	err = b.store.MapAdminMessages(session.TicketID, messageIDs)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", session.TicketID).Error("Failed to persist admin notification messages")
+	}
+
+	return nil
+}
+
+func (b *Bot) handleAdminMessage(message *tgbotapi.Message) {
+	text := message.Text
+
+	if message.ReplyToMessage != nil {
+		session := b.sessionForAdminMessage(message.ReplyToMessage.MessageID)
+		if session != nil {
+			answer, ok := b.replyAnswer(text, session)
+			if !ok {
+				return
+			}
+
+			b.answerTicket(session, answer, message.MessageID)
+			return
+		}
+	}
+
+	if b.reviewForm != nil && text != "" && !strings.HasPrefix(text, "/") {
+		b.recordReviewComment(text)
+		return
+	}
+
+	b.routeCommand(message)
+}
+
+// replyAnswer turns the admin's reply into the answer: the text itself, a
+// saved template ("/t <name>") or the answer of a past ticket
+// ("/reuse <ticket_id>"). Errors are reported to the admin.
+func (b *Bot) replyAnswer(text string, session *UserSession) (string, bool) {
+	if name, ok := strings.CutPrefix(text, "/t "); ok {
+		rendered, err := b.renderSavedTemplate(strings.TrimSpace(name), session)
+		if err != nil {
+			errorMsg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("❌ Template error: %v", err))
+			b.api.Send(errorMsg)
+			return "", false
+		}
+		return rendered, true
+	}
+
+	if id, ok := strings.CutPrefix(text, "/reuse "); ok {
+		previous, err := b.previousAnswer(strings.TrimSpace(id))
+		if err != nil {
+			errorMsg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("❌ %v", err))
+			b.api.Send(errorMsg)
+			return "", false
+		}
+		return previous, true
+	}
+
+	return text, true
+}
+
+// sessionForAdminMessage returns the open ticket an admin notification
+// message belongs to.
+func (b *Bot) sessionForAdminMessage(messageID int) *UserSession {
+	return b.adminMessages[messageID]
+}
+
+func (b *Bot) deliverAnswer(session *UserSession, answer string) {
+	userID := session.UserID
+
+	responseToUser := b.trMarkdown(userID, "answer_delivered", map[string]interface{}{"Answer": formatAnswer(answer)})
+	// Anyone in a group could press the rating buttons
+	var keyboard *tgbotapi.InlineKeyboardMarkup
+	if session.Group == nil {
+		ratingButtons := ratingKeyboard(session.TicketID)
+		keyboard = &ratingButtons
+	}
+	queuedID, err := b.deliverReliably(session.ChatID(), session.GroupMessageID(), session.TicketID, responseToUser, keyboard)
+	queued := queuedID != 0
+
+	recipient := fmt.Sprintf("user ID: %d", userID)
+	if session.Username != "" {
+		recipient = "@" + session.Username
+	}
+
+	if err != nil {
+		b.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":  userID,
+			"admin_id": b.adminID,
+		}).Error("Failed to send admin reply to user")
+		errorMsg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("❌ Reply to %s (ticket #%d) was not delivered: %s. The ticket stays open.",
+			recipient, session.TicketID, telegram.DeliveryFailure(err)))
+		if _, err := b.api.Send(errorMsg); err != nil {
+			b.logger.WithError(err).Error("Failed to send delivery failure to admin")
+		}
+		return
+	}
+
+	session.AnsweredAt = time.Now()
+	responseTime := formatDuration(session.AnsweredAt.Sub(session.CreatedAt))
+
+	confirmationMsg := fmt.Sprintf("✅ Reply sent successfully to %s (response time: %s)", recipient, responseTime)
+	if queued {
+		confirmationMsg = fmt.Sprintf("📤 Reply to %s is queued: Telegram did not accept it yet, the bot keeps retrying (response time: %s)", recipient, responseTime)
+	}
+
+	confirmMsg := tgbotapi.NewMessage(b.adminID, confirmationMsg)
+	if !queued {
+		confirmMsg.ReplyMarkup = undoKeyboard(session.TicketID)
+	}
+	confirmation, err := b.api.Send(confirmMsg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send confirmation to admin")
+	} else if queued {
+		b.trackQueuedDelivery(queuedID, confirmation)
+	}
+
+	b.audit.Record(AuditAnswerSent, b.adminID, logrus.Fields{
+		"user_id":   userID,
+		"ticket_id": session.TicketID,
+		"text":      answer,
+	})
+
+	err = b.store.AnswerTicket(session.TicketID, answer, session.AnsweredAt)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", session.TicketID).Error("Failed to persist answered ticket")
+	}
+	b.syncTicketStatusToSheet(session.TicketID, "answered")
+	b.markCVReviewedInNotion(session)
+	b.closeTrackerIssue(session.TicketID, answer)
+	b.postAnswerToSlack(session.TicketID, answer)
+	if ticket, exists := b.store.Ticket(session.TicketID); exists {
+		b.emitWebhook(WebhookAnswered, ticket)
+	}
+
+	b.closeSession(session)
+}
+
+// closeSession removes an open ticket, including every admin notification
+// message that maps to it.
+func (b *Bot) closeSession(session *UserSession) {
+	if b.userSessions[session.UserID] == session {
+		delete(b.userSessions, session.UserID)
+	}
+	delete(b.tickets, session.TicketID)
+	for msgID, candidate := range b.adminMessages {
+		if candidate == session {
+			delete(b.adminMessages, msgID)
+		}
+	}
+
+	b.updateQueuePositions()
+}
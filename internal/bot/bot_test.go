@@ -0,0 +1,2428 @@
+package bot
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const (
+	testAdminID int64 = 1000
+	testUserID  int64 = 42
+)
+
+// testConfig loads the configuration the test set up in the environment.
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	config.Telegram.AdminID = testAdminID
+
+	return config
+}
+
+// newTestBot builds a bot on a mock client, with its data and audit log in
+// a temporary directory.
+func newTestBot(t *testing.T) (*Bot, *mockTelegram) {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("DATA_FILE", filepath.Join(dir, "data.json"))
+	t.Setenv("AUDIT_LOG_FILE", filepath.Join(dir, "audit.log"))
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	api := newMockTelegram()
+	b, err := newBot(api, testConfig(t), logger)
+	if err != nil {
+		t.Fatalf("newBot: %v", err)
+	}
+
+	return b, api
+}
+
+func userMessage(userID int64, text string) *tgbotapi.Message {
+	return &tgbotapi.Message{
+		MessageID: 1,
+		From:      &tgbotapi.User{ID: userID, UserName: "tester"},
+		Chat:      &tgbotapi.Chat{ID: userID},
+		Text:      text,
+	}
+}
+
+func userCallback(userID int64, data string) *tgbotapi.CallbackQuery {
+	return &tgbotapi.CallbackQuery{
+		ID:   "callback",
+		From: &tgbotapi.User{ID: userID, UserName: "tester"},
+		Data: data,
+	}
+}
+
+func assertState(t *testing.T, b *Bot, want UserState) {
+	t.Helper()
+
+	if got := b.userStates[testUserID]; got != want {
+		t.Fatalf("state = %q, want %q", got, want)
+	}
+}
+
+// submitQuestion walks testUserID through the question flow and returns the
+// opened ticket.
+func submitQuestion(t *testing.T, b *Bot, question string) *UserSession {
+	t.Helper()
+
+	b.handleMessage(userMessage(testUserID, "/question"))
+	b.handleMessage(userMessage(testUserID, question))
+	b.handleCallbackQuery(userCallback(testUserID, "confirm_send"))
+
+	session, exists := b.userSessions[testUserID]
+	if !exists {
+		t.Fatal("no ticket was opened")
+	}
+
+	return session
+}
+
+func TestHandleUserQuestionShowsWelcomeMenuToNewUsers(t *testing.T) {
+	b, api := newTestBot(t)
+
+	b.handleUserQuestion(userMessage(testUserID, "hello"), testUserID, "tester")
+
+	assertState(t, b, StateWelcome)
+	msg := api.lastMessage(t, testUserID)
+	if msg.Text != b.trMarkdown(testUserID, "welcome_menu") {
+		t.Errorf("sent %q, want the welcome menu", msg.Text)
+	}
+	if _, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); !ok {
+		t.Errorf("welcome menu has no inline keyboard")
+	}
+}
+
+func TestHandleUserQuestionStartsFlowByCommand(t *testing.T) {
+	b, api := newTestBot(t)
+
+	b.handleUserQuestion(userMessage(testUserID, "/question"), testUserID, "tester")
+
+	assertState(t, b, StateQuestion)
+	if got := api.lastMessage(t, testUserID).Text; got != b.trMarkdown(testUserID, "question_instructions") {
+		t.Errorf("sent %q, want the question instructions", got)
+	}
+}
+
+func TestQuestionFlowStateTransitions(t *testing.T) {
+	b, api := newTestBot(t)
+
+	b.handleMessage(userMessage(testUserID, "/start"))
+	assertState(t, b, StateWelcome)
+
+	b.handleCallbackQuery(userCallback(testUserID, "question"))
+	assertState(t, b, StateQuestion)
+
+	b.handleCallbackQuery(userCallback(testUserID, "category:jobs"))
+	assertState(t, b, StateQuestion)
+	// A crafted category is not stored on the ticket
+	b.handleCallbackQuery(userCallback(testUserID, "category:"+strings.Repeat("x", 1000)))
+
+	b.handleMessage(userMessage(testUserID, "How do I apply?"))
+	assertState(t, b, StateConfirmQuestion)
+	if draft := b.drafts[testUserID]; draft == nil || draft.LastQuestion != "How do I apply?" {
+		t.Fatalf("draft = %+v, want the question", draft)
+	}
+
+	b.handleCallbackQuery(userCallback(testUserID, "confirm_edit"))
+	assertState(t, b, StateQuestion)
+
+	b.handleMessage(userMessage(testUserID, "How do I apply for a job?"))
+	assertState(t, b, StateConfirmQuestion)
+
+	b.handleCallbackQuery(userCallback(testUserID, "confirm_send"))
+	assertState(t, b, StateWelcome)
+
+	session, exists := b.userSessions[testUserID]
+	if !exists {
+		t.Fatal("no ticket was opened")
+	}
+	if session.LastQuestion != "How do I apply for a job?" || session.Category != "jobs" {
+		t.Errorf("ticket = %q in %q, want the edited question in jobs", session.LastQuestion, session.Category)
+	}
+	if _, exists := b.drafts[testUserID]; exists {
+		t.Error("draft was kept after submitting")
+	}
+	if _, exists := b.store.Ticket(session.TicketID); !exists {
+		t.Errorf("ticket #%d was not persisted", session.TicketID)
+	}
+	if !strings.Contains(api.lastMessage(t, testAdminID).Text, "How do I apply for a job?") {
+		t.Error("admin notification does not contain the question")
+	}
+	// Every callback is answered so the client stops its spinner
+	answered, typing := 0, false
+	for _, request := range api.requests {
+		switch request := request.(type) {
+		case tgbotapi.CallbackConfig:
+			answered++
+		case tgbotapi.ChatActionConfig:
+			typing = typing || request.ChatID == testUserID && request.Action == tgbotapi.ChatTyping
+		}
+	}
+	if answered != 5 {
+		t.Errorf("answered %d callbacks, want 5", answered)
+	}
+	// The user sees the bot typing while the ticket is submitted
+	if !typing {
+		t.Error("no typing action was sent while submitting the ticket")
+	}
+}
+
+func TestCancelReturnsToWelcome(t *testing.T) {
+	b, api := newTestBot(t)
+
+	b.handleMessage(userMessage(testUserID, "/question"))
+	b.handleMessage(userMessage(testUserID, "Draft question"))
+	assertState(t, b, StateConfirmQuestion)
+
+	b.handleMessage(userMessage(testUserID, "/cancel"))
+
+	assertState(t, b, StateWelcome)
+	if _, exists := b.drafts[testUserID]; exists {
+		t.Error("draft was kept after cancelling")
+	}
+	if got := api.lastMessage(t, testUserID).Text; got != b.trMarkdown(testUserID, "action_cancelled") {
+		t.Errorf("sent %q, want the cancel notice", got)
+	}
+}
+
+func TestExpiredCallbackShowsCurrentMenu(t *testing.T) {
+	b, api := newTestBot(t)
+
+	// A confirmation button from before a restart, when no draft is left
+	b.handleCallbackQuery(userCallback(testUserID, "confirm_send"))
+
+	answer, ok := api.requests[0].(tgbotapi.CallbackConfig)
+	if !ok || answer.Text != b.tr(testUserID, "menu_expired") {
+		t.Errorf("callback answered with %+v, want the expiry notice", api.requests[0])
+	}
+	if got := api.lastMessage(t, testUserID).Text; got != b.trMarkdown(testUserID, "welcome_menu") {
+		t.Errorf("sent %q, want the welcome menu", got)
+	}
+	if _, exists := b.userSessions[testUserID]; exists {
+		t.Error("an expired button opened a ticket")
+	}
+}
+
+func TestStateIsKeptWhenSendFails(t *testing.T) {
+	b, api := newTestBot(t)
+
+	b.handleMessage(userMessage(testUserID, "/start"))
+	api.sendErr = errors.New("network down")
+
+	b.handleMessage(userMessage(testUserID, "/question"))
+
+	assertState(t, b, StateWelcome)
+}
+
+func TestHandleAdminMessageReplyAnswersTicket(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Where is the office?")
+
+	// The admin notification is the last message sent
+	adminMsgID := api.lastID
+	if b.adminMessages[adminMsgID] != session {
+		t.Fatalf("admin notification #%d does not map to the ticket", adminMsgID)
+	}
+	api.reset()
+
+	reply := userMessage(testAdminID, "On the second floor.")
+	reply.ReplyToMessage = &tgbotapi.Message{MessageID: adminMsgID}
+	b.handleAdminMessage(reply)
+
+	if got := api.lastText(t, testUserID); !strings.Contains(got, "On the second floor.") {
+		t.Errorf("user got %q, want the answer", got)
+	}
+	if got := api.lastMessage(t, testAdminID).Text; !strings.HasPrefix(got, "✅ Reply sent successfully to @tester") {
+		t.Errorf("admin got %q, want a delivery confirmation", got)
+	}
+	if _, exists := b.userSessions[testUserID]; exists {
+		t.Error("ticket is still open after answering")
+	}
+	if _, exists := b.adminMessages[adminMsgID]; exists {
+		t.Error("admin notification still maps to the answered ticket")
+	}
+
+	ticket, _ := b.store.Ticket(session.TicketID)
+	if ticket.Answer != "On the second floor." || ticket.AnsweredAt.IsZero() {
+		t.Errorf("stored ticket = %+v, want it answered", ticket)
+	}
+	if size := b.store.OutboxSize(); size != 0 {
+		t.Errorf("outbox has %d messages after a successful delivery", size)
+	}
+}
+
+func TestAnswerToBlockedUserKeepsTicketOpen(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Do you offer evening classes?")
+	api.chatErrs[testUserID] = &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/reply %d Yes, on Tuesdays.", session.TicketID)))
+	if text := api.lastText(t, testAdminID); !strings.Contains(text, "was not delivered: the user blocked the bot") {
+		t.Errorf("admin got %q, want the delivery failure explained", text)
+	}
+	if _, open := b.tickets[session.TicketID]; !open {
+		t.Error("ticket was closed although the answer was not delivered")
+	}
+}
+
+func TestQueuedAnswerConfirmationIsUpdatedOnDelivery(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Is there parking nearby?")
+	api.chatErrs[testUserID] = errors.New("network down")
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/reply %d Yes, behind the building.", session.TicketID)))
+	confirmationID := api.lastID
+	confirmation := api.lastMessage(t, testAdminID)
+	if !strings.HasPrefix(confirmation.Text, "📤") {
+		t.Fatalf("admin got %q, want the answer queued", confirmation.Text)
+	}
+
+	delete(api.chatErrs, testUserID)
+	b.flushOutbox(time.Now().Add(time.Hour))
+	if len(api.requests) == 0 {
+		t.Fatal("the queued confirmation was not updated")
+	}
+	edit, ok := api.requests[len(api.requests)-1].(tgbotapi.EditMessageTextConfig)
+	if !ok || edit.MessageID != confirmationID || !strings.HasPrefix(edit.Text, "✅ Queued message for ticket") {
+		t.Errorf("last request = %+v, want the confirmation marked delivered", api.requests[len(api.requests)-1])
+	}
+}
+
+func TestOutboxSenderSkipsAnswerBeingSent(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Can I pay in installments?")
+
+	// The sender runs while the handler is still waiting on Telegram
+	api.beforeSend = func(chattable tgbotapi.Chattable) {
+		if msg, ok := chattable.(tgbotapi.MessageConfig); ok && msg.ChatID == testUserID {
+			api.beforeSend = nil
+			b.flushOutbox(time.Now().Add(time.Minute))
+		}
+	}
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/reply %d Yes, in three parts.", session.TicketID)))
+
+	answers := 0
+	for _, chattable := range api.sent {
+		if msg, ok := chattable.(tgbotapi.MessageConfig); ok && msg.ChatID == testUserID && strings.Contains(msg.Text, "three parts") {
+			answers++
+		}
+	}
+	if answers != 1 {
+		t.Errorf("user got the answer %d times, want once", answers)
+	}
+}
+
+func TestRateLimitedAnswerIsQueuedUntilThePauseEnds(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Do you review cover letters?")
+	api.chatErrs[testUserID] = &telegram.RateLimitError{ChatID: testUserID, RetryAfter: 90 * time.Second}
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/reply %d Yes, send it with your CV.", session.TicketID)))
+	if got := api.lastMessage(t, testAdminID).Text; !strings.HasPrefix(got, "📤") {
+		t.Fatalf("admin got %q, want the answer queued", got)
+	}
+	if due := b.store.DueOutbox(time.Now().Add(80 * time.Second)); len(due) != 0 {
+		t.Errorf("%d messages due before the pause ends", len(due))
+	}
+	due := b.store.DueOutbox(time.Now().Add(100 * time.Second))
+	if len(due) != 1 || due[0].Attempts != 0 {
+		t.Fatalf("due after the pause = %+v, want the answer without a used attempt", due)
+	}
+
+	delete(api.chatErrs, testUserID)
+	b.flushOutbox(time.Now().Add(100 * time.Second))
+	if got := api.lastText(t, testUserID); !strings.Contains(got, "send it with your CV") {
+		t.Errorf("user got %q, want the answer", got)
+	}
+}
+
+func TestHandleAdminMessageReplyAfterRestart(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Can I bring a friend?")
+	adminMsgID := api.lastID
+
+	restarted, err := newBot(api, b.config, b.logger)
+	if err != nil {
+		t.Fatalf("newBot: %v", err)
+	}
+	api.reset()
+
+	// The ticket is still listed as open
+	restarted.handleMessage(userMessage(testAdminID, "/sessions"))
+	if got := api.lastText(t, testAdminID); !strings.Contains(got, fmt.Sprintf("#%d", session.TicketID)) {
+		t.Errorf("/sessions after a restart = %q, want the open ticket", got)
+	}
+	ticket, _ := restarted.store.Ticket(session.TicketID)
+	if status := restarted.apiTicketFromRecord(ticket).Status; status != ticketStatusOpen {
+		t.Errorf("API status after a restart = %q, want open", status)
+	}
+
+	reply := userMessage(testAdminID, "Sure, register them too.")
+	reply.ReplyToMessage = &tgbotapi.Message{MessageID: adminMsgID}
+	restarted.handleAdminMessage(reply)
+
+	if got := api.lastText(t, testUserID); !strings.Contains(got, "Sure, register them too.") {
+		t.Errorf("user got %q, want the answer", got)
+	}
+	if ticket, _ := restarted.store.Ticket(session.TicketID); ticket.AnsweredAt.IsZero() {
+		t.Error("ticket is still open after answering")
+	}
+	if _, exists := restarted.store.AdminMessageTicket(adminMsgID); exists {
+		t.Error("admin notification still maps to the answered ticket")
+	}
+}
+
+func TestEditedAdminReplyUpdatesAnswer(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "When do you open?")
+	adminMsgID := api.lastID
+
+	reply := userMessage(testAdminID, "At 9 pm.")
+	reply.MessageID = 500
+	reply.ReplyToMessage = &tgbotapi.Message{MessageID: adminMsgID}
+	b.handleAdminMessage(reply)
+	api.reset()
+
+	edited := *reply
+	edited.Text = "At 9 am."
+	b.dispatchUpdate(tgbotapi.Update{EditedMessage: &edited})
+
+	if got := api.lastText(t, testUserID); !strings.HasPrefix(got, "✏️ Updated answer") || !strings.Contains(got, "At 9 am.") {
+		t.Errorf("user got %q, want the updated answer", got)
+	}
+	if ticket, _ := b.store.Ticket(session.TicketID); ticket.Answer != "At 9 am." {
+		t.Errorf("stored answer = %q, want the updated one", ticket.Answer)
+	}
+
+	// Edits of messages that answered nothing are ignored
+	api.reset()
+	other := *userMessage(testAdminID, "/stats")
+	b.dispatchUpdate(tgbotapi.Update{EditedMessage: &other})
+	if len(api.sent) != 0 {
+		t.Errorf("sent %d messages for an unrelated edit", len(api.sent))
+	}
+}
+
+func TestUndoReopensAnsweredTicket(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Is parking free?")
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/reply %d Yes.", session.TicketID)))
+
+	answerMsgID := api.lastID - 1
+	confirmation := api.lastMessage(t, testAdminID)
+	if confirmation.ReplyMarkup == nil {
+		t.Fatal("confirmation has no undo button")
+	}
+	api.reset()
+
+	undo := userCallback(testAdminID, fmt.Sprintf("undo:%d", session.TicketID))
+	undo.Message = &tgbotapi.Message{MessageID: api.lastID, Chat: &tgbotapi.Chat{ID: testAdminID}}
+	b.handleCallbackQuery(undo)
+
+	deleted := false
+	for _, request := range api.requests {
+		if config, ok := request.(tgbotapi.DeleteMessageConfig); ok && config.ChatID == testUserID && config.MessageID == answerMsgID {
+			deleted = true
+		}
+	}
+	if !deleted {
+		t.Error("the delivered answer was not deleted")
+	}
+	if ticket, _ := b.store.Ticket(session.TicketID); !ticket.AnsweredAt.IsZero() || ticket.Answer != "" {
+		t.Errorf("stored ticket = %+v, want it open", ticket)
+	}
+
+	reply := userMessage(testAdminID, "Yes, behind the building.")
+	reply.ReplyToMessage = undo.Message
+	b.handleAdminMessage(reply)
+	if got := api.lastText(t, testUserID); !strings.Contains(got, "behind the building") {
+		t.Errorf("user got %q, want the new answer", got)
+	}
+}
+
+func TestFormattedAnswerIsPreviewedFirst(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "What should I bring?")
+	adminMsgID := api.lastID
+	api.reset()
+
+	reply := userMessage(testAdminID, "Bring **your passport** and a pen.")
+	reply.ReplyToMessage = &tgbotapi.Message{MessageID: adminMsgID}
+	b.handleAdminMessage(reply)
+
+	if len(api.messages(testUserID)) != 0 {
+		t.Fatal("formatted answer was delivered before the admin confirmed it")
+	}
+	preview := api.lastMessage(t, testAdminID)
+	if preview.ParseMode != tgbotapi.ModeMarkdownV2 || !strings.Contains(preview.Text, "*your passport*") {
+		t.Fatalf("preview = %q, want the rendered answer", preview.Text)
+	}
+
+	send := userCallback(testAdminID, "preview:send")
+	send.Message = &tgbotapi.Message{MessageID: api.lastID, Chat: &tgbotapi.Chat{ID: testAdminID}}
+	b.handleCallbackQuery(send)
+
+	if got := api.lastMessage(t, testUserID).Text; !strings.Contains(got, "Bring *your passport* and a pen\\.") {
+		t.Errorf("user got %q, want the formatted answer", got)
+	}
+	if ticket, _ := b.store.Ticket(session.TicketID); ticket.AnsweredAt.IsZero() {
+		t.Error("ticket is still open after confirming the preview")
+	}
+}
+
+func TestEditedQuestionUpdatesTicket(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Where is the ofice?")
+	notificationID := api.lastID
+
+	edited := userMessage(testUserID, "Where is the office?")
+	b.dispatchUpdate(tgbotapi.Update{EditedMessage: edited})
+
+	notice := api.lastMessage(t, testAdminID)
+	if !strings.Contains(notice.Text, "Where is the office?") || notice.ReplyToMessageID != notificationID {
+		t.Errorf("admin got %q in reply to #%d, want the edited question in reply to #%d", notice.Text, notice.ReplyToMessageID, notificationID)
+	}
+	if ticket, _ := b.store.Ticket(session.TicketID); ticket.Question != "Where is the office?" {
+		t.Errorf("stored question = %q, want the edited one", ticket.Question)
+	}
+
+	reply := userMessage(testAdminID, "Second floor.")
+	reply.ReplyToMessage = &tgbotapi.Message{MessageID: api.lastID}
+	b.handleAdminMessage(reply)
+	if _, exists := b.userSessions[testUserID]; exists {
+		t.Error("replying to the edit notice did not answer the ticket")
+	}
+}
+
+func TestGroupMentionIsAnsweredInGroup(t *testing.T) {
+	b, api := newTestBot(t)
+	const groupID = -1001234567890
+
+	chatter := userMessage(testUserID, "Anyone here?")
+	chatter.Chat = &tgbotapi.Chat{ID: groupID, Type: "supergroup", Title: "Job seekers"}
+	b.handleMessage(chatter)
+	if len(api.sent) != 0 {
+		t.Fatalf("bot answered a group message that did not ask it: %+v", api.sent)
+	}
+
+	question := userMessage(testUserID, "@FAQ_test_bot is the course free?")
+	question.MessageID = 77
+	question.Chat = chatter.Chat
+	b.handleMessage(question)
+
+	notification := api.lastMessage(t, testAdminID)
+	if !strings.Contains(notification.Text, "is the course free?") || !strings.Contains(notification.Text, "https://t.me/c/1234567890/77") {
+		t.Fatalf("admin got %q, want the question with a link to the group message", notification.Text)
+	}
+
+	reply := userMessage(testAdminID, "Yes, it is.")
+	reply.ReplyToMessage = &tgbotapi.Message{MessageID: api.lastID}
+	b.handleAdminMessage(reply)
+
+	answer := api.lastMessage(t, groupID)
+	if !strings.Contains(answer.Text, "Yes, it is") || answer.ReplyToMessageID != 77 {
+		t.Errorf("group got %q in reply to #%d, want the answer in reply to #77", answer.Text, answer.ReplyToMessageID)
+	}
+	if answer.ReplyMarkup != nil {
+		t.Error("answer in the group has rating buttons anyone could press")
+	}
+}
+
+func TestChannelCommentLinksToPost(t *testing.T) {
+	b, api := newTestBot(t)
+
+	comment := userMessage(testUserID, "@faq_test_bot which cities?")
+	comment.MessageID = 77
+	comment.Chat = &tgbotapi.Chat{ID: -1009876543210, Type: "supergroup", Title: "Jobs chat"}
+	comment.ReplyToMessage = &tgbotapi.Message{
+		MessageID:            5,
+		Text:                 "We are hiring drivers",
+		IsAutomaticForward:   true,
+		ForwardFromChat:      &tgbotapi.Chat{ID: -1001111111111, Type: "channel", Title: "Jobs", UserName: "jobs_channel"},
+		ForwardFromMessageID: 12,
+	}
+	b.handleMessage(comment)
+
+	notification := api.lastMessage(t, testAdminID).Text
+	for _, want := range []string{"which cities?", "https://t.me/jobs_channel/12?comment=77", "We are hiring drivers"} {
+		if !strings.Contains(notification, want) {
+			t.Errorf("admin got %q, want it to contain %q", notification, want)
+		}
+	}
+}
+
+func TestQueuePositionUpdates(t *testing.T) {
+	b, api := newTestBot(t)
+
+	first := submitQuestion(t, b, "What should I learn first?")
+	if got := api.lastMessage(t, testUserID).Text; !strings.HasSuffix(got, b.trMarkdown(testUserID, "queue_next")) {
+		t.Errorf("confirmation %q does not tell the user they are next", got)
+	}
+
+	queued := make([]*UserSession, 3)
+	for i := range queued {
+		queued[i] = &UserSession{
+			TicketID:      100 + i,
+			UserID:        testUserID + 1 + int64(i),
+			CreatedAt:     first.CreatedAt.Add(time.Duration(i+1) * time.Minute),
+			QueuePosition: i + 2,
+		}
+		b.tickets[queued[i].TicketID] = queued[i]
+	}
+
+	api.reset()
+	b.closeSession(first)
+	if len(api.sent) != 1 || api.lastMessage(t, queued[0].UserID).Text != b.trMarkdown(queued[0].UserID, "queue_next") {
+		t.Fatalf("sent %d updates, want only #2 told they are next", len(api.sent))
+	}
+
+	api.reset()
+	b.closeSession(queued[0])
+	want := b.trMarkdown(queued[2].UserID, "queue_update_no_eta", map[string]interface{}{"Position": 2})
+	if len(api.sent) != 2 || api.lastMessage(t, queued[2].UserID).Text != want {
+		t.Errorf("sent %d updates, want #3 told they are next and #4 that they are #2", len(api.sent))
+	}
+}
+
+func TestHandleAdminMessageTemplateErrorKeepsTicketOpen(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Do you sponsor visas?")
+	adminMsgID := api.lastID
+	api.reset()
+
+	reply := userMessage(testAdminID, "/t missing")
+	reply.ReplyToMessage = &tgbotapi.Message{MessageID: adminMsgID}
+	b.handleAdminMessage(reply)
+
+	if got := api.lastMessage(t, testAdminID).Text; !strings.HasPrefix(got, "❌ Template error") {
+		t.Errorf("admin got %q, want a template error", got)
+	}
+	if len(api.messages(testUserID)) != 0 {
+		t.Error("user was answered despite the template error")
+	}
+	if b.userSessions[testUserID] != session {
+		t.Error("ticket was closed despite the template error")
+	}
+}
+
+func TestHandleAdminMessageHelp(t *testing.T) {
+	b, api := newTestBot(t)
+
+	b.handleAdminMessage(userMessage(testAdminID, "/help"))
+
+	if got := api.lastMessage(t, testAdminID).Text; !strings.HasPrefix(got, "Admin Commands:") {
+		t.Errorf("admin got %q, want the command list", got)
+	}
+}
+
+func TestReplyCommandAnswersTicketByID(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Is there a dress code?")
+	api.reset()
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/reply %d Smart casual.\nNo ties needed.", session.TicketID)))
+
+	if got := api.lastText(t, testUserID); !strings.Contains(got, "Smart casual.\nNo ties needed.") {
+		t.Errorf("user got %q, want the whole answer", got)
+	}
+	if _, exists := b.userSessions[testUserID]; exists {
+		t.Error("ticket is still open after answering")
+	}
+}
+
+func TestReplyCommandAnswersTicketByUserID(t *testing.T) {
+	b, api := newTestBot(t)
+	submitQuestion(t, b, "Do you review cover letters?")
+	api.reset()
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/reply %d Yes, send it along.", testUserID)))
+
+	if got := api.lastText(t, testUserID); !strings.Contains(got, "Yes, send it along.") {
+		t.Errorf("user got %q, want the answer", got)
+	}
+	if _, exists := b.userSessions[testUserID]; exists {
+		t.Error("ticket is still open after answering")
+	}
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/reply %d Again?", testUserID)))
+	if got := api.lastMessage(t, testAdminID).Text; !strings.HasPrefix(got, "❌ No open ticket") {
+		t.Errorf("admin got %q for a closed ticket, want an error", got)
+	}
+}
+
+func TestReplyCommandAnswersTicketFromBeforeRestart(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Are you hiring interns?")
+	b, err := newBot(api, b.config, b.logger)
+	if err != nil {
+		t.Fatalf("newBot: %v", err)
+	}
+	api.reset()
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/reply #%d Yes, in spring.", session.TicketID)))
+
+	if got := api.lastText(t, testUserID); !strings.Contains(got, "Yes, in spring.") {
+		t.Errorf("user got %q, want the answer", got)
+	}
+	if ticket, _ := b.store.Ticket(session.TicketID); ticket.Answer != "Yes, in spring." {
+		t.Errorf("stored answer = %q", ticket.Answer)
+	}
+}
+
+func TestCommandUsageAndPermissions(t *testing.T) {
+	b, api := newTestBot(t)
+
+	b.handleMessage(userMessage(testAdminID, "/reply 7"))
+	if got := api.lastMessage(t, testAdminID).Text; got != "Usage: /reply <ticket_id|user_id> <text>" {
+		t.Errorf("admin got %q, want the usage", got)
+	}
+
+	// Admin commands are ordinary text for users
+	b.handleMessage(userMessage(testUserID, "/question"))
+	b.handleMessage(userMessage(testUserID, "/stats"))
+	assertState(t, b, StateConfirmQuestion)
+	if draft := b.drafts[testUserID]; draft.LastQuestion != "/stats" {
+		t.Errorf("draft = %q, want the message as the question", draft.LastQuestion)
+	}
+}
+
+func TestUserCommandAliases(t *testing.T) {
+	b, _ := newTestBot(t)
+
+	for _, text := range []string{"/ask", "/Question@faq_test_bot", "ask question"} {
+		b.handleMessage(userMessage(testUserID, "main menu"))
+		assertState(t, b, StateWelcome)
+
+		b.handleMessage(userMessage(testUserID, text))
+		assertState(t, b, StateQuestion)
+	}
+}
+
+func TestPublishCommandsPerLanguage(t *testing.T) {
+	b, api := newTestBot(t)
+
+	if err := b.publishCommands(); err != nil {
+		t.Fatalf("publishCommands: %v", err)
+	}
+
+	menus := make(map[string][]tgbotapi.BotCommand)
+	for _, request := range api.requests {
+		config, ok := request.(tgbotapi.SetMyCommandsConfig)
+		if !ok {
+			t.Fatalf("unexpected request %T", request)
+		}
+		menus[config.Scope.Type+"/"+config.LanguageCode] = config.Commands
+	}
+
+	if len(menus) != len(supportedLanguages)+2 {
+		t.Fatalf("published %d menus, want one per language, a default and the admin's", len(menus))
+	}
+	if got := menus["all_private_chats/ru"][0]; got.Command != "start" || got.Description != "Главное меню" {
+		t.Errorf("first Russian command = %+v, want a localized /start", got)
+	}
+	for _, command := range menus["chat/"] {
+		if command.Command == "start" {
+			t.Error("admin menu lists user commands")
+		}
+	}
+}
+
+func TestStartPayloadStartsFlow(t *testing.T) {
+	b, api := newTestBot(t)
+
+	b.handleMessage(userMessage(testUserID, "/start cv_review-spring_fair"))
+	if _, exists := b.cvForms[testUserID]; !exists {
+		t.Error("deep link did not start the CV review flow")
+	}
+
+	b.handleMessage(userMessage(testUserID, "/start unknown"))
+	assertState(t, b, StateWelcome)
+	if got := api.lastMessage(t, testUserID).Text; got != b.trMarkdown(testUserID, "welcome_menu") {
+		t.Errorf("sent %q for an unknown payload, want the welcome menu", got)
+	}
+}
+
+func TestReferralLinkCreditsNewUsersOnce(t *testing.T) {
+	b, api := newTestBot(t)
+	b.referralThanks = true
+
+	const referrerID, friendID int64 = 7, 8
+	b.recordUser(&tgbotapi.User{ID: referrerID})
+	b.recordUser(&tgbotapi.User{ID: friendID})
+
+	payload := fmt.Sprintf("/start ref_%d", referrerID)
+	b.handleMessage(userMessage(friendID, payload))
+	b.handleMessage(userMessage(friendID, "/start ref_9"))
+	b.handleMessage(userMessage(referrerID, payload))
+
+	if user, _ := b.store.User(friendID); user.ReferredBy != referrerID {
+		t.Errorf("friend referred by %d, want %d", user.ReferredBy, referrerID)
+	}
+	if user, _ := b.store.User(referrerID); user.ReferredBy != 0 {
+		t.Error("referrer was credited to themselves")
+	}
+	if got := api.messages(referrerID); len(got) != 2 || got[0].Text != b.trMarkdown(referrerID, "referral_thanks") {
+		t.Errorf("referrer got %d messages, want a thank-you and the welcome menu", len(got))
+	}
+}
+
+func TestAdminNotificationShowsUserProfile(t *testing.T) {
+	b, api := newTestBot(t)
+	b.recordUser(&tgbotapi.User{ID: testUserID, UserName: "old_name", LanguageCode: "ru"})
+	b.recordUser(&tgbotapi.User{ID: testUserID, UserName: "tester", LanguageCode: "ru"})
+
+	submitQuestion(t, b, "Can I apply twice?")
+
+	notification := api.lastMessage(t, testAdminID).Text
+	if !strings.Contains(notification, "0 previous questions · ru · formerly @old_name") {
+		t.Errorf("notification %q lacks the user profile", notification)
+	}
+	if user, _ := b.store.User(testUserID); user.Questions != 1 {
+		t.Errorf("question count = %d, want 1", user.Questions)
+	}
+}
+
+func TestSearchRanksMatchesAndFiltersByPeriod(t *testing.T) {
+	b, api := newTestBot(t)
+	now := time.Now()
+
+	for _, ticket := range []storage.TicketRecord{
+		{ID: 1, Question: "When are the IELTS deadlines?", Answer: "Deadlines are in May", CreatedAt: now.AddDate(0, 0, -20)},
+		{ID: 2, Question: "Is IELTS required?", Answer: "Yes", CreatedAt: now.AddDate(0, 0, -60)},
+		{ID: 3, Question: "Visa deadline?", Answer: "In June", CreatedAt: now.AddDate(0, 0, -5)},
+		{ID: 4, Question: "IELTS deadline for the open ticket", CreatedAt: now},
+	} {
+		ticket.UserID = testUserID
+		if ticket.Answer != "" {
+			ticket.AnsweredAt = ticket.CreatedAt.Add(time.Hour)
+		}
+		if err := b.store.AddTicket(ticket); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches, err := b.searchIndex.search(b.store, "ielts deadline", time.Time{}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].ID != 1 {
+		t.Fatalf("search = %+v, want only ticket #1", matches)
+	}
+	matches, _ = b.searchIndex.search(b.store, "Deadline", time.Time{}, 10)
+	if len(matches) != 2 || matches[0].ID != 1 {
+		t.Errorf("search = %+v, want #1 with two mentions before the newer #3", matches)
+	}
+
+	b.handleMessage(userMessage(testAdminID, "/search ielts 30d"))
+	text := api.lastText(t, testAdminID)
+	if !strings.Contains(text, "#1 ") || strings.Contains(text, "#2 ") {
+		t.Errorf("/search ielts 30d = %q, want only last month's ticket", text)
+	}
+
+	// Saving anything but tickets, as every update does, keeps the index
+	built := b.searchIndex.revision
+	if err := b.store.SaveTemplate("hello", "Hello!"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.searchIndex.search(b.store, "ielts", time.Time{}, 10); err != nil {
+		t.Fatal(err)
+	}
+	if b.searchIndex.revision != built {
+		t.Error("the index was rebuilt without a ticket change")
+	}
+
+	if err := b.store.AnswerTicket(4, "Same as always", now); err != nil {
+		t.Fatal(err)
+	}
+	if matches, _ := b.searchIndex.search(b.store, "open ticket", time.Time{}, 10); len(matches) != 1 {
+		t.Errorf("search after answering = %+v, want the newly answered ticket", matches)
+	}
+}
+
+func TestTranscriptExportsUserTickets(t *testing.T) {
+	b, api := newTestBot(t)
+	b.recordUser(&tgbotapi.User{ID: testUserID, UserName: "tester"})
+	submitQuestion(t, b, "How do I prepare for IELTS?")
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/transcript %d", testUserID)))
+
+	document, ok := api.sent[len(api.sent)-1].(tgbotapi.DocumentConfig)
+	if !ok {
+		t.Fatalf("sent %T, want a document", api.sent[len(api.sent)-1])
+	}
+	content := string(document.File.(tgbotapi.FileBytes).Bytes)
+	for _, want := range []string{"@tester", "How do I prepare for IELTS?", "(not answered yet)"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("transcript = %q, want %q in it", content, want)
+		}
+	}
+}
+
+func TestArchivedTicketsLeaveSessionsAndSurviveRetention(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Can you keep this for later?")
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/archive %d", session.TicketID)))
+	b.handleMessage(userMessage(testAdminID, "/sessions"))
+	if text := api.lastText(t, testAdminID); text != "No active user sessions" {
+		t.Errorf("/sessions = %q, want the archived ticket gone", text)
+	}
+	b.handleMessage(userMessage(testAdminID, "/archived"))
+	if text := api.lastText(t, testAdminID); !strings.Contains(text, "Can you keep this for later?") {
+		t.Errorf("/archived = %q, want the archived ticket", text)
+	}
+
+	if err := b.store.AnswerTicket(session.TicketID, "Kept", time.Now().AddDate(0, 0, -100)); err != nil {
+		t.Fatal(err)
+	}
+	b.retention = &Retention{Period: 90 * 24 * time.Hour}
+	b.applyRetention(time.Now())
+	if _, exists := b.store.Ticket(session.TicketID); !exists {
+		t.Error("retention purged an archived ticket")
+	}
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/unarchive %d", session.TicketID)))
+	if ticket, _ := b.store.Ticket(session.TicketID); !ticket.ArchivedAt.IsZero() {
+		t.Error("ticket is still archived after /unarchive")
+	}
+}
+
+func TestPinnedTicketsComeFirstInSessions(t *testing.T) {
+	b, api := newTestBot(t)
+	now := time.Now()
+	b.tickets[1] = &UserSession{TicketID: 1, UserID: testUserID, LastQuestion: "First", Urgent: true, CreatedAt: now.Add(-time.Hour)}
+	// #2 was left open by the last run
+	if err := b.store.AddTicket(storage.TicketRecord{ID: 2, UserID: testUserID + 1, Question: "Complex case", CreatedAt: now}); err != nil {
+		t.Fatal(err)
+	}
+	b.loadOpenTickets()
+
+	b.handleMessage(userMessage(testAdminID, "/pin 2"))
+	b.handleMessage(userMessage(testAdminID, "/sessions"))
+	text := api.lastText(t, testAdminID)
+	if !strings.Contains(text, "📌 #2") || strings.Index(text, "Complex case") > strings.Index(text, "First") {
+		t.Errorf("/sessions = %q, want pinned #2 first", text)
+	}
+	if ticket, _ := b.store.Ticket(2); !ticket.Pinned {
+		t.Error("pin was not persisted")
+	}
+
+	b.handleMessage(userMessage(testAdminID, "/unpin 2"))
+	b.handleMessage(userMessage(testAdminID, "/sessions"))
+	if text := api.lastText(t, testAdminID); strings.Contains(text, "📌") {
+		t.Errorf("/sessions = %q, want no pinned tickets", text)
+	}
+}
+
+func TestSnoozedTicketIsHiddenAndNotifiedAgain(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Can this wait?")
+
+	b.handleCallbackQuery(userCallback(testAdminID, fmt.Sprintf("snooze:%d:1h0m0s", session.TicketID)))
+	if !session.Snoozed(time.Now()) {
+		t.Fatal("ticket was not snoozed")
+	}
+	b.handleMessage(userMessage(testAdminID, "/sessions"))
+	if text := api.lastText(t, testAdminID); strings.Contains(text, "Can this wait?") || !strings.Contains(text, "1 snoozed") {
+		t.Errorf("/sessions = %q, want the snoozed ticket hidden", text)
+	}
+
+	b.wakeSnoozedTickets(time.Now().Add(30 * time.Minute))
+	if !session.Snoozed(time.Now()) {
+		t.Fatal("snooze ended early")
+	}
+
+	b.wakeSnoozedTickets(time.Now().Add(2 * time.Hour))
+	if text := api.lastText(t, testAdminID); !strings.Contains(text, "Can this wait?") {
+		t.Errorf("sent %q, want the ticket notified again", text)
+	}
+	if ticket, _ := b.store.Ticket(session.TicketID); !ticket.SnoozedUntil.IsZero() {
+		t.Error("snooze is still stored after it ended")
+	}
+}
+
+func TestAssignedTicketIsRemindedToAndAnsweredByReviewer(t *testing.T) {
+	const reviewerID int64 = 2000
+	t.Setenv("REVIEWERS", fmt.Sprintf("alice:%d", reviewerID))
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Could someone check my essay?")
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/assign %d @alice", session.TicketID)))
+	forwarded := api.lastMessage(t, reviewerID)
+	if !strings.Contains(forwarded.Text, "Could someone check my essay?") {
+		t.Fatalf("reviewer got %q, want the ticket", forwarded.Text)
+	}
+
+	session.CreatedAt = time.Now().Add(-5 * time.Hour)
+	sentToAdmin := len(api.messages(testAdminID))
+	b.checkSLA([]time.Duration{4 * time.Hour})
+	if text := api.lastText(t, reviewerID); !strings.Contains(text, "SLA reminder") {
+		t.Errorf("reviewer got %q, want the SLA reminder", text)
+	}
+	if got := len(api.messages(testAdminID)); got != sentToAdmin {
+		t.Errorf("admin got %d new message(s), want the reminder to go to the assignee only", got-sentToAdmin)
+	}
+
+	assigned, _ := b.store.Ticket(session.TicketID)
+	reply := userMessage(reviewerID, "Looks good, tighten the intro")
+	reply.ReplyToMessage = &tgbotapi.Message{MessageID: assigned.AssigneeMessageIDs[0]}
+	b.handleMessage(reply)
+	ticket, _ := b.store.Ticket(session.TicketID)
+	if ticket.Answer != "Looks good, tighten the intro" {
+		t.Errorf("answer = %q, want the reviewer's reply", ticket.Answer)
+	}
+	if len(ticket.Assignments) != 1 || ticket.Assignments[0].To != reviewerID {
+		t.Errorf("assignments = %+v, want one to the reviewer", ticket.Assignments)
+	}
+}
+
+func TestFAQAnswerIsSuggestedInUserLanguage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faq.json")
+	faq := `[
+		{"id": "office", "keywords": {"en": ["office"], "ru": ["офис"]}, "answer": {"en": "We are on Main St.", "ru": "Мы на Главной улице."}},
+		{"id": "pdf", "keywords": {"en": ["pdf"]}, "answer": {"en": "Send a PDF, please."}}
+	]`
+	if err := os.WriteFile(path, []byte(faq), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FAQ_FILE", path)
+	b, api := newTestBot(t)
+	b.recordUser(&tgbotapi.User{ID: testUserID, LanguageCode: "ru"})
+
+	tests := []struct {
+		question string
+		want     string
+	}{
+		{"Где ваш офис?", "Мы на Главной улице."},
+		// No Russian answer, English is the fallback
+		{"Можно прислать PDF?", "Send a PDF, please."},
+	}
+	for _, tt := range tests {
+		b.handleMessage(userMessage(testUserID, "/question"))
+		b.handleMessage(userMessage(testUserID, tt.question))
+		messages := api.messages(testUserID)
+		if len(messages) < 2 || !strings.Contains(messages[len(messages)-2].Text, telegram.EscapeMarkdown(tt.want)) {
+			t.Errorf("question %q: want the FAQ answer %q before the confirmation", tt.question, tt.want)
+		}
+		b.handleCallbackQuery(userCallback(testUserID, "cancel"))
+	}
+}
+
+func TestFAQAnswerShipsFileAndButtons(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "template.docx"), []byte("template"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	faq := `[{"id": "template", "keywords": {"en": ["cv template"]}, "answer": {"en": "Here it is."},
+		"document": "template.docx", "buttons": [{"text": {"en": "Examples"}, "url": "https://example.com/cv"}]}]`
+	if err := os.WriteFile(filepath.Join(dir, "faq.json"), []byte(faq), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FAQ_FILE", filepath.Join(dir, "faq.json"))
+	b, api := newTestBot(t)
+
+	b.handleMessage(userMessage(testUserID, "/question"))
+	sent := len(api.sent)
+	b.handleMessage(userMessage(testUserID, "Do you have a CV template?"))
+
+	var answer tgbotapi.MessageConfig
+	var document tgbotapi.DocumentConfig
+	for _, chattable := range api.sent[sent:] {
+		switch msg := chattable.(type) {
+		case tgbotapi.MessageConfig:
+			if answer.Text == "" {
+				answer = msg
+			}
+		case tgbotapi.DocumentConfig:
+			document = msg
+		}
+	}
+	keyboard, ok := answer.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	if !ok || *keyboard.InlineKeyboard[0][0].URL != "https://example.com/cv" {
+		t.Errorf("FAQ answer markup = %+v, want the link button", answer.ReplyMarkup)
+	}
+	if document.File != tgbotapi.FilePath(filepath.Join(dir, "template.docx")) {
+		t.Errorf("sent document %+v, want the template next to the FAQ file", document.File)
+	}
+}
+
+func TestWelcomeExperimentKeepsVariantAndReportsConversion(t *testing.T) {
+	t.Setenv("WELCOME_VARIANTS", "welcome_menu,welcome_menu_short")
+	b, api := newTestBot(t)
+	b.recordUser(&tgbotapi.User{ID: testUserID})
+
+	b.handleMessage(userMessage(testUserID, "/start"))
+	variant := b.welcomeVariant(testUserID)
+	if got, want := api.lastMessage(t, testUserID).Text, b.trMarkdown(testUserID, variant); got != want {
+		t.Errorf("welcome menu = %q, want variant %s", got, variant)
+	}
+	for range 5 {
+		if again := b.welcomeVariant(testUserID); again != variant {
+			t.Fatalf("user switched from variant %s to %s", variant, again)
+		}
+	}
+
+	submitQuestion(t, b, "How long does a review take?")
+	b.handleMessage(userMessage(testAdminID, "/stats"))
+	if text := api.lastText(t, testAdminID); !strings.Contains(text, fmt.Sprintf("• %s - 1/1 (100%%)", variant)) {
+		t.Errorf("/stats = %q, want the conversion of variant %s", text, variant)
+	}
+}
+
+func TestWelcomeMenuLayoutFromConfig(t *testing.T) {
+	t.Setenv("MENU_LAYOUT", "cv_review,question=🙋 Ask us; 📅 Book a call=https://cal.example.com/me; language")
+	b, api := newTestBot(t)
+
+	b.handleMessage(userMessage(testUserID, "/start"))
+	keyboard := api.lastMessage(t, testUserID).ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard
+	var got []string
+	for _, row := range keyboard {
+		var labels []string
+		for _, button := range row {
+			labels = append(labels, button.Text)
+		}
+		got = append(got, strings.Join(labels, " | "))
+	}
+	want := []string{
+		b.tr(testUserID, "button_cv_review") + " | 🙋 Ask us",
+		"📅 Book a call",
+		b.tr(testUserID, "button_language"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("menu rows = %q, want %q", got, want)
+	}
+	if url := keyboard[1][0].URL; url == nil || *url != "https://cal.example.com/me" {
+		t.Errorf("link button URL = %v, want the booking link", url)
+	}
+
+	t.Setenv("MENU_LAYOUT", "question,shop")
+	if _, err := b.menuLayoutFromConfig(testConfig(t)); err == nil || !strings.Contains(err.Error(), `unknown button "shop"`) {
+		t.Errorf("menuLayoutFromConfig() error = %v, want the unknown button", err)
+	}
+}
+
+func TestTermsMustBeAcceptedBeforeFirstSubmission(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terms.md")
+	if err := os.WriteFile(path, []byte("We keep your questions for 90 days."), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TERMS_FILE", path)
+	t.Setenv("TERMS_VERSION", "1")
+	b, api := newTestBot(t)
+	b.recordUser(&tgbotapi.User{ID: testUserID})
+
+	b.handleMessage(userMessage(testUserID, "/question"))
+	if got := api.lastMessage(t, testUserID).Text; !strings.HasPrefix(got, b.trMarkdown(testUserID, "terms_prompt")) || !strings.Contains(got, "90 days") {
+		t.Fatalf("/question = %q, want the terms", got)
+	}
+
+	b.handleCallbackQuery(userCallback(testUserID, "terms:accept:question"))
+	assertState(t, b, StateQuestion)
+	if user, _ := b.store.User(testUserID); user.TermsVersion != "1" || user.TermsAcceptedAt.IsZero() {
+		t.Errorf("stored acceptance = %q at %v, want version 1", user.TermsVersion, user.TermsAcceptedAt)
+	}
+
+	b.handleMessage(userMessage(testUserID, "/question"))
+	if got := api.lastMessage(t, testUserID).Text; got != b.trMarkdown(testUserID, "question_instructions") {
+		t.Errorf("second /question = %q, want the instructions", got)
+	}
+
+	t.Setenv("TERMS_VERSION", "2")
+	terms, err := termsFromConfig(testConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.terms = terms
+	b.handleMessage(userMessage(testUserID, "/cv"))
+	if got := api.lastMessage(t, testUserID).Text; !strings.HasPrefix(got, b.trMarkdown(testUserID, "terms_updated")) {
+		t.Fatalf("/cv after a new version = %q, want the updated terms", got)
+	}
+
+	b.handleCallbackQuery(userCallback(testUserID, "terms:decline"))
+	if got := api.lastMessage(t, testUserID).Text; got != b.trMarkdown(testUserID, "terms_declined") {
+		t.Errorf("declining = %q, want the declined notice", got)
+	}
+	if user, _ := b.store.User(testUserID); user.TermsVersion != "1" {
+		t.Errorf("stored version after declining = %q, want 1", user.TermsVersion)
+	}
+}
+
+func TestTutorialIsShownOnce(t *testing.T) {
+	t.Setenv("ONBOARDING_TUTORIAL", "true")
+	b, api := newTestBot(t)
+	b.recordUser(&tgbotapi.User{ID: testUserID})
+
+	b.handleMessage(userMessage(testUserID, "/start"))
+	if got, want := api.lastMessage(t, testUserID).Text, b.trMarkdown(testUserID, "tutorial_intro"); got != want {
+		t.Fatalf("first /start = %q, want the tutorial", got)
+	}
+
+	for step := 1; step < len(tutorialSteps); step++ {
+		b.handleCallbackQuery(userCallback(testUserID, fmt.Sprintf("tutorial:%d", step)))
+		if got, want := api.lastMessage(t, testUserID).Text, b.trMarkdown(testUserID, tutorialSteps[step]); got != want {
+			t.Fatalf("step %d = %q, want %q", step, got, want)
+		}
+	}
+	keyboard := api.lastMessage(t, testUserID).ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard
+	if len(keyboard) != 1 || *keyboard[0][0].CallbackData != "tutorial:done" {
+		t.Fatalf("last step keyboard = %v, want a single done button", keyboard)
+	}
+
+	b.handleCallbackQuery(userCallback(testUserID, "tutorial:done"))
+	assertState(t, b, StateWelcome)
+	welcome := b.trMarkdown(testUserID, "welcome_menu")
+	if got := api.lastMessage(t, testUserID).Text; got != welcome {
+		t.Errorf("after the tutorial = %q, want the welcome menu", got)
+	}
+
+	b.handleMessage(userMessage(testUserID, "/start"))
+	if got := api.lastMessage(t, testUserID).Text; got != welcome {
+		t.Errorf("second /start = %q, want the welcome menu", got)
+	}
+}
+
+func TestAcknowledgeTellsUserOnce(t *testing.T) {
+	t.Setenv("ACKNOWLEDGE_NOTIFY_USER", "true")
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "When does registration close?")
+	sentToUser := len(api.messages(testUserID))
+
+	ack := fmt.Sprintf("ack:%d", session.TicketID)
+	b.handleCallbackQuery(userCallback(testAdminID, ack))
+	b.handleCallbackQuery(userCallback(testAdminID, ack))
+	if got := len(api.messages(testUserID)) - sentToUser; got != 1 {
+		t.Fatalf("user got %d message(s), want one acknowledgement", got)
+	}
+	want := b.trMarkdown(testUserID, "question_seen", map[string]interface{}{"TicketID": session.TicketID})
+	if got := api.lastMessage(t, testUserID).Text; got != want {
+		t.Errorf("user got %q, want %q", got, want)
+	}
+	if ticket, _ := b.store.Ticket(session.TicketID); ticket.AcknowledgedAt.IsZero() {
+		t.Error("ticket was not marked acknowledged")
+	}
+}
+
+func TestTicketCommentsStayInternal(t *testing.T) {
+	const reviewerID int64 = 2000
+	t.Setenv("REVIEWERS", fmt.Sprintf("alice:%d", reviewerID))
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Is my visa still valid?")
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/assign %d @alice", session.TicketID)))
+	sentToUser := len(api.messages(testUserID))
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/comment %d check the expiry date first", session.TicketID)))
+	if text := api.lastText(t, reviewerID); !strings.Contains(text, "check the expiry date first") {
+		t.Errorf("reviewer got %q, want the admin's comment", text)
+	}
+	b.handleMessage(userMessage(reviewerID, fmt.Sprintf("/comment %d it expired last week", session.TicketID)))
+	if text := api.lastText(t, testAdminID); !strings.Contains(text, "@alice commented") {
+		t.Errorf("admin got %q, want the reviewer's comment", text)
+	}
+	if got := len(api.messages(testUserID)); got != sentToUser {
+		t.Errorf("user got %d new message(s), want comments to stay internal", got-sentToUser)
+	}
+
+	b.handleMessage(userMessage(testAdminID, "/sessions"))
+	text := api.lastText(t, testAdminID)
+	for _, want := range []string{"the admin, ", "check the expiry date first", "@alice, ", "it expired last week"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("/sessions = %q, want %q in it", text, want)
+		}
+	}
+}
+
+func TestEscalateSendsContextToSeniorReviewer(t *testing.T) {
+	const seniorChatID int64 = -100500
+	t.Setenv("SENIOR_REVIEWER_CHAT_ID", strconv.FormatInt(seniorChatID, 10))
+	b, api := newTestBot(t)
+	b.recordUser(&tgbotapi.User{ID: testUserID, UserName: "tester"})
+	session := submitQuestion(t, b, "Can I appeal a visa refusal?")
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/escalate %d legal question", session.TicketID)))
+	text := api.lastText(t, seniorChatID)
+	for _, want := range []string{"Escalated ticket", "@tester", "Reason: legal question", "Can I appeal a visa refusal?"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("senior reviewer got %q, want %q in it", text, want)
+		}
+	}
+	if ticket, _ := b.store.Ticket(session.TicketID); ticket.EscalatedAt.IsZero() {
+		t.Error("ticket was not marked escalated")
+	}
+}
+
+func TestReviewFormCompilesFeedback(t *testing.T) {
+	b, api := newTestBot(t)
+	b.recordUser(&tgbotapi.User{ID: testUserID, LanguageCode: "ru"})
+	b.createUserSession(testUserID, "tester", "CV Review Request - Google Drive Link: https://drive.google.com/file/d/cv", 1, false, "", StateCVReview)
+	session := b.userSessions[testUserID]
+
+	keyboard := api.lastMessage(t, testAdminID).ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard
+	formButton := keyboard[len(keyboard)-1][0]
+	if formButton.CallbackData == nil || *formButton.CallbackData != fmt.Sprintf("review:%d", session.TicketID) {
+		t.Fatalf("last notification row = %v, want the review form button", keyboard[len(keyboard)-1])
+	}
+
+	b.handleCallbackQuery(userCallback(testAdminID, *formButton.CallbackData))
+	b.handleAdminMessage(userMessage(testAdminID, "Clean layout, keep it to one page."))
+	b.handleCallbackQuery(userCallback(testAdminID, "review:skip"))
+	b.handleAdminMessage(userMessage(testAdminID, "Add Kubernetes."))
+	b.handleAdminMessage(userMessage(testAdminID, "Use standard headings."))
+	if got := api.lastText(t, testAdminID); !strings.Contains(got, "Overall score") {
+		t.Fatalf("after the rubric = %q, want the score question", got)
+	}
+	b.handleCallbackQuery(userCallback(testAdminID, "review:score:7"))
+	if b.reviewForm != nil {
+		t.Error("review form is still open after the score")
+	}
+
+	preview := api.lastMessage(t, testAdminID)
+	if len(api.messages(testUserID)) != 1 {
+		t.Fatal("review was delivered before the admin confirmed it")
+	}
+	for _, want := range []string{
+		telegram.EscapeMarkdown(b.tr(testUserID, "review_formatting")),
+		"Clean layout, keep it to one page\\.",
+		telegram.EscapeMarkdown(b.tr(testUserID, "review_ats")),
+		"7/10",
+	} {
+		if !strings.Contains(preview.Text, want) {
+			t.Errorf("preview = %q, want %q in it", preview.Text, want)
+		}
+	}
+	if strings.Contains(preview.Text, telegram.EscapeMarkdown(b.tr(testUserID, "review_impact"))) {
+		t.Errorf("preview = %q, want the skipped field left out", preview.Text)
+	}
+
+	send := userCallback(testAdminID, "preview:send")
+	send.Message = &tgbotapi.Message{MessageID: api.lastID, Chat: &tgbotapi.Chat{ID: testAdminID}}
+	b.handleCallbackQuery(send)
+	if got := api.lastMessage(t, testUserID).Text; !strings.Contains(got, "Add Kubernetes\\.") {
+		t.Errorf("user got %q, want the review", got)
+	}
+	if ticket, _ := b.store.Ticket(session.TicketID); ticket.AnsweredAt.IsZero() {
+		t.Error("ticket is still open after sending the review")
+	}
+}
+
+func testDocx(t *testing.T, paragraphs ...string) []byte {
+	t.Helper()
+
+	var document strings.Builder
+	document.WriteString(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+	for _, paragraph := range paragraphs {
+		document.WriteString("<w:p><w:r><w:t>" + paragraph + "</w:t></w:r></w:p>")
+	}
+	document.WriteString("</w:body></w:document>")
+
+	var data bytes.Buffer
+	archive := zip.NewWriter(&data)
+	file, err := archive.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte(document.String())); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return data.Bytes()
+}
+
+func TestExtractCVText(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write([]byte("BT /F1 12 Tf (Senior Go) Tj [(Kuber) -20 (netes)] TJ ET"))
+	writer.Close()
+	pdf := append([]byte("%PDF-1.4\n1 0 obj << /Filter /FlateDecode >> stream\n"), compressed.Bytes()...)
+	pdf = append(pdf, []byte("\nendstream endobj")...)
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"cv.docx", testDocx(t, "Backend developer", "Go &amp; Docker"), "Go & Docker"},
+		{"cv.pdf", pdf, "Kubernetes"},
+		{"cv.txt", []byte("PostgreSQL"), "PostgreSQL"},
+	} {
+		text, err := extractCVText(tc.name, tc.data)
+		if err != nil || !strings.Contains(text, tc.want) {
+			t.Errorf("extractCVText(%s) = %q, %v, want %q in it", tc.name, text, err, tc.want)
+		}
+	}
+
+	if _, err := extractCVText("cv.png", []byte("image")); err == nil {
+		t.Error("extractCVText(cv.png) succeeded, want unsupported")
+	}
+}
+
+func TestReviewFormShowsKeywordGap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ats.json")
+	if err := os.WriteFile(path, []byte(`{"developer": ["Git"], "backend developer": ["Go", "Docker", "Kubernetes", "REST API"]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ATS_KEYWORDS_FILE", path)
+	b, api := newTestBot(t)
+	api.downloads["cv-file"] = testDocx(t, "Built REST APIs in Go", "Docker, PostgreSQL")
+
+	b.cvForms[testUserID] = &CVIntake{TargetRole: "Senior Backend Developer", step: len(cvIntakeSteps)}
+	b.userStates[testUserID] = StateCVReview
+	upload := userMessage(testUserID, "")
+	upload.Document = &tgbotapi.Document{FileID: "cv-file", FileName: "cv.docx"}
+	b.handleMessage(upload)
+	b.createUserSession(testUserID, "tester", "CV Review Request - File: cv.docx", upload.MessageID, true, "", StateCVReview)
+	session := b.userSessions[testUserID]
+
+	b.handleCallbackQuery(userCallback(testAdminID, fmt.Sprintf("review:%d", session.TicketID)))
+	for range 3 {
+		b.handleCallbackQuery(userCallback(testAdminID, "review:skip"))
+	}
+	if got := api.lastText(t, testAdminID); !strings.Contains(got, "Missing backend developer keywords: Kubernetes, REST API") {
+		t.Fatalf("ATS step = %q, want the missing keywords", got)
+	}
+
+	b.handleCallbackQuery(userCallback(testAdminID, "review:skip"))
+	b.handleCallbackQuery(userCallback(testAdminID, "review:score:6"))
+	preview := api.lastMessage(t, testAdminID).Text
+	if !strings.Contains(preview, telegram.EscapeMarkdown(b.tr(testUserID, "review_keywords"))) || !strings.Contains(preview, "Kubernetes, REST API") {
+		t.Errorf("preview = %q, want the keyword gap", preview)
+	}
+}
+
+func TestRedactPII(t *testing.T) {
+	for _, tc := range []struct {
+		text, want string
+	}{
+		{"Contact: jane.doe@example.com", "Contact: [email removed]"},
+		{"Phone: +998 (90) 123-45-67", "Phone: [phone removed]"},
+		{"Tel 8 912 345 67 89, Go developer", "Tel [phone removed], Go developer"},
+		{"Address: 221B Baker Street, London", "Address: [address removed]"},
+		{"Lives at 12 Baker Street", "Lives at [address removed]"},
+		{"Ташкент, ул. Навои, д. 5, кв. 12", "Ташкент, [address removed]"},
+		{"Amir Temur ko'chasi 15, Toshkent", "[address removed], Toshkent"},
+		{"2019 - 2021 Backend developer at Acme, 3 years", "2019 - 2021 Backend developer at Acme, 3 years"},
+	} {
+		if got, _ := redactPII(tc.text); got != tc.want {
+			t.Errorf("redactPII(%q) = %q, want %q", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestAssignedCVIsAnonymized(t *testing.T) {
+	const reviewerID, seniorChatID int64 = 2000, -100500
+	t.Setenv("REVIEWERS", fmt.Sprintf("alice:%d", reviewerID))
+	t.Setenv("SENIOR_REVIEWER_CHAT_ID", strconv.FormatInt(seniorChatID, 10))
+	t.Setenv("ANONYMIZE_CVS", "true")
+	b, api := newTestBot(t)
+	api.downloads["cv-file"] = testDocx(t, "Jane Doe", "jane.doe@example.com, +1 415 555 0100", "Backend developer, Go and Docker")
+
+	b.cvForms[testUserID] = &CVIntake{TargetRole: "Backend developer", step: len(cvIntakeSteps)}
+	b.userStates[testUserID] = StateCVReview
+	upload := userMessage(testUserID, "")
+	upload.Document = &tgbotapi.Document{FileID: "cv-file", FileName: "cv.docx"}
+	b.handleMessage(upload)
+	b.createUserSession(testUserID, "tester", "CV Review Request - File: cv.docx, call me at +1 415 555 0100", upload.MessageID, true, "", StateCVReview)
+	session := b.userSessions[testUserID]
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/assign %d @alice", session.TicketID)))
+	text := api.messages(reviewerID)[0].Text
+	if strings.Contains(text, "555") || !strings.Contains(text, "[phone removed]") {
+		t.Errorf("reviewer got %q, want the phone number removed", text)
+	}
+	if strings.Contains(text, "tester") || strings.Contains(text, strconv.FormatInt(testUserID, 10)) {
+		t.Errorf("reviewer got %q, want the ticket without its author", text)
+	}
+
+	document, ok := api.sent[len(api.sent)-2].(tgbotapi.DocumentConfig)
+	if !ok || document.ChatID != reviewerID {
+		t.Fatalf("sent %T before the admin reply, want the CV for the reviewer", api.sent[len(api.sent)-2])
+	}
+	cv := string(document.File.(tgbotapi.FileBytes).Bytes)
+	if strings.Contains(cv, "jane.doe@example.com") || strings.Contains(cv, "555") || !strings.Contains(cv, "Go and Docker") {
+		t.Errorf("reviewer CV = %q, want it without contact details", cv)
+	}
+	if reply := api.lastText(t, testAdminID); !strings.HasPrefix(reply, "👤 Ticket") || strings.Contains(reply, "⚠️") {
+		t.Errorf("admin got %q, want a plain assignment confirmation", reply)
+	}
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/escalate %d", session.TicketID)))
+	if text := api.messages(seniorChatID)[0].Text; strings.Contains(text, "tester") || strings.Contains(text, strconv.FormatInt(testUserID, 10)) {
+		t.Errorf("senior reviewer got %q, want the ticket without its author", text)
+	}
+}
+
+// fakeClamd answers clamd INSTREAM scans, finding "Eicar-Signature" in
+// data that contains the EICAR marker.
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			reader := bufio.NewReader(conn)
+			if command, err := reader.ReadString(0); err != nil || command != "zINSTREAM\x00" {
+				conn.Close()
+				continue
+			}
+			var data []byte
+			for {
+				var size uint32
+				if err := binary.Read(reader, binary.BigEndian, &size); err != nil || size == 0 {
+					break
+				}
+				chunk := make([]byte, size)
+				if _, err := io.ReadFull(reader, chunk); err != nil {
+					break
+				}
+				data = append(data, chunk...)
+			}
+			reply := "stream: OK\x00"
+			if bytes.Contains(data, []byte("EICAR-STANDARD-ANTIVIRUS-TEST-FILE")) {
+				reply = "stream: Eicar-Signature FOUND\x00"
+			}
+			conn.Write([]byte(reply))
+			conn.Close()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestInfectedUploadIsRejected(t *testing.T) {
+	t.Setenv("CLAMAV_ADDR", fakeClamd(t))
+	b, api := newTestBot(t)
+	api.downloads["infected"] = []byte(`X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+	api.downloads["clean"] = []byte("My question in a file")
+
+	b.handleMessage(userMessage(testUserID, "/question"))
+	upload := userMessage(testUserID, "")
+	upload.Document = &tgbotapi.Document{FileID: "infected", FileName: "question.pdf.exe"}
+	b.handleMessage(upload)
+	action, ok := api.requests[len(api.requests)-1].(tgbotapi.ChatActionConfig)
+	if !ok || action.Action != tgbotapi.ChatUploadDocument {
+		t.Errorf("last request %+v, want an upload chat action while the file is scanned", api.requests[len(api.requests)-1])
+	}
+	b.pendingScans.Wait()
+
+	if draft := b.drafts[testUserID]; draft != nil && draft.HasFile {
+		t.Errorf("draft = %+v, want the infected file left out", draft)
+	}
+	want := b.trMarkdown(testUserID, "file_infected", map[string]interface{}{"FileName": "question.pdf.exe", "Threat": "Eicar-Signature"})
+	if got := api.lastMessage(t, testUserID).Text; got != want {
+		t.Errorf("user got %q, want the infected file notice", got)
+	}
+	if got := api.lastText(t, testAdminID); !strings.Contains(got, "🦠 Rejected question.pdf.exe") {
+		t.Errorf("admin got %q, want the file flagged", got)
+	}
+
+	upload.Document = &tgbotapi.Document{FileID: "clean", FileName: "question.txt"}
+	b.handleMessage(upload)
+	b.pendingScans.Wait()
+	if draft, exists := b.drafts[testUserID]; !exists || draft.FileName != "question.txt" {
+		t.Errorf("clean file draft = %+v, want it accepted", draft)
+	}
+	if b.scannedMessage != nil {
+		t.Error("the scanned message was not forgotten after it was handled")
+	}
+
+	// A file sent in a flow the user left during the scan is dropped
+	b.handleMessage(userMessage(testUserID, "/question"))
+	b.mu.Lock()
+	b.handleMessage(upload)
+	b.handleMessage(userMessage(testUserID, "/cancel"))
+	sent := len(api.messages(testUserID))
+	b.mu.Unlock()
+	b.pendingScans.Wait()
+	if _, exists := b.drafts[testUserID]; exists || len(api.messages(testUserID)) != sent {
+		t.Errorf("draft = %+v, want the upload dropped after /cancel", b.drafts[testUserID])
+	}
+	if scans, _ := b.store.TakePendingScans(); len(scans) != 0 {
+		t.Errorf("pending scans = %+v, want none once they finished", scans)
+	}
+}
+
+func TestInterruptedScanAsksToResend(t *testing.T) {
+	b, api := newTestBot(t)
+	scan := storage.PendingScan{ChatID: testUserID, MessageID: 7, UserID: testUserID, FileName: "cv.pdf", StartedAt: time.Now()}
+	if err := b.store.AddPendingScan(scan); err != nil {
+		t.Fatal(err)
+	}
+
+	b.notifyInterruptedScans()
+	want := b.trMarkdown(testUserID, "file_scan_interrupted", map[string]interface{}{"FileName": "cv.pdf"})
+	if got := api.lastMessage(t, testUserID); got.Text != want || got.ReplyToMessageID != 7 {
+		t.Errorf("user got %q, want to be asked to send the file again", got.Text)
+	}
+	b.notifyInterruptedScans()
+	if len(api.messages(testUserID)) != 1 {
+		t.Error("an interrupted scan was reported twice")
+	}
+}
+
+// fakeS3 is an S3 bucket that checks requests are signed.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	hash := sha256.Sum256(body)
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test-key/") ||
+		r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(hash[:]) {
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/uploads/")
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[key] = body
+	case http.MethodGet:
+		data, exists := f.objects[key]
+		if !exists {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) object(key string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, exists := f.objects[key]
+	return data, exists
+}
+
+func TestUploadedFilesAreArchived(t *testing.T) {
+	bucket := &fakeS3{objects: make(map[string][]byte)}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+	t.Setenv("S3_BUCKET", "uploads")
+	t.Setenv("S3_ENDPOINT", server.URL)
+	t.Setenv("S3_ACCESS_KEY_ID", "test-key")
+	t.Setenv("S3_SECRET_ACCESS_KEY", "test-secret")
+
+	b, api := newTestBot(t)
+	api.downloads["cv-file"] = []byte("Jane Doe, Go developer")
+	b.drafts[testUserID] = &UserSession{UserID: testUserID, FileID: "cv-file"}
+	b.createUserSession(testUserID, "tester", "[File: my cv.pdf]", 1, true, "my cv.pdf", StateQuestion)
+	ticketID := b.userSessions[testUserID].TicketID
+
+	const key = "tickets/1/my_cv.pdf"
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if ticket, _ := b.store.Ticket(ticketID); len(ticket.Files) > 0 {
+			if ticket.Files[0] != key {
+				t.Fatalf("archived as %q, want %q", ticket.Files[0], key)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the file was not archived")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if data, _ := bucket.object(key); string(data) != "Jane Doe, Go developer" {
+		t.Errorf("archived %q, want the uploaded file", data)
+	}
+
+	b.handleAdminMessage(userMessage(testAdminID, fmt.Sprintf("/files %d", ticketID)))
+	document, ok := api.sent[len(api.sent)-1].(tgbotapi.DocumentConfig)
+	if !ok || document.ChatID != testAdminID {
+		t.Fatalf("last sent %T, want the archived file for the admin", api.sent[len(api.sent)-1])
+	}
+	if file, ok := document.File.(tgbotapi.FileBytes); !ok || file.Name != "my_cv.pdf" || string(file.Bytes) != "Jane Doe, Go developer" {
+		t.Errorf("admin got %+v, want the archived CV", document.File)
+	}
+
+	if err := b.store.AnswerTicket(ticketID, "Looks good", time.Now().AddDate(0, 0, -100)); err != nil {
+		t.Fatal(err)
+	}
+	b.retention = &Retention{Period: 90 * 24 * time.Hour}
+	b.applyRetention(time.Now())
+	if _, exists := bucket.object(key); exists {
+		t.Error("retention kept the archived file of a purged ticket")
+	}
+	if got := api.lastText(t, testAdminID); !strings.Contains(got, "with 1 archived upload(s)") {
+		t.Errorf("retention report %q does not mention the archived file", got)
+	}
+}
+
+func TestLocalArchivePrunesOldestFiles(t *testing.T) {
+	archive := &LocalArchive{dir: t.TempDir(), quota: 100, userQuota: 60}
+	data := bytes.Repeat([]byte("x"), 40)
+	put := func(userID int64, ticketID int, age time.Duration) string {
+		t.Helper()
+		key := archive.TicketKey(userID, ticketID, "cv.pdf")
+		if err := archive.Put(key, data); err != nil {
+			t.Fatalf("Put(%s): %v", key, err)
+		}
+		modTime := time.Now().Add(-age)
+		if err := os.Chtimes(filepath.Join(archive.dir, filepath.FromSlash(key)), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	first := put(1, 1, 3*time.Hour)
+	second := put(1, 2, 2*time.Hour)
+	if _, err := archive.Get(first); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("oldest file of a user over quota was kept (err %v)", err)
+	}
+
+	put(2, 3, time.Hour)
+	put(3, 4, 0)
+	if _, err := archive.Get(second); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("oldest file of the archive over quota was kept (err %v)", err)
+	}
+	if _, err := os.Stat(filepath.Join(archive.dir, "1")); !os.IsNotExist(err) {
+		t.Error("the emptied user folder was kept")
+	}
+	if got, err := archive.Get(archive.TicketKey(3, 4, "cv.pdf")); err != nil || len(got) != len(data) {
+		t.Errorf("newest file = %d bytes (err %v), want it kept", len(got), err)
+	}
+
+	if err := archive.Put(archive.TicketKey(4, 5, "huge.pdf"), bytes.Repeat([]byte("x"), 200)); err == nil {
+		t.Error("a file larger than the quota was stored")
+	}
+	if _, err := archive.Get("../data.json"); err == nil || errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Get outside the archive returned %v, want an invalid key error", err)
+	}
+}
+
+func TestPaymentSurvivesFailedAdminNotification(t *testing.T) {
+	b, api := newTestBot(t)
+	if _, err := b.store.AddPayment(storage.PaymentRecord{ChargeID: "charge-1", Provider: "telegram", UserID: testUserID, Amount: 1500, Currency: "USD", PaidAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	api.chatErrs[testAdminID] = errors.New("Bad Request: chat not found")
+	b.cvForms[testUserID] = &CVIntake{TargetRole: "Backend developer", step: len(cvIntakeSteps)}
+	b.createUserSession(testUserID, "tester", "CV Review Request - Google Drive Link: https://drive.google.com/cv", 1, false, "", StateCVReview)
+	if _, open := b.userSessions[testUserID]; open {
+		t.Fatal("ticket opened although the admin was not notified")
+	}
+	if _, unused := b.store.UnusedPayment(testUserID); !unused {
+		t.Fatal("the payment was used up by a ticket that never opened")
+	}
+
+	delete(api.chatErrs, testAdminID)
+	b.cvForms[testUserID] = &CVIntake{TargetRole: "Backend developer", step: len(cvIntakeSteps)}
+	b.createUserSession(testUserID, "tester", "CV Review Request - Google Drive Link: https://drive.google.com/cv", 1, false, "", StateCVReview)
+	session, open := b.userSessions[testUserID]
+	if !open || session.Payment != PaymentPaid {
+		t.Fatalf("session = %+v, want an open paid ticket", session)
+	}
+	if _, unused := b.store.UnusedPayment(testUserID); unused {
+		t.Error("the payment was not tied to the ticket")
+	}
+}
+
+func TestLongFormattedAnswerIsSplitIntoValidParts(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Can you review my whole CV?")
+
+	// The bold span is too long for one message, so it is cut
+	answer := strings.Repeat("Plain feedback. ", 200) + "**" + strings.Repeat("Important: fix this. ", 250) + "**\n\nSee [the guide](https://example.com/guide)."
+	api.reset()
+	b.deliverAnswer(session, answer)
+
+	parts := api.messages(testUserID)
+	if len(parts) < 3 {
+		t.Fatalf("answer sent as %d message(s), want it split in the bold span", len(parts))
+	}
+	for i, part := range parts {
+		if size := len(utf16.Encode([]rune(part.Text))); size > telegram.MessageLimit {
+			t.Errorf("part %d has %d characters, over the limit", i, size)
+		}
+		unescaped := markdownEscape.ReplaceAllString(part.Text, "")
+		if strings.Count(unescaped, "*")%2 != 0 {
+			t.Errorf("part %d has an unbalanced bold span: ...%s", i, part.Text[max(0, len(part.Text)-60):])
+		}
+		if strings.HasSuffix(unescaped, "\\") {
+			t.Errorf("part %d ends in a lone backslash", i)
+		}
+	}
+	if !strings.HasPrefix(parts[2].Text, "*Important") {
+		t.Errorf("part 3 starts with %q, want the bold span reopened", parts[2].Text[:20])
+	}
+	if last := parts[len(parts)-1].Text; !strings.Contains(last, "[the guide](https://example.com/guide)") {
+		t.Errorf("last part %q lost the link", last)
+	}
+}
+
+func TestRetentionKeepsOpenAndRecentTickets(t *testing.T) {
+	b, _ := newTestBot(t)
+	now := time.Now()
+
+	for id, answeredAt := range map[int]time.Time{
+		1: {},
+		2: now.AddDate(0, 0, -100),
+		3: now.AddDate(0, 0, -10),
+	} {
+		if err := b.store.AddTicket(storage.TicketRecord{ID: id, UserID: testUserID, AnsweredAt: answeredAt}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b.retention = &Retention{Period: 90 * 24 * time.Hour, DryRun: true}
+	b.applyRetention(now)
+	if got := len(b.store.Tickets()); got != 3 {
+		t.Fatalf("dry run left %d tickets, want 3", got)
+	}
+
+	b.retention.DryRun = false
+	b.applyRetention(now)
+	if _, exists := b.store.Ticket(2); exists {
+		t.Error("ticket answered 100 days ago was kept")
+	}
+	if got := len(b.store.Tickets()); got != 2 {
+		t.Errorf("%d tickets left, want the open and the recent one", got)
+	}
+}
+
+func TestJanitorExpiresIdleDrafts(t *testing.T) {
+	b, api := newTestBot(t)
+	session := submitQuestion(t, b, "Still open?")
+
+	const idleUserID int64 = 43
+	b.handleMessage(userMessage(idleUserID, "/question"))
+	b.handleMessage(userMessage(idleUserID, "Half-written question"))
+
+	now := time.Now()
+	b.cleanUpSessions(now)
+	api.reset()
+
+	b.cleanUpSessions(now.Add(b.sessionTTL))
+
+	if _, exists := b.drafts[idleUserID]; exists {
+		t.Error("idle draft was kept")
+	}
+	if got := api.lastMessage(t, idleUserID).Text; got != b.trMarkdown(idleUserID, "session_expired") {
+		t.Errorf("idle user got %q, want the expiry notice", got)
+	}
+	if len(api.messages(testUserID)) != 0 {
+		t.Error("user without a draft was told about an expiry")
+	}
+	if b.userSessions[testUserID] != session {
+		t.Error("open ticket was expired")
+	}
+}
+
+func TestStalledFlowIsNudgedOnce(t *testing.T) {
+	b, api := newTestBot(t)
+	b.handleMessage(userMessage(testUserID, "/question"))
+
+	now := time.Now()
+	b.lastActivity[testUserID] = now.Add(-b.nudgeDelay)
+	api.reset()
+
+	b.nudgeStalledFlows(now)
+	b.nudgeStalledFlows(now.Add(janitorInterval))
+	if got := len(api.messages(testUserID)); got != 1 {
+		t.Fatalf("sent %d reminders, want 1", got)
+	}
+	msg := api.lastMessage(t, testUserID)
+	want := b.trMarkdown(testUserID, "stalled_flow_nudge") + "\n\n" + b.trMarkdown(testUserID, "question_instructions")
+	if msg.Text != want || msg.ReplyMarkup == nil {
+		t.Errorf("reminder = %q, want the instructions with a cancel button", msg.Text)
+	}
+
+	// Going silent again after some activity earns another reminder
+	b.lastActivity[testUserID] = now
+	b.nudgeStalledFlows(now.Add(b.nudgeDelay))
+	if got := len(api.messages(testUserID)); got != 2 {
+		t.Errorf("sent %d reminders after a second silence, want 2", got)
+	}
+}
+
+func TestReengagementCountsReturningUsers(t *testing.T) {
+	t.Setenv("REENGAGEMENT_PERIOD", "7d")
+	b, api := newTestBot(t)
+	b.recordUser(&tgbotapi.User{ID: testUserID})
+	b.handleMessage(userMessage(testUserID, "/question"))
+	b.handleMessage(userMessage(testUserID, "/cancel"))
+	api.reset()
+
+	later := time.Now().Add(reengagementMinIdle + time.Hour)
+	b.sendReengagements(later)
+	b.sendReengagements(later.Add(reengagementCheckInterval))
+	if got := len(api.messages(testUserID)); got != 1 {
+		t.Fatalf("sent %d re-engagement messages, want 1", got)
+	}
+	if text := api.lastMessage(t, testUserID).Text; text != b.trMarkdown(testUserID, "reengagement_question") {
+		t.Errorf("sent %q, want the question re-engagement", text)
+	}
+
+	b.handleCallbackQuery(userCallback(testUserID, "question"))
+	submitQuestion(t, b, "Coming back with my question")
+	if user, _ := b.store.User(testUserID); user.ReturnedAt.IsZero() {
+		t.Error("the ticket after the re-engagement was not counted as a return")
+	}
+
+	b.handleCallbackQuery(userCallback(testUserID, "reengage:stop"))
+	if user, _ := b.store.User(testUserID); !user.NoReengagement {
+		t.Error("the user did not opt out")
+	}
+}
+
+func TestHandledUpdatesAreSkippedWithoutRewritingTheDataFile(t *testing.T) {
+	b, _ := newTestBot(t)
+	handle := b.newUpdatePipeline()
+	dataFile := os.Getenv("DATA_FILE")
+
+	handle(tgbotapi.Update{UpdateID: 7, Message: userMessage(testUserID, "/start")})
+	before, err := os.ReadFile(dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.store.SetLastUpdateID(8); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.ReadFile(dataFile); !bytes.Equal(before, after) {
+		t.Error("the data file was rewritten for the update offset")
+	}
+
+	reopened, err := storage.OpenStore(dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.LastUpdateID(); got != 8 {
+		t.Errorf("last update ID after a restart = %d, want 8", got)
+	}
+	handle(tgbotapi.Update{UpdateID: 8, Message: userMessage(testUserID, "/question")})
+	if _, started := b.drafts[testUserID]; started {
+		t.Error("an update that was already handled was handled again")
+	}
+
+	// Stores from before the offset file keep their offset
+	legacyFile := filepath.Join(t.TempDir(), "legacy.json")
+	if err := os.WriteFile(legacyFile, []byte(`{"last_update_id": 20}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := storage.OpenStore(legacyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := legacy.LastUpdateID(); got != 20 {
+		t.Errorf("last update ID of an old store = %d, want 20", got)
+	}
+}
+
+func TestNewUsersAreVerifiedBeforeTheirFirstMessage(t *testing.T) {
+	t.Setenv("VERIFY_NEW_USERS", "true")
+	b, api := newTestBot(t)
+	handle := b.newUpdatePipeline()
+
+	handle(tgbotapi.Update{UpdateID: 1, Message: userMessage(testUserID, "/question")})
+	if _, started := b.drafts[testUserID]; started {
+		t.Fatal("an unverified user started a flow")
+	}
+	challenge, exists := b.verifications[testUserID]
+	if !exists || api.lastMessage(t, testUserID).ReplyMarkup == nil {
+		t.Fatal("no verification was sent")
+	}
+
+	wrong := challenge.answer%18 + 1
+	handle(tgbotapi.Update{UpdateID: 2, CallbackQuery: userCallback(testUserID, fmt.Sprintf("verify:%d", wrong))})
+	if user, _ := b.store.User(testUserID); !user.VerifiedAt.IsZero() {
+		t.Fatal("a wrong answer verified the user")
+	}
+
+	// The right answer handles the message that was held back
+	handle(tgbotapi.Update{UpdateID: 3, CallbackQuery: userCallback(testUserID, fmt.Sprintf("verify:%d", challenge.answer))})
+	assertState(t, b, StateQuestion)
+
+	api.reset()
+	handle(tgbotapi.Update{UpdateID: 4, Message: userMessage(testUserID, "/cancel")})
+	if text := api.lastMessage(t, testUserID).Text; text != b.trMarkdown(testUserID, "action_cancelled") {
+		t.Errorf("a verified user got %q, want their message handled", text)
+	}
+}
+
+func TestBlockedLinksNeverReachTheAdmin(t *testing.T) {
+	t.Setenv("BLOCKED_DOMAINS", "scam.example, *.phish.test")
+	b, api := newTestBot(t)
+
+	b.handleMessage(userMessage(testUserID, "/question"))
+	b.handleMessage(userMessage(testUserID, "Is https://gift.scam.example/claim legit?"))
+	assertState(t, b, StateQuestion)
+	if text := api.lastMessage(t, testUserID).Text; text != b.trMarkdown(testUserID, "submission_blocked") {
+		t.Errorf("sent %q, want the blocked link notice", text)
+	}
+
+	hidden := userMessage(testUserID, "Claim your free Premium")
+	hidden.Entities = []tgbotapi.MessageEntity{{Type: "text_link", Offset: 0, Length: 5, URL: "https://telegram-premium.xyz/gift"}}
+	b.handleMessage(hidden)
+	assertState(t, b, StateQuestion)
+
+	b.handleMessage(userMessage(testUserID, "How do I call telegram.Bot from https://core.telegram.org docs?"))
+	assertState(t, b, StateConfirmQuestion)
+	if got := len(api.messages(testAdminID)); got != 0 {
+		t.Errorf("the admin got %d messages, want none", got)
+	}
+}
+
+func TestAdminNotesAreShownWithNotifications(t *testing.T) {
+	b, api := newTestBot(t)
+	b.recordUser(&tgbotapi.User{ID: testUserID, UserName: "tester"})
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/note %d Asked about visas before", testUserID)))
+	submitQuestion(t, b, "Do you sponsor visas?")
+	if text := api.lastText(t, testAdminID); !strings.Contains(text, "📝 "+time.Now().Format("2006-01-02")+": Asked about visas before") {
+		t.Errorf("notification = %q, want the note", text)
+	}
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/note %d clear", testUserID)))
+	if user, _ := b.store.User(testUserID); len(user.Notes) != 0 {
+		t.Errorf("%d notes left after clearing", len(user.Notes))
+	}
+}
+
+func TestLabelsFilterSessionsAndBroadcasts(t *testing.T) {
+	b, api := newTestBot(t)
+	const otherUserID = testUserID + 1
+	b.recordUser(&tgbotapi.User{ID: testUserID, UserName: "tester"})
+	b.recordUser(&tgbotapi.User{ID: otherUserID})
+
+	b.handleMessage(userMessage(testAdminID, fmt.Sprintf("/tag %d #Student", testUserID)))
+	if labels := b.userLabels(testUserID); !slices.Equal(labels, []string{"student"}) {
+		t.Fatalf("labels = %v, want [student]", labels)
+	}
+
+	submitQuestion(t, b, "Which course should I take?")
+	b.handleMessage(userMessage(testAdminID, "/sessions student"))
+	if text := api.lastText(t, testAdminID); !strings.Contains(text, "Which course should I take?") {
+		t.Errorf("/sessions student = %q, want the student's ticket", text)
+	}
+	b.handleMessage(userMessage(testAdminID, "/sessions paid"))
+	if text := api.lastText(t, testAdminID); strings.Contains(text, "Which course") {
+		t.Errorf("/sessions paid = %q, want no tickets", text)
+	}
+
+	b.handleMessage(userMessage(testAdminID, "/broadcast student Classes start on Monday"))
+	due := b.store.DueOutbox(time.Now())
+	if len(due) != 1 || due[0].ChatID != testUserID || due[0].Text != "Classes start on Monday" {
+		t.Errorf("queued %+v, want the announcement for the student only", due)
+	}
+}
+
+func TestPaidPriorityReview(t *testing.T) {
+	t.Setenv("PAYMENT_PROVIDER_TOKEN", "provider-token")
+	t.Setenv("PRIORITY_REVIEW_PRICE", "1500")
+	b, api := newTestBot(t)
+
+	b.startCVReviewFlow(testUserID)
+	for range cvIntakeSteps {
+		b.handleCallbackQuery(userCallback(testUserID, "cv_intake_skip"))
+	}
+	assertState(t, b, StateCVTier)
+
+	b.handleCallbackQuery(userCallback(testUserID, "cv_tier:priority"))
+	invoice, ok := api.sent[len(api.sent)-1].(tgbotapi.InvoiceConfig)
+	if !ok || invoice.Prices[0].Amount != 1500 || invoice.Currency != "USD" {
+		t.Fatalf("last sent = %+v, want a 15.00 USD invoice", api.sent[len(api.sent)-1])
+	}
+
+	checkout := &tgbotapi.PreCheckoutQuery{
+		ID:             "checkout",
+		From:           &tgbotapi.User{ID: testUserID},
+		Currency:       "USD",
+		TotalAmount:    1500,
+		InvoicePayload: invoice.Payload,
+	}
+	b.dispatchUpdate(tgbotapi.Update{PreCheckoutQuery: checkout})
+	if answer := api.requests[len(api.requests)-1].(tgbotapi.PreCheckoutConfig); !answer.OK {
+		t.Fatalf("checkout refused: %q", answer.ErrorMessage)
+	}
+
+	paid := userMessage(testUserID, "")
+	paid.SuccessfulPayment = &tgbotapi.SuccessfulPayment{Currency: "USD", TotalAmount: 1500, InvoicePayload: invoice.Payload, TelegramPaymentChargeID: "charge-1"}
+	b.handleMessage(paid)
+	assertState(t, b, StateCVReview)
+
+	b.handleMessage(userMessage(testUserID, "https://drive.google.com/file/d/cv"))
+	if got := api.lastMessage(t, testAdminID).Text; !strings.HasPrefix(got, "💳 PAID PRIORITY") {
+		t.Errorf("admin got %q, want a paid priority notification", got)
+	}
+	if _, unused := b.store.UnusedPayment(testUserID); unused {
+		t.Error("payment was not assigned to the ticket")
+	}
+
+	// The invoice belonged to the finished flow
+	b.dispatchUpdate(tgbotapi.Update{PreCheckoutQuery: checkout})
+	if answer := api.requests[len(api.requests)-1].(tgbotapi.PreCheckoutConfig); answer.OK {
+		t.Error("checkout of a used invoice was accepted")
+	}
+}
+
+func TestStripeWebhookUnlocksPriorityReview(t *testing.T) {
+	t.Setenv("STRIPE_SECRET_KEY", "sk_test")
+	t.Setenv("STRIPE_WEBHOOK_SECRET", "whsec_test")
+	t.Setenv("STRIPE_WEBHOOK_ADDR", "127.0.0.1:0")
+	t.Setenv("PRIORITY_REVIEW_PRICE", "1500")
+	b, _ := newTestBot(t)
+
+	b.startCVReviewFlow(testUserID)
+	for range cvIntakeSteps {
+		b.handleCallbackQuery(userCallback(testUserID, "cv_intake_skip"))
+	}
+	assertState(t, b, StateCVTier)
+	// Stands in for sendStripeCheckout, which calls the Stripe API
+	payload := newPriorityPayload(testUserID)
+	b.cvForms[testUserID].Payment = PaymentPending
+	b.cvForms[testUserID].invoicePayload = payload
+
+	body := `{"type":"checkout.session.completed","data":{"object":{"id":"cs_1","client_reference_id":"` + payload +
+		`","payment_status":"paid","payment_intent":"pi_1","amount_total":1500,"currency":"usd"}}}`
+	post := func(signature string) int {
+		req := httptest.NewRequest(http.MethodPost, "/stripe/webhook", strings.NewReader(body))
+		req.Header.Set("Stripe-Signature", signature)
+		rec := httptest.NewRecorder()
+		b.handleStripeWebhook(rec, req)
+		return rec.Code
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	if code := post("t=" + timestamp + ",v1=forged"); code != http.StatusUnauthorized {
+		t.Fatalf("forged webhook answered %d, want 401", code)
+	}
+	assertState(t, b, StateCVTier)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte(timestamp + "." + body))
+	if code := post("t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))); code != http.StatusOK {
+		t.Fatalf("webhook answered %d, want 200", code)
+	}
+	assertState(t, b, StateCVReview)
+	if payment, unused := b.store.UnusedPayment(testUserID); !unused || payment.ChargeID != "pi_1" {
+		t.Errorf("stored payment = %+v, want the unused charge pi_1", payment)
+	}
+}
+
+// roundTripFunc stands in for the transport of an HTTP client.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestStripeCheckoutIsCreatedWithoutHoldingTheLock(t *testing.T) {
+	t.Setenv("STRIPE_SECRET_KEY", "sk_test")
+	t.Setenv("STRIPE_WEBHOOK_SECRET", "whsec_test")
+	t.Setenv("STRIPE_WEBHOOK_ADDR", "127.0.0.1:0")
+	t.Setenv("PRIORITY_REVIEW_PRICE", "1500")
+	b, api := newTestBot(t)
+
+	b.startCVReviewFlow(testUserID)
+	for range cvIntakeSteps {
+		b.handleCallbackQuery(userCallback(testUserID, "cv_intake_skip"))
+	}
+	assertState(t, b, StateCVTier)
+
+	locked := false
+	b.stripe.client = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if locked = !b.mu.TryLock(); !locked {
+			b.mu.Unlock()
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Body:       io.NopCloser(strings.NewReader(`{"url":"https://checkout.stripe.com/c/pay/cs_1"}`)),
+		}, nil
+	})}
+	// Handlers run with the state locked, as in handleUpdate
+	b.mu.Lock()
+	b.handleCallbackQuery(userCallback(testUserID, "cv_tier:stripe"))
+	b.mu.Unlock()
+
+	if locked {
+		t.Error("Stripe was called while the bot's state was locked")
+	}
+	link := api.lastMessage(t, testUserID)
+	markup, ok := link.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	if !ok || markup.InlineKeyboard[0][0].URL == nil || *markup.InlineKeyboard[0][0].URL != "https://checkout.stripe.com/c/pay/cs_1" {
+		t.Fatalf("user got %+v, want the payment link", link)
+	}
+	if intake := b.cvForms[testUserID]; intake.Payment != PaymentPending || intake.price != 1500 || intake.invoicePayload == "" {
+		t.Errorf("intake = %+v, want the checkout recorded", intake)
+	}
+}
+
+func TestSubscriptionLiftsQuestionQuota(t *testing.T) {
+	t.Setenv("PAYMENT_PROVIDER_TOKEN", "provider-token")
+	t.Setenv("SUBSCRIPTION_PRICE", "2000")
+	t.Setenv("FREE_QUESTIONS_PER_MONTH", "1")
+	b, api := newTestBot(t)
+
+	if b.priorityReview != nil {
+		t.Error("priority review offered without PRIORITY_REVIEW_PRICE")
+	}
+
+	submitQuestion(t, b, "How do I prepare for a system design interview?")
+	b.handleMessage(userMessage(testUserID, "/question"))
+	if want := b.trMarkdown(testUserID, "subscription_quota_reached", map[string]interface{}{"Free": 1, "Price": "20.00 USD"}); api.lastMessage(t, testUserID).Text != want {
+		t.Fatalf("user got %q, want the quota notice", api.lastMessage(t, testUserID).Text)
+	}
+	assertState(t, b, StateWelcome)
+
+	b.handleCallbackQuery(userCallback(testUserID, "sub:telegram"))
+	invoice, ok := api.sent[len(api.sent)-1].(tgbotapi.InvoiceConfig)
+	if !ok || invoice.Prices[0].Amount != 2000 {
+		t.Fatalf("last sent = %+v, want a 20.00 USD invoice", api.sent[len(api.sent)-1])
+	}
+	b.dispatchUpdate(tgbotapi.Update{PreCheckoutQuery: &tgbotapi.PreCheckoutQuery{
+		ID:             "checkout",
+		From:           &tgbotapi.User{ID: testUserID},
+		Currency:       "USD",
+		TotalAmount:    2000,
+		InvoicePayload: invoice.Payload,
+	}})
+	if answer := api.requests[len(api.requests)-1].(tgbotapi.PreCheckoutConfig); !answer.OK {
+		t.Fatalf("checkout refused: %q", answer.ErrorMessage)
+	}
+
+	paid := userMessage(testUserID, "")
+	paid.SuccessfulPayment = &tgbotapi.SuccessfulPayment{Currency: "USD", TotalAmount: 2000, InvoicePayload: invoice.Payload, TelegramPaymentChargeID: "charge-1"}
+	b.handleMessage(paid)
+	if !b.subscribed(testUserID) {
+		t.Fatal("user is not subscribed after paying")
+	}
+	if _, unused := b.store.UnusedPayment(testUserID); unused {
+		t.Error("subscription payment was booked as a priority review")
+	}
+
+	b.handleMessage(userMessage(testUserID, "/question"))
+	assertState(t, b, StateQuestion)
+
+	b.handleMessage(userMessage(testAdminID, "/subscribers"))
+	if got := api.lastMessage(t, testAdminID).Text; !strings.HasPrefix(got, "Subscribers: 1 active, 0 expired") {
+		t.Errorf("admin got %q, want the subscriber report", got)
+	}
+}
+
+func TestConsultationBooking(t *testing.T) {
+	t.Setenv("BOOKING_ENABLED", "true")
+	t.Setenv("BOOKING_TIMEZONE", "UTC")
+	b, api := newTestBot(t)
+	const otherUserID = testUserID + 1
+
+	b.handleMessage(userMessage(testAdminID, "/availability mon-sun 10:00-11:00"))
+	b.handleMessage(userMessage(testUserID, "/book"))
+	assertState(t, b, StateBooking)
+
+	days := api.lastMessage(t, testUserID).ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	b.handleCallbackQuery(userCallback(testUserID, *days.InlineKeyboard[0][0].CallbackData))
+	slots := api.lastMessage(t, testUserID).ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	slot := *slots.InlineKeyboard[0][0].CallbackData
+	b.handleCallbackQuery(userCallback(testUserID, slot))
+	assertState(t, b, StateWelcome)
+
+	booking, booked := b.upcomingBooking(testUserID)
+	if !booked || booking.End.Sub(booking.Start) != defaultBookingSlotLength {
+		t.Fatalf("booking = %+v, want a 30m booking", booking)
+	}
+	if got := api.lastMessage(t, testAdminID).Text; !strings.HasPrefix(got, "📅 Consultation #1 booked") {
+		t.Errorf("admin got %q, want the booking notification", got)
+	}
+
+	// A second slot of the same keyboard shows the call already booked
+	b.userStates[testUserID] = StateBooking
+	b.handleCallbackQuery(userCallback(testUserID, fmt.Sprintf("booking:slot:%d", booking.Start.AddDate(0, 0, 1).Unix())))
+	if bookings := b.store.UpcomingBookings(time.Now()); len(bookings) != 1 {
+		t.Errorf("%d bookings, want the user to book one call at a time", len(bookings))
+	}
+	if got := api.lastMessage(t, testUserID).ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); *got.InlineKeyboard[0][0].CallbackData != fmt.Sprintf("booking:cancel:%d", booking.ID) {
+		t.Errorf("user got %+v, want the upcoming booking", got)
+	}
+
+	b.handleMessage(userMessage(otherUserID, "/book"))
+	b.userStates[otherUserID] = StateBooking
+	b.handleCallbackQuery(userCallback(otherUserID, slot))
+	if _, booked := b.upcomingBooking(otherUserID); booked {
+		t.Error("a booked slot was booked twice")
+	}
+
+	api.reset()
+	b.sendBookingReminders(booking.Start.Add(-time.Hour))
+	b.sendBookingReminders(booking.Start.Add(-30 * time.Minute))
+	if len(api.sent) != 2 || !strings.HasPrefix(api.lastText(t, testUserID), "⏰") {
+		t.Errorf("sent %d messages, want one reminder for each side", len(api.sent))
+	}
+}
+
+func TestPromoCodes(t *testing.T) {
+	t.Setenv("PAYMENT_PROVIDER_TOKEN", "provider-token")
+	t.Setenv("PRIORITY_REVIEW_PRICE", "1500")
+	b, api := newTestBot(t)
+	const otherUserID = testUserID + 1
+
+	b.handleMessage(userMessage(testAdminID, "/promo add half 50% 1"))
+	b.handleMessage(userMessage(testAdminID, "/promo add FREE 100"))
+
+	b.handleMessage(userMessage(testUserID, "/promo HALF"))
+	if want := b.trMarkdown(testUserID, "promo_discount", map[string]interface{}{"Discount": 50, "Price": "7.50 USD"}); api.lastMessage(t, testUserID).Text != want {
+		t.Errorf("user got %q, want %q", api.lastMessage(t, testUserID).Text, want)
+	}
+	if price := b.priorityPrice(testUserID); price != 750 {
+		t.Errorf("priority price = %d, want 750", price)
+	}
+
+	b.handleMessage(userMessage(testUserID, "/promo half"))
+	if got := api.lastMessage(t, testUserID).Text; got != b.trMarkdown(testUserID, "promo_already_redeemed") {
+		t.Errorf("second redemption got %q, want the already redeemed notice", got)
+	}
+	b.handleMessage(userMessage(otherUserID, "/promo half"))
+	if got := api.lastMessage(t, otherUserID).Text; got != b.trMarkdown(otherUserID, "promo_used_up") {
+		t.Errorf("redemption beyond max uses got %q, want the used up notice", got)
+	}
+
+	b.handleMessage(userMessage(otherUserID, "/promo free"))
+	if _, paid := b.store.UnusedPayment(otherUserID); !paid {
+		t.Error("free promo code did not book a priority review")
+	}
+}
+
+func TestLoadConfigValidatesEnvironmentOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	file := "limits:\n  urgent_cooldown: 1h\nfeatures:\n  daily_report_time: \"10:00\"\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("URGENT_COOLDOWN", "2h")
+
+	config := testConfig(t)
+	if got := durationOr(config.Limits.UrgentCooldown, 0); got != 2*time.Hour {
+		t.Errorf("urgent cooldown = %v, want the environment's 2h", got)
+	}
+	if config.Features.DailyReportTime != "10:00" {
+		t.Errorf("daily report time = %q, want the file's 10:00", config.Features.DailyReportTime)
+	}
+	if value := os.Getenv("DAILY_REPORT_TIME"); value != "" {
+		t.Errorf("DAILY_REPORT_TIME = %q, want the file kept out of the environment", value)
+	}
+
+	t.Setenv("DAILY_REPORT_TIME", "25:00")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "daily_report_time") {
+		t.Errorf("LoadConfig() error = %v, want the invalid report time", err)
+	}
+
+	t.Setenv("DAILY_REPORT_TIME", "")
+	t.Setenv("API_ADDR", ":0")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "features.api.token") {
+		t.Errorf("LoadConfig() error = %v, want the missing API token", err)
+	}
+}
+
+func TestFailedReloadKeepsRunningSettings(t *testing.T) {
+	b, api := newTestBot(t)
+	running := b.config
+	cooldown := b.urgentCooldown
+
+	t.Setenv("URGENT_COOLDOWN", "7m")
+	t.Setenv("FAQ_FILE", filepath.Join(t.TempDir(), "missing.json"))
+	b.handleMessage(userMessage(testAdminID, "/reload"))
+
+	if text := api.lastText(t, testAdminID); !strings.HasPrefix(text, "❌ Reload failed") {
+		t.Errorf("admin got %q, want the reload rejected", text)
+	}
+	if b.config != running || b.urgentCooldown != cooldown {
+		t.Errorf("urgent cooldown = %s, want the running %s kept", b.urgentCooldown, cooldown)
+	}
+
+	t.Setenv("FAQ_FILE", "")
+	b.handleMessage(userMessage(testAdminID, "/reload"))
+	if b.config == running || b.urgentCooldown != 7*time.Minute {
+		t.Errorf("urgent cooldown = %s after a valid reload, want 7m", b.urgentCooldown)
+	}
+}
+
+func TestDigestGivesEveryTicketAButton(t *testing.T) {
+	b, api := newTestBot(t)
+	now := time.Now()
+	for id := 1; id <= digestMaxButtons+5; id++ {
+		b.tickets[id] = &UserSession{TicketID: id, UserID: testUserID + int64(id), LastQuestion: "Question", CreatedAt: now.Add(time.Duration(id) * time.Second)}
+	}
+
+	api.sendErr = errors.New("network down")
+	b.sendDigest()
+	for _, session := range b.tickets {
+		if session.Digested {
+			t.Fatalf("ticket #%d was digested although the digest was not sent", session.TicketID)
+		}
+	}
+
+	api.sendErr = nil
+	b.sendDigest()
+	buttons := 0
+	for _, chattable := range api.sent {
+		if msg, ok := chattable.(tgbotapi.MessageConfig); ok && strings.HasPrefix(msg.Text, "📬 Digest") {
+			buttons += len(msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard)
+		}
+	}
+	if buttons != len(b.tickets) {
+		t.Errorf("digest has %d buttons for %d tickets", buttons, len(b.tickets))
+	}
+	for _, session := range b.tickets {
+		if !session.Digested {
+			t.Errorf("ticket #%d was not digested", session.TicketID)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"slices"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const defaultCategory = "other"
+
+type QuestionCategory struct {
+	Key   string
+	Label string
+}
+
+var questionCategories = []QuestionCategory{
+	{Key: "visas", Label: "🛂 Visas"},
+	{Key: "jobs", Label: "💼 Jobs"},
+	{Key: "courses", Label: "🎓 Courses"},
+	{Key: "other", Label: "💬 Other"},
+}
+
+// categoryLabel returns the English label shown to the admin.
+func categoryLabel(key string) string {
+	for _, category := range questionCategories {
+		if category.Key == key {
+			return category.Label
+		}
+	}
+
+	return categoryLabel(defaultCategory)
+}
+
+// userCategoryLabel returns the label of a category in the user's language.
+func (b *Bot) userCategoryLabel(userID int64, key string) string {
+	for _, category := range questionCategories {
+		if category.Key == key {
+			return b.tr(userID, "category_"+key)
+		}
+	}
+
+	return b.tr(userID, "category_"+defaultCategory)
+}
+
+func (b *Bot) categoryKeyboardRow(userID int64) []tgbotapi.InlineKeyboardButton {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(questionCategories))
+	for _, category := range questionCategories {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(b.userCategoryLabel(userID, category.Key), "category:"+category.Key))
+	}
+
+	return row
+}
+
+// handleCategoryCallback processes "category:<key>" callbacks from the
+// question flow and stores the choice on the user's draft. Keys that are not
+// in questionCategories, which only a crafted callback can carry, are
+// ignored.
+func (b *Bot) handleCategoryCallback(callback *tgbotapi.CallbackQuery) {
+	userID := callback.From.ID
+	key := strings.TrimPrefix(callback.Data, "category:")
+	if !slices.ContainsFunc(questionCategories, func(category QuestionCategory) bool { return category.Key == key }) {
+		b.logger.WithField("callback_data", callback.Data).Warn("Unknown question category")
+		return
+	}
+
+	state := b.userStates[userID]
+	if state != StateQuestion && state != StateConfirmQuestion {
+		return
+	}
+
+	draft, exists := b.drafts[userID]
+	if !exists {
+		draft = &UserSession{UserID: userID, State: StateQuestion}
+		b.drafts[userID] = draft
+	}
+	draft.Category = key
+
+	if state == StateConfirmQuestion {
+		b.showQuestionConfirmation(userID)
+		return
+	}
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "category_selected", map[string]interface{}{
+		"Category": b.userCategoryLabel(userID, key),
+	}))
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send category confirmation")
+	}
+}
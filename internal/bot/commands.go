@@ -0,0 +1,381 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/commands"
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// registerCommands registers the user and admin commands, including the
+// commands that start a flow. Their order is the order of the command
+// lists. Flows must be registered first.
+func (b *Bot) registerCommands() error {
+	userCommands := []*commands.Command{
+		{
+			Name:        "/start",
+			Aliases:     []string{"/menu", "menu", "main menu", "back"},
+			Description: "command_start",
+			Handler:     b.handleStartCommand,
+		},
+	}
+	for _, flow := range b.flows.Flows() {
+		userCommands = append(userCommands, &commands.Command{
+			Name:        flow.Commands[0],
+			Aliases:     flow.Commands[1:],
+			Description: flow.Description,
+			Handler:     userCommand(flow.Start),
+		})
+	}
+	userCommands = append(userCommands,
+		&commands.Command{
+			Name:        "/status",
+			Aliases:     []string{"status"},
+			Description: "command_status",
+			Handler:     userCommand(b.showUserStatus),
+		},
+		&commands.Command{
+			Name:        "/history",
+			Aliases:     []string{"history"},
+			Description: "command_history",
+			Handler: userCommand(func(userID int64) {
+				b.showUserHistory(userID, 0, 0)
+			}),
+		},
+		&commands.Command{
+			Name:        "/language",
+			Aliases:     []string{"language"},
+			Description: "command_language",
+			Handler:     userCommand(b.showLanguagePicker),
+		},
+		&commands.Command{
+			Name:        "/invite",
+			Aliases:     []string{"invite"},
+			Description: "command_invite",
+			Handler:     userCommand(b.showInviteLink),
+		},
+		&commands.Command{
+			Name:        "/promo",
+			Aliases:     []string{"promo"},
+			Usage:       "<code>",
+			MinArgs:     1,
+			Description: "command_promo",
+			Handler:     b.redeemPromoCode,
+		},
+		&commands.Command{
+			Name:        "/subscribe",
+			Aliases:     []string{"subscribe", "subscription"},
+			Description: "command_subscribe",
+			Handler:     userCommand(b.showSubscription),
+		},
+		&commands.Command{
+			Name:        "/cancel",
+			Aliases:     []string{"cancel", "stop"},
+			Description: "command_cancel",
+			Handler:     userCommand(b.cancelCurrentAction),
+		},
+		&commands.Command{
+			Name:        "/help",
+			Aliases:     []string{"help"},
+			Description: "command_help",
+			Handler:     userCommand(b.showUserHelp),
+		},
+		&commands.Command{
+			Name:        "/commands",
+			Aliases:     []string{"commands"},
+			Description: "command_commands",
+			Handler:     userCommand(b.showUserCommands),
+		},
+	)
+	for _, command := range userCommands {
+		command.Roles = commands.User
+	}
+
+	adminCommands := []*commands.Command{
+		{Name: "/sessions", Usage: "[label]", Description: "View all active user sessions, or those of users with a label", Handler: adminArgsCommand(b.showSessions)},
+		{Name: "/reply", Usage: "<ticket_id|user_id> <text>", MinArgs: 2, Description: "Answer an open ticket without replying to its notification", Handler: b.replyToTicket},
+		{Name: "/templates", Description: "List saved templates", Handler: adminCommand(b.showTemplates)},
+		{Name: "/template", Usage: "add <name> <text> | delete <name>", Description: "Save or delete a template", Handler: adminArgsCommand(b.handleTemplateCommand)},
+		{Name: "/digest", Usage: "on|off|<interval>", Description: "Batch new tickets into a periodic digest", Handler: adminArgsCommand(b.handleDigestCommand)},
+		{Name: "/stats", Usage: "[7d|30d|all]", Description: "Show bot statistics", Handler: adminArgsCommand(b.showStats)},
+		{Name: "/export", Usage: "[7d|30d|all]", Description: "Export tickets as CSV", Handler: adminArgsCommand(b.exportTickets)},
+		{Name: "/search", Usage: "<keywords> [7d|30d|all]", Description: "Find past tickets and answers", Handler: adminArgsCommand(b.searchTickets)},
+		{Name: "/tag", Usage: "<user_id> <label>", Description: "Label a user, or list the labels in use", Handler: adminArgsCommand(b.handleTagCommand)},
+		{Name: "/untag", Usage: "<user_id> <label>", MinArgs: 2, Description: "Remove a label from a user", Handler: adminArgsCommand(b.handleUntagCommand)},
+		{Name: "/broadcast", Usage: "<label|all> <text>", MinArgs: 2, Description: "Send a message to all users with a label", Handler: adminArgsCommand(b.handleBroadcastCommand)},
+		{Name: "/pin", Usage: "<ticket_id>", MinArgs: 1, Description: "Keep an open ticket at the top of /sessions", Handler: adminArgsCommand(b.pinTicket)},
+		{Name: "/unpin", Usage: "<ticket_id>", MinArgs: 1, Description: "Unpin a ticket", Handler: adminArgsCommand(b.unpinTicket)},
+		{Name: "/assign", Usage: "<ticket_id> <@reviewer|me>", MinArgs: 1, Description: "Assign a ticket to a reviewer, or show its assignments", Handler: adminArgsCommand(b.handleAssignCommand)},
+		{Name: "/comment", Usage: "<ticket_id> [text]", MinArgs: 1, Description: "Add an internal comment to a ticket, or list its comments", Handler: adminArgsCommand(b.handleCommentCommand)},
+		{Name: "/review", Usage: "<ticket_id>", MinArgs: 1, Description: "Fill in the CV review form of a ticket", Handler: adminArgsCommand(b.handleReviewCommand)},
+		{Name: "/escalate", Usage: "<ticket_id> [reason]", MinArgs: 1, Description: "Send a ticket to the senior reviewer", Handler: adminArgsCommand(b.escalateTicket)},
+		{Name: "/files", Usage: "<ticket_id>", MinArgs: 1, Description: "Send the archived files of a ticket", Handler: adminArgsCommand(b.sendTicketFiles)},
+		{Name: "/archive", Usage: "<ticket_id>", MinArgs: 1, Description: "Archive a ticket without deleting it", Handler: adminArgsCommand(b.archiveTicket)},
+		{Name: "/unarchive", Usage: "<ticket_id>", MinArgs: 1, Description: "Bring an archived ticket back", Handler: adminArgsCommand(b.unarchiveTicket)},
+		{Name: "/archived", Description: "List archived tickets", Handler: adminCommand(b.showArchivedTickets)},
+		{Name: "/transcript", Usage: "<user_id>", MinArgs: 1, Description: "Export the conversation history with a user as a file", Handler: adminArgsCommand(b.sendTranscript)},
+		{Name: "/note", Usage: "<user_id> [text|clear]", MinArgs: 1, Description: "Add, list or delete private notes about a user", Handler: adminArgsCommand(b.handleNoteCommand)},
+		{Name: "/audit", Usage: "<user_id>", Description: "Show recent activity of a user", Handler: adminArgsCommand(b.showAuditLog)},
+		{Name: "/blocked", Description: "Show blocked domains and messages that linked to them", Handler: adminCommand(b.showBlockedAttempts)},
+		{Name: "/referrals", Description: "Show who invited the most users", Handler: adminCommand(b.showReferrals)},
+		{Name: "/promos", Description: "List promo codes and their uses", Handler: adminCommand(b.showPromoCodes)},
+		{Name: "/promo", Usage: "add <code> <discount%> [max_uses] [YYYY-MM-DD] | delete <code>", Description: "Create or delete a promo code", Handler: adminArgsCommand(b.handlePromoCommand)},
+		{Name: "/bookings", Usage: "[cancel <id>]", Description: "List or cancel upcoming consultations", Handler: adminArgsCommand(b.showBookings)},
+		{Name: "/availability", Usage: "<days> <HH:MM-HH:MM>[,...] | <days> off", Description: "Show or set weekly consultation availability", Handler: adminArgsCommand(b.handleAvailabilityCommand)},
+		{Name: "/reengagement", Description: "Show how many users came back after a reminder", Handler: adminCommand(b.showReengagement)},
+		{Name: "/subscribers", Description: "List subscribers and their renewals", Handler: adminCommand(b.showSubscribers)},
+		{Name: "/features", Description: "Show integration health", Handler: adminCommand(b.showFeatures)},
+		{Name: "/reload", Description: "Reload texts and limits from .env and the config file", Handler: adminCommand(b.handleReloadCommand)},
+		{Name: "/help", Description: "Show this help message", Handler: adminCommand(b.showAdminHelp)},
+	}
+	for _, command := range adminCommands {
+		command.Roles = commands.Admin
+	}
+
+	for _, command := range append(userCommands, adminCommands...) {
+		if err := b.commands.Register(command); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func userCommand(handler func(userID int64)) commands.Handler {
+	return func(req commands.Request) {
+		handler(req.Message.From.ID)
+	}
+}
+
+func adminCommand(handler func()) commands.Handler {
+	return func(req commands.Request) {
+		handler()
+	}
+}
+
+func adminArgsCommand(handler func(args string)) commands.Handler {
+	return func(req commands.Request) {
+		handler(req.Args)
+	}
+}
+
+// routeCommand runs the command in message, if it is one the sender may
+// run, and reports whether it was.
+func (b *Bot) routeCommand(message *tgbotapi.Message) bool {
+	userID := message.From.ID
+	role := commands.User
+	if userID == b.adminID {
+		role = commands.Admin
+	}
+
+	handled, err := b.commands.Route(role, message)
+
+	var usageErr *commands.UsageError
+	if errors.As(err, &usageErr) {
+		usage := strings.TrimSpace(usageErr.Command.Name + " " + usageErr.Command.Usage)
+		msg := tgbotapi.NewMessage(userID, "Usage: "+usage)
+		if role == commands.User {
+			msg = telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "command_usage", map[string]interface{}{"Usage": usage}))
+		}
+
+		_, err = b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send command usage")
+		}
+	}
+
+	return handled
+}
+
+// commandListing formats a command for a command list, e.g.
+// "/start, /menu - Main menu".
+func commandListing(command *commands.Command, description string) string {
+	names := strings.Join(command.Names(), ", ")
+	if command.Usage != "" {
+		names += " " + command.Usage
+	}
+
+	return names + " - " + description
+}
+
+func (b *Bot) showUserCommands(userID int64) {
+	lines := []string{b.trMarkdown(userID, "user_commands"), ""}
+	for _, command := range b.commands.Commands(commands.User) {
+		lines = append(lines, telegram.EscapeMarkdown("• "+commandListing(command, b.tr(userID, command.Description))))
+	}
+	lines = append(lines, "", b.trMarkdown(userID, "commands_footer"))
+
+	msg := telegram.NewMarkdownMessage(userID, strings.Join(lines, "\n"))
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send user commands")
+	}
+}
+
+func (b *Bot) showAdminHelp() {
+	lines := []string{
+		"Admin Commands:",
+		"💬 Reply to any question message to answer the user",
+		"/t <name> - Reply with a saved template",
+		"/reuse <ticket_id> - Reply with the answer of a past ticket",
+	}
+	for _, command := range b.commands.Commands(commands.Admin) {
+		lines = append(lines, commandListing(command, command.Description))
+	}
+
+	msg := tgbotapi.NewMessage(b.adminID, strings.Join(lines, "\n"))
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send help message")
+	}
+}
+
+// publishCommands registers the command lists with Telegram so its "/"
+// menu autocompletes them: the user commands in every supported language,
+// English for other languages, and the admin commands in the admin's chat.
+func (b *Bot) publishCommands() error {
+	var errs []error
+	publish := func(config tgbotapi.SetMyCommandsConfig) {
+		if _, err := b.api.Request(config); err != nil {
+			name := config.Scope.Type
+			if config.LanguageCode != "" {
+				name += "/" + config.LanguageCode
+			}
+			errs = append(errs, fmt.Errorf("%s commands: %w", name, err))
+		}
+	}
+
+	userScope := tgbotapi.NewBotCommandScopeAllPrivateChats()
+	for _, lang := range append([]string{""}, supportedLanguages...) {
+		descriptionLang := lang
+		if lang == "" {
+			descriptionLang = defaultLanguage
+		}
+
+		var botCommands []tgbotapi.BotCommand
+		for _, command := range b.commands.Commands(commands.User) {
+			botCommands = append(botCommands, tgbotapi.BotCommand{
+				Command:     strings.TrimPrefix(command.Name, "/"),
+				Description: b.trLang(descriptionLang, command.Description),
+			})
+		}
+
+		publish(tgbotapi.NewSetMyCommandsWithScopeAndLanguage(userScope, lang, botCommands...))
+	}
+
+	var adminCommands []tgbotapi.BotCommand
+	for _, command := range b.commands.Commands(commands.Admin) {
+		adminCommands = append(adminCommands, tgbotapi.BotCommand{
+			Command:     strings.TrimPrefix(command.Name, "/"),
+			Description: command.Description,
+		})
+	}
+	publish(tgbotapi.NewSetMyCommandsWithScope(tgbotapi.NewBotCommandScopeChat(b.adminID), adminCommands...))
+
+	return errors.Join(errs...)
+}
+
+// replyToTicket answers an open ticket without replying to its
+// notification, which may be buried, batched into a digest or sent before a
+// restart. The target is a ticket ID ("42" or "#42") or the ID of a user
+// with an open ticket.
+func (b *Bot) replyToTicket(req commands.Request) {
+	args := req.SplitArgs(2)
+
+	session := b.findOpenTicket(args[0])
+	if session == nil {
+		msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("❌ No open ticket for %s", args[0]))
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send reply error")
+		}
+		return
+	}
+
+	// Edits of a /reply message are not passed on, its text is more than
+	// the answer
+	b.answerTicket(session, args[1], 0)
+}
+
+// findOpenTicket resolves a ticket ID or user ID to an open ticket. Ticket
+// IDs are tried first; "#" forces a ticket ID.
+func (b *Bot) findOpenTicket(target string) *UserSession {
+	id, err := strconv.ParseInt(strings.TrimPrefix(target, "#"), 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	if session, exists := b.tickets[int(id)]; exists {
+		return session
+	}
+	if strings.HasPrefix(target, "#") {
+		return nil
+	}
+
+	return b.userSessions[id]
+}
+
+// loadOpenTickets brings the tickets the last run left open back into
+// memory, with the admin notification messages that announce them. Tickets
+// without one were only listed in a digest, or not yet, and are listed in
+// the next digest again.
+func (b *Bot) loadOpenTickets() {
+	for _, ticket := range b.store.OpenTickets() {
+		session := sessionFromTicket(ticket)
+		b.tickets[ticket.ID] = session
+		// The newest open ticket of a user is theirs
+		b.userSessions[ticket.UserID] = session
+	}
+
+	for messageID, ticketID := range b.store.AdminMessages() {
+		if session, open := b.tickets[ticketID]; open {
+			b.adminMessages[messageID] = session
+			session.AdminMsgID = max(session.AdminMsgID, messageID)
+		}
+	}
+}
+
+// trackOpenTicket adds a ticket that is open again in the store, after its
+// answer was undone or it was unarchived, to the open tickets in memory.
+func (b *Bot) trackOpenTicket(session *UserSession) {
+	if _, exists := b.tickets[session.TicketID]; exists {
+		return
+	}
+	b.tickets[session.TicketID] = session
+	if _, exists := b.userSessions[session.UserID]; !exists {
+		b.userSessions[session.UserID] = session
+	}
+}
+
+// sessionFromTicket rebuilds the session of a stored ticket.
+func sessionFromTicket(ticket storage.TicketRecord) *UserSession {
+	session := &UserSession{
+		TicketID:       ticket.ID,
+		UserID:         ticket.UserID,
+		Username:       ticket.Username,
+		LastQuestion:   ticket.Question,
+		State:          UserState(ticket.Kind),
+		Category:       ticket.Category,
+		Pinned:         ticket.Pinned,
+		SnoozedUntil:   ticket.SnoozedUntil,
+		AssignedTo:     ticket.AssignedTo,
+		EscalatedAt:    ticket.EscalatedAt,
+		CreatedAt:      ticket.CreatedAt,
+		AcknowledgedAt: ticket.AcknowledgedAt,
+	}
+	if ticket.Paid {
+		session.Payment = PaymentPaid
+	}
+	if ticket.GroupChatID != 0 {
+		session.MessageID = ticket.GroupMessageID
+		session.Group = &GroupOrigin{ChatID: ticket.GroupChatID}
+	}
+
+	return session
+}
@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+// handleCommentCommand handles the admin's /comment <ticket_id> [text].
+func (b *Bot) handleCommentCommand(args string) {
+	_, err := b.api.SendLong(b.adminID, b.commentOnTicket(b.adminID, args), nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send comment command reply")
+	}
+}
+
+// commentOnTicket adds an internal comment to a ticket for
+// /comment <ticket_id> <text>, or lists its comments for
+// /comment <ticket_id>, and returns the reply for the author. Comments are
+// shown in the admin's and reviewers' views of the ticket but never reach
+// the user. Reviewers may only comment on tickets assigned to them.
+func (b *Bot) commentOnTicket(author int64, args string) string {
+	idArg, text, _ := strings.Cut(strings.TrimSpace(args), " ")
+	text = strings.TrimSpace(text)
+
+	ticketID, err := parseTicketID(idArg)
+	if err != nil {
+		return fmt.Sprintf("❌ %v\n\nUsage: /comment <ticket_id> <text>\n\n/comment <ticket_id> lists the comments on a ticket.", err)
+	}
+	ticket, exists := b.store.Ticket(ticketID)
+	if !exists || (author != b.adminID && ticket.AssignedTo != author) {
+		return fmt.Sprintf("❌ Ticket #%d not found", ticketID)
+	}
+
+	if text == "" {
+		if len(ticket.Comments) == 0 {
+			return fmt.Sprintf("No comments on ticket #%d", ticketID)
+		}
+		return fmt.Sprintf("🗨 Comments on ticket #%d:\n\n%s", ticketID, b.formatTicketComments(ticket.Comments))
+	}
+
+	comment := storage.TicketComment{By: author, Text: text, At: time.Now()}
+	if _, err := b.store.AddTicketComment(ticketID, comment); err != nil {
+		b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to save ticket comment")
+		return fmt.Sprintf("❌ Failed to save comment: %v", err)
+	}
+
+	// The other side of an assigned ticket sees the comment right away
+	recipient := ticket.AssignedTo
+	if author != b.adminID {
+		recipient = b.adminID
+	}
+	if recipient != 0 {
+		msg := tgbotapi.NewMessage(recipient, fmt.Sprintf("🗨 %s commented on ticket #%d:\n\n%s", b.assigneeName(author), ticketID, text))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to pass on ticket comment")
+		}
+	}
+
+	return fmt.Sprintf("🗨 Comment saved on ticket #%d, the user does not see it", ticketID)
+}
+
+// ticketCommentLines returns the comments on a ticket for the admin's and
+// reviewers' views of it, or "" without comments.
+func (b *Bot) ticketCommentLines(ticketID int) string {
+	ticket, exists := b.store.Ticket(ticketID)
+	if !exists || len(ticket.Comments) == 0 {
+		return ""
+	}
+
+	return b.formatTicketComments(ticket.Comments)
+}
+
+// formatTicketComments lists comments one per line, e.g.
+// "🗨 @alice, 2026-03-01 15:04: asked a lawyer, waiting".
+func (b *Bot) formatTicketComments(comments []storage.TicketComment) string {
+	lines := make([]string, len(comments))
+	for i, comment := range comments {
+		lines[i] = fmt.Sprintf("🗨 %s, %s: %s", b.assigneeName(comment.By), comment.At.Format("2006-01-02 15:04"), comment.Text)
+	}
+
+	return strings.Join(lines, "\n")
+}
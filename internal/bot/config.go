@@ -0,0 +1,711 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigFile = "config.yaml"
+
+// Duration is a time.Duration written as "30m" or "24h" in the config file.
+// "off" is zero, which switches off the settings that can be.
+type Duration time.Duration
+
+func parseDuration(value string) (Duration, error) {
+	if value == "off" {
+		return 0, nil
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, use values like 30m or 24h", value)
+	}
+	return Duration(parsed), nil
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := parseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Config is the typed form of every setting the bot reads, built by
+// LoadConfig. Each field maps to the environment variable named in its
+// comment, which wins over the config file.
+type Config struct {
+	file string
+
+	Telegram struct {
+		Token   string `yaml:"token"`    // TELEGRAM_BOT_TOKEN
+		AdminID int64  `yaml:"admin_id"` // ADMIN_ID
+	} `yaml:"telegram"`
+
+	Storage struct {
+		DataFile        string `yaml:"data_file"`         // DATA_FILE
+		AuditLogFile    string `yaml:"audit_log_file"`    // AUDIT_LOG_FILE
+		RetentionPeriod string `yaml:"retention_period"`  // RETENTION_PERIOD
+		RetentionDryRun *bool  `yaml:"retention_dry_run"` // RETENTION_DRY_RUN
+		// ArchiveDir keeps uploaded files locally, an alternative to S3;
+		// the quotas are in megabytes
+		ArchiveDir       string `yaml:"archive_dir"`        // ARCHIVE_DIR
+		ArchiveQuota     *int   `yaml:"archive_quota"`      // ARCHIVE_QUOTA
+		ArchiveUserQuota *int   `yaml:"archive_user_quota"` // ARCHIVE_USER_QUOTA
+	} `yaml:"storage"`
+
+	Logging struct {
+		Level          string    `yaml:"level"`           // LOG_LEVEL
+		File           string    `yaml:"file"`            // LOG_FILE
+		MaxSize        *int      `yaml:"max_size"`        // LOG_MAX_SIZE
+		MaxBackups     *int      `yaml:"max_backups"`     // LOG_MAX_BACKUPS
+		MaxAge         *int      `yaml:"max_age"`         // LOG_MAX_AGE
+		RotateInterval *Duration `yaml:"rotate_interval"` // LOG_ROTATE_INTERVAL
+	} `yaml:"logging"`
+
+	Limits struct {
+		// UrgentCooldown is the minimum time between two urgent questions
+		// from the same user
+		UrgentCooldown *Duration  `yaml:"urgent_cooldown"` // URGENT_COOLDOWN
+		SLAThresholds  []Duration `yaml:"sla_thresholds"`  // SLA_THRESHOLDS
+		DisableSLA     bool       `yaml:"disable_sla"`     // SLA_THRESHOLDS=off
+		// FollowUpSurveyDelay is how long after an answer users are asked
+		// whether it helped; 0 disables the survey
+		FollowUpSurveyDelay *Duration `yaml:"followup_survey_delay"` // FOLLOWUP_SURVEY_DELAY
+		// UserRateLimit is the number of updates a user may send per
+		// minute; 0 (or "off" in the environment) disables the limit
+		UserRateLimit *int `yaml:"user_rate_limit"` // USER_RATE_LIMIT
+		// SessionTTL is how long a user may stay idle before their
+		// unfinished draft or flow is dropped; 0 keeps them forever
+		SessionTTL *Duration `yaml:"session_ttl"` // SESSION_TTL
+		// StalledFlowNudge is how long a user may stay silent after
+		// starting a question or CV review before they are reminded; 0
+		// disables the reminder
+		StalledFlowNudge *Duration `yaml:"stalled_flow_nudge"` // STALLED_FLOW_NUDGE
+	} `yaml:"limits"`
+
+	OfficeHours struct {
+		Hours    string `yaml:"hours"`    // OFFICE_HOURS
+		Days     string `yaml:"days"`     // OFFICE_DAYS
+		Timezone string `yaml:"timezone"` // OFFICE_TIMEZONE
+	} `yaml:"office_hours"`
+
+	Booking struct {
+		Enabled          *bool      `yaml:"enabled"`           // BOOKING_ENABLED
+		SlotLength       *Duration  `yaml:"slot_length"`       // BOOKING_SLOT_LENGTH
+		DaysAhead        *int       `yaml:"days_ahead"`        // BOOKING_DAYS_AHEAD
+		Reminders        []Duration `yaml:"reminders"`         // BOOKING_REMINDERS
+		DisableReminders bool       `yaml:"disable_reminders"` // BOOKING_REMINDERS=off
+		Timezone         string     `yaml:"timezone"`          // BOOKING_TIMEZONE
+	} `yaml:"booking"`
+
+	Moderation struct {
+		BlockedDomains []string `yaml:"blocked_domains"` // BLOCKED_DOMAINS
+		// ClamAVAddr is the clamd "host:port" or unix socket uploaded
+		// files are scanned with
+		ClamAVAddr string `yaml:"clamav_addr"` // CLAMAV_ADDR
+	} `yaml:"moderation"`
+
+	Team struct {
+		// Reviewers maps names to Telegram user IDs
+		Reviewers map[string]int64 `yaml:"reviewers"` // REVIEWERS
+		// SeniorReviewerChatID is the chat (a person or a group) /escalate
+		// sends tickets to; 0 disables /escalate
+		SeniorReviewerChatID int64 `yaml:"senior_reviewer_chat_id"` // SENIOR_REVIEWER_CHAT_ID
+		// AnonymizeCVs removes contact details from CVs shared with
+		// reviewers and the senior reviewer chat; only the admin sees the
+		// original file
+		AnonymizeCVs *bool `yaml:"anonymize_cvs"` // ANONYMIZE_CVS
+	} `yaml:"team"`
+
+	Texts struct {
+		MessagesDir string `yaml:"messages_dir"` // MESSAGES_DIR
+		FAQFile     string `yaml:"faq_file"`     // FAQ_FILE
+		// TermsFile is the privacy notice or terms of service users
+		// accept before their first submission
+		TermsFile    string `yaml:"terms_file"`    // TERMS_FILE
+		TermsVersion string `yaml:"terms_version"` // TERMS_VERSION
+		// ATSKeywordsFile maps target roles to the keywords CVs are
+		// checked for
+		ATSKeywordsFile string `yaml:"ats_keywords_file"` // ATS_KEYWORDS_FILE
+		// WelcomeVariants are welcome menu message IDs to A/B test
+		WelcomeVariants []string `yaml:"welcome_variants"` // WELCOME_VARIANTS
+		// Menu lists the welcome menu buttons row by row
+		Menu [][]string `yaml:"menu"` // MENU_LAYOUT
+	} `yaml:"texts"`
+
+	Features struct {
+		HealthAddr      string `yaml:"health_addr"`       // HEALTH_ADDR
+		DailyReportTime string `yaml:"daily_report_time"` // DAILY_REPORT_TIME
+		// ReferralThanks thanks referrers whenever someone joins through
+		// their link
+		ReferralThanks *bool `yaml:"referral_thanks"` // REFERRAL_THANKS
+		// VerifyNewUsers makes users solve a simple sum before the bot
+		// handles anything they send
+		VerifyNewUsers *bool `yaml:"verify_new_users"` // VERIFY_NEW_USERS
+		// AcknowledgeNotifyUser tells users when the admin presses
+		// 👀 Acknowledge on their ticket
+		AcknowledgeNotifyUser *bool `yaml:"acknowledge_notify_user"` // ACKNOWLEDGE_NOTIFY_USER
+		// OnboardingTutorial walks new users through asking a question and
+		// requesting a CV review the first time they send /start
+		OnboardingTutorial *bool `yaml:"onboarding_tutorial"` // ONBOARDING_TUTORIAL
+		// ReengagementPeriod is a duration or a number of days, e.g. 7d
+		ReengagementPeriod string `yaml:"reengagement_period"` // REENGAGEMENT_PERIOD
+		API                struct {
+			Addr  string `yaml:"addr"`  // API_ADDR
+			Token string `yaml:"token"` // API_TOKEN
+		} `yaml:"api"`
+		GRPC struct {
+			Addr  string `yaml:"addr"`  // GRPC_ADDR
+			Token string `yaml:"token"` // GRPC_TOKEN
+		} `yaml:"grpc"`
+		Dashboard struct {
+			Addr  string `yaml:"addr"`  // DASHBOARD_ADDR
+			Token string `yaml:"token"` // DASHBOARD_TOKEN
+		} `yaml:"dashboard"`
+	} `yaml:"features"`
+
+	Observability struct {
+		SentryDSN         string `yaml:"sentry_dsn"`         // SENTRY_DSN
+		SentryEnvironment string `yaml:"sentry_environment"` // SENTRY_ENVIRONMENT
+		OTLPEndpoint      string `yaml:"otlp_endpoint"`      // OTEL_EXPORTER_OTLP_ENDPOINT
+		ServiceName       string `yaml:"otel_service_name"`  // OTEL_SERVICE_NAME
+	} `yaml:"observability"`
+
+	Integrations struct {
+		GoogleSheets struct {
+			ID          string `yaml:"id"`          // GOOGLE_SHEETS_ID
+			Credentials string `yaml:"credentials"` // GOOGLE_SHEETS_CREDENTIALS
+			Tab         string `yaml:"tab"`         // GOOGLE_SHEETS_TAB
+		} `yaml:"google_sheets"`
+		Notion struct {
+			Token    string `yaml:"token"`    // NOTION_TOKEN
+			DBID     string `yaml:"db_id"`    // NOTION_DB_ID
+			Reviewer string `yaml:"reviewer"` // NOTION_REVIEWER
+		} `yaml:"notion"`
+		Tracker struct {
+			Type   string `yaml:"type"` // TRACKER
+			Trello struct {
+				Key        string `yaml:"key"`          // TRELLO_KEY
+				Token      string `yaml:"token"`        // TRELLO_TOKEN
+				ListID     string `yaml:"list_id"`      // TRELLO_LIST_ID
+				DoneListID string `yaml:"done_list_id"` // TRELLO_DONE_LIST_ID
+			} `yaml:"trello"`
+			Jira struct {
+				URL       string `yaml:"url"`        // JIRA_URL
+				Email     string `yaml:"email"`      // JIRA_EMAIL
+				APIToken  string `yaml:"api_token"`  // JIRA_API_TOKEN
+				Project   string `yaml:"project"`    // JIRA_PROJECT
+				IssueType string `yaml:"issue_type"` // JIRA_ISSUE_TYPE
+			} `yaml:"jira"`
+		} `yaml:"tracker"`
+		Slack struct {
+			WebhookURL    string `yaml:"webhook_url"`    // SLACK_WEBHOOK_URL
+			BotToken      string `yaml:"bot_token"`      // SLACK_BOT_TOKEN
+			ChannelID     string `yaml:"channel_id"`     // SLACK_CHANNEL_ID
+			SigningSecret string `yaml:"signing_secret"` // SLACK_SIGNING_SECRET
+			EventsAddr    string `yaml:"events_addr"`    // SLACK_EVENTS_ADDR
+		} `yaml:"slack"`
+		Email struct {
+			AdminEmail    string    `yaml:"admin_email"`    // ADMIN_EMAIL
+			FallbackDelay *Duration `yaml:"fallback_delay"` // EMAIL_FALLBACK_DELAY
+			SMTPHost      string    `yaml:"smtp_host"`      // SMTP_HOST
+			SMTPPort      *int      `yaml:"smtp_port"`      // SMTP_PORT
+			SMTPUsername  string    `yaml:"smtp_username"`  // SMTP_USERNAME
+			SMTPPassword  string    `yaml:"smtp_password"`  // SMTP_PASSWORD
+			SMTPFrom      string    `yaml:"smtp_from"`      // SMTP_FROM
+		} `yaml:"email"`
+		Webhook struct {
+			URL    string   `yaml:"url"`    // WEBHOOK_URL
+			Secret string   `yaml:"secret"` // WEBHOOK_SECRET
+			Events []string `yaml:"events"` // WEBHOOK_EVENTS
+		} `yaml:"webhook"`
+		S3 struct {
+			Bucket          string `yaml:"bucket"`            // S3_BUCKET
+			Endpoint        string `yaml:"endpoint"`          // S3_ENDPOINT
+			Region          string `yaml:"region"`            // S3_REGION
+			AccessKeyID     string `yaml:"access_key_id"`     // S3_ACCESS_KEY_ID
+			SecretAccessKey string `yaml:"secret_access_key"` // S3_SECRET_ACCESS_KEY
+			Prefix          string `yaml:"prefix"`            // S3_PREFIX
+		} `yaml:"s3"`
+		Payments struct {
+			ProviderToken       string `yaml:"provider_token"`        // PAYMENT_PROVIDER_TOKEN
+			PriorityReviewPrice *int   `yaml:"priority_review_price"` // PRIORITY_REVIEW_PRICE
+			Currency            string `yaml:"currency"`              // PAYMENT_CURRENCY
+			Stripe              struct {
+				SecretKey     string `yaml:"secret_key"`     // STRIPE_SECRET_KEY
+				WebhookSecret string `yaml:"webhook_secret"` // STRIPE_WEBHOOK_SECRET
+				WebhookAddr   string `yaml:"webhook_addr"`   // STRIPE_WEBHOOK_ADDR
+			} `yaml:"stripe"`
+			Subscription struct {
+				Price               *int     `yaml:"price"`                    // SUBSCRIPTION_PRICE
+				FreeQuestions       *int     `yaml:"free_questions_per_month"` // FREE_QUESTIONS_PER_MONTH
+				SubscriberOnlyFlows []string `yaml:"subscriber_only_flows"`    // SUBSCRIBER_ONLY_FLOWS
+			} `yaml:"subscription"`
+		} `yaml:"payments"`
+	} `yaml:"integrations"`
+}
+
+// LoadConfig builds the settings the bot runs on: CONFIG_FILE (config.yaml
+// by default), overridden by .env, overridden by the process environment.
+// The merged result is validated, so a bad value is reported whichever of
+// them it came from. A missing default config.yaml is not an error.
+func LoadConfig() (*Config, error) {
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf(".env: %w", err)
+	}
+	lookup := func(name string) string {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		return dotenv[name]
+	}
+
+	config, err := readConfigFile(lookup("CONFIG_FILE"))
+	if err != nil {
+		return nil, err
+	}
+	if err := config.applyEnv(lookup); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// File returns the config file the settings were read from, or "" when
+// they come from the environment alone.
+func (c *Config) File() string {
+	return c.file
+}
+
+// readConfigFile parses path, or the default config.yaml when path is
+// empty. It returns an empty config when that default does not exist.
+func readConfigFile(path string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	config := &Config{file: path}
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %s", path, configDecodeError(err))
+	}
+
+	return config, nil
+}
+
+// configDecodeError shortens yaml.v3 errors, which spell out the whole
+// anonymous section struct, to "line 3: field tokn not found".
+func configDecodeError(err error) string {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err.Error()
+	}
+
+	problems := make([]string, 0, len(typeErr.Errors))
+	for _, problem := range typeErr.Errors {
+		if i := strings.Index(problem, " in type "); i >= 0 {
+			problem = problem[:i]
+		}
+		problems = append(problems, problem)
+	}
+	return strings.Join(problems, "; ")
+}
+
+// Validate reports the first setting that would stop the bot from starting,
+// named by its path in the config file. LoadConfig runs it after the
+// environment overrides are applied.
+func (c *Config) Validate() error {
+	if c.Telegram.AdminID < 0 {
+		return fmt.Errorf("telegram.admin_id: must be your numeric Telegram user ID, got %d", c.Telegram.AdminID)
+	}
+
+	switch strings.ToLower(c.Logging.Level) {
+	case "", "debug", "info", "warn", "warning", "error":
+	default:
+		return fmt.Errorf("logging.level: unknown level %q, use debug, info, warn or error", c.Logging.Level)
+	}
+
+	for name, value := range map[string]*int{
+		"storage.archive_quota":                                       c.Storage.ArchiveQuota,
+		"storage.archive_user_quota":                                  c.Storage.ArchiveUserQuota,
+		"booking.days_ahead":                                          c.Booking.DaysAhead,
+		"limits.user_rate_limit":                                      c.Limits.UserRateLimit,
+		"integrations.payments.priority_review_price":                 c.Integrations.Payments.PriorityReviewPrice,
+		"integrations.payments.subscription.price":                    c.Integrations.Payments.Subscription.Price,
+		"integrations.payments.subscription.free_questions_per_month": c.Integrations.Payments.Subscription.FreeQuestions,
+		"logging.max_size":                                            c.Logging.MaxSize,
+		"logging.max_backups":                                         c.Logging.MaxBackups,
+		"logging.max_age":                                             c.Logging.MaxAge,
+	} {
+		if value != nil && *value < 0 {
+			return fmt.Errorf("%s: must not be negative", name)
+		}
+	}
+
+	for name, value := range map[string]*Duration{
+		"limits.urgent_cooldown":            c.Limits.UrgentCooldown,
+		"limits.followup_survey_delay":      c.Limits.FollowUpSurveyDelay,
+		"limits.session_ttl":                c.Limits.SessionTTL,
+		"limits.stalled_flow_nudge":         c.Limits.StalledFlowNudge,
+		"logging.rotate_interval":           c.Logging.RotateInterval,
+		"integrations.email.fallback_delay": c.Integrations.Email.FallbackDelay,
+	} {
+		if value != nil && *value < 0 {
+			return fmt.Errorf("%s: must not be negative", name)
+		}
+	}
+
+	if c.Limits.DisableSLA && len(c.Limits.SLAThresholds) > 0 {
+		return fmt.Errorf("limits: set either sla_thresholds or disable_sla, not both")
+	}
+
+	if report := c.Features.DailyReportTime; report != "" && report != "off" {
+		if _, err := time.Parse("15:04", report); err != nil {
+			return fmt.Errorf("features.daily_report_time: %q is not HH:MM (or \"off\")", report)
+		}
+	}
+
+	servers := []struct{ name, addr, token, tokenEnv string }{
+		{"features.api", c.Features.API.Addr, c.Features.API.Token, "API_TOKEN"},
+		{"features.grpc", c.Features.GRPC.Addr, c.Features.GRPC.Token, "GRPC_TOKEN"},
+		{"features.dashboard", c.Features.Dashboard.Addr, c.Features.Dashboard.Token, "DASHBOARD_TOKEN"},
+	}
+	for _, server := range servers {
+		if server.addr != "" && server.token == "" {
+			return fmt.Errorf("%s.token: required when %s.addr is set (or set %s)", server.name, server.name, server.tokenEnv)
+		}
+	}
+
+	if zone := c.OfficeHours.Timezone; zone != "" {
+		if _, err := time.LoadLocation(zone); err != nil {
+			return fmt.Errorf("office_hours.timezone: unknown time zone %q, use an IANA name like Asia/Tashkent", zone)
+		}
+	}
+
+	switch c.Integrations.Tracker.Type {
+	case "", "trello", "jira":
+	default:
+		return fmt.Errorf("integrations.tracker.type: unknown tracker %q, use trello or jira", c.Integrations.Tracker.Type)
+	}
+
+	if port := c.Integrations.Email.SMTPPort; port != nil && (*port <= 0 || *port > 65535) {
+		return fmt.Errorf("integrations.email.smtp_port: %d is not a valid port", *port)
+	}
+
+	for _, event := range c.Integrations.Webhook.Events {
+		switch WebhookEvent(event) {
+		case WebhookNewQuestion, WebhookAnswered, WebhookCVRequested, WebhookUserBanned:
+		default:
+			return fmt.Errorf("integrations.webhook.events: unknown event %q", event)
+		}
+	}
+
+	return nil
+}
+
+// applyEnv overrides every setting whose variable lookup returns a value
+// for. Malformed values are reported by variable name.
+func (c *Config) applyEnv(lookup func(string) string) error {
+	text := func(field *string) func(string) error {
+		return func(value string) error {
+			*field = value
+			return nil
+		}
+	}
+	number := func(field **int) func(string) error {
+		return func(value string) error {
+			parsed, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return errors.New("expected a whole number")
+			}
+			*field = &parsed
+			return nil
+		}
+	}
+	id := func(field *int64) func(string) error {
+		return func(value string) error {
+			parsed, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return errors.New("expected a numeric Telegram ID")
+			}
+			*field = parsed
+			return nil
+		}
+	}
+	flag := func(field **bool) func(string) error {
+		return func(value string) error {
+			parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return errors.New("expected true or false")
+			}
+			*field = &parsed
+			return nil
+		}
+	}
+	duration := func(field **Duration) func(string) error {
+		return func(value string) error {
+			parsed, err := parseDuration(strings.TrimSpace(value))
+			if err != nil {
+				return err
+			}
+			*field = &parsed
+			return nil
+		}
+	}
+	durations := func(field *[]Duration, off *bool) func(string) error {
+		return func(value string) error {
+			*field, *off = nil, strings.TrimSpace(value) == "off"
+			if *off {
+				return nil
+			}
+			for _, part := range strings.Split(value, ",") {
+				parsed, err := parseDuration(strings.TrimSpace(part))
+				if err != nil {
+					return err
+				}
+				*field = append(*field, parsed)
+			}
+			return nil
+		}
+	}
+	list := func(field *[]string) func(string) error {
+		return func(value string) error {
+			*field = splitList(value, ",")
+			return nil
+		}
+	}
+	rateLimit := func(value string) error {
+		if strings.TrimSpace(value) == "off" {
+			value = "0"
+		}
+		return number(&c.Limits.UserRateLimit)(value)
+	}
+	reviewers := func(value string) error {
+		c.Team.Reviewers = make(map[string]int64)
+		for _, entry := range splitList(value, ",") {
+			name, idArg, found := strings.Cut(entry, ":")
+			name = strings.TrimSpace(name)
+			reviewerID, err := strconv.ParseInt(strings.TrimSpace(idArg), 10, 64)
+			if !found || name == "" || err != nil {
+				return fmt.Errorf("%q is not name:user_id", entry)
+			}
+			if _, listed := c.Team.Reviewers[name]; listed {
+				return fmt.Errorf("%q is listed twice", entry)
+			}
+			c.Team.Reviewers[name] = reviewerID
+		}
+		return nil
+	}
+	menu := func(value string) error {
+		c.Texts.Menu = nil
+		for _, row := range splitList(value, ";") {
+			if items := splitList(row, ","); len(items) > 0 {
+				c.Texts.Menu = append(c.Texts.Menu, items)
+			}
+		}
+		return nil
+	}
+
+	integrations := &c.Integrations
+	overrides := []struct {
+		name string
+		set  func(string) error
+	}{
+		{"TELEGRAM_BOT_TOKEN", text(&c.Telegram.Token)},
+		{"ADMIN_ID", id(&c.Telegram.AdminID)},
+
+		{"DATA_FILE", text(&c.Storage.DataFile)},
+		{"AUDIT_LOG_FILE", text(&c.Storage.AuditLogFile)},
+		{"RETENTION_PERIOD", text(&c.Storage.RetentionPeriod)},
+		{"RETENTION_DRY_RUN", flag(&c.Storage.RetentionDryRun)},
+		{"ARCHIVE_DIR", text(&c.Storage.ArchiveDir)},
+		{"ARCHIVE_QUOTA", number(&c.Storage.ArchiveQuota)},
+		{"ARCHIVE_USER_QUOTA", number(&c.Storage.ArchiveUserQuota)},
+
+		{"LOG_LEVEL", text(&c.Logging.Level)},
+		{"LOG_FILE", text(&c.Logging.File)},
+		{"LOG_MAX_SIZE", number(&c.Logging.MaxSize)},
+		{"LOG_MAX_BACKUPS", number(&c.Logging.MaxBackups)},
+		{"LOG_MAX_AGE", number(&c.Logging.MaxAge)},
+		{"LOG_ROTATE_INTERVAL", duration(&c.Logging.RotateInterval)},
+
+		{"URGENT_COOLDOWN", duration(&c.Limits.UrgentCooldown)},
+		{"SLA_THRESHOLDS", durations(&c.Limits.SLAThresholds, &c.Limits.DisableSLA)},
+		{"FOLLOWUP_SURVEY_DELAY", duration(&c.Limits.FollowUpSurveyDelay)},
+		{"USER_RATE_LIMIT", rateLimit},
+		{"SESSION_TTL", duration(&c.Limits.SessionTTL)},
+		{"STALLED_FLOW_NUDGE", duration(&c.Limits.StalledFlowNudge)},
+
+		{"OFFICE_HOURS", text(&c.OfficeHours.Hours)},
+		{"OFFICE_DAYS", text(&c.OfficeHours.Days)},
+		{"OFFICE_TIMEZONE", text(&c.OfficeHours.Timezone)},
+
+		{"BOOKING_ENABLED", flag(&c.Booking.Enabled)},
+		{"BOOKING_SLOT_LENGTH", duration(&c.Booking.SlotLength)},
+		{"BOOKING_DAYS_AHEAD", number(&c.Booking.DaysAhead)},
+		{"BOOKING_REMINDERS", durations(&c.Booking.Reminders, &c.Booking.DisableReminders)},
+		{"BOOKING_TIMEZONE", text(&c.Booking.Timezone)},
+
+		{"BLOCKED_DOMAINS", list(&c.Moderation.BlockedDomains)},
+		{"CLAMAV_ADDR", text(&c.Moderation.ClamAVAddr)},
+
+		{"REVIEWERS", reviewers},
+		{"SENIOR_REVIEWER_CHAT_ID", id(&c.Team.SeniorReviewerChatID)},
+		{"ANONYMIZE_CVS", flag(&c.Team.AnonymizeCVs)},
+
+		{"MESSAGES_DIR", text(&c.Texts.MessagesDir)},
+		{"FAQ_FILE", text(&c.Texts.FAQFile)},
+		{"TERMS_FILE", text(&c.Texts.TermsFile)},
+		{"TERMS_VERSION", text(&c.Texts.TermsVersion)},
+		{"ATS_KEYWORDS_FILE", text(&c.Texts.ATSKeywordsFile)},
+		{"WELCOME_VARIANTS", list(&c.Texts.WelcomeVariants)},
+		{"MENU_LAYOUT", menu},
+
+		{"HEALTH_ADDR", text(&c.Features.HealthAddr)},
+		{"DAILY_REPORT_TIME", text(&c.Features.DailyReportTime)},
+		{"REFERRAL_THANKS", flag(&c.Features.ReferralThanks)},
+		{"VERIFY_NEW_USERS", flag(&c.Features.VerifyNewUsers)},
+		{"ACKNOWLEDGE_NOTIFY_USER", flag(&c.Features.AcknowledgeNotifyUser)},
+		{"ONBOARDING_TUTORIAL", flag(&c.Features.OnboardingTutorial)},
+		{"REENGAGEMENT_PERIOD", text(&c.Features.ReengagementPeriod)},
+		{"API_ADDR", text(&c.Features.API.Addr)},
+		{"API_TOKEN", text(&c.Features.API.Token)},
+		{"GRPC_ADDR", text(&c.Features.GRPC.Addr)},
+		{"GRPC_TOKEN", text(&c.Features.GRPC.Token)},
+		{"DASHBOARD_ADDR", text(&c.Features.Dashboard.Addr)},
+		{"DASHBOARD_TOKEN", text(&c.Features.Dashboard.Token)},
+
+		{"SENTRY_DSN", text(&c.Observability.SentryDSN)},
+		{"SENTRY_ENVIRONMENT", text(&c.Observability.SentryEnvironment)},
+		{"OTEL_EXPORTER_OTLP_ENDPOINT", text(&c.Observability.OTLPEndpoint)},
+		{"OTEL_SERVICE_NAME", text(&c.Observability.ServiceName)},
+
+		{"GOOGLE_SHEETS_ID", text(&integrations.GoogleSheets.ID)},
+		{"GOOGLE_SHEETS_CREDENTIALS", text(&integrations.GoogleSheets.Credentials)},
+		{"GOOGLE_SHEETS_TAB", text(&integrations.GoogleSheets.Tab)},
+
+		{"NOTION_TOKEN", text(&integrations.Notion.Token)},
+		{"NOTION_DB_ID", text(&integrations.Notion.DBID)},
+		{"NOTION_REVIEWER", text(&integrations.Notion.Reviewer)},
+
+		{"TRACKER", text(&integrations.Tracker.Type)},
+		{"TRELLO_KEY", text(&integrations.Tracker.Trello.Key)},
+		{"TRELLO_TOKEN", text(&integrations.Tracker.Trello.Token)},
+		{"TRELLO_LIST_ID", text(&integrations.Tracker.Trello.ListID)},
+		{"TRELLO_DONE_LIST_ID", text(&integrations.Tracker.Trello.DoneListID)},
+		{"JIRA_URL", text(&integrations.Tracker.Jira.URL)},
+		{"JIRA_EMAIL", text(&integrations.Tracker.Jira.Email)},
+		{"JIRA_API_TOKEN", text(&integrations.Tracker.Jira.APIToken)},
+		{"JIRA_PROJECT", text(&integrations.Tracker.Jira.Project)},
+		{"JIRA_ISSUE_TYPE", text(&integrations.Tracker.Jira.IssueType)},
+
+		{"SLACK_WEBHOOK_URL", text(&integrations.Slack.WebhookURL)},
+		{"SLACK_BOT_TOKEN", text(&integrations.Slack.BotToken)},
+		{"SLACK_CHANNEL_ID", text(&integrations.Slack.ChannelID)},
+		{"SLACK_SIGNING_SECRET", text(&integrations.Slack.SigningSecret)},
+		{"SLACK_EVENTS_ADDR", text(&integrations.Slack.EventsAddr)},
+
+		{"ADMIN_EMAIL", text(&integrations.Email.AdminEmail)},
+		{"EMAIL_FALLBACK_DELAY", duration(&integrations.Email.FallbackDelay)},
+		{"SMTP_HOST", text(&integrations.Email.SMTPHost)},
+		{"SMTP_PORT", number(&integrations.Email.SMTPPort)},
+		{"SMTP_USERNAME", text(&integrations.Email.SMTPUsername)},
+		{"SMTP_PASSWORD", text(&integrations.Email.SMTPPassword)},
+		{"SMTP_FROM", text(&integrations.Email.SMTPFrom)},
+
+		{"WEBHOOK_URL", text(&integrations.Webhook.URL)},
+		{"WEBHOOK_SECRET", text(&integrations.Webhook.Secret)},
+		{"WEBHOOK_EVENTS", list(&integrations.Webhook.Events)},
+
+		{"S3_BUCKET", text(&integrations.S3.Bucket)},
+		{"S3_ENDPOINT", text(&integrations.S3.Endpoint)},
+		{"S3_REGION", text(&integrations.S3.Region)},
+		{"S3_ACCESS_KEY_ID", text(&integrations.S3.AccessKeyID)},
+		{"S3_SECRET_ACCESS_KEY", text(&integrations.S3.SecretAccessKey)},
+		{"S3_PREFIX", text(&integrations.S3.Prefix)},
+
+		{"PAYMENT_PROVIDER_TOKEN", text(&integrations.Payments.ProviderToken)},
+		{"PRIORITY_REVIEW_PRICE", number(&integrations.Payments.PriorityReviewPrice)},
+		{"PAYMENT_CURRENCY", text(&integrations.Payments.Currency)},
+		{"STRIPE_SECRET_KEY", text(&integrations.Payments.Stripe.SecretKey)},
+		{"STRIPE_WEBHOOK_SECRET", text(&integrations.Payments.Stripe.WebhookSecret)},
+		{"STRIPE_WEBHOOK_ADDR", text(&integrations.Payments.Stripe.WebhookAddr)},
+
+		{"SUBSCRIPTION_PRICE", number(&integrations.Payments.Subscription.Price)},
+		{"FREE_QUESTIONS_PER_MONTH", number(&integrations.Payments.Subscription.FreeQuestions)},
+		{"SUBSCRIBER_ONLY_FLOWS", list(&integrations.Payments.Subscription.SubscriberOnlyFlows)},
+	}
+
+	for _, override := range overrides {
+		value := lookup(override.name)
+		if value == "" {
+			continue
+		}
+		if err := override.set(value); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", override.name, value, err)
+		}
+	}
+
+	return nil
+}
+
+// splitList splits a list setting such as "a, b,c" and drops empty items.
+func splitList(value, separator string) []string {
+	var items []string
+	for _, item := range strings.Split(value, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// enabled reports whether an optional switch is set and true.
+func enabled(flag *bool) bool {
+	return flag != nil && *flag
+}
+
+// durationOr returns the configured duration, or fallback when unset.
+func durationOr(value *Duration, fallback time.Duration) time.Duration {
+	if value == nil {
+		return fallback
+	}
+	return time.Duration(*value)
+}
+
+// intOr returns the configured number, or fallback when unset.
+func intOr(value *int, fallback int) int {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
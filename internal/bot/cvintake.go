@@ -0,0 +1,135 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// CVIntake holds the context collected from the user before a CV is
+// submitted, so the admin can give role-specific feedback.
+type CVIntake struct {
+	TargetRole string
+	Experience string
+	Industries string
+	Deadline   string
+	// CVFileID and CVFileName are the CV the user uploaded, if they sent a
+	// file rather than a link
+	CVFileID   string
+	CVFileName string
+	// Payment is set once a priority review was ordered
+	Payment        PaymentStatus
+	invoicePayload string
+	price          int
+	step           int
+	// creatingCheckout is set while Stripe creates a payment page, so a
+	// second tap does not create another
+	creatingCheckout bool
+}
+
+type cvIntakeStep struct {
+	// prompt is the message ID of the question asked for this step
+	prompt string
+	set    func(intake *CVIntake, answer string)
+}
+
+var cvIntakeSteps = []cvIntakeStep{
+	{
+		prompt: "cv_intake_role",
+		set:    func(intake *CVIntake, answer string) { intake.TargetRole = answer },
+	},
+	{
+		prompt: "cv_intake_experience",
+		set:    func(intake *CVIntake, answer string) { intake.Experience = answer },
+	},
+	{
+		prompt: "cv_intake_industries",
+		set:    func(intake *CVIntake, answer string) { intake.Industries = answer },
+	},
+	{
+		prompt: "cv_intake_deadline",
+		set:    func(intake *CVIntake, answer string) { intake.Deadline = answer },
+	},
+}
+
+func (intake *CVIntake) Summary() string {
+	field := func(value string) string {
+		if value == "" {
+			return "-"
+		}
+		return value
+	}
+
+	var summary strings.Builder
+	summary.WriteString("📋 CV intake:\n")
+	summary.WriteString(fmt.Sprintf("🎯 Target role: %s\n", field(intake.TargetRole)))
+	summary.WriteString(fmt.Sprintf("📈 Experience: %s\n", field(intake.Experience)))
+	summary.WriteString(fmt.Sprintf("🏢 Industries: %s\n", field(intake.Industries)))
+	summary.WriteString(fmt.Sprintf("📅 Deadline: %s", field(intake.Deadline)))
+
+	return summary.String()
+}
+
+func (b *Bot) askCVIntakeStep(userID int64) {
+	intake := b.cvForms[userID]
+	step := cvIntakeSteps[intake.step]
+
+	promptText := b.trMarkdown(userID, "cv_intake_step", map[string]interface{}{
+		"Step":   intake.step + 1,
+		"Total":  len(cvIntakeSteps),
+		"Prompt": markdown(b.trMarkdown(userID, step.prompt)),
+	})
+	if intake.step == 0 {
+		promptText = b.trMarkdown(userID, "cv_intake_intro") + "\n\n" + promptText
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_skip"), "cv_intake_skip"),
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_cancel"), "cancel"),
+		),
+	)
+
+	msg := telegram.NewMarkdownMessage(userID, promptText)
+	msg.ReplyMarkup = keyboard
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send CV intake question")
+		return
+	}
+
+	b.userStates[userID] = StateCVIntake
+}
+
+func (b *Bot) handleCVIntakeState(message *tgbotapi.Message, userID int64) {
+	answer := strings.TrimSpace(message.Text)
+	if answer == "" {
+		b.askCVIntakeStep(userID)
+		return
+	}
+
+	b.recordCVIntakeAnswer(userID, answer)
+}
+
+// recordCVIntakeAnswer stores the answer for the current step (empty when
+// skipped) and moves on to the next step or the CV upload instructions.
+func (b *Bot) recordCVIntakeAnswer(userID int64, answer string) {
+	intake, exists := b.cvForms[userID]
+	if !exists {
+		b.startCVReviewFlow(userID)
+		return
+	}
+
+	cvIntakeSteps[intake.step].set(intake, answer)
+	intake.step++
+
+	if intake.step < len(cvIntakeSteps) {
+		b.askCVIntakeStep(userID)
+		return
+	}
+
+	b.offerPriorityReview(userID)
+}
@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// extractCVText returns the text of a CV file for keyword analysis. Plain
+// text, Word (.docx) and PDF files are supported. PDFs are read on a best
+// effort basis: scanned CVs and fonts with custom encodings give no text.
+func extractCVText(fileName string, data []byte) (string, error) {
+	var text string
+	switch ext := strings.ToLower(filepath.Ext(fileName)); ext {
+	case ".txt", ".md":
+		if !utf8.Valid(data) {
+			return "", errors.New("the file is not UTF-8 text")
+		}
+		text = string(data)
+	case ".docx":
+		var err error
+		if text, err = docxText(data); err != nil {
+			return "", err
+		}
+	case ".pdf":
+		text = pdfText(data)
+	default:
+		return "", fmt.Errorf("%q files are not supported, only .pdf, .docx and .txt", ext)
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("no text found in the file, it may be a scanned image")
+	}
+
+	return text, nil
+}
+
+// docxText returns the text of the paragraphs of a Word document.
+func docxText(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("not a Word document: %w", err)
+	}
+	document, err := archive.Open("word/document.xml")
+	if err != nil {
+		return "", fmt.Errorf("not a Word document: %w", err)
+	}
+	defer document.Close()
+
+	var text strings.Builder
+	inText := false
+	decoder := xml.NewDecoder(document)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("broken Word document: %w", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "t":
+				inText = true
+			case "tab", "br":
+				text.WriteString(" ")
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(element)
+			}
+		}
+	}
+
+	return text.String(), nil
+}
+
+var (
+	pdfStream = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+	// pdfShowText matches the operators that show text: a string or an
+	// array of strings and kerning followed by Tj, TJ, ' or "
+	pdfShowText = regexp.MustCompile(`(?s)(\((?:\\.|[^\\)])*\)|\[(?:\\.|[^\\\]])*\])\s*(?:Tj|TJ|'|")`)
+	pdfString   = regexp.MustCompile(`(?s)\((?:\\.|[^\\)])*\)`)
+)
+
+// pdfText collects the strings shown by the content streams of a PDF.
+func pdfText(data []byte) string {
+	var text strings.Builder
+	for _, stream := range pdfStream.FindAllSubmatch(data, -1) {
+		content := stream[1]
+		if reader, err := zlib.NewReader(bytes.NewReader(content)); err == nil {
+			// Streams often end with padding, keep what was inflated
+			if inflated, _ := io.ReadAll(reader); len(inflated) > 0 {
+				content = inflated
+			}
+		}
+
+		for _, operation := range pdfShowText.FindAllSubmatch(content, -1) {
+			for _, literal := range pdfString.FindAll(operation[1], -1) {
+				text.WriteString(unescapePDFString(literal[1 : len(literal)-1]))
+			}
+			text.WriteString(" ")
+		}
+	}
+
+	return text.String()
+}
+
+func unescapePDFString(literal []byte) string {
+	var text strings.Builder
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '\\' || i == len(literal)-1 {
+			text.WriteByte(literal[i])
+			continue
+		}
+
+		i++
+		switch next := literal[i]; next {
+		case 'n':
+			text.WriteByte('\n')
+		case 'r':
+			text.WriteByte('\r')
+		case 't':
+			text.WriteByte('\t')
+		case 'b', 'f':
+			text.WriteByte(' ')
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			end := i + 1
+			for end < len(literal) && end < i+3 && literal[end] >= '0' && literal[end] <= '7' {
+				end++
+			}
+			code, _ := strconv.ParseUint(string(literal[i:end]), 8, 8)
+			text.WriteByte(byte(code))
+			i = end - 1
+		default:
+			text.WriteByte(next)
+		}
+	}
+
+	return text.String()
+}
@@ -0,0 +1,220 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dashboardCookie      = "faqbot_dashboard"
+	dashboardHistorySize = 50
+)
+
+//go:embed web/templates/*.html
+var dashboardFS embed.FS
+
+var dashboardFuncs = template.FuncMap{
+	"category": categoryLabel,
+	"duration": formatDuration,
+	"waiting":  func(since time.Time) string { return formatDuration(time.Since(since)) },
+	"kind": func(session *UserSession) string {
+		if session.State == StateCVReview {
+			return "CV review"
+		}
+		return categoryLabel(session.Category)
+	},
+}
+
+// dashboardPage is the data passed to every dashboard template.
+type dashboardPage struct {
+	Title string
+	Flash string
+	Error string
+
+	Tickets    interface{}
+	Query      string
+	Period     string
+	Open       int
+	Summary    StatsSummary
+	Categories []string
+}
+
+type dashboard struct {
+	bot     *Bot
+	token   string
+	session string
+	pages   map[string]*template.Template
+}
+
+// startDashboard serves the web admin dashboard on DASHBOARD_ADDR. Admins
+// sign in with DASHBOARD_TOKEN.
+func (b *Bot) startDashboard() {
+	addr := b.config.Features.Dashboard.Addr
+	if addr == "" {
+		return
+	}
+
+	token := b.config.Features.Dashboard.Token
+	if token == "" {
+		b.logger.Fatal("DASHBOARD_TOKEN is required when DASHBOARD_ADDR is set")
+	}
+
+	// The cookie carries a value derived from the token, never the token itself
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write([]byte(dashboardCookie))
+
+	d := &dashboard{
+		bot:     b,
+		token:   token,
+		session: hex.EncodeToString(mac.Sum(nil)),
+		pages:   make(map[string]*template.Template),
+	}
+	for _, page := range []string{"login", "tickets", "history", "stats"} {
+		d.pages[page] = template.Must(template.New(page).Funcs(dashboardFuncs).ParseFS(dashboardFS,
+			"web/templates/layout.html", "web/templates/"+page+".html"))
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /login", d.showLogin)
+	mux.HandleFunc("POST /login", d.login)
+	mux.Handle("GET /{$}", d.requireSession(d.showTickets))
+	mux.Handle("POST /tickets/{id}/answer", d.requireSession(d.answerTicket))
+	mux.Handle("GET /history", d.requireSession(d.showHistory))
+	mux.Handle("GET /stats", d.requireSession(d.showStats))
+
+	go func() {
+		err := http.ListenAndServe(addr, mux)
+		if err != nil {
+			b.logger.WithError(err).WithField("addr", addr).Error("Dashboard server stopped")
+		}
+	}()
+}
+
+func (d *dashboard) requireSession(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(dashboardCookie)
+		if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(d.session)) != 1 {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (d *dashboard) render(w http.ResponseWriter, page string, data dashboardPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err := d.pages[page].ExecuteTemplate(w, page+".html", data)
+	if err != nil {
+		d.bot.logger.WithError(err).WithField("page", page).Error("Failed to render dashboard page")
+	}
+}
+
+func (d *dashboard) showLogin(w http.ResponseWriter, r *http.Request) {
+	d.render(w, "login", dashboardPage{Title: "Sign in"})
+}
+
+func (d *dashboard) login(w http.ResponseWriter, r *http.Request) {
+	if subtle.ConstantTimeCompare([]byte(r.FormValue("token")), []byte(d.token)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		d.render(w, "login", dashboardPage{Title: "Sign in", Error: "Invalid token"})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     dashboardCookie,
+		Value:    d.session,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		Secure:   r.TLS != nil,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (d *dashboard) showTickets(w http.ResponseWriter, r *http.Request) {
+	page := dashboardPage{Title: "Open tickets"}
+	if sent := r.URL.Query().Get("sent"); sent != "" {
+		page.Flash = fmt.Sprintf("✅ Answer to ticket #%s sent", sent)
+	}
+	if failed := r.URL.Query().Get("failed"); failed != "" {
+		page.Error = fmt.Sprintf("❌ Could not answer ticket #%s, it may already be closed", failed)
+	}
+
+	d.bot.mu.Lock()
+	defer d.bot.mu.Unlock()
+
+	page.Tickets = d.bot.openTickets()
+	d.render(w, "tickets", page)
+}
+
+func (d *dashboard) answerTicket(w http.ResponseWriter, r *http.Request) {
+	ticketID, err := strconv.Atoi(r.PathValue("id"))
+	answer := strings.TrimSpace(r.FormValue("answer"))
+	if err != nil || answer == "" {
+		http.Redirect(w, r, "/?failed="+r.PathValue("id"), http.StatusSeeOther)
+		return
+	}
+
+	delivered := false
+	d.bot.mu.Lock()
+	if session, open := d.bot.tickets[ticketID]; open {
+		d.bot.deliverAnswer(session, answer)
+		_, stillOpen := d.bot.tickets[ticketID]
+		delivered = !stillOpen
+	}
+	d.bot.mu.Unlock()
+
+	if !delivered {
+		http.Redirect(w, r, fmt.Sprintf("/?failed=%d", ticketID), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/?sent=%d", ticketID), http.StatusSeeOther)
+}
+
+func (d *dashboard) showHistory(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	tickets, err := d.bot.searchIndex.search(d.bot.store, query, time.Time{}, dashboardHistorySize)
+	if err != nil {
+		d.bot.logger.WithError(err).Error("Failed to search dashboard history")
+		http.Error(w, "search failed", http.StatusInternalServerError)
+		return
+	}
+
+	d.render(w, "history", dashboardPage{Title: "History", Tickets: tickets, Query: query})
+}
+
+func (d *dashboard) showStats(w http.ResponseWriter, r *http.Request) {
+	period := r.URL.Query().Get("period")
+	window, exists := statsPeriods[period]
+	if !exists {
+		period, window = "all", 0
+	}
+
+	now := time.Now()
+	from := time.Time{}
+	if window > 0 {
+		from = now.Add(-window)
+	}
+	summary := computeStats(d.bot.store.Tickets(), d.bot.store.Users(), from, now)
+
+	d.bot.mu.Lock()
+	open := len(d.bot.tickets)
+	d.bot.mu.Unlock()
+
+	d.render(w, "stats", dashboardPage{
+		Title:      "Statistics",
+		Period:     period,
+		Open:       open,
+		Summary:    summary,
+		Categories: summary.TopCategories(5),
+	})
+}
@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/commands"
+)
+
+// handleStartCommand shows the welcome menu, or follows the payload of a
+// https://t.me/<bot>?start=<payload> deep link. A payload naming a flow
+// starts it right away; campaigns can tag their links with a suffix, e.g.
+// "cv_review-spring_fair", which ends up in the audit log. Referral links
+// ("ref_<user_id>") credit the referrer and show the welcome menu. New
+// users get the first-run tutorial instead, if enabled.
+func (b *Bot) handleStartCommand(req commands.Request) {
+	userID := req.Message.From.ID
+	if req.Args == "" {
+		if b.startTutorial(userID) {
+			return
+		}
+		b.showWelcomeMenu(userID)
+		return
+	}
+
+	if strings.HasPrefix(req.Args, referralPayloadPrefix) {
+		b.recordReferral(userID, req.Args)
+		if b.startTutorial(userID) {
+			return
+		}
+		b.showWelcomeMenu(userID)
+		return
+	}
+
+	name, campaign, _ := strings.Cut(req.Args, "-")
+	flow, exists := b.flows.Flow(name)
+
+	b.audit.Record(AuditDeepLink, userID, logrus.Fields{
+		"payload":  req.Args,
+		"flow":     name,
+		"campaign": campaign,
+		"known":    exists,
+	})
+
+	if !exists {
+		b.showWelcomeMenu(userID)
+		return
+	}
+
+	flow.Start(userID)
+}
@@ -0,0 +1,186 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	defaultDigestInterval = time.Hour
+	digestCheckInterval   = time.Minute
+	digestMaxButtons      = 20
+)
+
+func (b *Bot) digestEnabled() bool {
+	return b.store.DigestSettings().Enabled
+}
+
+func (b *Bot) digestInterval() time.Duration {
+	interval, err := time.ParseDuration(b.store.DigestSettings().Interval)
+	if err != nil || interval <= 0 {
+		return defaultDigestInterval
+	}
+
+	return interval
+}
+
+func (b *Bot) runDigest() {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+
+	lastDigest := time.Now()
+	for now := range ticker.C {
+		if !b.digestEnabled() || now.Sub(lastDigest) < b.digestInterval() {
+			continue
+		}
+		lastDigest = now
+
+		b.mu.Lock()
+		b.sendDigest()
+		b.mu.Unlock()
+	}
+}
+
+// sendDigest lists the tickets that have not been notified individually
+// yet, digestMaxButtons per message. Each ticket gets a button that opens
+// its full, reply-able notification, and is only marked as digested once
+// the message with its button was sent.
+func (b *Bot) sendDigest() {
+	var fresh []*UserSession
+	open := b.openTickets()
+	for _, session := range open {
+		if session.AdminMsgID == 0 && !session.Digested {
+			fresh = append(fresh, session)
+		}
+	}
+
+	if len(fresh) == 0 {
+		return
+	}
+
+	pages := (len(fresh) + digestMaxButtons - 1) / digestMaxButtons
+	page := 0
+	for batch := range slices.Chunk(fresh, digestMaxButtons) {
+		page++
+
+		var digestText strings.Builder
+		digestText.WriteString(fmt.Sprintf("📬 Digest: %d new ticket(s), %d open in total", len(fresh), len(open)))
+		if pages > 1 {
+			digestText.WriteString(fmt.Sprintf(" (part %d/%d)", page, pages))
+		}
+		digestText.WriteString("\n\n")
+
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, session := range batch {
+			user := fmt.Sprintf("user ID %d", session.UserID)
+			if session.Username != "" {
+				user = "@" + session.Username
+			}
+			marker := ""
+			if session.AfterHours {
+				marker = "🌙 "
+			}
+			if b.userNotesLines(session.UserID) != "" {
+				marker += "📝 "
+			}
+			digestText.WriteString(fmt.Sprintf("%s#%d %s: %s\n\n", marker, session.TicketID, user, truncateText(session.LastQuestion, 150)))
+
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("💬 Open #%d", session.TicketID), fmt.Sprintf("ticket:%d", session.TicketID)),
+			))
+		}
+		digestText.WriteString("Press a button to open a ticket and reply to it.")
+
+		_, err := b.api.SendLong(b.adminID, digestText.String(), tgbotapi.NewInlineKeyboardMarkup(rows...))
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send digest")
+			return
+		}
+
+		for _, session := range batch {
+			session.Digested = true
+		}
+	}
+}
+
+// handleOpenTicketCallback processes "ticket:<id>" callbacks from the digest
+// by sending the full notification for that ticket.
+func (b *Bot) handleOpenTicketCallback(callback *tgbotapi.CallbackQuery) {
+	ticketID, err := strconv.Atoi(strings.TrimPrefix(callback.Data, "ticket:"))
+	if err != nil {
+		b.logger.WithError(err).WithField("callback_data", callback.Data).Error("Malformed ticket callback")
+		return
+	}
+
+	session, exists := b.tickets[ticketID]
+	if !exists {
+		msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("Ticket #%d is already closed", ticketID))
+		_, err = b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send closed ticket message")
+		}
+		return
+	}
+
+	err = b.sendAdminNotification(session)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to send ticket notification")
+	}
+}
+
+func (b *Bot) handleDigestCommand(args string) {
+	settings := b.store.DigestSettings()
+
+	var reply string
+	switch args {
+	case "":
+		status := "off"
+		if settings.Enabled {
+			status = "on"
+		}
+		reply = fmt.Sprintf(`📬 Digest is %s (interval: %s)
+
+Usage:
+/digest on - Batch new tickets into a periodic digest
+/digest off - Notify about every ticket immediately
+/digest <interval> - Set the digest interval, e.g. 30m, 2h, 24h`, status, formatDuration(b.digestInterval()))
+	case "on", "off":
+		settings.Enabled = args == "on"
+		reply = fmt.Sprintf("📬 Digest turned %s", args)
+		if settings.Enabled {
+			reply += fmt.Sprintf(". New tickets will arrive every %s; urgent tickets are still notified immediately.",
+				formatDuration(b.digestInterval()))
+		}
+	default:
+		interval, err := time.ParseDuration(args)
+		if err != nil || interval < time.Minute {
+			reply = "❌ Invalid interval. Use a duration of at least 1m, e.g. 30m, 2h, 24h"
+			break
+		}
+		settings.Interval = args
+		reply = fmt.Sprintf("📬 Digest interval set to %s", formatDuration(interval))
+	}
+
+	if settings != b.store.DigestSettings() {
+		if err := b.store.SetDigestSettings(settings); err != nil {
+			b.logger.WithError(err).Error("Failed to save digest settings")
+			reply = fmt.Sprintf("❌ Failed to save digest settings: %v", err)
+		}
+	}
+
+	msg := tgbotapi.NewMessage(b.adminID, reply)
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send digest command reply")
+	}
+
+	// Flush tickets that were waiting for the next digest
+	if args == "off" {
+		b.sendDigest()
+	}
+}
@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// rememberAnswerMessage records which of the admin's messages answered a
+// ticket, so a later edit of it reaches the user.
+func (b *Bot) rememberAnswerMessage(ticketID, messageID int) {
+	err := b.store.SetAnswerMessage(ticketID, messageID)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to persist answer message")
+	}
+}
+
+// handleEditedAnswer passes an edit of an admin reply on to the user as an
+// updated answer. It is sent as a new message rather than an edit of the
+// delivered one so the user notices the correction. Edits of other messages
+// are ignored.
+func (b *Bot) handleEditedAnswer(message *tgbotapi.Message) {
+	ticket, exists := b.store.TicketByAnswerMessage(message.MessageID)
+	if !exists {
+		return
+	}
+
+	answer, ok := b.replyAnswer(message.Text, sessionFromTicket(ticket))
+	if !ok || answer == ticket.Answer {
+		return
+	}
+
+	text := b.trMarkdown(ticket.UserID, "answer_updated", map[string]interface{}{"Answer": formatAnswer(answer)})
+	queuedID, err := b.deliverReliably(ticket.ChatID(), ticket.GroupMessageID, ticket.ID, text, nil)
+	if err != nil {
+		b.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":   ticket.UserID,
+			"ticket_id": ticket.ID,
+		}).Error("Failed to send updated answer to user")
+		errorMsg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("❌ Updated answer to ticket #%d was not delivered: %s",
+			ticket.ID, telegram.DeliveryFailure(err)))
+		if _, err := b.api.Send(errorMsg); err != nil {
+			b.logger.WithError(err).Error("Failed to send delivery failure to admin")
+		}
+		return
+	}
+
+	err = b.store.UpdateAnswer(ticket.ID, answer)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", ticket.ID).Error("Failed to persist updated answer")
+	}
+
+	b.audit.Record(AuditAnswerEdited, b.adminID, logrus.Fields{
+		"user_id":   ticket.UserID,
+		"ticket_id": ticket.ID,
+		"text":      answer,
+	})
+
+	confirmation := fmt.Sprintf("✏️ Updated answer to ticket #%d sent", ticket.ID)
+	if queuedID != 0 {
+		confirmation = fmt.Sprintf("📤 Updated answer to ticket #%d is queued: Telegram did not accept it yet, the bot keeps retrying", ticket.ID)
+	}
+	sent, err := b.api.Send(tgbotapi.NewMessage(b.adminID, confirmation))
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send updated answer confirmation to admin")
+	} else if queuedID != 0 {
+		b.trackQueuedDelivery(queuedID, sent)
+	}
+}
+
+// handleEditedQuestion picks up a user's edit of their question while it is
+// still open: a draft awaiting confirmation is shown again, an open ticket
+// is updated and the admin gets the new version as a reply to the original
+// notification. Edits of other messages are ignored.
+func (b *Bot) handleEditedQuestion(message *tgbotapi.Message) {
+	userID := message.From.ID
+
+	// An edit that adds a blocked link or an infected file is dropped, the
+	// question stays as it was
+	if b.rejectBlockedLink(message) || b.rejectInfectedFile(tgbotapi.Update{EditedMessage: message}) {
+		return
+	}
+
+	if draft, exists := b.drafts[userID]; exists && draft.MessageID == message.MessageID && b.userStates[userID] == StateConfirmQuestion {
+		draft.LastQuestion = questionText(message)
+		b.showQuestionConfirmation(userID)
+		return
+	}
+
+	session, exists := b.userSessions[userID]
+	if !exists || session.Group != nil || session.MessageID != message.MessageID || session.State != StateQuestion {
+		return
+	}
+
+	question := questionText(message)
+	if question == session.LastQuestion {
+		return
+	}
+	session.LastQuestion = question
+
+	err := b.store.UpdateQuestion(session.TicketID, question)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", session.TicketID).Error("Failed to persist edited question")
+	}
+	b.audit.Record(AuditTicketEdited, userID, logrus.Fields{
+		"ticket_id": session.TicketID,
+		"text":      question,
+	})
+
+	// Tickets still waiting for the digest are listed with the new text
+	if session.AdminMsgID == 0 {
+		return
+	}
+
+	text := fmt.Sprintf("✏️ The user edited ticket #%d:\n\n%s\n\n💡 Simply reply to this message to answer the user", session.TicketID, question)
+	msg := tgbotapi.NewMessage(b.adminID, text)
+	msg.ReplyToMessageID = session.AdminMsgID
+	sent, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", session.TicketID).Error("Failed to send edited question to admin")
+		return
+	}
+
+	b.adminMessages[sent.MessageID] = session
+	err = b.store.MapAdminMessages(session.TicketID, []int{sent.MessageID})
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", session.TicketID).Error("Failed to persist admin notification messages")
+	}
+}
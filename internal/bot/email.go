@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	integrationEmail         = "email"
+	defaultSMTPPort          = 587
+	emailFallbackCheckPeriod = time.Minute
+)
+
+// EmailNotifier emails the admin about tickets they have not looked at in
+// Telegram.
+type EmailNotifier struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       string
+	delay    time.Duration
+}
+
+// emailNotifierFromConfig returns nil when ADMIN_EMAIL is unset.
+func emailNotifierFromConfig(config *Config) (*EmailNotifier, error) {
+	settings := &config.Integrations.Email
+	to := settings.AdminEmail
+	if to == "" {
+		return nil, nil
+	}
+
+	host := settings.SMTPHost
+	if host == "" {
+		return nil, fmt.Errorf("SMTP_HOST is required when ADMIN_EMAIL is set")
+	}
+
+	port := strconv.Itoa(intOr(settings.SMTPPort, defaultSMTPPort))
+	delay := durationOr(settings.FallbackDelay, 30*time.Minute)
+
+	from := settings.SMTPFrom
+	if from == "" {
+		from = settings.SMTPUsername
+	}
+	if from == "" {
+		return nil, fmt.Errorf("SMTP_FROM or SMTP_USERNAME is required when ADMIN_EMAIL is set")
+	}
+
+	return &EmailNotifier{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: settings.SMTPUsername,
+		password: settings.SMTPPassword,
+		from:     from,
+		to:       to,
+		delay:    delay,
+	}, nil
+}
+
+func (e *EmailNotifier) Send(subject, body string) error {
+	var auth smtp.Auth
+	if e.username != "" {
+		auth = smtp.PlainAuth("", e.username, e.password, e.host)
+	}
+
+	message := strings.Join([]string{
+		"From: " + e.from,
+		"To: " + e.to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		strings.ReplaceAll(body, "\n", "\r\n"),
+	}, "\r\n")
+
+	sender := e.from
+	if address, err := mail.ParseAddress(e.from); err == nil {
+		sender = address.Address
+	}
+
+	return smtp.SendMail(e.addr, auth, sender, []string{e.to}, []byte(message))
+}
+
+func (b *Bot) runEmailFallback() {
+	ticker := time.NewTicker(emailFallbackCheckPeriod)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		b.mu.Lock()
+		b.checkEmailFallback()
+		b.mu.Unlock()
+	}
+}
+
+// checkEmailFallback emails tickets that have waited longer than the fallback
+// delay without the admin touching the bot since they arrived. Telegram does
+// not report read receipts to bots, so admin activity stands in for "read".
+func (b *Bot) checkEmailFallback() {
+	for _, session := range b.openTickets() {
+		if session.Emailed || time.Since(session.CreatedAt) < b.email.delay {
+			continue
+		}
+		if b.adminSeen.After(session.CreatedAt) {
+			continue
+		}
+		session.Emailed = true
+
+		user := fmt.Sprintf("user ID %d", session.UserID)
+		if session.Username != "" {
+			user = "@" + session.Username
+		}
+
+		subject := fmt.Sprintf("[FAQ bot] Ticket #%d from %s is waiting", session.TicketID, user)
+		body := fmt.Sprintf("Ticket #%d from %s has been waiting %s without a reply.\n\n%s\n\nOpen the chat: https://t.me/%s",
+			session.TicketID, user, formatDuration(time.Since(session.CreatedAt)),
+			session.LastQuestion, b.api.Self().UserName)
+
+		b.runIntegration(integrationEmail, fmt.Sprintf("email ticket #%d to admin", session.TicketID), func() error {
+			return b.email.Send(subject, body)
+		})
+	}
+}
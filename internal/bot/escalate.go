@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// escalationHistorySize is how many earlier answered tickets of the user
+// go with an escalation.
+const escalationHistorySize = 3
+
+// escalateTicket handles /escalate <ticket_id> [reason]: the ticket and
+// everything known about its author go to the senior reviewer chat, and
+// the ticket is marked escalated. The admin still answers the user.
+func (b *Bot) escalateTicket(args string) {
+	idArg, reason, _ := strings.Cut(strings.TrimSpace(args), " ")
+	reason = strings.TrimSpace(reason)
+
+	var reply string
+	ticketID, err := parseTicketID(idArg)
+	switch {
+	case b.seniorChatID == 0:
+		reply = "❌ Escalation is not set up, set SENIOR_REVIEWER_CHAT_ID to the senior reviewer's chat"
+	case err != nil:
+		reply = fmt.Sprintf("❌ %v\n\nUsage: /escalate <ticket_id> [reason]", err)
+	default:
+		reply = b.escalate(ticketID, reason)
+	}
+
+	_, err = b.api.SendLong(b.adminID, reply, nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send escalation reply")
+	}
+}
+
+func (b *Bot) escalate(ticketID int, reason string) string {
+	session := b.findOpenTicket(fmt.Sprintf("#%d", ticketID))
+	if session == nil {
+		return fmt.Sprintf("❌ No open ticket #%d", ticketID)
+	}
+	if !session.EscalatedAt.IsZero() {
+		return fmt.Sprintf("Ticket #%d was already escalated on %s", ticketID, session.EscalatedAt.Format("2006-01-02 15:04"))
+	}
+
+	_, err := b.api.SendLong(b.seniorChatID, b.escalationText(session, reason), nil)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to escalate ticket")
+		return fmt.Sprintf("❌ Could not reach the senior reviewer chat: %v", err)
+	}
+	// The attached CV itself, when the ticket came with one, or its
+	// anonymized text
+	var note string
+	if b.anonymizesAuthor(session) {
+		note = b.shareAnonymizedCV(b.seniorChatID, session)
+	} else if session.HasFile && session.MessageID != 0 {
+		forward := tgbotapi.NewForward(b.seniorChatID, session.UserID, session.MessageID)
+		if _, err := b.api.Send(forward); err != nil {
+			b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to forward escalated file")
+		}
+	}
+
+	session.EscalatedAt = time.Now()
+	if err := b.store.EscalateTicket(ticketID, session.EscalatedAt); err != nil {
+		b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to persist escalated ticket")
+	}
+
+	b.audit.Record(AuditTicketEscalated, b.adminID, logrus.Fields{
+		"user_id":   session.UserID,
+		"ticket_id": ticketID,
+		"reason":    reason,
+	})
+
+	reply := fmt.Sprintf("⬆️ Ticket #%d escalated to the senior reviewer", ticketID)
+	if note != "" {
+		reply += "\n" + note
+	}
+
+	return reply
+}
+
+// escalationText is the full context of a ticket for the senior reviewer:
+// the question, the author's profile and notes, and their last answered
+// tickets. Anonymized tickets leave out who the author is, and the profile,
+// which lists their former usernames.
+func (b *Bot) escalationText(session *UserSession, reason string) string {
+	anonymized := b.anonymizesAuthor(session)
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("⬆️ Escalated ticket #%d", session.TicketID))
+	if session.Username != "" && !anonymized {
+		text.WriteString(fmt.Sprintf(" from @%s (ID: %d)", session.Username, session.UserID))
+	} else if !anonymized {
+		text.WriteString(fmt.Sprintf(" from user ID %d", session.UserID))
+	}
+	text.WriteString(fmt.Sprintf(", waiting %s\n", formatDuration(time.Since(session.CreatedAt))))
+	if reason != "" {
+		text.WriteString("Reason: " + reason + "\n")
+	}
+	if profile := b.userProfileLine(session); profile != "" && !anonymized {
+		text.WriteString(profile + "\n")
+	}
+	if notes := b.userNotesLines(session.UserID); notes != "" {
+		text.WriteString(notes + "\n")
+	}
+
+	if comments := b.ticketCommentLines(session.TicketID); comments != "" {
+		text.WriteString(comments + "\n")
+	}
+
+	text.WriteString("\n" + b.reviewerText(session, session.LastQuestion) + "\n")
+	if session.CVIntake != nil {
+		text.WriteString("\n" + b.reviewerText(session, session.CVIntake.Summary()) + "\n")
+	}
+
+	previous := b.store.UserTickets(session.UserID)
+	if len(previous) > 0 {
+		text.WriteString("\nEarlier tickets:\n")
+		for _, ticket := range previous[:min(escalationHistorySize, len(previous))] {
+			text.WriteString(fmt.Sprintf("#%d (%s)\n❓ %s\n💬 %s\n",
+				ticket.ID, ticket.AnsweredAt.Format("2006-01-02"),
+				truncateText(ticket.Question, 150), truncateText(ticket.Answer, 200)))
+		}
+	}
+
+	return text.String()
+}
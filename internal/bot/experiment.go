@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+const welcomeMessageID = "welcome_menu"
+
+// welcomeVariantsFromConfig reads WELCOME_VARIANTS, the message IDs of the
+// welcome menu texts to compare, e.g. "welcome_menu,welcome_menu_short".
+// New users get one of them at random and keep it; with fewer than two
+// variants there is no experiment.
+func welcomeVariantsFromConfig(config *Config, translations *i18n.Bundle) ([]string, error) {
+	var variants []string
+	for _, variant := range config.Texts.WelcomeVariants {
+		if slices.Contains(variants, variant) {
+			continue
+		}
+		if !strings.HasPrefix(variant, welcomeMessageID) {
+			return nil, fmt.Errorf("%q is not a welcome menu text, its ID must start with %s", variant, welcomeMessageID)
+		}
+
+		localizer := i18n.NewLocalizer(translations, defaultLanguage)
+		if _, err := localizer.Localize(&i18n.LocalizeConfig{MessageID: variant}); err != nil {
+			return nil, fmt.Errorf("unknown message %q, see internal/bot/locales/en.json for the available IDs", variant)
+		}
+		variants = append(variants, variant)
+	}
+
+	if len(variants) < 2 {
+		return nil, nil
+	}
+
+	return variants, nil
+}
+
+// welcomeVariant returns the welcome menu text to show a user. Users who
+// never opened a ticket join the experiment with a random variant the
+// first time they see the menu; everyone else gets the default text.
+func (b *Bot) welcomeVariant(userID int64) string {
+	if len(b.welcomeVariants) == 0 {
+		return welcomeMessageID
+	}
+
+	user, exists := b.store.User(userID)
+	switch {
+	case !exists:
+		return welcomeMessageID
+	case user.WelcomeVariant != "":
+		if slices.Contains(b.welcomeVariants, user.WelcomeVariant) {
+			return user.WelcomeVariant
+		}
+		// The variant was dropped from the experiment
+		return welcomeMessageID
+	case user.Questions > 0:
+		return welcomeMessageID
+	}
+
+	variant := b.welcomeVariants[rand.N(len(b.welcomeVariants))]
+	if err := b.store.AssignWelcomeVariant(userID, variant, time.Now()); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist welcome variant")
+	}
+
+	return variant
+}
+
+// VariantStats is how one welcome menu variant did.
+type VariantStats struct {
+	Users int
+	// Converted counts the users who submitted a question after seeing
+	// the variant
+	Converted int
+}
+
+// computeExperiment counts, per welcome variant, the users assigned in the
+// window and how many of them went on to submit a question.
+func computeExperiment(tickets []storage.TicketRecord, users []storage.UserRecord, from, to time.Time) map[string]*VariantStats {
+	firstQuestion := make(map[int64]time.Time)
+	for _, ticket := range tickets {
+		if ticket.Kind != string(StateQuestion) {
+			continue
+		}
+		if first, seen := firstQuestion[ticket.UserID]; !seen || ticket.CreatedAt.Before(first) {
+			firstQuestion[ticket.UserID] = ticket.CreatedAt
+		}
+	}
+
+	experiment := make(map[string]*VariantStats)
+	for _, user := range users {
+		if user.WelcomeVariant == "" || !inWindow(user.WelcomeVariantAt, from, to) {
+			continue
+		}
+		stats, exists := experiment[user.WelcomeVariant]
+		if !exists {
+			stats = &VariantStats{}
+			experiment[user.WelcomeVariant] = stats
+		}
+		stats.Users++
+		if first, asked := firstQuestion[user.ID]; asked && !first.Before(user.WelcomeVariantAt) {
+			stats.Converted++
+		}
+	}
+
+	return experiment
+}
+
+// experimentLines reports the welcome experiment for /stats, or "" if no
+// user was part of it.
+func experimentLines(experiment map[string]*VariantStats) string {
+	if len(experiment) == 0 {
+		return ""
+	}
+
+	variants := make([]string, 0, len(experiment))
+	for variant := range experiment {
+		variants = append(variants, variant)
+	}
+	sort.Strings(variants)
+
+	var text strings.Builder
+	text.WriteString("\n🧪 Welcome experiment (users who asked a question):\n")
+	for _, variant := range variants {
+		stats := experiment[variant]
+		text.WriteString(fmt.Sprintf("• %s - %d/%d (%.0f%%)\n",
+			variant, stats.Converted, stats.Users, float64(stats.Converted)*100/float64(stats.Users)))
+	}
+
+	return text.String()
+}
@@ -0,0 +1,81 @@
+package bot
+
+import "strings"
+
+// callbackPrefixes are the callbacks that carry their own context, such as a
+// ticket ID, and stay valid whatever the user does in between.
+var callbackPrefixes = []string{"ticket:", "answer:", "preview:", "review:", "undo:", "ack:", "snooze:", "assign:", "rate:", "history:", "survey:", "language:", "sub:", "booking:cancel:", "reengage:", "tutorial:", "terms:"}
+
+// callbackExpired reports whether a button was pressed on a menu that no
+// longer applies: the user moved on to another step, the bot restarted and
+// forgot the step the menu belonged to, or the button was removed.
+func (b *Bot) callbackExpired(userID int64, data string) bool {
+	state := b.userStates[userID]
+
+	switch data {
+	case "help", "commands", "language", "back_to_menu", "cancel":
+		return false
+	case "confirm_send", "confirm_urgent", "confirm_edit":
+		return state != StateConfirmQuestion
+	case "cv_intake_skip":
+		return state != StateCVIntake
+	case "1", "2":
+		return state != StateWaitingCV
+	}
+
+	if strings.HasPrefix(data, "cv_tier:") {
+		return state != StateCVTier
+	}
+	if strings.HasPrefix(data, "booking:") && !strings.HasPrefix(data, "booking:cancel:") {
+		return state != StateBooking
+	}
+	if strings.HasPrefix(data, "category:") {
+		return state != StateQuestion && state != StateConfirmQuestion
+	}
+	for _, prefix := range callbackPrefixes {
+		if strings.HasPrefix(data, prefix) {
+			return false
+		}
+	}
+	_, isFlow := b.flows.Flow(data)
+
+	return !isFlow
+}
+
+// showCurrentMenu shows the menu of the step the user is at again, e.g.
+// after they pressed a button on an expired one.
+func (b *Bot) showCurrentMenu(userID int64) {
+	switch b.userStates[userID] {
+	case StateConfirmQuestion:
+		if _, exists := b.drafts[userID]; exists {
+			b.showQuestionConfirmation(userID)
+			return
+		}
+	case StateQuestion:
+		b.startQuestionFlow(userID)
+		return
+	case StateCVIntake:
+		if _, exists := b.cvForms[userID]; exists {
+			b.askCVIntakeStep(userID)
+			return
+		}
+	case StateCVTier:
+		if _, exists := b.cvForms[userID]; exists {
+			b.offerPriorityReview(userID)
+			return
+		}
+	case StateCVReview:
+		b.showCVInstructions(userID)
+		return
+	case StateWaitingCV:
+		b.showCVUploadChoice(userID)
+		return
+	case StateBooking:
+		if b.consultations != nil {
+			b.showBookingDays(userID)
+			return
+		}
+	}
+
+	b.showWelcomeMenu(userID)
+}
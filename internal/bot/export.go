@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func ticketsCSV(tickets []storage.TicketRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	header := []string{"ticket_id", "user_id", "username", "kind", "category", "status",
+		"question", "answer", "created_at", "answered_at", "response_seconds", "rating", "resolved"}
+	if err := writer.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, ticket := range tickets {
+		status := "open"
+		responseSeconds := ""
+		if !ticket.AnsweredAt.IsZero() {
+			status = "answered"
+			if !ticket.CreatedAt.IsZero() {
+				responseSeconds = strconv.Itoa(int(ticket.AnsweredAt.Sub(ticket.CreatedAt).Seconds()))
+			}
+		}
+
+		resolved := ""
+		if ticket.Resolved != nil {
+			resolved = strconv.FormatBool(*ticket.Resolved)
+		}
+
+		record := []string{
+			strconv.Itoa(ticket.ID),
+			strconv.FormatInt(ticket.UserID, 10),
+			ticket.Username,
+			ticket.Kind,
+			ticket.Category,
+			status,
+			ticket.Question,
+			ticket.Answer,
+			formatTimestamp(ticket.CreatedAt),
+			formatTimestamp(ticket.AnsweredAt),
+			responseSeconds,
+			ticket.Rating,
+			resolved,
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+func (b *Bot) exportTickets(args string) {
+	if args == "" {
+		args = "all"
+	}
+
+	period, exists := statsPeriods[args]
+	if !exists {
+		msg := tgbotapi.NewMessage(b.adminID, "Usage: /export [7d|30d|all]")
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send export usage")
+		}
+		return
+	}
+
+	b.sendChatAction(b.adminID, tgbotapi.ChatUploadDocument)
+
+	now := time.Now()
+	var tickets []storage.TicketRecord
+	for _, ticket := range b.store.Tickets() {
+		created := ticket.CreatedAt
+		if created.IsZero() {
+			created = ticket.AnsweredAt
+		}
+		if period == 0 || created.After(now.Add(-period)) {
+			tickets = append(tickets, ticket)
+		}
+	}
+
+	content, err := ticketsCSV(tickets)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to build CSV export")
+		return
+	}
+
+	document := tgbotapi.NewDocument(b.adminID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("tickets-%s-%s.csv", args, now.Format("2006-01-02")),
+		Bytes: content,
+	})
+	document.Caption = fmt.Sprintf("📤 %d ticket(s) exported (%s)", len(tickets), args)
+
+	_, err = b.api.Send(document)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send CSV export")
+	}
+}
@@ -0,0 +1,231 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// FAQEntry is a frequently asked question the bot answers by itself.
+// Keywords, Answer and button texts are keyed by language code; English is
+// required and used for languages without a translation. Photo and
+// Document are a Telegram file ID, an http(s) URL or a path relative to the
+// FAQ file, sent after the answer.
+//
+//	{
+//	  "id": "cv_template",
+//	  "keywords": {"en": ["cv template"], "ru": ["шаблон резюме"]},
+//	  "answer": {"en": "Here is our **CV template**.", "ru": "Вот наш **шаблон резюме**."},
+//	  "document": "files/cv_template.docx",
+//	  "buttons": [{"text": {"en": "📄 Examples"}, "url": "https://example.com/cv"}]
+//	}
+type FAQEntry struct {
+	ID       string              `json:"id"`
+	Keywords map[string][]string `json:"keywords"`
+	Answer   map[string]string   `json:"answer"`
+	Photo    string              `json:"photo,omitempty"`
+	Document string              `json:"document,omitempty"`
+	Buttons  []FAQButton         `json:"buttons,omitempty"`
+}
+
+// FAQButton is a link button under an FAQ answer.
+type FAQButton struct {
+	Text map[string]string `json:"text"`
+	URL  string            `json:"url"`
+}
+
+// FAQ holds the entries of FAQ_FILE.
+type FAQ struct {
+	entries []FAQEntry
+}
+
+// faqFromConfig loads FAQ_FILE, a JSON array of FAQ entries. Without it the
+// FAQ is empty and every question goes to the admin.
+func faqFromConfig(config *Config) (*FAQ, error) {
+	path := config.Texts.FAQFile
+	if path == "" {
+		return &FAQ{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	faq := &FAQ{}
+	if err := json.Unmarshal(data, &faq.entries); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, entry := range faq.entries {
+		if entry.ID == "" {
+			return nil, fmt.Errorf("%s: entry %d has no id", path, i+1)
+		}
+		if strings.TrimSpace(entry.Answer[defaultLanguage]) == "" {
+			return nil, fmt.Errorf("%s: entry %q has no English answer", path, entry.ID)
+		}
+		for lang := range entry.Keywords {
+			if !slices.Contains(supportedLanguages, lang) {
+				return nil, fmt.Errorf("%s: entry %q has keywords in unsupported language %q", path, entry.ID, lang)
+			}
+		}
+		for lang := range entry.Answer {
+			if !slices.Contains(supportedLanguages, lang) {
+				return nil, fmt.Errorf("%s: entry %q has an answer in unsupported language %q", path, entry.ID, lang)
+			}
+		}
+		for _, button := range entry.Buttons {
+			if strings.TrimSpace(button.Text[defaultLanguage]) == "" {
+				return nil, fmt.Errorf("%s: entry %q has a button without English text", path, entry.ID)
+			}
+			if !strings.HasPrefix(button.URL, "https://") && !strings.HasPrefix(button.URL, "http://") && !strings.HasPrefix(button.URL, "tg://") {
+				return nil, fmt.Errorf("%s: entry %q has a button with invalid URL %q", path, entry.ID, button.URL)
+			}
+		}
+		// Telegram file IDs have neither slashes nor dots, so anything else
+		// is a local file, relative to the FAQ file
+		for _, file := range []*string{&faq.entries[i].Photo, &faq.entries[i].Document} {
+			if *file == "" || isFileURL(*file) || !strings.ContainsAny(*file, "/.") {
+				continue
+			}
+			if !filepath.IsAbs(*file) {
+				*file = filepath.Join(filepath.Dir(path), *file)
+			}
+			if _, err := os.Stat(*file); err != nil {
+				return nil, fmt.Errorf("%s: entry %q: %w", path, entry.ID, err)
+			}
+		}
+	}
+
+	return faq, nil
+}
+
+// Len returns the number of entries.
+func (f *FAQ) Len() int {
+	return len(f.entries)
+}
+
+// Match returns the entry whose keywords, in any language, a question
+// mentions most often. Keywords match whole words or phrases regardless of
+// case.
+func (f *FAQ) Match(question string) (FAQEntry, bool) {
+	text := " " + strings.Join(faqWords(question), " ") + " "
+
+	var best FAQEntry
+	bestScore := 0
+	for _, entry := range f.entries {
+		score := 0
+		for _, keywords := range entry.Keywords {
+			for _, keyword := range keywords {
+				words := faqWords(keyword)
+				if len(words) > 0 && strings.Contains(text, " "+strings.Join(words, " ")+" ") {
+					score++
+				}
+			}
+		}
+		if score > bestScore {
+			best, bestScore = entry, score
+		}
+	}
+
+	return best, bestScore > 0
+}
+
+// AnswerIn returns the answer in lang, or in English without a translation.
+func (e FAQEntry) AnswerIn(lang string) string {
+	if answer := strings.TrimSpace(e.Answer[lang]); answer != "" {
+		return answer
+	}
+
+	return e.Answer[defaultLanguage]
+}
+
+// Keyboard returns the link buttons of the entry in lang, one per row, or
+// nil without buttons.
+func (e FAQEntry) Keyboard(lang string) *tgbotapi.InlineKeyboardMarkup {
+	if len(e.Buttons) == 0 {
+		return nil
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, len(e.Buttons))
+	for i, button := range e.Buttons {
+		text := strings.TrimSpace(button.Text[lang])
+		if text == "" {
+			text = button.Text[defaultLanguage]
+		}
+		rows[i] = tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(text, button.URL))
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	return &keyboard
+}
+
+// faqFile resolves the photo or document of an entry for sending: a URL,
+// a local file or, otherwise, a Telegram file ID.
+func faqFile(file string) tgbotapi.RequestFileData {
+	if isFileURL(file) {
+		return tgbotapi.FileURL(file)
+	}
+	if _, err := os.Stat(file); err == nil {
+		return tgbotapi.FilePath(file)
+	}
+
+	return tgbotapi.FileID(file)
+}
+
+func isFileURL(file string) bool {
+	return strings.HasPrefix(file, "https://") || strings.HasPrefix(file, "http://")
+}
+
+func faqWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// suggestFAQAnswer sends the user the FAQ answer to their question, in
+// their language, if there is one. They can still send the question to the
+// admin.
+func (b *Bot) suggestFAQAnswer(userID int64, question string) {
+	entry, found := b.faq.Match(question)
+	if !found {
+		return
+	}
+
+	lang := b.userLanguage(userID)
+	answer := markdown(telegram.FormatMarkdown(entry.AnswerIn(lang)))
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "faq_suggestion", map[string]interface{}{"Answer": answer}))
+	if keyboard := entry.Keyboard(lang); keyboard != nil {
+		msg.ReplyMarkup = *keyboard
+	}
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send FAQ answer")
+		return
+	}
+
+	if entry.Photo != "" {
+		if _, err := b.api.Send(tgbotapi.NewPhoto(userID, faqFile(entry.Photo))); err != nil {
+			b.logger.WithError(err).WithField("faq_id", entry.ID).Error("Failed to send FAQ photo")
+		}
+	}
+	if entry.Document != "" {
+		b.sendChatAction(userID, tgbotapi.ChatUploadDocument)
+		if _, err := b.api.Send(tgbotapi.NewDocument(userID, faqFile(entry.Document))); err != nil {
+			b.logger.WithError(err).WithField("faq_id", entry.ID).Error("Failed to send FAQ document")
+		}
+	}
+
+	b.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"faq_id":   entry.ID,
+		"language": lang,
+	}).Info("Suggested FAQ answer")
+}
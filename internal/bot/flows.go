@@ -0,0 +1,77 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/flows"
+)
+
+// registerFlows registers the built-in conversation flows. Their order is
+// the order of the welcome menu buttons.
+func (b *Bot) registerFlows() error {
+	flows := []*flows.Flow{
+		{
+			Name:        "question",
+			Button:      "button_ask_question",
+			Commands:    []string{"/question", "/ask", "question", "ask", "ask question"},
+			Description: "command_question",
+			Keywords:    []string{"question"},
+			Start:       b.startQuestionFlow,
+			States: map[UserState]flows.StateHandler{
+				StateQuestion: b.handleQuestionState,
+				// A new message replaces the draft awaiting confirmation
+				StateConfirmQuestion: b.handleQuestionState,
+			},
+		},
+		{
+			Name:        "cv_review",
+			Button:      "button_cv_review",
+			Commands:    []string{"/cv", "/resume", "/cvreview", "cv", "resume", "cv review"},
+			Description: "command_cv_review",
+			Keywords:    []string{"cv", "review"},
+			Start:       b.startCVReviewFlow,
+			States: map[UserState]flows.StateHandler{
+				StateCVReview:  b.handleCVReviewState,
+				StateWaitingCV: b.handleWaitingCVState,
+				StateCVIntake: func(message *tgbotapi.Message, userID int64, username string) {
+					b.handleCVIntakeState(message, userID)
+				},
+				StateCVTier: func(message *tgbotapi.Message, userID int64, username string) {
+					b.offerPriorityReview(userID)
+				},
+			},
+		},
+	}
+
+	if b.consultations != nil {
+		flows = append(flows, b.bookingFlow())
+	}
+
+	for _, flow := range flows {
+		flow.Start = b.requireTerms(flow.Name, b.requireEntitlement(flow.Name, flow.Start))
+		if err := b.flows.Register(flow); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flowKeyboardRows lays out the welcome menu buttons of all flows, two per
+// row.
+func (b *Bot) flowKeyboardRows(userID int64) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, flow := range b.flows.Flows() {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, flow.Button), flow.Name))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	return rows
+}
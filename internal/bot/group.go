@@ -0,0 +1,187 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// GroupOrigin is the group a question was asked in.
+type GroupOrigin struct {
+	ChatID int64
+	Title  string
+	// Link opens the question in the group, empty for groups Telegram has
+	// no message links for
+	Link string
+	// Channel and Post are set for comments under a channel post, which
+	// Telegram keeps in the channel's discussion group
+	Channel string
+	Post    string
+}
+
+// postExcerptLength is how much of a commented channel post the admin sees.
+const postExcerptLength = 200
+
+// Describe is the line of the admin notification that names the group.
+func (g *GroupOrigin) Describe() string {
+	if g.Channel != "" {
+		line := "📰 Comment under a post in " + g.Channel
+		if g.Link != "" {
+			line += ": " + g.Link
+		}
+		if g.Post != "" {
+			line += "\n> " + truncateText(g.Post, postExcerptLength)
+		}
+		return line
+	}
+
+	title := g.Title
+	if title == "" {
+		title = fmt.Sprintf("group %d", g.ChatID)
+	}
+	if g.Link == "" {
+		return "👥 Asked in " + title
+	}
+
+	return fmt.Sprintf("👥 Asked in %s: %s", title, g.Link)
+}
+
+// newGroupOrigin describes where message was asked. A reply to a post the
+// channel forwarded to its discussion group is a comment under that post.
+func newGroupOrigin(message *tgbotapi.Message) *GroupOrigin {
+	origin := &GroupOrigin{
+		ChatID: message.Chat.ID,
+		Title:  message.Chat.Title,
+		Link:   groupMessageLink(message.Chat, message.MessageID),
+	}
+
+	post := message.ReplyToMessage
+	if post == nil || !post.IsAutomaticForward || post.ForwardFromChat == nil {
+		return origin
+	}
+
+	channel := post.ForwardFromChat
+	origin.Channel = channel.Title
+	origin.Post = questionText(post)
+	if link := groupMessageLink(channel, post.ForwardFromMessageID); link != "" {
+		origin.Link = fmt.Sprintf("%s?comment=%d", link, message.MessageID)
+	}
+
+	return origin
+}
+
+// isGroupChat reports whether chat is a group or supergroup, where the bot
+// only answers when asked.
+func isGroupChat(chat *tgbotapi.Chat) bool {
+	return chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+}
+
+// groupMessageLink links to a message of a public group or channel by its
+// username or of a private supergroup or channel by its ID. Basic groups
+// have no message links.
+func groupMessageLink(chat *tgbotapi.Chat, messageID int) string {
+	if chat.UserName != "" {
+		return fmt.Sprintf("https://t.me/%s/%d", chat.UserName, messageID)
+	}
+	if chat.IsSuperGroup() || chat.IsChannel() {
+		// Supergroup and channel IDs are -100 followed by the ID the link
+		// uses
+		return fmt.Sprintf("https://t.me/c/%s/%d", strings.TrimPrefix(fmt.Sprint(chat.ID), "-100"), messageID)
+	}
+
+	return ""
+}
+
+// mentionPattern matches a mention of the bot called botName in any case.
+func mentionPattern(botName string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)@` + regexp.QuoteMeta(botName) + `\b`)
+}
+
+// groupQuestion extracts the question from a group message that asks the
+// bot: "/ask@bot <question>", or any message mentioning @bot. It reports
+// false for messages that are not for the bot.
+func (b *Bot) groupQuestion(message *tgbotapi.Message) (string, bool) {
+	botName := b.api.Self().UserName
+
+	if message.IsCommand() {
+		command := strings.ToLower(message.CommandWithAt())
+		if command != "ask@"+strings.ToLower(botName) {
+			return "", false
+		}
+		return strings.TrimSpace(message.CommandArguments()), true
+	}
+
+	text := questionText(message)
+	if !b.mention.MatchString(text) {
+		return "", false
+	}
+
+	return strings.TrimSpace(b.mention.ReplaceAllString(text, "")), true
+}
+
+// handleGroupMessage turns group messages that ask the bot into tickets.
+// The admin answers them like any other ticket and the answer is posted in
+// the group as a reply to the question. Everything else said in the group
+// is ignored.
+func (b *Bot) handleGroupMessage(message *tgbotapi.Message) {
+	question, asked := b.groupQuestion(message)
+	if !asked {
+		return
+	}
+
+	userID := message.From.ID
+	chatID := message.Chat.ID
+	defer b.startSpan("handler.group_message")()
+
+	reply := func(text string) error {
+		msg := telegram.NewMarkdownMessage(chatID, text)
+		msg.ReplyToMessageID = message.MessageID
+		_, err := b.api.Send(msg)
+		return err
+	}
+
+	if question == "" {
+		err := reply(b.trMarkdown(userID, "group_usage", map[string]interface{}{"Bot": b.api.Self().UserName}))
+		if err != nil {
+			b.logger.WithError(err).WithField("chat_id", chatID).Error("Failed to send group usage")
+		}
+		return
+	}
+	if b.rejectBlockedLink(message) || b.rejectInfectedFile(tgbotapi.Update{Message: message}) {
+		return
+	}
+
+	session := &UserSession{
+		UserID:       userID,
+		Username:     message.From.UserName,
+		LastQuestion: question,
+		MessageID:    message.MessageID,
+		State:        StateQuestion,
+		Category:     defaultCategory,
+		Group:        newGroupOrigin(message),
+		CreatedAt:    time.Now(),
+	}
+
+	confirmText := b.trMarkdown(userID, "confirmation_group")
+	if b.officeHours != nil && !b.officeHours.IsOpen(session.CreatedAt) {
+		session.AfterHours = true
+		confirmText += "\n\n" + b.afterHoursNotice(userID, session.CreatedAt)
+	}
+
+	err := reply(confirmText)
+	if err != nil {
+		b.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id": userID,
+			"chat_id": chatID,
+		}).Error("Failed to send confirmation message to group")
+		return
+	}
+
+	b.openTicket(session)
+}
@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/DilmurodYangiboev/faq_bot/faqbotpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// watchBuffer is how many new questions a slow WatchQuestions client may lag
+// behind before questions are dropped for it.
+const watchBuffer = 32
+
+// grpcService implements faqbotpb.FAQBotServer on top of the bot's session
+// state.
+type grpcService struct {
+	faqbotpb.UnimplementedFAQBotServer
+
+	bot *Bot
+
+	mu       sync.Mutex
+	watchers map[chan *faqbotpb.Session]struct{}
+}
+
+func sessionToProto(session *UserSession) *faqbotpb.Session {
+	return &faqbotpb.Session{
+		TicketId:  int32(session.TicketID),
+		UserId:    session.UserID,
+		Username:  session.Username,
+		Question:  session.LastQuestion,
+		Kind:      string(session.State),
+		Category:  session.Category,
+		Urgent:    session.Urgent,
+		CreatedAt: timestamppb.New(session.CreatedAt),
+	}
+}
+
+// startGRPCServer serves the FAQBot gRPC service on GRPC_ADDR. Calls must
+// carry "authorization: Bearer <GRPC_TOKEN>" metadata.
+func (b *Bot) startGRPCServer() {
+	addr := b.config.Features.GRPC.Addr
+	if addr == "" {
+		return
+	}
+
+	token := b.config.Features.GRPC.Token
+	if token == "" {
+		b.logger.Fatal("GRPC_TOKEN is required when GRPC_ADDR is set")
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		b.logger.WithError(err).WithField("addr", addr).Fatal("Failed to listen for gRPC")
+	}
+
+	b.rpc = &grpcService{bot: b, watchers: make(map[chan *faqbotpb.Session]struct{})}
+
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := checkGRPCToken(ctx, token); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := checkGRPCToken(stream.Context(), token); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	)
+	faqbotpb.RegisterFAQBotServer(server, b.rpc)
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil {
+			b.logger.WithError(err).WithField("addr", addr).Error("gRPC server stopped")
+		}
+	}()
+}
+
+func checkGRPCToken(ctx context.Context, token string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		provided, found := strings.CutPrefix(value, "Bearer ")
+		if found && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+			return nil
+		}
+	}
+
+	return status.Error(codes.Unauthenticated, "invalid or missing bearer token")
+}
+
+func (s *grpcService) ListSessions(ctx context.Context, req *faqbotpb.ListSessionsRequest) (*faqbotpb.ListSessionsResponse, error) {
+	s.bot.mu.Lock()
+	defer s.bot.mu.Unlock()
+
+	response := &faqbotpb.ListSessionsResponse{}
+	for _, session := range s.bot.openTickets() {
+		response.Sessions = append(response.Sessions, sessionToProto(session))
+	}
+
+	return response, nil
+}
+
+func (s *grpcService) WatchQuestions(req *faqbotpb.WatchQuestionsRequest, stream faqbotpb.FAQBot_WatchQuestionsServer) error {
+	watcher := make(chan *faqbotpb.Session, watchBuffer)
+
+	s.mu.Lock()
+	s.watchers[watcher] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers, watcher)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case session := <-watcher:
+			if err := stream.Send(session); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *grpcService) SendAnswer(ctx context.Context, req *faqbotpb.SendAnswerRequest) (*faqbotpb.SendAnswerResponse, error) {
+	if strings.TrimSpace(req.GetAnswer()) == "" {
+		return nil, status.Error(codes.InvalidArgument, "answer must not be empty")
+	}
+
+	s.bot.mu.Lock()
+	defer s.bot.mu.Unlock()
+
+	ticketID := int(req.GetTicketId())
+	session, exists := s.bot.tickets[ticketID]
+	if !exists {
+		if _, known := s.bot.store.Ticket(ticketID); known {
+			return nil, status.Errorf(codes.FailedPrecondition, "ticket #%d is not open", ticketID)
+		}
+		return nil, status.Errorf(codes.NotFound, "ticket #%d not found", ticketID)
+	}
+
+	s.bot.deliverAnswer(session, req.GetAnswer())
+	if _, stillOpen := s.bot.tickets[ticketID]; stillOpen {
+		return nil, status.Error(codes.Unavailable, "failed to deliver the answer through Telegram")
+	}
+
+	return &faqbotpb.SendAnswerResponse{
+		Session:    sessionToProto(session),
+		AnsweredAt: timestamppb.New(session.AnsweredAt),
+	}, nil
+}
+
+// publish hands a new ticket to every WatchQuestions stream without blocking
+// the update loop.
+func (s *grpcService) publish(session *faqbotpb.Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for watcher := range s.watchers {
+		select {
+		case watcher <- session:
+		default:
+			s.bot.logger.WithField("ticket_id", session.TicketId).Warn("gRPC watcher is lagging, dropping question")
+		}
+	}
+}
+
+func (b *Bot) publishQuestion(session *UserSession) {
+	if b.rpc == nil {
+		return
+	}
+
+	b.rpc.publish(sessionToProto(session))
+}
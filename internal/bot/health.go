@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultHealthAddr = ":8080"
+	heartbeatInterval = 15 * time.Second
+	heartbeatTimeout  = 2 * time.Minute
+)
+
+func (b *Bot) beat() {
+	b.lastHeartbeat.Store(time.Now().UnixNano())
+}
+
+// updateLoopAlive reports whether the update loop has ticked recently. The
+// loop beats on a timer even without updates, so a stale heartbeat means a
+// handler is wedged.
+func (b *Bot) updateLoopAlive() bool {
+	last := time.Unix(0, b.lastHeartbeat.Load())
+	return time.Since(last) < heartbeatTimeout
+}
+
+// startHealthServer serves /healthz (update loop alive), /readyz (update
+// loop alive and storage reachable) and /metrics (update counters).
+// HEALTH_ADDR=off disables it.
+func (b *Bot) startHealthServer() {
+	addr := b.config.Features.HealthAddr
+	if addr == "off" {
+		return
+	}
+	if addr == "" {
+		addr = defaultHealthAddr
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if !b.updateLoopAlive() {
+			http.Error(w, "update loop stalled", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/metrics", b.serveMetrics)
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !b.updateLoopAlive() {
+			http.Error(w, "update loop stalled", http.StatusServiceUnavailable)
+			return
+		}
+		if err := b.store.Ping(); err != nil {
+			http.Error(w, fmt.Sprintf("storage unavailable: %v", err), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ready")
+	})
+
+	go func() {
+		err := http.ListenAndServe(addr, mux)
+		if err != nil {
+			b.logger.WithError(err).WithField("addr", addr).Error("Health server stopped")
+		}
+	}()
+}
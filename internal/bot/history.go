@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const historyPageSize = 3
+
+// showUserHistory renders one page of a user's answered tickets. When
+// messageID is non-zero the existing history message is edited in place.
+func (b *Bot) showUserHistory(userID int64, page int, messageID int) {
+	tickets := b.store.UserTickets(userID)
+
+	if len(tickets) == 0 {
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "history_empty"))
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send empty history")
+		}
+		return
+	}
+
+	pages := (len(tickets) + historyPageSize - 1) / historyPageSize
+	if page < 0 || page >= pages {
+		page = 0
+	}
+
+	var historyText strings.Builder
+	historyText.WriteString(b.trMarkdown(userID, "history_header", map[string]interface{}{
+		"Page":  page + 1,
+		"Pages": pages,
+	}) + "\n\n")
+
+	end := min((page+1)*historyPageSize, len(tickets))
+	for _, ticket := range tickets[page*historyPageSize : end] {
+		historyText.WriteString(b.trMarkdown(userID, "history_ticket", map[string]interface{}{
+			"TicketID": ticket.ID,
+			"Date":     ticket.AnsweredAt.Format("2006-01-02"),
+			"Question": truncateText(ticket.Question, 200),
+			"Answer":   truncateText(ticket.Answer, 300),
+		}) + "\n\n")
+	}
+
+	var buttons []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_newer"), fmt.Sprintf("history:%d", page-1)))
+	}
+	if page < pages-1 {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_older"), fmt.Sprintf("history:%d", page+1)))
+	}
+
+	keyboard := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	if len(buttons) > 0 {
+		keyboard = tgbotapi.NewInlineKeyboardMarkup(buttons)
+	}
+
+	var err error
+	if messageID != 0 {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(userID, messageID, historyText.String(), keyboard)
+		edit.ParseMode = tgbotapi.ModeMarkdownV2
+		_, err = b.api.Send(edit)
+	} else {
+		msg := telegram.NewMarkdownMessage(userID, historyText.String())
+		msg.ReplyMarkup = keyboard
+		_, err = b.api.Send(msg)
+	}
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send user history")
+	}
+}
+
+// handleHistoryCallback processes "history:<page>" pagination callbacks.
+func (b *Bot) handleHistoryCallback(callback *tgbotapi.CallbackQuery) {
+	page, err := strconv.Atoi(strings.TrimPrefix(callback.Data, "history:"))
+	if err != nil {
+		b.logger.WithError(err).WithField("callback_data", callback.Data).Error("Malformed history callback")
+		return
+	}
+
+	messageID := 0
+	if callback.Message != nil {
+		messageID = callback.Message.MessageID
+	}
+
+	b.showUserHistory(callback.From.ID, page, messageID)
+}
@@ -0,0 +1,204 @@
+package bot
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const defaultLanguage = "en"
+
+//go:embed locales/*.json
+var localesFS embed.FS
+
+// supportedLanguages lists the catalogs shipped in locales/, keyed by the
+// language code stored for users.
+var supportedLanguages = []string{"en", "ru", "uz"}
+
+// languageNames are shown on the /language buttons, each in its own language.
+var languageNames = map[string]string{
+	"en": "🇬🇧 English",
+	"ru": "🇷🇺 Русский",
+	"uz": "🇺🇿 O'zbekcha",
+}
+
+// loadTranslations reads the user-facing message catalogs. English is the
+// source language and the fallback for missing translations.
+//
+// When MESSAGES_DIR is set, <lang>.json files found there override the
+// built-in texts message by message, so wording can change without a
+// rebuild.
+func loadTranslations(config *Config) (*i18n.Bundle, error) {
+	bundle := i18n.NewBundle(language.English)
+	bundle.RegisterUnmarshalFunc("json", json.Unmarshal)
+
+	known := make(map[string]bool)
+	for _, lang := range supportedLanguages {
+		file, err := bundle.LoadMessageFileFS(localesFS, "locales/"+lang+".json")
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range file.Messages {
+			known[message.ID] = true
+		}
+	}
+
+	dir := config.Texts.MessagesDir
+	if dir == "" {
+		return bundle, nil
+	}
+
+	for _, lang := range supportedLanguages {
+		path := filepath.Join(dir, lang+".json")
+		file, err := bundle.LoadMessageFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+
+		for _, message := range file.Messages {
+			if !known[message.ID] {
+				return nil, fmt.Errorf("%s: unknown message %q, see internal/bot/locales/en.json for the available IDs", path, message.ID)
+			}
+		}
+	}
+
+	return bundle, nil
+}
+
+// normalizeLanguage maps a Telegram language code such as "ru-RU" to one of
+// the supported languages, defaulting to English.
+func normalizeLanguage(code string) string {
+	base, _, _ := strings.Cut(strings.ToLower(code), "-")
+	for _, lang := range supportedLanguages {
+		if base == lang {
+			return lang
+		}
+	}
+
+	return defaultLanguage
+}
+
+// recordUser persists a user's profile together with the language their
+// Telegram client uses, so new users get a localized welcome menu before
+// they ever open /language.
+func (b *Bot) recordUser(user *tgbotapi.User) {
+	_, err := b.store.RecordUser(user.ID, user.UserName, user.LanguageCode, time.Now())
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to persist user")
+	}
+}
+
+func (b *Bot) userLanguage(userID int64) string {
+	return normalizeLanguage(b.store.UserLanguage(userID))
+}
+
+// tr renders a user-facing message in the user's language. data fills the
+// {{.Field}} placeholders of the message, if any.
+func (b *Bot) tr(userID int64, messageID string, data ...map[string]interface{}) string {
+	return b.trLang(b.userLanguage(userID), messageID, data...)
+}
+
+// trLang renders a user-facing message in lang.
+func (b *Bot) trLang(lang, messageID string, data ...map[string]interface{}) string {
+	config := &i18n.LocalizeConfig{MessageID: messageID}
+	if len(data) > 0 {
+		config.TemplateData = data[0]
+	}
+
+	localizer := i18n.NewLocalizer(b.translations.Load(), lang, defaultLanguage)
+	text, err := localizer.Localize(config)
+	if err != nil {
+		b.logger.WithError(err).WithField("message_id", messageID).Error("Failed to localize message")
+		return messageID
+	}
+
+	return text
+}
+
+// markdown is MarkdownV2 text, such as another formatted message, that
+// trMarkdown inserts as is.
+type markdown string
+
+// trMarkdown renders a user-facing message like tr, as MarkdownV2 for
+// telegram.NewMarkdownMessage. The markup of the text, such as **bold**, is
+// converted and data, typically typed by a user or the admin, is escaped so
+// it shows exactly as written.
+func (b *Bot) trMarkdown(userID int64, messageID string, data ...map[string]interface{}) string {
+	// Data is swapped for placeholders while the text is formatted, so its
+	// characters are never taken for markup
+	var values []string
+	placeholders := make(map[string]interface{})
+	if len(data) > 0 {
+		for key, value := range data[0] {
+			formatted, ok := value.(markdown)
+			if !ok {
+				formatted = markdown(telegram.EscapeMarkdown(fmt.Sprint(value)))
+			}
+			placeholders[key] = fmt.Sprintf("\x00%d\x00", len(values))
+			values = append(values, string(formatted))
+		}
+	}
+
+	text := telegram.FormatMarkdown(b.tr(userID, messageID, placeholders))
+	for i, value := range values {
+		text = strings.ReplaceAll(text, fmt.Sprintf("\x00%d\x00", i), value)
+	}
+
+	return text
+}
+
+func (b *Bot) showLanguagePicker(userID int64) {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(supportedLanguages))
+	for _, lang := range supportedLanguages {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(languageNames[lang], "language:"+lang))
+	}
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "language_prompt"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send language picker")
+	}
+}
+
+// handleLanguageCallback processes "language:<code>" callbacks from the
+// /language picker and re-renders the menu in the chosen language.
+func (b *Bot) handleLanguageCallback(callback *tgbotapi.CallbackQuery) {
+	userID := callback.From.ID
+	lang := strings.TrimPrefix(callback.Data, "language:")
+	if _, supported := languageNames[lang]; !supported {
+		b.logger.WithField("callback_data", callback.Data).Error("Unsupported language selected")
+		return
+	}
+
+	err := b.store.SetUserLanguage(userID, lang, time.Now())
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist language preference")
+		return
+	}
+
+	if callback.Message != nil {
+		edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, b.trMarkdown(userID, "language_set"))
+		edit.ParseMode = tgbotapi.ModeMarkdownV2
+		_, err = b.api.Send(edit)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to confirm language change")
+		}
+	}
+
+	b.showWelcomeMenu(userID)
+}
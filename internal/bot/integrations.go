@@ -0,0 +1,272 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// IntegrationStatus describes the health of an optional third-party
+// integration.
+type IntegrationStatus string
+
+const (
+	IntegrationHealthy  IntegrationStatus = "healthy"
+	IntegrationDegraded IntegrationStatus = "degraded"
+	IntegrationDown     IntegrationStatus = "down"
+)
+
+// FallbackPolicy decides what happens to a step when its integration fails.
+type FallbackPolicy string
+
+const (
+	FallbackSkip        FallbackPolicy = "skip"
+	FallbackNotifyAdmin FallbackPolicy = "notify_admin"
+	FallbackRetry       FallbackPolicy = "retry"
+)
+
+const (
+	integrationDownThreshold  = 3
+	integrationMaxAttempts    = 5
+	integrationRetryBaseDelay = time.Minute
+	integrationRetryInterval  = 30 * time.Second
+)
+
+type Integration struct {
+	Name        string
+	Policy      FallbackPolicy
+	Status      IntegrationStatus
+	Failures    int
+	LastError   string
+	LastSuccess time.Time
+	LastFailure time.Time
+}
+
+type integrationJob struct {
+	integration string
+	description string
+	run         func() error
+	attempts    int
+	nextAttempt time.Time
+}
+
+// IntegrationRegistry tracks optional integrations (LLM, Drive, translation,
+// payments, ...) so that a failing third party never blocks core Q&A.
+type IntegrationRegistry struct {
+	mu           sync.Mutex
+	integrations map[string]*Integration
+	retryQueue   []*integrationJob
+}
+
+func NewIntegrationRegistry() *IntegrationRegistry {
+	return &IntegrationRegistry{
+		integrations: make(map[string]*Integration),
+	}
+}
+
+func (r *IntegrationRegistry) Register(name string, policy FallbackPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.integrations[name] = &Integration{
+		Name:   name,
+		Policy: policy,
+		Status: IntegrationHealthy,
+	}
+}
+
+func (r *IntegrationRegistry) Enabled(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, exists := r.integrations[name]
+	return exists
+}
+
+// Snapshot returns a copy of every registered integration sorted by name.
+func (r *IntegrationRegistry) Snapshot() ([]Integration, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := make([]Integration, 0, len(r.integrations))
+	for _, integration := range r.integrations {
+		list = append(list, *integration)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list, len(r.retryQueue)
+}
+
+// recordResult updates the health of an integration and reports the policy to
+// apply and whether the integration just transitioned to or from down.
+func (r *IntegrationRegistry) recordResult(name string, err error) (FallbackPolicy, IntegrationStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	integration := r.integrations[name]
+	previous := integration.Status
+
+	if err == nil {
+		integration.Status = IntegrationHealthy
+		integration.Failures = 0
+		integration.LastSuccess = time.Now()
+	} else {
+		integration.Failures++
+		integration.LastError = err.Error()
+		integration.LastFailure = time.Now()
+		if integration.Failures >= integrationDownThreshold {
+			integration.Status = IntegrationDown
+		} else {
+			integration.Status = IntegrationDegraded
+		}
+	}
+
+	changed := previous != integration.Status && (previous == IntegrationDown || integration.Status == IntegrationDown)
+	return integration.Policy, integration.Status, changed
+}
+
+func (r *IntegrationRegistry) enqueue(job *integrationJob) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retryQueue = append(r.retryQueue, job)
+}
+
+func (r *IntegrationRegistry) dueJobs(now time.Time) []*integrationJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var due, pending []*integrationJob
+	for _, job := range r.retryQueue {
+		if now.Before(job.nextAttempt) {
+			pending = append(pending, job)
+		} else {
+			due = append(due, job)
+		}
+	}
+	r.retryQueue = pending
+
+	return due
+}
+
+// runIntegration executes an optional integration step in the background and
+// applies its fallback policy when it fails.
+func (b *Bot) runIntegration(name, description string, run func() error) {
+	if !b.integrations.Enabled(name) {
+		return
+	}
+
+	go b.executeIntegrationJob(&integrationJob{
+		integration: name,
+		description: description,
+		run:         run,
+	})
+}
+
+func (b *Bot) executeIntegrationJob(job *integrationJob) {
+	job.attempts++
+	err := job.run()
+	policy, status, changed := b.integrations.recordResult(job.integration, err)
+
+	if changed {
+		b.notifyIntegrationStatus(job.integration, status, err)
+	}
+
+	if err == nil {
+		return
+	}
+
+	b.logger.WithError(err).WithFields(logrus.Fields{
+		"integration": job.integration,
+		"step":        job.description,
+		"attempt":     job.attempts,
+		"policy":      policy,
+	}).Error("Optional integration failed")
+
+	switch policy {
+	case FallbackNotifyAdmin:
+		if !changed {
+			b.notifyAdminf("⚠️ %s failed: %s\n\nError: %v", job.integration, job.description, err)
+		}
+	case FallbackRetry:
+		if job.attempts >= integrationMaxAttempts {
+			b.notifyAdminf("⚠️ Giving up on %s after %d attempts: %s\n\nError: %v",
+				job.integration, job.attempts, job.description, err)
+			return
+		}
+		job.nextAttempt = time.Now().Add(integrationRetryBaseDelay << (job.attempts - 1))
+		b.integrations.enqueue(job)
+	}
+}
+
+func (b *Bot) notifyIntegrationStatus(name string, status IntegrationStatus, err error) {
+	if status == IntegrationDown {
+		b.notifyAdminf("❌ Integration %s is down. Core Q&A keeps working without it.\n\nLast error: %v", name, err)
+	} else {
+		b.notifyAdminf("✅ Integration %s has recovered.", name)
+	}
+}
+
+func (b *Bot) notifyAdminf(format string, args ...interface{}) {
+	msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf(format, args...))
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send integration notice to admin")
+	}
+}
+
+func (b *Bot) runIntegrationRetries() {
+	ticker := time.NewTicker(integrationRetryInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		for _, job := range b.integrations.dueJobs(now) {
+			b.executeIntegrationJob(job)
+		}
+	}
+}
+
+func (b *Bot) showFeatures() {
+	integrations, queued := b.integrations.Snapshot()
+
+	var text strings.Builder
+	text.WriteString("🧩 Features:\n\n✅ Core Q&A - always on\n")
+
+	if len(integrations) == 0 {
+		text.WriteString("\nNo optional integrations are enabled.")
+	} else {
+		text.WriteString("\nOptional integrations:\n")
+		for _, integration := range integrations {
+			icon := "✅"
+			switch integration.Status {
+			case IntegrationDegraded:
+				icon = "⚠️"
+			case IntegrationDown:
+				icon = "❌"
+			}
+
+			text.WriteString(fmt.Sprintf("%s %s - %s (fallback: %s)\n",
+				icon, integration.Name, integration.Status, integration.Policy))
+			if integration.Status != IntegrationHealthy {
+				text.WriteString(fmt.Sprintf("   %d failures, last error: %s\n",
+					integration.Failures, integration.LastError))
+			}
+		}
+		text.WriteString(fmt.Sprintf("\n🔁 Retry queue: %d pending", queued))
+	}
+
+	if pending := b.store.OutboxSize(); pending > 0 {
+		text.WriteString(fmt.Sprintf("\n📤 Outbox: %d message(s) waiting for delivery", pending))
+	}
+
+	msg := tgbotapi.NewMessage(b.adminID, text.String())
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send features list")
+	}
+}
@@ -0,0 +1,148 @@
+package bot
+
+import (
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const (
+	defaultSessionTTL = 24 * time.Hour
+	defaultNudgeDelay = time.Hour
+	janitorInterval   = 5 * time.Minute
+)
+
+// runJanitor keeps the in-memory state from growing for as long as the bot
+// runs. Open tickets are never touched.
+func (b *Bot) runJanitor() {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		b.mu.Lock()
+		b.cleanUpSessions(now)
+		b.nudgeStalledFlows(now)
+		b.mu.Unlock()
+	}
+}
+
+// cleanUpSessions expires the state of users idle for longer than the
+// session TTL, telling those who had a draft or flow in progress, and
+// drops urgent cooldowns that ran out and notifications of closed tickets.
+// Callers must hold b.mu.
+func (b *Bot) cleanUpSessions(now time.Time) {
+	// Users whose state predates activity tracking start their idle time now
+	track := func(userID int64) {
+		if _, tracked := b.lastActivity[userID]; !tracked {
+			b.lastActivity[userID] = now
+		}
+	}
+	for userID := range b.userStates {
+		track(userID)
+	}
+	for userID := range b.drafts {
+		track(userID)
+	}
+	for userID := range b.cvForms {
+		track(userID)
+	}
+
+	expired := 0
+	for userID, seen := range b.lastActivity {
+		if b.sessionTTL <= 0 || now.Sub(seen) < b.sessionTTL {
+			continue
+		}
+
+		state, hasState := b.userStates[userID]
+		_, hasDraft := b.drafts[userID]
+		_, hasCVForm := b.cvForms[userID]
+		unfinished := hasDraft || hasCVForm || (hasState && state != StateWelcome)
+
+		delete(b.userStates, userID)
+		delete(b.drafts, userID)
+		delete(b.cvForms, userID)
+		delete(b.lastActivity, userID)
+		delete(b.nudged, userID)
+		delete(b.verifications, userID)
+
+		if unfinished {
+			expired++
+			msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "session_expired"))
+			if _, err := b.api.Send(msg); err != nil {
+				b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send session expiry notice")
+			}
+		}
+	}
+
+	for userID, last := range b.lastUrgent {
+		if now.Sub(last) >= b.urgentCooldown {
+			delete(b.lastUrgent, userID)
+		}
+	}
+
+	for previewID, preview := range b.previews {
+		if b.sessionTTL > 0 && now.Sub(preview.createdAt) >= b.sessionTTL {
+			delete(b.previews, previewID)
+		}
+	}
+
+	for msgID, session := range b.adminMessages {
+		if b.tickets[session.TicketID] != session {
+			delete(b.adminMessages, msgID)
+		}
+	}
+
+	if expired > 0 {
+		b.logger.WithFields(logrus.Fields{
+			"expired": expired,
+			"ttl":     b.sessionTTL,
+		}).Info("Expired idle sessions")
+	}
+}
+
+// nudgeStalledFlows reminds users who started a question or CV review and
+// went silent of what to send, with a button to cancel. Each silence gets
+// one reminder: users are nudged again only after they were active since.
+// Callers must hold b.mu.
+func (b *Bot) nudgeStalledFlows(now time.Time) {
+	if b.nudgeDelay <= 0 {
+		return
+	}
+
+	for userID, state := range b.userStates {
+		var instructions string
+		switch state {
+		case StateQuestion:
+			instructions = "question_instructions"
+		case StateCVReview:
+			instructions = "cv_instructions"
+		default:
+			continue
+		}
+
+		last, tracked := b.lastActivity[userID]
+		if !tracked || now.Sub(last) < b.nudgeDelay {
+			continue
+		}
+		if nudged, exists := b.nudged[userID]; exists && nudged.Equal(last) {
+			continue
+		}
+
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_cancel"), "cancel"),
+			),
+		)
+		text := b.trMarkdown(userID, "stalled_flow_nudge") + "\n\n" + b.trMarkdown(userID, instructions)
+		msg := telegram.NewMarkdownMessage(userID, text)
+		msg.ReplyMarkup = keyboard
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send stalled flow reminder")
+			continue
+		}
+		b.nudged[userID] = last
+	}
+}
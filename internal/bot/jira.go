@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+const defaultJiraIssueType = "Task"
+
+// jiraTracker creates an issue per ticket in JIRA_PROJECT and, when the
+// ticket is answered, comments the answer and applies the first transition
+// that leads to a done status.
+type jiraTracker struct {
+	baseURL   string
+	email     string
+	apiToken  string
+	project   string
+	issueType string
+	client    *http.Client
+}
+
+func jiraFromConfig(config *Config) (*jiraTracker, error) {
+	settings := &config.Integrations.Tracker.Jira
+	tracker := &jiraTracker{
+		baseURL:   strings.TrimRight(settings.URL, "/"),
+		email:     settings.Email,
+		apiToken:  settings.APIToken,
+		project:   settings.Project,
+		issueType: settings.IssueType,
+		client:    &http.Client{Timeout: trackerHTTPTimeout},
+	}
+	if tracker.baseURL == "" || tracker.email == "" || tracker.apiToken == "" || tracker.project == "" {
+		return nil, fmt.Errorf("JIRA_URL, JIRA_EMAIL, JIRA_API_TOKEN and JIRA_PROJECT are required when TRACKER=jira")
+	}
+	if tracker.issueType == "" {
+		tracker.issueType = defaultJiraIssueType
+	}
+
+	return tracker, nil
+}
+
+func (t *jiraTracker) Name() string {
+	return "jira"
+}
+
+func (t *jiraTracker) CreateIssue(ticket storage.TicketRecord) (string, error) {
+	body := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": t.project},
+			"issuetype":   map[string]string{"name": t.issueType},
+			"summary":     trackerTitle(ticket),
+			"description": trackerDescription(ticket),
+		},
+	}
+
+	var issue struct {
+		Key string `json:"key"`
+	}
+	if err := t.do(http.MethodPost, "/rest/api/2/issue", body, &issue); err != nil {
+		return "", err
+	}
+
+	return issue.Key, nil
+}
+
+func (t *jiraTracker) CloseIssue(key, answer string) error {
+	comment := map[string]string{"body": "Answer sent:\n\n" + answer}
+	if err := t.do(http.MethodPost, "/rest/api/2/issue/"+key+"/comment", comment, nil); err != nil {
+		return err
+	}
+
+	var available struct {
+		Transitions []struct {
+			ID string `json:"id"`
+			To struct {
+				StatusCategory struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := t.do(http.MethodGet, "/rest/api/2/issue/"+key+"/transitions", nil, &available); err != nil {
+		return err
+	}
+
+	for _, transition := range available.Transitions {
+		if transition.To.StatusCategory.Key == "done" {
+			body := map[string]interface{}{"transition": map[string]string{"id": transition.ID}}
+			return t.do(http.MethodPost, "/rest/api/2/issue/"+key+"/transitions", body, nil)
+		}
+	}
+
+	return fmt.Errorf("issue %s has no transition to a done status", key)
+}
+
+func (t *jiraTracker) do(method, path string, body, result interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, t.baseURL+path, payload)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.email, t.apiToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("jira API returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,204 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+// broadcastAll targets every user in /broadcast instead of a label.
+const broadcastAll = "all"
+
+var labelPattern = regexp.MustCompile(`^[\p{L}0-9_-]{1,32}$`)
+
+// parseLabel normalizes a label such as "#Student" to "student".
+func parseLabel(value string) (string, error) {
+	label := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), "#"))
+	if !labelPattern.MatchString(label) {
+		return "", fmt.Errorf("invalid label %q, use up to 32 letters, digits, - or _", value)
+	}
+
+	return label, nil
+}
+
+// handleTagCommand labels a user for /tag <user_id> <label>, or lists the
+// labels in use for a bare /tag.
+func (b *Bot) handleTagCommand(args string) {
+	if strings.TrimSpace(args) == "" {
+		b.showLabels()
+		return
+	}
+
+	b.changeLabel(args, true)
+}
+
+// handleUntagCommand removes a label for /untag <user_id> <label>.
+func (b *Bot) handleUntagCommand(args string) {
+	b.changeLabel(args, false)
+}
+
+func (b *Bot) changeLabel(args string, add bool) {
+	reply := b.applyLabelChange(strings.Fields(args), add)
+
+	_, err := b.api.SendLong(b.adminID, reply, nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send tag command reply")
+	}
+}
+
+func (b *Bot) applyLabelChange(fields []string, add bool) string {
+	if len(fields) != 2 {
+		return "Usage: /tag <user_id> <label>, /untag <user_id> <label>\n\nLabels such as student or repeat-offender filter /sessions and /broadcast."
+	}
+	userID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return fmt.Sprintf("❌ Invalid user ID %q", fields[0])
+	}
+	label, err := parseLabel(fields[1])
+	if err != nil {
+		return fmt.Sprintf("❌ %v", err)
+	}
+	if label == broadcastAll {
+		return fmt.Sprintf("❌ %q is reserved for broadcasts to everyone", broadcastAll)
+	}
+
+	var changed bool
+	if add {
+		changed, err = b.store.TagUser(userID, label)
+	} else {
+		changed, err = b.store.UntagUser(userID, label)
+	}
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to save user label")
+		return fmt.Sprintf("❌ Failed to save label: %v", err)
+	}
+
+	user, exists := b.store.User(userID)
+	switch {
+	case !exists:
+		return fmt.Sprintf("User ID %d has never used the bot", userID)
+	case !changed && add:
+		return fmt.Sprintf("User ID %d is already tagged %s", userID, label)
+	case !changed:
+		return fmt.Sprintf("User ID %d is not tagged %s", userID, label)
+	}
+
+	return fmt.Sprintf("🏷 User ID %d: %s", userID, describeLabels(user.Labels))
+}
+
+// showLabels lists the labels in use and how many users have each.
+func (b *Bot) showLabels() {
+	counts := make(map[string]int)
+	for _, user := range b.store.Users() {
+		for _, label := range user.Labels {
+			counts[label]++
+		}
+	}
+
+	labels := make([]string, 0, len(counts))
+	for label := range counts {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	var text strings.Builder
+	if len(labels) == 0 {
+		text.WriteString("No labels yet. Tag a user with /tag <user_id> <label>")
+	} else {
+		text.WriteString("🏷 Labels:\n\n")
+		for _, label := range labels {
+			text.WriteString(fmt.Sprintf("• %s: %d user(s)\n", label, counts[label]))
+		}
+	}
+
+	_, err := b.api.SendLong(b.adminID, text.String(), nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send label list")
+	}
+}
+
+// describeLabels formats labels for the admin, e.g. "student, paid".
+func describeLabels(labels []string) string {
+	if len(labels) == 0 {
+		return "no labels"
+	}
+
+	return strings.Join(labels, ", ")
+}
+
+// userLabels returns the labels of a user.
+func (b *Bot) userLabels(userID int64) []string {
+	user, exists := b.store.User(userID)
+	if !exists {
+		return nil
+	}
+
+	return user.Labels
+}
+
+// handleBroadcastCommand announces text to every user with a label, or to
+// all users, for /broadcast <label|all> <text>. The messages go through the
+// outbox, so a large audience does not hold up the bot and Telegram errors
+// are retried.
+func (b *Bot) handleBroadcastCommand(args string) {
+	target, text, _ := strings.Cut(strings.TrimSpace(args), " ")
+	text = strings.TrimSpace(text)
+
+	var reply string
+	label, err := parseLabel(target)
+	if err != nil || text == "" {
+		reply = "Usage: /broadcast <label|all> <text>\n\nSends text to every user with the label, or to all users."
+	} else {
+		queued := b.queueBroadcast(label, text)
+		b.audit.Record(AuditBroadcast, b.adminID, logrus.Fields{
+			"label":      label,
+			"text":       text,
+			"recipients": queued,
+		})
+
+		reply = fmt.Sprintf("📣 Broadcast queued for %d user(s) tagged %s", queued, label)
+		if label == broadcastAll {
+			reply = fmt.Sprintf("📣 Broadcast queued for all %d user(s)", queued)
+		}
+	}
+
+	_, err = b.api.SendLong(b.adminID, reply, nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send broadcast reply")
+	}
+}
+
+// queueBroadcast puts text in the outbox for every user with label, or all
+// users, and returns how many there are.
+func (b *Bot) queueBroadcast(label, text string) int {
+	now := time.Now()
+	queued := 0
+	for _, user := range b.store.Users() {
+		if user.ID == b.adminID || (label != broadcastAll && !slices.Contains(user.Labels, label)) {
+			continue
+		}
+
+		_, err := b.store.EnqueueOutbox(storage.OutboxMessage{
+			ChatID:      user.ID,
+			Text:        text,
+			Broadcast:   true,
+			CreatedAt:   now,
+			NextAttempt: now,
+		})
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to queue broadcast message")
+			continue
+		}
+		queued++
+	}
+
+	return queued
+}
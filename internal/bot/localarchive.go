@@ -0,0 +1,178 @@
+package bot
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LocalArchive is a FileArchive in a local directory with a subfolder per
+// user: <dir>/<user_id>/<ticket_id>_<file name>. When a new file takes the
+// archive, or the user's subfolder, over its quota the oldest files are
+// pruned.
+type LocalArchive struct {
+	dir string
+	// quota and userQuota are in bytes, zero for no limit
+	quota     int64
+	userQuota int64
+
+	mu sync.Mutex
+}
+
+// localArchiveFromConfig reads ARCHIVE_DIR and the quotas ARCHIVE_QUOTA for
+// the whole archive and ARCHIVE_USER_QUOTA per user, in megabytes. It
+// returns nil when ARCHIVE_DIR is unset.
+func localArchiveFromConfig(config *Config) (*LocalArchive, error) {
+	dir := config.Storage.ArchiveDir
+	if dir == "" {
+		return nil, nil
+	}
+
+	quota := intOr(config.Storage.ArchiveQuota, 0)
+	userQuota := intOr(config.Storage.ArchiveUserQuota, 0)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create ARCHIVE_DIR: %w", err)
+	}
+
+	return &LocalArchive{
+		dir:       filepath.Clean(dir),
+		quota:     int64(quota) << 20,
+		userQuota: int64(userQuota) << 20,
+	}, nil
+}
+
+func (a *LocalArchive) TicketKey(userID int64, ticketID int, fileName string) string {
+	return fmt.Sprintf("%d/%d_%s", userID, ticketID, safeFileName(fileName))
+}
+
+// path returns where the file under key is stored. Keys come from the data
+// file, so they are checked to stay inside the archive.
+func (a *LocalArchive) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("invalid archive key %q", key)
+	}
+
+	return filepath.Join(a.dir, filepath.FromSlash(key)), nil
+}
+
+func (a *LocalArchive) Put(key string, data []byte) error {
+	path, err := a.path(key)
+	if err != nil {
+		return err
+	}
+	size := int64(len(data))
+	if a.quota > 0 && size > a.quota || a.userQuota > 0 && size > a.userQuota {
+		return fmt.Errorf("%s is larger than the archive quota", key)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	if err := a.prune(filepath.Dir(path), a.userQuota, path); err != nil {
+		return err
+	}
+
+	return a.prune(a.dir, a.quota, path)
+}
+
+func (a *LocalArchive) Get(key string) ([]byte, error) {
+	path, err := a.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(path)
+}
+
+func (a *LocalArchive) Delete(key string) error {
+	path, err := a.path(key)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	a.removeIfEmpty(filepath.Dir(path))
+
+	return nil
+}
+
+type archivedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// prune removes the oldest files under dir until they fit in quota, keeping
+// the file just stored. Callers must hold a.mu.
+func (a *LocalArchive) prune(dir string, quota int64, keep string) error {
+	if quota <= 0 {
+		return nil
+	}
+
+	var files []archivedFile
+	var total int64
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		files = append(files, archivedFile{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, file := range files {
+		if total <= quota {
+			break
+		}
+		if file.path == keep {
+			continue
+		}
+		if err := os.Remove(file.path); err != nil {
+			return err
+		}
+		total -= file.size
+		a.removeIfEmpty(filepath.Dir(file.path))
+	}
+
+	return nil
+}
+
+// removeIfEmpty removes the subfolder of a user without files left.
+func (a *LocalArchive) removeIfEmpty(dir string) {
+	if dir != a.dir {
+		os.Remove(dir)
+	}
+}
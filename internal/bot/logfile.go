@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	defaultLogMaxSize    = 100 // megabytes
+	defaultLogMaxBackups = 5
+)
+
+// SetupLogFile mirrors log output to LOG_FILE with rotation when the file
+// reaches LOG_MAX_SIZE megabytes and, optionally, every LOG_ROTATE_INTERVAL.
+// Rotated files are kept per LOG_MAX_BACKUPS and LOG_MAX_AGE (days).
+func SetupLogFile(logger *logrus.Logger, config *Config) {
+	path := config.Logging.File
+	if path == "" {
+		return
+	}
+
+	file := &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    intOr(config.Logging.MaxSize, defaultLogMaxSize),
+		MaxBackups: intOr(config.Logging.MaxBackups, defaultLogMaxBackups),
+		MaxAge:     intOr(config.Logging.MaxAge, 0),
+		Compress:   true,
+	}
+	logger.SetOutput(io.MultiWriter(os.Stderr, file))
+
+	if rotateInterval := durationOr(config.Logging.RotateInterval, 0); rotateInterval > 0 {
+		go func() {
+			for range time.Tick(rotateInterval) {
+				if err := file.Rotate(); err != nil {
+					logger.WithError(err).Error("Failed to rotate log file")
+				}
+			}
+		}()
+	}
+}
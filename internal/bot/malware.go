@@ -0,0 +1,240 @@
+package bot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const (
+	clamAVTimeout = 30 * time.Second
+	// clamAVChunkSize stays well below clamd's StreamMaxLength chunks
+	clamAVChunkSize = 64 << 10
+)
+
+// ClamAV scans files with a clamd daemon over its INSTREAM protocol.
+type ClamAV struct {
+	network string
+	addr    string
+}
+
+// clamAVFromConfig reads CLAMAV_ADDR, the clamd address: "host:port" for
+// TCP or the path of its unix socket, e.g. /var/run/clamav/clamd.ctl.
+// Without it uploaded files are not scanned.
+func clamAVFromConfig(config *Config) *ClamAV {
+	addr := strings.TrimSpace(config.Moderation.ClamAVAddr)
+	if addr == "" {
+		return nil
+	}
+
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return &ClamAV{network: "unix", addr: path}
+	}
+	if strings.HasPrefix(addr, "/") {
+		return &ClamAV{network: "unix", addr: addr}
+	}
+
+	return &ClamAV{network: "tcp", addr: addr}
+}
+
+// Scan sends data to clamd and returns the name of the threat it found, or
+// "" if the file is clean.
+func (c *ClamAV) Scan(data []byte) (string, error) {
+	conn, err := net.DialTimeout(c.network, c.addr, clamAVTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(clamAVTimeout)); err != nil {
+		return "", err
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return "", err
+	}
+	for start := 0; start < len(data); start += clamAVChunkSize {
+		chunk := data[start:min(start+clamAVChunkSize, len(data))]
+		if err := binary.Write(conn, binary.BigEndian, uint32(len(chunk))); err != nil {
+			return "", err
+		}
+		if _, err := conn.Write(chunk); err != nil {
+			return "", err
+		}
+	}
+	if err := binary.Write(conn, binary.BigEndian, uint32(0)); err != nil {
+		return "", err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && len(reply) == 0 {
+		return "", err
+	}
+	result := strings.TrimPrefix(string(bytes.TrimRight(reply, "\x00\n")), "stream: ")
+
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	default:
+		return "", fmt.Errorf("clamd: %s", result)
+	}
+}
+
+// rejectInfectedFile scans the document attached to the user message of
+// update before it is handled. Downloading and scanning take a while, so
+// they run in the background without b.mu and rejectInfectedFile reports
+// true: the update is taken over. If the scanner finds malware the user is
+// told why the file was not accepted and the admin is alerted; otherwise
+// the update is handled again through resumePipeline, this time without a
+// scan, unless the user left the flow or moved on in it meanwhile. Files
+// that cannot be scanned are accepted and flagged to the admin. Callers
+// must hold b.mu.
+func (b *Bot) rejectInfectedFile(update tgbotapi.Update) bool {
+	message := update.Message
+	if message == nil {
+		message = update.EditedMessage
+	}
+	if b.malwareScanner == nil || message.Document == nil || message == b.scannedMessage {
+		return false
+	}
+	document := message.Document
+	userID := message.From.ID
+
+	// What the update is handled against when it is handled again
+	state, draft, cvForm := b.userStates[userID], b.drafts[userID], b.cvForms[userID]
+	private := !isGroupChat(message.Chat)
+
+	// The update is already confirmed to Telegram, so the scan is kept on
+	// file in case a restart interrupts it
+	scan := storage.PendingScan{
+		ChatID:    message.Chat.ID,
+		MessageID: message.MessageID,
+		UserID:    userID,
+		FileName:  document.FileName,
+		StartedAt: time.Now(),
+	}
+	if err := b.store.AddPendingScan(scan); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist pending scan")
+	}
+
+	b.sendChatAction(message.Chat.ID, tgbotapi.ChatUploadDocument)
+
+	scanner := b.malwareScanner
+	b.pendingScans.Add(1)
+	go func() {
+		defer b.pendingScans.Done()
+
+		threat, err := b.scanDocument(scanner, document)
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if err := b.store.RemovePendingScan(scan.ChatID, scan.MessageID); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to forget pending scan")
+		}
+		if threat != "" && err == nil {
+			b.rejectInfected(message, threat)
+			return
+		}
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to scan uploaded file")
+			b.notifyAdminf("⚠️ %s from %s could not be scanned for malware (%v), open it with care", document.FileName, uploadSender(message), err)
+		}
+
+		if private && (b.userStates[userID] != state || b.drafts[userID] != draft || b.cvForms[userID] != cvForm) {
+			b.logger.WithFields(logrus.Fields{
+				"user_id":   userID,
+				"file_name": document.FileName,
+			}).Info("Dropped an upload whose flow changed while it was scanned")
+			return
+		}
+
+		b.scannedMessage = message
+		defer func() { b.scannedMessage = nil }()
+		b.resumePipeline(update)
+	}()
+
+	return true
+}
+
+// notifyInterruptedScans asks the senders of uploads whose scan a restart
+// interrupted to send them again.
+func (b *Bot) notifyInterruptedScans() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	scans, err := b.store.TakePendingScans()
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to load interrupted scans")
+	}
+
+	for _, scan := range scans {
+		msg := telegram.NewMarkdownMessage(scan.ChatID, b.trMarkdown(scan.UserID, "file_scan_interrupted", map[string]interface{}{
+			"FileName": scan.FileName,
+		}))
+		msg.ReplyToMessageID = scan.MessageID
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", scan.UserID).Error("Failed to send interrupted scan notice")
+		}
+	}
+}
+
+// rejectInfected tells the user their file was not accepted and flags it to
+// the admin.
+func (b *Bot) rejectInfected(message *tgbotapi.Message, threat string) {
+	userID := message.From.ID
+	document := message.Document
+
+	b.logger.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"file_name": document.FileName,
+		"threat":    threat,
+	}).Warn("Rejected an infected file")
+	b.audit.Record(AuditFileInfected, userID, logrus.Fields{
+		"username":  message.From.UserName,
+		"file_name": document.FileName,
+		"threat":    threat,
+	})
+	b.notifyAdminf("🦠 Rejected %s from %s: the virus scanner found %s", document.FileName, uploadSender(message), threat)
+
+	msg := telegram.NewMarkdownMessage(message.Chat.ID, b.trMarkdown(userID, "file_infected", map[string]interface{}{
+		"FileName": document.FileName,
+		"Threat":   threat,
+	}))
+	msg.ReplyToMessageID = message.MessageID
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send infected file notice")
+	}
+}
+
+func uploadSender(message *tgbotapi.Message) string {
+	if message.From.UserName != "" {
+		return fmt.Sprintf("@%s (ID: %d)", message.From.UserName, message.From.ID)
+	}
+
+	return fmt.Sprintf("user ID %d", message.From.ID)
+}
+
+// scanDocument downloads a document and scans it. It runs without b.mu.
+func (b *Bot) scanDocument(scanner *ClamAV, document *tgbotapi.Document) (string, error) {
+	if document.FileSize > telegram.MaxDownloadSize {
+		return "", fmt.Errorf("the file is larger than %d MB", telegram.MaxDownloadSize>>20)
+	}
+
+	data, err := b.api.DownloadFile(document.FileID)
+	if err != nil {
+		return "", err
+	}
+
+	return scanner.Scan(data)
+}
@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// menuActions are the welcome menu buttons besides the flows, with the
+// message ID of their label.
+var menuActions = map[string]string{
+	"help":     "button_help",
+	"commands": "button_commands",
+	"language": "button_language",
+}
+
+// menuButton is a welcome menu button: an action (a flow or one of
+// menuActions) or, with URL set, a link.
+type menuButton struct {
+	Action string
+	// Label replaces the translated label of an action
+	Label string
+	URL   string
+}
+
+// menuLayoutFromConfig reads MENU_LAYOUT, the welcome menu buttons with
+// rows separated by ";" and buttons by ",". A button is an action such as
+// "question", an action with its own label such as "question=🙋 Ask us",
+// or a link such as "📅 Book a call=https://cal.com/me". Without it the
+// default menu is shown.
+func (b *Bot) menuLayoutFromConfig(config *Config) ([][]menuButton, error) {
+	var layout [][]menuButton
+	for _, items := range config.Texts.Menu {
+		var row []menuButton
+		for _, item := range items {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				continue
+			}
+
+			button, err := b.parseMenuButton(item)
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, button)
+		}
+		if len(row) > 0 {
+			layout = append(layout, row)
+		}
+	}
+
+	return layout, nil
+}
+
+func (b *Bot) parseMenuButton(item string) (menuButton, error) {
+	name, value, _ := strings.Cut(item, "=")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+	if strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "tg://") {
+		if name == "" {
+			return menuButton{}, fmt.Errorf("link %q has no label", value)
+		}
+		return menuButton{Label: name, URL: value}, nil
+	}
+
+	if _, isFlow := b.flows.Flow(name); !isFlow && menuActions[name] == "" {
+		names := []string{"help", "commands", "language"}
+		for _, flow := range b.flows.Flows() {
+			names = append(names, flow.Name)
+		}
+		return menuButton{}, fmt.Errorf("unknown button %q, use one of %s or a label=https://link", name, strings.Join(names, ", "))
+	}
+
+	return menuButton{Action: name, Label: value}, nil
+}
+
+// welcomeMenuRows lays out the welcome menu: MENU_LAYOUT if set, otherwise
+// the flows two per row, then help and commands, then the language.
+func (b *Bot) welcomeMenuRows(userID int64) [][]tgbotapi.InlineKeyboardButton {
+	if len(b.menuLayout) == 0 {
+		rows := b.flowKeyboardRows(userID)
+		return append(rows,
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_help"), "help"),
+				tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_commands"), "commands"),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_language"), "language"),
+			),
+		)
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(b.menuLayout))
+	for _, layoutRow := range b.menuLayout {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, button := range layoutRow {
+			if button.URL != "" {
+				row = append(row, tgbotapi.NewInlineKeyboardButtonURL(button.Label, button.URL))
+				continue
+			}
+
+			label := button.Label
+			if label == "" {
+				labelID := menuActions[button.Action]
+				if flow, isFlow := b.flows.Flow(button.Action); isFlow {
+					labelID = flow.Button
+				}
+				label = b.tr(userID, labelID)
+			}
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, button.Action))
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
@@ -0,0 +1,331 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// UpdateHandler handles a single Telegram update. Handlers run with b.mu
+// held.
+type UpdateHandler func(update tgbotapi.Update)
+
+// Middleware wraps an UpdateHandler with a cross-cutting concern such as
+// logging or rate limiting. It may skip next to drop the update.
+type Middleware func(next UpdateHandler) UpdateHandler
+
+// chainMiddleware applies middlewares around handler; the first one is the
+// outermost and sees the update first.
+func chainMiddleware(handler UpdateHandler, middlewares ...Middleware) UpdateHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// newUpdatePipeline builds the middleware chain every update goes through
+// before it reaches the message and callback handlers.
+func (b *Bot) newUpdatePipeline() UpdateHandler {
+	return chainMiddleware(b.dispatchUpdate,
+		b.skipHandledUpdates,
+		b.traceUpdates,
+		b.recoverPanics,
+		b.countUpdates,
+		b.logUpdates,
+		b.identifySender,
+		b.rateLimitUsers,
+		b.verifyNewUsers,
+	)
+}
+
+// newResumePipeline builds the chain for updates handled again once the
+// file they came with was scanned. They were counted, audited, rate limited
+// and checked for verification when they arrived, so they are only traced,
+// guarded against panics and logged again.
+func (b *Bot) newResumePipeline() UpdateHandler {
+	return chainMiddleware(b.dispatchUpdate,
+		b.traceUpdates,
+		b.recoverPanics,
+		b.logUpdates,
+	)
+}
+
+// dispatchUpdate routes an update to its handler.
+func (b *Bot) dispatchUpdate(update tgbotapi.Update) {
+	if update.Message != nil && update.Message.From != nil {
+		b.handleMessage(update.Message)
+	} else if update.CallbackQuery != nil {
+		b.handleCallbackQuery(update.CallbackQuery)
+	} else if update.PreCheckoutQuery != nil {
+		b.handlePreCheckout(update.PreCheckoutQuery)
+	} else if edited := update.EditedMessage; edited != nil && edited.From != nil && !isGroupChat(edited.Chat) {
+		if edited.From.ID == b.adminID {
+			b.handleEditedAnswer(edited)
+		} else {
+			b.handleEditedQuestion(edited)
+		}
+	}
+}
+
+// skipHandledUpdates drops updates that were handled before a restart and
+// persists the ID of every update once it has been handled.
+func (b *Bot) skipHandledUpdates(next UpdateHandler) UpdateHandler {
+	return func(update tgbotapi.Update) {
+		if update.UpdateID <= b.store.LastUpdateID() {
+			b.logger.WithField("update_id", update.UpdateID).Debug("Skipping already handled update")
+			return
+		}
+		defer func() {
+			if err := b.store.SetLastUpdateID(update.UpdateID); err != nil {
+				b.logger.WithError(err).WithField("update_id", update.UpdateID).Error("Failed to persist last update ID")
+			}
+		}()
+
+		next(update)
+	}
+}
+
+// traceUpdates wraps each update in a tracing span and a Sentry scope.
+func (b *Bot) traceUpdates(next UpdateHandler) UpdateHandler {
+	return func(update tgbotapi.Update) {
+		defer b.startSpan("telegram.update", attribute.Int("telegram.update_id", update.UpdateID))()
+		defer withSentryScope(update)()
+
+		next(update)
+	}
+}
+
+// recoverPanics keeps a panicking handler from killing the bot: the panic is
+// logged with its stack, reported to Sentry and the admin is notified, then
+// the update loop moves on to the next update.
+func (b *Bot) recoverPanics(next UpdateHandler) UpdateHandler {
+	return func(update tgbotapi.Update) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			b.metrics.panics.Add(1)
+			reportPanic(r)
+
+			fields := logrus.Fields{
+				"update_id": update.UpdateID,
+				"kind":      updateKind(update),
+				"stack":     string(debug.Stack()),
+			}
+			sender := "unknown sender"
+			if user := update.SentFrom(); user != nil {
+				fields["user_id"] = user.ID
+				sender = fmt.Sprintf("user ID %d", user.ID)
+			}
+			b.logger.WithFields(fields).Errorf("Recovered from panic in update handler: %v", r)
+
+			b.notifyAdminf("💥 Internal error while handling an update from %s: %v\n\nThe bot keeps running; the stack trace is in the logs.", sender, r)
+		}()
+
+		next(update)
+	}
+}
+
+// updateKind names the type of an update for logs and metrics.
+func updateKind(update tgbotapi.Update) string {
+	switch {
+	case update.Message != nil:
+		return "message"
+	case update.CallbackQuery != nil:
+		return "callback_query"
+	case update.EditedMessage != nil:
+		return "edited_message"
+	case update.PreCheckoutQuery != nil:
+		return "pre_checkout_query"
+	default:
+		return "other"
+	}
+}
+
+func (b *Bot) logUpdates(next UpdateHandler) UpdateHandler {
+	return func(update tgbotapi.Update) {
+		started := time.Now()
+		next(update)
+
+		entry := b.logger.WithFields(logrus.Fields{
+			"update_id": update.UpdateID,
+			"kind":      updateKind(update),
+			"duration":  time.Since(started),
+		})
+		if user := update.SentFrom(); user != nil {
+			entry = entry.WithField("user_id", user.ID)
+		}
+		entry.Debug("Update handled")
+	}
+}
+
+// identifySender audits every interaction, remembers users and tracks when
+// users and the admin were last active. Handlers tell the admin apart by
+// user ID.
+func (b *Bot) identifySender(next UpdateHandler) UpdateHandler {
+	return func(update tgbotapi.Update) {
+		user := update.SentFrom()
+		if user == nil {
+			next(update)
+			return
+		}
+
+		isAdmin := user.ID == b.adminID
+		if isAdmin {
+			b.adminSeen = time.Now()
+		} else {
+			b.lastActivity[user.ID] = time.Now()
+			b.recordUser(user)
+		}
+
+		if message := update.Message; message != nil {
+			if isAdmin {
+				b.audit.Record(AuditAdminMessage, user.ID, logrus.Fields{"text": message.Text})
+			} else {
+				b.audit.Record(AuditUserMessage, user.ID, logrus.Fields{
+					"username":     user.UserName,
+					"text":         message.Text,
+					"has_document": message.Document != nil,
+				})
+			}
+		} else if callback := update.CallbackQuery; callback != nil {
+			event := AuditUserCallback
+			if isAdmin {
+				event = AuditAdminCallback
+			}
+			b.audit.Record(event, user.ID, logrus.Fields{
+				"username":      user.UserName,
+				"callback_data": callback.Data,
+			})
+		}
+
+		next(update)
+	}
+}
+
+const (
+	defaultUserRateLimit = 30
+	userRateWindow       = time.Minute
+	// rateWindowPruneSize is how many users' windows are kept before
+	// finished ones are forgotten
+	rateWindowPruneSize = 1000
+)
+
+type rateWindow struct {
+	start  time.Time
+	count  int
+	warned bool
+}
+
+// rateLimitUsers drops updates from users who exceed the per-minute limit
+// and tells them once per window. The admin is never limited.
+func (b *Bot) rateLimitUsers(next UpdateHandler) UpdateHandler {
+	windows := make(map[int64]*rateWindow)
+	var pruned time.Time
+
+	return func(update tgbotapi.Update) {
+		user := update.SentFrom()
+		if b.userRateLimit <= 0 || user == nil || user.ID == b.adminID || b.isReviewer(user.ID) {
+			next(update)
+			return
+		}
+
+		now := time.Now()
+		// Forget finished windows, at most once per window, so the map
+		// does not grow with every user ever seen
+		if len(windows) > rateWindowPruneSize && now.Sub(pruned) >= userRateWindow {
+			for userID, candidate := range windows {
+				if now.Sub(candidate.start) >= userRateWindow {
+					delete(windows, userID)
+				}
+			}
+			pruned = now
+		}
+
+		window, exists := windows[user.ID]
+		if !exists || now.Sub(window.start) >= userRateWindow {
+			window = &rateWindow{start: now}
+			windows[user.ID] = window
+		}
+		window.count++
+
+		if window.count <= b.userRateLimit {
+			next(update)
+			return
+		}
+
+		b.metrics.rateLimited.Add(1)
+		b.logger.WithField("user_id", user.ID).Warn("User rate limited")
+		if !window.warned {
+			window.warned = true
+			msg := telegram.NewMarkdownMessage(user.ID, b.trMarkdown(user.ID, "rate_limited"))
+			if _, err := b.api.Send(msg); err != nil {
+				b.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to send rate limit notice")
+			}
+		}
+	}
+}
+
+// updateMetrics counts handled updates for the /metrics endpoint.
+type updateMetrics struct {
+	messages    atomic.Int64
+	callbacks   atomic.Int64
+	other       atomic.Int64
+	rateLimited atomic.Int64
+	panics      atomic.Int64
+	// handlingNanos is the total time spent in handlers
+	handlingNanos atomic.Int64
+}
+
+func (b *Bot) countUpdates(next UpdateHandler) UpdateHandler {
+	return func(update tgbotapi.Update) {
+		started := time.Now()
+		defer func() {
+			b.metrics.handlingNanos.Add(int64(time.Since(started)))
+		}()
+
+		switch updateKind(update) {
+		case "message":
+			b.metrics.messages.Add(1)
+		case "callback_query":
+			b.metrics.callbacks.Add(1)
+		default:
+			b.metrics.other.Add(1)
+		}
+
+		next(update)
+	}
+}
+
+// serveMetrics writes the update counters in the Prometheus text format.
+func (b *Bot) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintln(w, "# HELP faqbot_updates_total Telegram updates received, by kind.")
+	fmt.Fprintln(w, "# TYPE faqbot_updates_total counter")
+	fmt.Fprintf(w, "faqbot_updates_total{kind=\"message\"} %d\n", b.metrics.messages.Load())
+	fmt.Fprintf(w, "faqbot_updates_total{kind=\"callback_query\"} %d\n", b.metrics.callbacks.Load())
+	fmt.Fprintf(w, "faqbot_updates_total{kind=\"other\"} %d\n", b.metrics.other.Load())
+
+	fmt.Fprintln(w, "# HELP faqbot_updates_rate_limited_total Updates dropped by the per-user rate limit.")
+	fmt.Fprintln(w, "# TYPE faqbot_updates_rate_limited_total counter")
+	fmt.Fprintf(w, "faqbot_updates_rate_limited_total %d\n", b.metrics.rateLimited.Load())
+
+	fmt.Fprintln(w, "# HELP faqbot_update_panics_total Handler panics recovered by the update pipeline.")
+	fmt.Fprintln(w, "# TYPE faqbot_update_panics_total counter")
+	fmt.Fprintf(w, "faqbot_update_panics_total %d\n", b.metrics.panics.Load())
+
+	fmt.Fprintln(w, "# HELP faqbot_update_handling_seconds_total Time spent handling updates.")
+	fmt.Fprintln(w, "# TYPE faqbot_update_handling_seconds_total counter")
+	fmt.Fprintf(w, "faqbot_update_handling_seconds_total %g\n", time.Duration(b.metrics.handlingNanos.Load()).Seconds())
+}
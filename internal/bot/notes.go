@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+// handleNoteCommand manages the admin's private notes about users:
+//
+//	/note <user_id> <text>
+//	/note <user_id>
+//	/note <user_id> clear
+func (b *Bot) handleNoteCommand(args string) {
+	idArg, text, _ := strings.Cut(strings.TrimSpace(args), " ")
+	text = strings.TrimSpace(text)
+
+	var reply string
+	userID, err := strconv.ParseInt(idArg, 10, 64)
+	switch {
+	case err != nil:
+		reply = `Usage:
+/note <user_id> <text> - add a private note, shown with every notification from the user
+/note <user_id> - list the notes
+/note <user_id> clear - delete the notes`
+
+	case text == "":
+		user, exists := b.store.User(userID)
+		if !exists || len(user.Notes) == 0 {
+			reply = fmt.Sprintf("No notes about user ID %d", userID)
+			break
+		}
+		reply = fmt.Sprintf("📝 Notes about user ID %d:\n\n%s", userID, formatUserNotes(user.Notes))
+
+	case text == "clear":
+		cleared, err := b.store.ClearUserNotes(userID)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to clear user notes")
+			reply = fmt.Sprintf("❌ Failed to delete notes: %v", err)
+			break
+		}
+		reply = fmt.Sprintf("🗑 Deleted %d note(s) about user ID %d", cleared, userID)
+
+	default:
+		added, err := b.store.AddUserNote(userID, storage.UserNote{Text: text, AddedAt: time.Now()})
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to save user note")
+			reply = fmt.Sprintf("❌ Failed to save note: %v", err)
+		} else if !added {
+			reply = fmt.Sprintf("User ID %d has never used the bot", userID)
+		} else {
+			reply = fmt.Sprintf("📝 Note saved, it will be shown with every notification from user ID %d", userID)
+		}
+	}
+
+	_, err = b.api.SendLong(b.adminID, reply, nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send note command reply")
+	}
+}
+
+// userNotesLines returns the admin's notes about a user for a ticket
+// notification, or "" without notes.
+func (b *Bot) userNotesLines(userID int64) string {
+	user, exists := b.store.User(userID)
+	if !exists || len(user.Notes) == 0 {
+		return ""
+	}
+
+	return formatUserNotes(user.Notes)
+}
+
+// formatUserNotes lists notes one per line, e.g.
+// "📝 2026-03-01: asked for a refund twice".
+func formatUserNotes(notes []storage.UserNote) string {
+	lines := make([]string, len(notes))
+	for i, note := range notes {
+		lines[i] = fmt.Sprintf("📝 %s: %s", note.AddedAt.Format("2006-01-02"), note.Text)
+	}
+
+	return strings.Join(lines, "\n")
+}
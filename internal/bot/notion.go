@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+const (
+	integrationNotion = "notion"
+
+	notionAPIBase     = "https://api.notion.com/v1"
+	notionAPIVersion  = "2022-06-28"
+	defaultReviewer   = "admin"
+	notionStatusOpen  = "Open"
+	notionStatusDone  = "Reviewed"
+	notionTextLimit   = 2000
+	notionHTTPTimeout = 15 * time.Second
+)
+
+// NotionClient pushes CV review requests into a Notion database. The database
+// needs the properties Name (title), User (text), Link (URL), Status (select)
+// and Reviewer (text).
+type NotionClient struct {
+	token      string
+	databaseID string
+	reviewer   string
+	client     *http.Client
+
+	mu    sync.Mutex
+	pages map[int]string
+}
+
+// notionClientFromConfig returns nil when NOTION_TOKEN or NOTION_DB_ID is unset.
+func notionClientFromConfig(config *Config) *NotionClient {
+	token := config.Integrations.Notion.Token
+	databaseID := config.Integrations.Notion.DBID
+	if token == "" || databaseID == "" {
+		return nil
+	}
+
+	reviewer := config.Integrations.Notion.Reviewer
+	if reviewer == "" {
+		reviewer = defaultReviewer
+	}
+
+	return &NotionClient{
+		token:      token,
+		databaseID: databaseID,
+		reviewer:   reviewer,
+		client:     &http.Client{Timeout: notionHTTPTimeout},
+		pages:      make(map[int]string),
+	}
+}
+
+func notionText(content string) []map[string]interface{} {
+	if len([]rune(content)) > notionTextLimit {
+		content = string([]rune(content)[:notionTextLimit])
+	}
+	return []map[string]interface{}{{"text": map[string]string{"content": content}}}
+}
+
+// CreateCVReview adds a page for a new CV review request.
+func (n *NotionClient) CreateCVReview(ticket storage.TicketRecord) error {
+	user := fmt.Sprintf("%d", ticket.UserID)
+	if ticket.Username != "" {
+		user = "@" + ticket.Username
+	}
+
+	properties := map[string]interface{}{
+		"Name":     map[string]interface{}{"title": notionText(fmt.Sprintf("CV review #%d", ticket.ID))},
+		"User":     map[string]interface{}{"rich_text": notionText(user)},
+		"Status":   map[string]interface{}{"select": map[string]string{"name": notionStatusOpen}},
+		"Reviewer": map[string]interface{}{"rich_text": notionText("")},
+	}
+	if link := cvLink(ticket.Question); link != "" {
+		properties["Link"] = map[string]interface{}{"url": link}
+	}
+
+	body := map[string]interface{}{
+		"parent":     map[string]string{"database_id": n.databaseID},
+		"properties": properties,
+	}
+
+	var page struct {
+		ID string `json:"id"`
+	}
+	if err := n.do(http.MethodPost, notionAPIBase+"/pages", body, &page); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	n.pages[ticket.ID] = page.ID
+	n.mu.Unlock()
+
+	return nil
+}
+
+// MarkReviewed sets the status and reviewer of a previously created page.
+func (n *NotionClient) MarkReviewed(ticketID int) error {
+	n.mu.Lock()
+	pageID, exists := n.pages[ticketID]
+	n.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("no Notion page recorded for ticket #%d", ticketID)
+	}
+
+	body := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"Status":   map[string]interface{}{"select": map[string]string{"name": notionStatusDone}},
+			"Reviewer": map[string]interface{}{"rich_text": notionText(n.reviewer)},
+		},
+	}
+
+	return n.do(http.MethodPatch, notionAPIBase+"/pages/"+pageID, body, nil)
+}
+
+func (n *NotionClient) do(method, endpoint string, body, result interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+n.token)
+	req.Header.Set("Notion-Version", notionAPIVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notion API returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// cvLink returns the first URL found in a CV review request.
+func cvLink(question string) string {
+	for _, field := range strings.Fields(question) {
+		if strings.HasPrefix(field, "http://") || strings.HasPrefix(field, "https://") {
+			return field
+		}
+	}
+	return ""
+}
+
+func (b *Bot) syncCVReviewToNotion(ticket storage.TicketRecord) {
+	if b.notion == nil || ticket.Kind != string(StateCVReview) {
+		return
+	}
+
+	b.runIntegration(integrationNotion, fmt.Sprintf("create Notion page for CV review #%d", ticket.ID), func() error {
+		return b.notion.CreateCVReview(ticket)
+	})
+}
+
+func (b *Bot) markCVReviewedInNotion(session *UserSession) {
+	if b.notion == nil || session.State != StateCVReview {
+		return
+	}
+
+	ticketID := session.TicketID
+	b.runIntegration(integrationNotion, fmt.Sprintf("mark CV review #%d reviewed in Notion", ticketID), func() error {
+		return b.notion.MarkReviewed(ticketID)
+	})
+}
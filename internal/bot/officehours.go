@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const defaultOfficeDays = "mon-fri"
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// OfficeHours is the admin's working schedule. Questions that arrive outside
+// of it get an auto-reply saying when to expect an answer.
+type OfficeHours struct {
+	start    time.Duration // since midnight
+	end      time.Duration // since midnight
+	days     [7]bool       // indexed by time.Weekday
+	location *time.Location
+}
+
+// officeHoursFromConfig reads OFFICE_HOURS ("09:00-18:00"), OFFICE_DAYS
+// ("mon-fri" or "mon,wed,fri") and OFFICE_TIMEZONE (an IANA name such as
+// "Asia/Tashkent", default local time). It returns nil when OFFICE_HOURS is
+// unset, i.e. the bot is always "open".
+func officeHoursFromConfig(config *Config) (*OfficeHours, error) {
+	value := strings.TrimSpace(config.OfficeHours.Hours)
+	if value == "" {
+		return nil, nil
+	}
+
+	from, to, found := strings.Cut(value, "-")
+	if !found {
+		return nil, fmt.Errorf("invalid OFFICE_HOURS %q, expected HH:MM-HH:MM", value)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OFFICE_HOURS: %w", err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OFFICE_HOURS: %w", err)
+	}
+	if end <= start {
+		return nil, fmt.Errorf("invalid OFFICE_HOURS %q, the end must be after the start", value)
+	}
+
+	days := config.OfficeHours.Days
+	if days == "" {
+		days = defaultOfficeDays
+	}
+	hours := &OfficeHours{start: start, end: end, location: time.Local}
+	hours.days, err = parseWeekdays(days)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OFFICE_DAYS: %w", err)
+	}
+
+	if zone := config.OfficeHours.Timezone; zone != "" {
+		hours.location, err = time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OFFICE_TIMEZONE: %w", err)
+		}
+	}
+
+	return hours, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	clock, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not HH:MM", value)
+	}
+
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+func weekdayIndex(name string) (int, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, weekday := range weekdayNames {
+		if name == weekday {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown day %q, use mon, tue, ... sun", name)
+}
+
+// parseWeekdays accepts ranges and lists, e.g. "mon-fri" or "mon-thu,sat",
+// and returns the days indexed by time.Weekday. A range may wrap around
+// the week ("sat-mon").
+func parseWeekdays(value string) ([7]bool, error) {
+	var days [7]bool
+	for _, part := range strings.Split(value, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := weekdayIndex(from)
+		if err != nil {
+			return days, err
+		}
+		last := first
+		if isRange {
+			if last, err = weekdayIndex(to); err != nil {
+				return days, err
+			}
+		}
+
+		for day := first; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+func (o *OfficeHours) IsOpen(t time.Time) bool {
+	t = t.In(o.location)
+	if !o.days[t.Weekday()] {
+		return false
+	}
+
+	elapsed := t.Sub(startOfDay(t))
+	return elapsed >= o.start && elapsed < o.end
+}
+
+// NextOpening returns the start of the next working period after t, or t
+// itself while the office is open.
+func (o *OfficeHours) NextOpening(t time.Time) time.Time {
+	t = t.In(o.location)
+	if o.IsOpen(t) {
+		return t
+	}
+
+	for day := 0; day <= 7; day++ {
+		date := startOfDay(t).AddDate(0, 0, day)
+		opening := date.Add(o.start)
+		if o.days[date.Weekday()] && opening.After(t) {
+			return opening
+		}
+	}
+
+	return t
+}
+
+// String describes the schedule for users, e.g. "09:00-18:00 (Asia/Tashkent)".
+func (o *OfficeHours) String() string {
+	midnight := startOfDay(time.Now().In(o.location))
+	return fmt.Sprintf("%s-%s (%s)", midnight.Add(o.start).Format("15:04"),
+		midnight.Add(o.end).Format("15:04"), o.location)
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// afterHoursNotice tells the user when to expect an answer to a ticket
+// created outside of office hours.
+func (b *Bot) afterHoursNotice(userID int64, createdAt time.Time) string {
+	opening := b.officeHours.NextOpening(createdAt)
+	return b.trMarkdown(userID, "after_hours_notice", map[string]interface{}{
+		"Hours":   b.officeHours.String(),
+		"Opening": opening.Format("02.01.2006 15:04"),
+	})
+}
@@ -0,0 +1,202 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const (
+	outboxCheckInterval  = 10 * time.Second
+	outboxRetryBaseDelay = 30 * time.Second
+	outboxMaxRetryDelay  = time.Hour
+	outboxMaxAttempts    = 20
+	// outboxClaimLease outlasts any attempt, including the client's own
+	// retries, and only matters if the bot stopped in the middle of one
+	outboxClaimLease = 5 * time.Minute
+)
+
+// deliverReliably sends MarkdownV2 text to chatID through the persistent
+// outbox, as a reply to message replyTo if not zero. The message is stored
+// before the first attempt, so a Telegram outage or a restart only delays
+// it. It reports whether delivery was postponed to the outbox sender by
+// returning the ID of the queued message, zero once it is delivered; err
+// is set only when the message could not be delivered at all (e.g. the
+// user blocked the bot), in which case it is dropped.
+func (b *Bot) deliverReliably(chatID int64, replyTo, ticketID int, text string, markup *tgbotapi.InlineKeyboardMarkup) (int, error) {
+	now := time.Now()
+	message := storage.OutboxMessage{
+		ChatID:      chatID,
+		TicketID:    ticketID,
+		Text:        text,
+		ParseMode:   tgbotapi.ModeMarkdownV2,
+		ReplyTo:     replyTo,
+		Markup:      markup,
+		CreatedAt:   now,
+		NextAttempt: now,
+		// The sender only picks this up if the attempt below never finishes
+		ClaimedUntil: now.Add(outboxClaimLease),
+	}
+
+	id, err := b.store.EnqueueOutbox(message)
+	if err != nil {
+		b.logger.WithError(err).WithField("chat_id", chatID).Error("Failed to persist outbox message")
+	}
+	message.ID = id
+
+	delivered, err := b.attemptOutbox(&message)
+	if delivered || err != nil {
+		return 0, err
+	}
+
+	return message.ID, nil
+}
+
+// trackQueuedDelivery remembers the admin's confirmation of a queued
+// message, so it can be updated with the outcome of the delivery.
+func (b *Bot) trackQueuedDelivery(outboxID int, confirmation tgbotapi.Message) {
+	if err := b.store.SetOutboxStatusMessage(outboxID, confirmation.MessageID); err != nil {
+		b.logger.WithError(err).WithField("outbox_id", outboxID).Error("Failed to persist delivery status message")
+	}
+}
+
+// attemptOutbox sends the remaining parts of message and either removes it
+// from the outbox or schedules the next attempt.
+func (b *Bot) attemptOutbox(message *storage.OutboxMessage) (bool, error) {
+	parts := telegram.SplitMessage(message.Text, telegram.MessageLimit)
+	if message.ParseMode == tgbotapi.ModeMarkdownV2 {
+		parts = telegram.SplitMarkdown(message.Text, telegram.MessageLimit)
+	}
+	for message.PartsSent < len(parts) {
+		msg := tgbotapi.NewMessage(message.ChatID, parts[message.PartsSent])
+		msg.ParseMode = message.ParseMode
+		if message.PartsSent == 0 {
+			msg.ReplyToMessageID = message.ReplyTo
+		}
+		if message.PartsSent == len(parts)-1 && message.Markup != nil {
+			msg.ReplyMarkup = *message.Markup
+		}
+
+		sent, err := b.api.Send(msg)
+		if err != nil {
+			return false, b.postponeOutbox(message, err)
+		}
+		message.PartsSent++
+		message.MessageIDs = append(message.MessageIDs, sent.MessageID)
+	}
+
+	if message.TicketID != 0 {
+		if err := b.store.AddDeliveredMessages(message.TicketID, message.MessageIDs); err != nil {
+			b.logger.WithError(err).WithField("ticket_id", message.TicketID).Error("Failed to persist delivered messages")
+		}
+	}
+
+	if message.ID != 0 {
+		if err := b.store.RemoveOutbox(message.ID); err != nil {
+			b.logger.WithError(err).WithField("outbox_id", message.ID).Error("Failed to remove delivered outbox message")
+		}
+	}
+
+	return true, nil
+}
+
+// postponeOutbox schedules another attempt with exponential backoff, or
+// right after the pause of a rate limited chat, which does not count as an
+// attempt. It returns the send error when the message is dropped instead:
+// the error is permanent, the attempts are used up, or the message was
+// never persisted.
+func (b *Bot) postponeOutbox(message *storage.OutboxMessage, sendErr error) error {
+	wait, rateLimited := telegram.RetryAfter(sendErr)
+	if !rateLimited {
+		message.Attempts++
+	}
+	message.LastError = sendErr.Error()
+
+	if message.ID == 0 {
+		return sendErr
+	}
+
+	if telegram.PermanentError(sendErr) || message.Attempts >= outboxMaxAttempts {
+		if err := b.store.RemoveOutbox(message.ID); err != nil {
+			b.logger.WithError(err).WithField("outbox_id", message.ID).Error("Failed to remove outbox message")
+		}
+		return sendErr
+	}
+
+	message.ClaimedUntil = time.Time{}
+	message.NextAttempt = time.Now().Add(min(outboxRetryBaseDelay<<max(message.Attempts-1, 0), outboxMaxRetryDelay))
+	if rateLimited {
+		message.NextAttempt = time.Now().Add(wait)
+	}
+	if err := b.store.UpdateOutbox(*message); err != nil {
+		b.logger.WithError(err).WithField("outbox_id", message.ID).Error("Failed to reschedule outbox message")
+	}
+
+	b.logger.WithError(sendErr).WithFields(logrus.Fields{
+		"outbox_id":    message.ID,
+		"chat_id":      message.ChatID,
+		"attempt":      message.Attempts,
+		"next_attempt": message.NextAttempt,
+	}).Warn("Outbox message postponed")
+
+	return nil
+}
+
+// runOutbox delivers queued messages, including those left over from before
+// a restart.
+func (b *Bot) runOutbox() {
+	ticker := time.NewTicker(outboxCheckInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		b.flushOutbox(now)
+	}
+}
+
+// flushOutbox makes another attempt at the queued messages due at now,
+// claiming them first since it runs alongside the handlers' own attempts.
+func (b *Bot) flushOutbox(now time.Time) {
+	due, err := b.store.ClaimDueOutbox(now, outboxClaimLease)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to claim outbox messages")
+	}
+	for _, message := range due {
+		delivered, err := b.attemptOutbox(&message)
+		if delivered {
+			b.logger.WithFields(logrus.Fields{
+				"outbox_id": message.ID,
+				"chat_id":   message.ChatID,
+				"attempts":  message.Attempts + 1,
+			}).Info("Outbox message delivered")
+			if message.StatusMessageID != 0 {
+				b.reportDelivery(message, fmt.Sprintf("✅ Queued message for ticket #%d delivered to user ID %d after %d attempt(s)",
+					message.TicketID, message.ChatID, message.Attempts+1))
+			}
+		}
+		if err != nil && message.Broadcast {
+			b.logger.WithError(err).WithField("chat_id", message.ChatID).Warn("Gave up delivering a broadcast message")
+		} else if err != nil {
+			b.reportDelivery(message, fmt.Sprintf("❌ Message for ticket #%d was not delivered to user ID %d after %d attempt(s): %s",
+				message.TicketID, message.ChatID, message.Attempts, telegram.DeliveryFailure(err)))
+		}
+	}
+}
+
+// reportDelivery tells the admin how a queued message ended, by updating
+// the confirmation they got when it was queued if there is one.
+func (b *Bot) reportDelivery(message storage.OutboxMessage, status string) {
+	if message.StatusMessageID == 0 {
+		b.notifyAdminf("%s", status)
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(b.adminID, message.StatusMessageID, status)
+	if _, err := b.api.Request(edit); err != nil {
+		b.logger.WithError(err).WithField("outbox_id", message.ID).Error("Failed to update delivery status")
+	}
+}
@@ -0,0 +1,285 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const (
+	defaultPaymentCurrency = "USD"
+	priorityPayloadPrefix  = "priority_cv:"
+)
+
+// PaymentStatus tracks a paid priority CV review from the invoice to the
+// ticket.
+type PaymentStatus string
+
+const (
+	PaymentPending PaymentStatus = "pending"
+	PaymentPaid    PaymentStatus = "paid"
+)
+
+// PriorityReview is the paid "priority CV review" tier sold through
+// Telegram Payments or Stripe. Price is in the smallest unit of Currency,
+// e.g. cents.
+type PriorityReview struct {
+	ProviderToken string
+	Price         int
+	Currency      string
+}
+
+// priorityReviewFromConfig reads PAYMENT_PROVIDER_TOKEN (from @BotFather's
+// Payments section), PRIORITY_REVIEW_PRICE in the smallest currency unit and
+// PAYMENT_CURRENCY (default USD). It returns nil when neither Telegram
+// Payments nor Stripe (STRIPE_SECRET_KEY) is set up, or when payments are
+// only used for subscriptions, i.e. every CV review is free.
+func priorityReviewFromConfig(config *Config) (*PriorityReview, error) {
+	payments := &config.Integrations.Payments
+	token := payments.ProviderToken
+	if token == "" && payments.Stripe.SecretKey == "" {
+		return nil, nil
+	}
+	if payments.PriorityReviewPrice == nil && payments.Subscription.Price != nil {
+		return nil, nil
+	}
+
+	price := intOr(payments.PriorityReviewPrice, 0)
+	if price <= 0 {
+		return nil, fmt.Errorf("PRIORITY_REVIEW_PRICE must be a positive amount in the smallest currency unit (e.g. 1500 for 15.00), got %d", price)
+	}
+
+	currency := strings.ToUpper(payments.Currency)
+	if currency == "" {
+		currency = defaultPaymentCurrency
+	}
+
+	return &PriorityReview{ProviderToken: token, Price: price, Currency: currency}, nil
+}
+
+// String formats the price, e.g. "15.00 USD".
+func (p *PriorityReview) String() string {
+	return formatPrice(p.Price, p.Currency)
+}
+
+// formatPrice formats an amount in the smallest currency unit.
+func formatPrice(amount int, currency string) string {
+	return fmt.Sprintf("%d.%02d %s", amount/100, amount%100, currency)
+}
+
+// priorityPrice is what the user pays for a priority review, after the
+// discount of a redeemed promo code.
+func (b *Bot) priorityPrice(userID int64) int {
+	price := b.priorityReview.Price
+	if user, exists := b.store.User(userID); exists && user.PromoDiscount > 0 {
+		price = price * (100 - user.PromoDiscount) / 100
+	}
+
+	return price
+}
+
+// offerPriorityReview lets the user pick the free or the paid review tier
+// once the CV intake is done. Without payments, or when the user already
+// paid for a review they did not submit, it goes on to the CV instructions.
+func (b *Bot) offerPriorityReview(userID int64) {
+	if b.priorityReview == nil {
+		b.showCVInstructions(userID)
+		return
+	}
+	if _, paid := b.store.UnusedPayment(userID); paid {
+		if intake, exists := b.cvForms[userID]; exists {
+			intake.Payment = PaymentPaid
+		}
+		b.showCVInstructions(userID)
+		return
+	}
+
+	price := formatPrice(b.priorityPrice(userID), b.priorityReview.Currency)
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_standard_review"), "cv_tier:standard"),
+		),
+	}
+	if b.priorityReview.ProviderToken != "" {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_priority_review", map[string]interface{}{"Price": price}), "cv_tier:priority"),
+		))
+	}
+	if b.stripe != nil {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_priority_review_stripe", map[string]interface{}{"Price": price}), "cv_tier:stripe"),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_cancel"), "cancel"),
+	))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "cv_tier_choice", map[string]interface{}{"Price": price}))
+	msg.ReplyMarkup = keyboard
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send CV review tiers")
+		return
+	}
+
+	b.userStates[userID] = StateCVTier
+}
+
+func (b *Bot) handleCVTierCallback(callback *tgbotapi.CallbackQuery) {
+	userID := callback.From.ID
+
+	intake, exists := b.cvForms[userID]
+	if !exists || b.userStates[userID] != StateCVTier {
+		return
+	}
+
+	switch {
+	case callback.Data == "cv_tier:priority" && b.priorityReview != nil && b.priorityReview.ProviderToken != "":
+		b.sendPriorityInvoice(userID, intake)
+	case callback.Data == "cv_tier:stripe" && b.priorityReview != nil && b.stripe != nil:
+		b.sendStripeCheckout(userID, intake)
+	default:
+		b.showCVInstructions(userID)
+	}
+}
+
+// newPriorityPayload identifies one checkout of a priority review, so
+// payments for an older one are recognized.
+func newPriorityPayload(userID int64) string {
+	return fmt.Sprintf("%s%d:%d", priorityPayloadPrefix, userID, time.Now().UnixNano())
+}
+
+// priorityPayloadUser returns the user a priority review payload belongs to.
+func priorityPayloadUser(payload string) (int64, bool) {
+	rest, found := strings.CutPrefix(payload, priorityPayloadPrefix)
+	if !found {
+		return 0, false
+	}
+
+	userID, _, _ := strings.Cut(rest, ":")
+	id, err := strconv.ParseInt(userID, 10, 64)
+	return id, err == nil
+}
+
+// sendPriorityInvoice sends the Telegram invoice for a priority review. The
+// payload ties the payment to this invoice, so an older one is refused at
+// checkout.
+func (b *Bot) sendPriorityInvoice(userID int64, intake *CVIntake) {
+	payload := newPriorityPayload(userID)
+	price := b.priorityPrice(userID)
+	prices := []tgbotapi.LabeledPrice{{Label: b.tr(userID, "invoice_title"), Amount: price}}
+
+	invoice := tgbotapi.NewInvoice(userID, b.tr(userID, "invoice_title"), b.tr(userID, "invoice_description"),
+		payload, b.priorityReview.ProviderToken, "", b.priorityReview.Currency, prices)
+	// A nil slice is sent as null, which Telegram rejects
+	invoice.SuggestedTipAmounts = []int{}
+
+	_, err := b.api.Send(invoice)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send priority review invoice")
+		return
+	}
+
+	intake.Payment = PaymentPending
+	intake.invoicePayload = payload
+	intake.price = price
+}
+
+// handlePreCheckout confirms a checkout only for the latest invoice of a CV
+// review the user is still in, at the price it was sent with, or for the
+// latest subscription invoice.
+func (b *Bot) handlePreCheckout(query *tgbotapi.PreCheckoutQuery) {
+	userID := query.From.ID
+
+	var valid bool
+	if strings.HasPrefix(query.InvoicePayload, subscriptionPayloadPrefix) {
+		valid = b.validSubscriptionCheckout(query)
+	} else {
+		intake, exists := b.cvForms[userID]
+		valid = exists && intake.Payment == PaymentPending && intake.invoicePayload == query.InvoicePayload &&
+			b.priorityReview != nil && query.TotalAmount == intake.price && query.Currency == b.priorityReview.Currency
+	}
+
+	answer := tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: query.ID, OK: valid}
+	if !valid {
+		answer.ErrorMessage = b.tr(userID, "payment_expired")
+	}
+
+	_, err := b.api.Request(answer)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to answer pre-checkout query")
+	}
+}
+
+// handleSuccessfulPayment records a payment made through a Telegram
+// invoice, for a priority review or a month of the subscription.
+func (b *Bot) handleSuccessfulPayment(message *tgbotapi.Message) {
+	payment := message.SuccessfulPayment
+	record := storage.PaymentRecord{
+		ChargeID: payment.TelegramPaymentChargeID,
+		Provider: "telegram",
+		UserID:   message.From.ID,
+		Amount:   payment.TotalAmount,
+		Currency: payment.Currency,
+		PaidAt:   time.Now(),
+	}
+
+	if strings.HasPrefix(payment.InvoicePayload, subscriptionPayloadPrefix) {
+		b.confirmSubscription(record, "", time.Time{})
+		return
+	}
+	b.confirmPayment(payment.InvoicePayload, record)
+}
+
+// confirmPayment records a completed payment and, if the user is still at
+// the checkout it belongs to, moves on to the CV instructions. The payment
+// is kept in the store until a review is submitted, so it survives restarts
+// and expired sessions and otherwise gives the next CV review priority.
+// Callers must hold b.mu.
+func (b *Bot) confirmPayment(payload string, payment storage.PaymentRecord) {
+	userID := payment.UserID
+
+	added, err := b.store.AddPayment(payment)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist payment")
+	}
+	if !added && err == nil {
+		return
+	}
+	// The checkout was priced with the user's promo discount, if any
+	if err := b.store.ClearPromoDiscount(userID); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to clear promo discount")
+	}
+	b.audit.Record(AuditPaymentReceived, userID, logrus.Fields{
+		"provider":  payment.Provider,
+		"charge_id": payment.ChargeID,
+		"amount":    payment.Amount,
+		"currency":  payment.Currency,
+	})
+
+	intake, exists := b.cvForms[userID]
+	if !exists || intake.invoicePayload != payload {
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "payment_received_later"))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send payment confirmation")
+		}
+		return
+	}
+	intake.Payment = PaymentPaid
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "payment_received"))
+	_, err = b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send payment confirmation")
+	}
+
+	b.showCVInstructions(userID)
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[\p{L}0-9._%+-]+@[\p{L}0-9.-]+\.\p{L}{2,}`)
+	// phonePattern finds candidates, phoneNumber decides by the number of
+	// digits so years and date ranges stay
+	phonePattern = regexp.MustCompile(`\+?\(?\d[\d \t().-]{6,}\d`)
+	// addressLine matches a labelled address up to the end of the line
+	addressLine = regexp.MustCompile(`(?im)^(\s*(?:home\s+)?(?:address|адрес|manzil)\s*:).*$`)
+	// streetAddress matches a house number and street, e.g. "12 Baker
+	// Street", "ул. Ленина, 5" or "Amir Temur ko'chasi 15"
+	streetAddress = regexp.MustCompile(`(?i)\b\d+[a-z]?\s+(?:[\p{L}'-]+\s+){1,3}(?:street|st|avenue|ave|road|rd|boulevard|blvd|lane|ln|drive|dr|way|court|ct)\b\.?` +
+		`|(?:ул\.|улица|пр\.|проспект|пер\.|переулок)\s*[\p{L}'. -]+,?\s*(?:д\.\s*)?\d+[\p{L}]?(?:\s*,?\s*(?:кв\.|кв)\s*\d+)?` +
+		`|\b(?:[\p{L}']+\s+){1,3}(?:ko'chasi|ko‘chasi|mahallasi|tor ko'chasi)\s*,?\s*\d+[\p{L}]?`)
+)
+
+// redactPII replaces email addresses, phone numbers and street addresses
+// in text and returns the number of replacements. It errs on the side of
+// removing too much: anything that looks like a phone number is removed.
+func redactPII(text string) (string, int) {
+	count := 0
+	text = emailPattern.ReplaceAllStringFunc(text, func(string) string {
+		count++
+		return "[email removed]"
+	})
+	text = phonePattern.ReplaceAllStringFunc(text, func(match string) string {
+		if !phoneNumber(match) {
+			return match
+		}
+		count++
+		return "[phone removed]"
+	})
+	text = addressLine.ReplaceAllStringFunc(text, func(match string) string {
+		count++
+		return addressLine.ReplaceAllString(match, "$1 [address removed]")
+	})
+	text = streetAddress.ReplaceAllStringFunc(text, func(string) string {
+		count++
+		return "[address removed]"
+	})
+
+	return text, count
+}
+
+// phoneNumber reports whether a phonePattern match has as many digits as a
+// phone number, 9 to 15.
+func phoneNumber(match string) bool {
+	digits := 0
+	for _, r := range match {
+		if unicode.IsDigit(r) {
+			digits++
+		}
+	}
+
+	return digits >= 9 && digits <= 15
+}
+
+// anonymizesAuthor reports whether reviewers see a ticket anonymized: with
+// ANONYMIZE_CVS, CV review tickets go by their number only, without the
+// username or ID of their author.
+func (b *Bot) anonymizesAuthor(session *UserSession) bool {
+	return b.anonymizeCVs && session.State == StateCVReview
+}
+
+// reviewerText is ticket text as shown to reviewers: with ANONYMIZE_CVS,
+// contact details in CV review tickets are removed.
+func (b *Bot) reviewerText(session *UserSession, text string) string {
+	if !b.anonymizesAuthor(session) {
+		return text
+	}
+
+	redacted, _ := redactPII(text)
+	return redacted
+}
+
+// shareAnonymizedCV sends the uploaded CV of a ticket to a reviewer chat as
+// text with contact details removed. It returns a note for the admin when
+// the CV could not be shared, "" otherwise.
+func (b *Bot) shareAnonymizedCV(chatID int64, session *UserSession) string {
+	intake := session.CVIntake
+	if intake == nil || intake.CVFileID == "" {
+		return ""
+	}
+
+	data, err := b.api.DownloadFile(intake.CVFileID)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", session.TicketID).Error("Failed to download CV")
+		return fmt.Sprintf("⚠️ The CV of ticket #%d could not be downloaded to anonymize it, it was not shared", session.TicketID)
+	}
+	text, err := extractCVText(intake.CVFileName, data)
+	if err != nil {
+		return fmt.Sprintf("⚠️ The CV of ticket #%d could not be anonymized (%v), it was not shared", session.TicketID, err)
+	}
+	redacted, count := redactPII(text)
+
+	document := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("cv_ticket_%d_anonymized.txt", session.TicketID),
+		Bytes: []byte(redacted),
+	})
+	document.Caption = fmt.Sprintf("📄 CV of ticket #%d as text, %d contact detail(s) removed. Only the admin has the original file.", session.TicketID, count)
+	b.sendChatAction(chatID, tgbotapi.ChatUploadDocument)
+	if _, err := b.api.Send(document); err != nil {
+		b.logger.WithError(err).WithField("ticket_id", session.TicketID).Error("Failed to share anonymized CV")
+		return fmt.Sprintf("⚠️ The anonymized CV of ticket #%d could not be sent: %v", session.TicketID, err)
+	}
+
+	return ""
+}
@@ -0,0 +1,54 @@
+package bot
+
+import "fmt"
+
+// pinTicket handles /pin <ticket_id>: the open ticket is listed first in
+// /sessions with a 📌, e.g. for an ongoing complex case.
+func (b *Bot) pinTicket(args string) {
+	b.sendPinReply(b.setPinned(args, true))
+}
+
+// unpinTicket handles /unpin <ticket_id>.
+func (b *Bot) unpinTicket(args string) {
+	b.sendPinReply(b.setPinned(args, false))
+}
+
+func (b *Bot) setPinned(args string, pinned bool) string {
+	command := "/unpin"
+	if pinned {
+		command = "/pin"
+	}
+
+	ticketID, err := parseTicketID(args)
+	if err != nil {
+		return fmt.Sprintf("❌ %v\n\nUsage: %s <ticket_id>", err, command)
+	}
+
+	session := b.findOpenTicket(fmt.Sprintf("#%d", ticketID))
+	switch {
+	case session == nil:
+		return fmt.Sprintf("❌ No open ticket #%d, only open tickets can be pinned", ticketID)
+	case session.Pinned == pinned && pinned:
+		return fmt.Sprintf("Ticket #%d is already pinned", ticketID)
+	case session.Pinned == pinned:
+		return fmt.Sprintf("Ticket #%d is not pinned", ticketID)
+	}
+
+	if err := b.store.SetTicketPinned(ticketID, pinned); err != nil {
+		b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to persist pinned ticket")
+		return fmt.Sprintf("❌ Failed to save ticket #%d: %v", ticketID, err)
+	}
+	session.Pinned = pinned
+
+	if pinned {
+		return fmt.Sprintf("📌 Ticket #%d pinned to the top of /sessions", ticketID)
+	}
+	return fmt.Sprintf("Ticket #%d unpinned", ticketID)
+}
+
+func (b *Bot) sendPinReply(text string) {
+	_, err := b.api.SendLong(b.adminID, text, nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send pin reply")
+	}
+}
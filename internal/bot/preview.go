@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// answerPreview is a formatted answer waiting for the admin to confirm it.
+type answerPreview struct {
+	session *UserSession
+	answer  string
+	// messageID is the admin's message the answer came from
+	messageID int
+	createdAt time.Time
+}
+
+// formatAnswer converts the markup of an answer written by the admin, such
+// as **bold**, to MarkdownV2.
+func formatAnswer(answer string) markdown {
+	return markdown(telegram.FormatMarkdown(answer))
+}
+
+// hasMarkup reports whether an answer uses formatting.
+func hasMarkup(answer string) bool {
+	return telegram.FormatMarkdown(answer) != telegram.EscapeMarkdown(answer)
+}
+
+// answerTicket delivers the answer the admin sent as message messageID, if
+// not zero, whose later edits then reach the user too. Formatted answers
+// are previewed first and only delivered once the admin confirms them, so
+// they reach the user as intended.
+func (b *Bot) answerTicket(session *UserSession, answer string, messageID int) {
+	if !hasMarkup(answer) {
+		b.deliverAnswer(session, answer)
+		if !session.AnsweredAt.IsZero() && messageID != 0 {
+			b.rememberAnswerMessage(session.TicketID, messageID)
+		}
+		return
+	}
+
+	header := telegram.EscapeMarkdown(fmt.Sprintf("👀 Preview of the answer to ticket #%d:", session.TicketID))
+	msg := telegram.NewMarkdownMessage(b.adminID, header+"\n\n"+string(formatAnswer(answer)))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Send", "preview:send"),
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Discard", "preview:discard"),
+		),
+	)
+
+	sent, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", session.TicketID).Error("Failed to send answer preview")
+		errorMsg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("❌ The answer could not be rendered, check its formatting: %v", err))
+		b.api.Send(errorMsg)
+		return
+	}
+
+	b.previews[sent.MessageID] = &answerPreview{
+		session:   session,
+		answer:    answer,
+		messageID: messageID,
+		createdAt: time.Now(),
+	}
+}
+
+// handlePreviewCallback sends or discards a previewed answer.
+func (b *Bot) handlePreviewCallback(callback *tgbotapi.CallbackQuery) {
+	if callback.Message == nil {
+		return
+	}
+
+	previewID := callback.Message.MessageID
+	preview, exists := b.previews[previewID]
+	if !exists {
+		b.editPreview(callback, "This preview has expired, send the answer again")
+		return
+	}
+	delete(b.previews, previewID)
+
+	ticketID := preview.session.TicketID
+	if callback.Data == "preview:discard" {
+		b.editPreview(callback, fmt.Sprintf("🗑 Answer discarded, ticket #%d stays open", ticketID))
+		return
+	}
+
+	session := b.findOpenTicket(fmt.Sprintf("#%d", ticketID))
+	if session == nil {
+		b.editPreview(callback, fmt.Sprintf("Ticket #%d is already closed", ticketID))
+		return
+	}
+
+	b.removePreviewButtons(callback)
+	b.deliverAnswer(session, preview.answer)
+	if !session.AnsweredAt.IsZero() && preview.messageID != 0 {
+		b.rememberAnswerMessage(ticketID, preview.messageID)
+	}
+}
+
+// editPreview replaces the text of a preview, which also removes its
+// buttons.
+func (b *Bot) editPreview(callback *tgbotapi.CallbackQuery, text string) {
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, text)
+	_, err := b.api.Request(edit)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to update answer preview")
+	}
+}
+
+func (b *Bot) removePreviewButtons(callback *tgbotapi.CallbackQuery) {
+	removeButtons := tgbotapi.NewEditMessageReplyMarkup(callback.Message.Chat.ID, callback.Message.MessageID,
+		tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	_, err := b.api.Request(removeButtons)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to remove answer preview buttons")
+	}
+}
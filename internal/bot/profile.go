@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// userProfileLine summarizes what the bot knows about the author of a
+// ticket, shown under the admin notification, e.g.
+// "👤 First seen 2025-03-01 · 4 previous questions · ru · formerly @old_name".
+func (b *Bot) userProfileLine(session *UserSession) string {
+	user, exists := b.store.User(session.UserID)
+	if !exists {
+		return ""
+	}
+
+	previous := user.Questions
+	if _, stored := b.store.Ticket(session.TicketID); stored {
+		previous--
+	}
+
+	parts := []string{
+		"First seen " + user.FirstSeen.Format("2006-01-02"),
+		fmt.Sprintf("%d previous questions", previous),
+		b.userLanguage(session.UserID),
+	}
+	if previous == 1 {
+		parts[1] = "1 previous question"
+	}
+
+	var former []string
+	for _, username := range user.Usernames {
+		if username != session.Username {
+			former = append(former, "@"+username)
+		}
+	}
+	if len(former) > 0 {
+		parts = append(parts, "formerly "+strings.Join(former, ", "))
+	}
+	if user.ReferredBy != 0 {
+		parts = append(parts, fmt.Sprintf("invited by %d", user.ReferredBy))
+	}
+	if len(user.Labels) > 0 {
+		parts = append(parts, "🏷 "+strings.Join(user.Labels, ", "))
+	}
+
+	return "👤 " + strings.Join(parts, " · ")
+}
@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/commands"
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// promoErrorMessages are the texts for codes RedeemPromoCode refuses.
+var promoErrorMessages = map[error]string{
+	storage.ErrPromoNotFound: "promo_invalid",
+	storage.ErrPromoExpired:  "promo_expired",
+	storage.ErrPromoUsedUp:   "promo_used_up",
+	storage.ErrPromoRedeemed: "promo_already_redeemed",
+}
+
+// redeemPromoCode handles /promo <code>. A code with a 100% discount is
+// booked as a free payment, so the next CV review has priority without a
+// checkout; smaller discounts lower the price of the next paid review.
+func (b *Bot) redeemPromoCode(req commands.Request) {
+	userID := req.Message.From.ID
+
+	reply := func(messageID string, data ...map[string]interface{}) {
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, messageID, data...))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send promo code reply")
+		}
+	}
+
+	if b.priorityReview == nil {
+		reply("promo_unavailable")
+		return
+	}
+
+	now := time.Now()
+	promo, err := b.store.RedeemPromoCode(userID, req.Args, now)
+	if err != nil {
+		for refusal, messageID := range promoErrorMessages {
+			if errors.Is(err, refusal) {
+				reply(messageID)
+				return
+			}
+		}
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to redeem promo code")
+		return
+	}
+
+	b.audit.Record(AuditPromoRedeemed, userID, logrus.Fields{
+		"code":     promo.Code,
+		"discount": promo.Discount,
+	})
+
+	if promo.Discount < 100 {
+		reply("promo_discount", map[string]interface{}{
+			"Discount": promo.Discount,
+			"Price":    formatPrice(b.priorityPrice(userID), b.priorityReview.Currency),
+		})
+	} else {
+		_, err = b.store.AddPayment(storage.PaymentRecord{
+			ChargeID: fmt.Sprintf("promo:%s:%d", promo.Code, userID),
+			Provider: "promo",
+			UserID:   userID,
+			Currency: b.priorityReview.Currency,
+			PaidAt:   now,
+		})
+		if err == nil {
+			err = b.store.ClearPromoDiscount(userID)
+		}
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to book free priority review")
+		}
+		reply("promo_free")
+	}
+
+	// A user looking at the review tiers sees them at the new price, or
+	// moves on when the review became free
+	if b.userStates[userID] == StateCVTier {
+		b.offerPriorityReview(userID)
+	}
+}
+
+// handlePromoCommand manages promo codes for the admin:
+//
+//	/promo add <code> <discount%> [max_uses] [YYYY-MM-DD]
+//	/promo delete <code>
+func (b *Bot) handlePromoCommand(args string) {
+	fields := strings.Fields(args)
+
+	var reply string
+	switch {
+	case len(fields) >= 3 && len(fields) <= 5 && fields[0] == "add":
+		promo, err := parsePromoCode(fields[1:])
+		if err != nil {
+			reply = fmt.Sprintf("❌ %v", err)
+			break
+		}
+		promo.CreatedAt = time.Now()
+
+		if err := b.store.SavePromoCode(promo); err != nil {
+			b.logger.WithError(err).Error("Failed to save promo code")
+			reply = fmt.Sprintf("❌ Failed to save promo code: %v", err)
+			break
+		}
+		reply = fmt.Sprintf("✅ Promo code saved: %s", describePromoCode(promo))
+
+	case len(fields) == 2 && fields[0] == "delete":
+		deleted, err := b.store.DeletePromoCode(fields[1])
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to delete promo code")
+			reply = fmt.Sprintf("❌ Failed to delete promo code: %v", err)
+		} else if !deleted {
+			reply = fmt.Sprintf("Promo code %q not found", fields[1])
+		} else {
+			reply = fmt.Sprintf("🗑 Promo code %s deleted", strings.ToUpper(fields[1]))
+		}
+
+	default:
+		reply = `Usage:
+/promo add <code> <discount%> [max_uses] [YYYY-MM-DD]
+/promo delete <code>
+
+A 100% discount makes the priority CV review free. max_uses 0 means unlimited; the code expires at the start of the given day.`
+	}
+
+	msg := tgbotapi.NewMessage(b.adminID, reply)
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send promo command reply")
+	}
+}
+
+// parsePromoCode parses "<code> <discount%> [max_uses] [YYYY-MM-DD]".
+func parsePromoCode(fields []string) (storage.PromoCode, error) {
+	promo := storage.PromoCode{Code: strings.ToUpper(fields[0])}
+
+	discount, err := strconv.Atoi(strings.TrimSuffix(fields[1], "%"))
+	if err != nil || discount <= 0 || discount > 100 {
+		return promo, fmt.Errorf("invalid discount %q, use a percentage from 1 to 100", fields[1])
+	}
+	promo.Discount = discount
+
+	for _, field := range fields[2:] {
+		if expires, err := time.ParseInLocation("2006-01-02", field, time.Local); err == nil {
+			promo.ExpiresAt = expires
+			continue
+		}
+		uses, err := strconv.Atoi(field)
+		if err != nil || uses < 0 {
+			return promo, fmt.Errorf("invalid %q, expected max uses or an expiry date (YYYY-MM-DD)", field)
+		}
+		promo.MaxUses = uses
+	}
+
+	return promo, nil
+}
+
+// describePromoCode formats a code for the admin, e.g.
+// "SPRING: 50% off, 3/10 used, expires 2026-05-01".
+func describePromoCode(promo storage.PromoCode) string {
+	parts := []string{fmt.Sprintf("%s: %d%% off", promo.Code, promo.Discount)}
+	if promo.Discount == 100 {
+		parts[0] = promo.Code + ": free"
+	}
+
+	if promo.MaxUses > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d used", promo.Uses, promo.MaxUses))
+	} else {
+		parts = append(parts, fmt.Sprintf("%d used", promo.Uses))
+	}
+
+	if !promo.ExpiresAt.IsZero() {
+		state := "expires"
+		if !time.Now().Before(promo.ExpiresAt) {
+			state = "expired"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", state, promo.ExpiresAt.Format("2006-01-02")))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func (b *Bot) showPromoCodes() {
+	codes := b.store.PromoCodes()
+
+	var text strings.Builder
+	if len(codes) == 0 {
+		text.WriteString("No promo codes. Add one with /promo add <code> <discount%>")
+	} else {
+		text.WriteString("Promo codes:\n\n")
+		for _, promo := range codes {
+			text.WriteString("• " + describePromoCode(promo) + "\n")
+		}
+	}
+
+	_, err := b.api.SendLong(b.adminID, text.String(), nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send promo code list")
+	}
+}
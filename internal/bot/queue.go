@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"time"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// responseTimeWindow is how far back answered tickets count towards the
+// typical response time users are told.
+const responseTimeWindow = 30 * 24 * time.Hour
+
+// queuedBefore reports whether ticket a is worked on before ticket b:
+// priority tickets first, then oldest first.
+func queuedBefore(a, b *UserSession) bool {
+	if a.Priority() != b.Priority() {
+		return a.Priority()
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
+
+// queuePosition is the 1-based place of session in the admin queue, also
+// for a session that is not queued yet.
+func (b *Bot) queuePosition(session *UserSession) int {
+	position := 1
+	for _, other := range b.tickets {
+		if other != session && queuedBefore(other, session) {
+			position++
+		}
+	}
+
+	return position
+}
+
+// typicalResponseTime is the median response time of the tickets answered
+// in the last 30 days, or zero without any.
+func (b *Bot) typicalResponseTime() time.Duration {
+	now := time.Now()
+	summary := computeStats(b.store.Tickets(), nil, now.Add(-responseTimeWindow), now)
+	return summary.ResponseTimePercentile(50)
+}
+
+// queueNotice tells the user their place in the queue and, once there is
+// a history to go by, the typical response time. messageID is the text for
+// a position in the middle of the queue; the next ticket in line gets
+// queue_next.
+func (b *Bot) queueNotice(userID int64, messageID string, position int, typical time.Duration) string {
+	if position == 1 {
+		return b.trMarkdown(userID, "queue_next")
+	}
+
+	data := map[string]interface{}{"Position": position}
+	if typical <= 0 {
+		return b.trMarkdown(userID, messageID+"_no_eta", data)
+	}
+	data["ETA"] = formatDuration(typical)
+
+	return b.trMarkdown(userID, messageID, data)
+}
+
+// positionImproved reports whether a move up the queue is worth telling the
+// user about: the position at least halved or the ticket is next in line.
+// Moving down, e.g. behind a new urgent ticket, is not announced.
+func positionImproved(previous, current int) bool {
+	return current < previous && (current == 1 || current*2 <= previous)
+}
+
+// updateQueuePositions tells users whose tickets moved up the queue
+// significantly since they were last told their position.
+func (b *Bot) updateQueuePositions() {
+	var typical time.Duration
+	computed := false
+
+	for i, session := range b.openTickets() {
+		position := i + 1
+		if session.Group != nil || session.QueuePosition == 0 || !positionImproved(session.QueuePosition, position) {
+			continue
+		}
+		if !computed {
+			typical = b.typicalResponseTime()
+			computed = true
+		}
+
+		msg := telegram.NewMarkdownMessage(session.UserID, b.queueNotice(session.UserID, "queue_update", position, typical))
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", session.UserID).Error("Failed to send queue position update")
+			continue
+		}
+		session.QueuePosition = position
+	}
+}
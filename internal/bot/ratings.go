@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+func ratingKeyboard(ticketID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👍", fmt.Sprintf("rate:%d:%s", ticketID, storage.RatingUp)),
+			tgbotapi.NewInlineKeyboardButtonData("👎", fmt.Sprintf("rate:%d:%s", ticketID, storage.RatingDown)),
+		),
+	)
+}
+
+// handleRatingCallback processes "rate:<ticket_id>:<up|down>" callbacks sent
+// from the buttons attached to delivered answers.
+func (b *Bot) handleRatingCallback(callback *tgbotapi.CallbackQuery) {
+	userID := callback.From.ID
+
+	parts := strings.Split(callback.Data, ":")
+	if len(parts) != 3 || (parts[2] != storage.RatingUp && parts[2] != storage.RatingDown) {
+		b.logger.WithField("callback_data", callback.Data).Error("Malformed rating callback")
+		return
+	}
+
+	ticketID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		b.logger.WithError(err).WithField("callback_data", callback.Data).Error("Malformed rating ticket ID")
+		return
+	}
+
+	found, err := b.store.RateTicket(ticketID, userID, parts[2])
+	if err != nil {
+		b.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":   userID,
+			"ticket_id": ticketID,
+		}).Error("Failed to persist rating")
+	}
+	if !found {
+		return
+	}
+
+	if callback.Message != nil {
+		removeButtons := tgbotapi.NewEditMessageReplyMarkup(callback.Message.Chat.ID, callback.Message.MessageID,
+			tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+		_, err = b.api.Request(removeButtons)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to remove rating buttons")
+		}
+	}
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "rating_thanks"))
+	_, err = b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send rating acknowledgement")
+	}
+}
@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const (
+	reengagementCheckInterval = time.Hour
+	// reengagementMinIdle keeps users who may still come back on their own,
+	// or were just nudged, from being asked again
+	reengagementMinIdle = 24 * time.Hour
+)
+
+// reengagementPeriodFromConfig reads REENGAGEMENT_PERIOD, a duration such as
+// "168h" or a number of days such as "7d". Users who started a question or
+// CV review within that period and never submitted it are asked once to
+// come back; a ticket within the same period afterwards counts as a
+// return. 0 means the campaign is off, which is the default.
+func reengagementPeriodFromConfig(config *Config) (time.Duration, error) {
+	value := strings.TrimSpace(config.Features.ReengagementPeriod)
+	if value == "" || value == "off" {
+		return 0, nil
+	}
+
+	period, err := parseDayDuration(value)
+	if err != nil || period <= reengagementMinIdle {
+		return 0, fmt.Errorf("invalid REENGAGEMENT_PERIOD %q, expected more than a day, e.g. 7d", value)
+	}
+
+	return period, nil
+}
+
+// parseDayDuration parses a number of days such as "90d" or a duration
+// such as "2160h".
+func parseDayDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number of days %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+func (b *Bot) runReengagement() {
+	ticker := time.NewTicker(reengagementCheckInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		b.mu.Lock()
+		b.sendReengagements(now)
+		b.mu.Unlock()
+	}
+}
+
+// sendReengagements asks the users who abandoned a question or CV review
+// to come back. Users in a flow or with an open ticket are left alone.
+// Callers must hold b.mu.
+func (b *Bot) sendReengagements(now time.Time) {
+	for _, user := range b.store.AbandonedUsers(now.Add(-b.reengagementPeriod), now.Add(-reengagementMinIdle), now.Add(-b.reengagementPeriod)) {
+		if state := b.userStates[user.ID]; state != "" && state != StateWelcome {
+			continue
+		}
+		if _, open := b.userSessions[user.ID]; open {
+			continue
+		}
+		flow, exists := b.flows.Flow(user.StartedFlow)
+		if !exists {
+			continue
+		}
+
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.tr(user.ID, flow.Button), flow.Name),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.tr(user.ID, "button_reengagement_stop"), "reengage:stop"),
+			),
+		)
+
+		msg := telegram.NewMarkdownMessage(user.ID, b.trMarkdown(user.ID, "reengagement_"+flow.Name))
+		msg.ReplyMarkup = keyboard
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to send re-engagement message")
+		} else {
+			b.audit.Record(AuditReengagementSent, user.ID, logrus.Fields{"flow": flow.Name})
+		}
+
+		// Mark as sent even on failure so blocked users are not retried
+		if err := b.store.MarkReengaged(user.ID, now); err != nil {
+			b.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to persist re-engagement")
+		}
+	}
+}
+
+// recordFlowStart remembers that a user started a flow, so the campaign
+// can find them if they never submit it.
+func (b *Bot) recordFlowStart(userID int64, flow string) {
+	if b.reengagementPeriod <= 0 {
+		return
+	}
+	if err := b.store.RecordFlowStart(userID, flow, time.Now()); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist flow start")
+	}
+}
+
+// recordReturn counts a new ticket as a return when the user was asked to
+// come back within the campaign period.
+func (b *Bot) recordReturn(userID int64, ticketID int) {
+	if b.reengagementPeriod <= 0 {
+		return
+	}
+	returned, err := b.store.RecordReturn(userID, time.Now(), b.reengagementPeriod)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist re-engagement return")
+	}
+	if returned {
+		b.audit.Record(AuditReengagementReturned, userID, logrus.Fields{"ticket_id": ticketID})
+	}
+}
+
+// handleReengagementCallback handles "reengage:stop", the opt-out button of
+// the campaign message.
+func (b *Bot) handleReengagementCallback(callback *tgbotapi.CallbackQuery) {
+	userID := callback.From.ID
+	if callback.Data != "reengage:stop" {
+		return
+	}
+
+	if err := b.store.SetReengagementOptOut(userID, true); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist re-engagement opt-out")
+		return
+	}
+	b.audit.Record(AuditReengagementOptOut, userID, nil)
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "reengagement_stopped"))
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to confirm re-engagement opt-out")
+	}
+}
+
+// showReengagement reports the campaign's results to the admin for
+// /reengagement.
+func (b *Bot) showReengagement() {
+	var text string
+	if b.reengagementPeriod <= 0 {
+		text = "Re-engagement is off. Set REENGAGEMENT_PERIOD, e.g. 7d, to ask users who abandoned a question or CV review to come back."
+	} else {
+		var sent, returned, optedOut int
+		for _, user := range b.store.Users() {
+			if !user.ReengagedAt.IsZero() {
+				sent++
+			}
+			if !user.ReturnedAt.IsZero() {
+				returned++
+			}
+			if user.NoReengagement {
+				optedOut++
+			}
+		}
+
+		rate := 0
+		if sent > 0 {
+			rate = returned * 100 / sent
+		}
+		text = fmt.Sprintf(`🔁 Re-engagement of users who abandoned a flow, period %s
+
+Asked to come back: %d
+Came back and submitted: %d (%d%%)
+Opted out: %d`, formatDuration(b.reengagementPeriod), sent, returned, rate, optedOut)
+	}
+
+	_, err := b.api.Send(tgbotapi.NewMessage(b.adminID, text))
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send re-engagement report")
+	}
+}
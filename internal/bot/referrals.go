@@ -0,0 +1,119 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const (
+	referralPayloadPrefix = "ref_"
+	// referralWindow is how long after first opening the bot a user can
+	// still be credited to a referrer
+	referralWindow = time.Hour
+	topReferrers   = 10
+)
+
+// referralLink is the deep link users share to invite others.
+func (b *Bot) referralLink(userID int64) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%d", b.api.Self().UserName, referralPayloadPrefix, userID)
+}
+
+func (b *Bot) showInviteLink(userID int64) {
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "invite_link", map[string]interface{}{
+		"Link": b.referralLink(userID),
+	}))
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send invite link")
+	}
+}
+
+// recordReferral credits a user who opened a referral link to its owner.
+// Only users new to the bot count, and nobody can refer themselves.
+func (b *Bot) recordReferral(userID int64, payload string) {
+	referrerID, err := strconv.ParseInt(strings.TrimPrefix(payload, referralPayloadPrefix), 10, 64)
+	if err != nil || referrerID == userID || referrerID == b.adminID {
+		return
+	}
+	if _, exists := b.store.User(referrerID); !exists {
+		return
+	}
+
+	now := time.Now()
+	user, exists := b.store.User(userID)
+	if !exists || now.Sub(user.FirstSeen) > referralWindow {
+		return
+	}
+
+	recorded, err := b.store.SetReferrer(userID, referrerID, now)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist referral")
+		return
+	}
+	if !recorded {
+		return
+	}
+
+	b.audit.Record(AuditReferral, userID, logrus.Fields{"referrer_id": referrerID})
+
+	if b.referralThanks {
+		msg := telegram.NewMarkdownMessage(referrerID, b.trMarkdown(referrerID, "referral_thanks"))
+		_, err = b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", referrerID).Error("Failed to thank referrer")
+		}
+	}
+}
+
+func (b *Bot) showReferrals() {
+	counts := make(map[int64]int)
+	total, recent := 0, 0
+	weekAgo := time.Now().AddDate(0, 0, -7)
+	for _, user := range b.store.Users() {
+		if user.ReferredBy == 0 {
+			continue
+		}
+		counts[user.ReferredBy]++
+		total++
+		if user.ReferredAt.After(weekAgo) {
+			recent++
+		}
+	}
+
+	referrers := make([]int64, 0, len(counts))
+	for referrerID := range counts {
+		referrers = append(referrers, referrerID)
+	}
+	sort.Slice(referrers, func(i, j int) bool {
+		if counts[referrers[i]] != counts[referrers[j]] {
+			return counts[referrers[i]] > counts[referrers[j]]
+		}
+		return referrers[i] < referrers[j]
+	})
+
+	var report strings.Builder
+	fmt.Fprintf(&report, "🤝 Referrals\n\nTotal: %d (%d in the last 7 days)\n", total, recent)
+	if len(referrers) > 0 {
+		report.WriteString("\nTop referrers:\n")
+	}
+	for i, referrerID := range referrers {
+		if i == topReferrers {
+			break
+		}
+		fmt.Fprintf(&report, "%d. user ID %d - %d\n", i+1, referrerID, counts[referrerID])
+	}
+
+	msg := tgbotapi.NewMessage(b.adminID, report.String())
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send referral report")
+	}
+}
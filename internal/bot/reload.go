@@ -0,0 +1,192 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// reloadSettings re-reads .env, the config file and MESSAGES_DIR and applies
+// everything that can change without a restart: message texts, the urgent
+// cooldown, SLA thresholds, the follow-up survey delay, office hours, the
+// session TTL, the stalled flow reminder, new-user verification and the
+// domain blocklist.
+// Nothing is applied unless all of them are valid. Callers must hold b.mu.
+func (b *Bot) reloadSettings() (string, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	translations, err := loadTranslations(config)
+	if err != nil {
+		return "", err
+	}
+
+	welcomeVariants, err := welcomeVariantsFromConfig(config, translations)
+	if err != nil {
+		return "", fmt.Errorf("invalid WELCOME_VARIANTS: %w", err)
+	}
+
+	menuLayout, err := b.menuLayoutFromConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("invalid MENU_LAYOUT: %w", err)
+	}
+
+	officeHours, err := officeHoursFromConfig(config)
+	if err != nil {
+		return "", err
+	}
+
+	reviewers, err := reviewersFromConfig(config, b.adminID)
+	if err != nil {
+		return "", fmt.Errorf("invalid REVIEWERS: %w", err)
+	}
+
+	terms, err := termsFromConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("invalid TERMS_FILE: %w", err)
+	}
+
+	atsKeywords, err := atsKeywordsFromConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("invalid ATS_KEYWORDS_FILE: %w", err)
+	}
+
+	faq, err := faqFromConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("invalid FAQ_FILE: %w", err)
+	}
+
+	slaThresholds := slaThresholdsFromConfig(config)
+
+	b.config = config
+	b.translations.Store(translations)
+	b.welcomeVariants = welcomeVariants
+	b.menuLayout = menuLayout
+	b.urgentCooldown = durationOr(config.Limits.UrgentCooldown, defaultUrgentCooldown)
+	b.surveyDelay = durationOr(config.Limits.FollowUpSurveyDelay, defaultSurveyDelay)
+	b.slaThresholds = slaThresholds
+	b.officeHours = officeHours
+	b.sessionTTL = durationOr(config.Limits.SessionTTL, defaultSessionTTL)
+	b.nudgeDelay = durationOr(config.Limits.StalledFlowNudge, defaultNudgeDelay)
+	b.verifyUsers = enabled(config.Features.VerifyNewUsers)
+	b.blocklist = blocklistFromConfig(config)
+	b.malwareScanner = clamAVFromConfig(config)
+	b.faq = faq
+	b.terms = terms
+	b.atsKeywords = atsKeywords
+	b.reviewers = reviewers
+	b.seniorChatID = config.Team.SeniorReviewerChatID
+	b.acknowledgeNotify = enabled(config.Features.AcknowledgeNotifyUser)
+	b.onboardingTutorial = enabled(config.Features.OnboardingTutorial)
+	b.anonymizeCVs = enabled(config.Team.AnonymizeCVs)
+
+	// Keep reminder levels within the new thresholds so a shorter list
+	// does not skip or repeat escalations
+	for _, session := range b.tickets {
+		if session.SLALevel > len(slaThresholds) {
+			session.SLALevel = len(slaThresholds)
+		}
+	}
+
+	return config.File(), nil
+}
+
+func (b *Bot) handleReloadCommand() {
+	configFile, err := b.reloadSettings()
+
+	var reply string
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to reload settings")
+		reply = fmt.Sprintf("❌ Reload failed, the running settings were kept: %v", err)
+	} else {
+		b.logger.WithField("file", configFile).Info("Settings reloaded")
+
+		source := ".env"
+		if configFile != "" {
+			source += " and " + configFile
+		}
+
+		sla := "off"
+		if len(b.slaThresholds) > 0 {
+			levels := make([]string, len(b.slaThresholds))
+			for i, threshold := range b.slaThresholds {
+				levels[i] = formatDuration(threshold)
+			}
+			sla = strings.Join(levels, ", ")
+		}
+
+		survey := "off"
+		if b.surveyDelay > 0 {
+			survey = "after " + formatDuration(b.surveyDelay)
+		}
+
+		menu := "updated"
+		if err := b.publishCommands(); err != nil {
+			b.logger.WithError(err).Error("Failed to publish the command menu")
+			menu = fmt.Sprintf("not updated: %v", err)
+		}
+
+		ttl := "off"
+		if b.sessionTTL > 0 {
+			ttl = formatDuration(b.sessionTTL)
+		}
+
+		nudge := "off"
+		if b.nudgeDelay > 0 {
+			nudge = "after " + formatDuration(b.nudgeDelay)
+		}
+
+		verification := "off"
+		if b.verifyUsers {
+			verification = "on"
+		}
+
+		blocked := "none"
+		if domains := b.blocklist.Domains(); len(domains) > 0 {
+			blocked = strings.Join(domains, ", ")
+		}
+
+		terms := "off"
+		if b.terms != nil {
+			terms = "version " + b.terms.Version
+		}
+
+		scanning := "off"
+		if b.malwareScanner != nil {
+			scanning = "clamd at " + b.malwareScanner.addr
+		}
+
+		hours := "always open"
+		if b.officeHours != nil {
+			hours = b.officeHours.String()
+		}
+
+		reply = fmt.Sprintf(`🔄 Settings reloaded from %s
+
+Message texts: reloaded
+FAQ entries: %d
+Terms of service: %s
+Command menu: %s
+Urgent cooldown: %s
+SLA reminders: %s
+Follow-up survey: %s
+Office hours: %s
+Session TTL: %s
+Stalled flow reminder: %s
+New-user verification: %s
+Blocked domains: %s
+Malware scanning: %s
+
+Open sessions were kept. Token, admin, storage, servers and integrations change on restart.`,
+			source, b.faq.Len(), terms, menu, formatDuration(b.urgentCooldown), sla, survey, hours, ttl, nudge, verification, blocked, scanning)
+	}
+
+	msg := tgbotapi.NewMessage(b.adminID, reply)
+	_, err = b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send reload reply")
+	}
+}
@@ -0,0 +1,156 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const retentionCheckInterval = 24 * time.Hour
+
+// Retention removes data older than Period: answered tickets with their
+// archived files and rotated log files. Open tickets and the log files
+// being written are kept. Without a file archive uploaded files are not
+// stored by the bot, they stay on Telegram.
+type Retention struct {
+	Period time.Duration
+	// DryRun only reports what would be removed
+	DryRun bool
+}
+
+// retentionFromConfig reads RETENTION_PERIOD, either a duration such as
+// "2160h" or a number of days such as "90d", and RETENTION_DRY_RUN. It
+// returns nil when retention is off, which is the default.
+func retentionFromConfig(config *Config) (*Retention, error) {
+	value := strings.TrimSpace(config.Storage.RetentionPeriod)
+	if value == "" || value == "off" {
+		return nil, nil
+	}
+
+	period, err := parseDayDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RETENTION_PERIOD: %w", err)
+	}
+	if period <= 0 {
+		return nil, fmt.Errorf("RETENTION_PERIOD must be positive, got %q", value)
+	}
+
+	return &Retention{Period: period, DryRun: enabled(config.Storage.RetentionDryRun)}, nil
+}
+
+func (b *Bot) runRetention() {
+	ticker := time.NewTicker(retentionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		b.mu.Lock()
+		b.applyRetention(time.Now())
+		b.mu.Unlock()
+
+		<-ticker.C
+	}
+}
+
+// applyRetention purges, or with a dry run reports, everything older than
+// the retention period and tells the admin about it.
+func (b *Bot) applyRetention(now time.Time) {
+	cutoff := now.Add(-b.retention.Period)
+	dryRun := b.retention.DryRun
+
+	tickets, err := b.store.PurgeAnsweredTickets(cutoff, dryRun)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to purge old tickets")
+	}
+
+	files := 0
+	if b.fileArchive != nil {
+		if dryRun {
+			for _, ticket := range tickets {
+				files += len(ticket.Files)
+			}
+		} else {
+			files = b.deleteArchivedFiles(tickets)
+		}
+	}
+
+	var logFiles []string
+	for _, path := range []string{b.config.Logging.File, b.audit.path} {
+		files, err := purgeLogBackups(path, cutoff, dryRun)
+		if err != nil {
+			b.logger.WithError(err).WithField("file", path).Error("Failed to purge old log files")
+		}
+		logFiles = append(logFiles, files...)
+	}
+
+	b.logger.WithFields(logrus.Fields{
+		"cutoff":    cutoff,
+		"dry_run":   dryRun,
+		"tickets":   len(tickets),
+		"files":     files,
+		"log_files": len(logFiles),
+	}).Info("Applied data retention")
+
+	if len(tickets) == 0 && len(logFiles) == 0 {
+		return
+	}
+
+	verb := "Removed"
+	if dryRun {
+		verb = "Dry run, would remove"
+	}
+	text := fmt.Sprintf("🧹 Data retention (%s): %s %d answered ticket(s) and %d log file(s) older than %s",
+		formatDuration(b.retention.Period), verb, len(tickets), len(logFiles), cutoff.Format("2006-01-02"))
+	if files > 0 {
+		text += fmt.Sprintf(", with %d archived upload(s)", files)
+	}
+	for _, file := range logFiles {
+		text += "\n• " + file
+	}
+
+	msg := tgbotapi.NewMessage(b.adminID, text)
+	_, err = b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send retention report")
+	}
+}
+
+// purgeLogBackups removes the rotated backups of a log file, named
+// <name>-<timestamp><ext> by the rotation, last modified before cutoff.
+// It returns the files that were, or with dryRun would be, removed.
+func purgeLogBackups(path string, cutoff time.Time, dryRun bool) ([]string, error) {
+	if path == "" || path == "stdout" {
+		return nil, nil
+	}
+
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*" + ext + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	var purged []string
+	var errs []error
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || info.IsDir() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		if !dryRun {
+			if err := os.Remove(match); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		purged = append(purged, match)
+	}
+
+	return purged, errors.Join(errs...)
+}
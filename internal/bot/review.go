@@ -0,0 +1,197 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// reviewMaxScore is the top of the overall score of a CV review.
+const reviewMaxScore = 10
+
+// rubricField is a part of a CV review the admin comments on.
+type rubricField struct {
+	// Heading is the message ID of the heading the user sees
+	Heading string
+	// Prompt tells the admin what to look at
+	Prompt string
+}
+
+var reviewRubric = []rubricField{
+	{Heading: "review_formatting", Prompt: "📐 Formatting: layout, length, consistency, typos"},
+	{Heading: "review_impact", Prompt: "💥 Impact statements: do the bullet points show results, with numbers where possible?"},
+	{Heading: "review_skills", Prompt: "🛠 Skills: are the skills relevant to the target role and backed by experience?"},
+	{Heading: "review_ats", Prompt: "🤖 ATS readiness: plain structure, standard headings, keywords of the job description"},
+}
+
+// reviewForm is a structured CV review the admin is filling in, one rubric
+// field at a time, then the overall score.
+type reviewForm struct {
+	ticketID int
+	// step is the index of the rubric field being asked, len(reviewRubric)
+	// for the score
+	step     int
+	comments []string
+	// gap is the keyword analysis of the CV, nil without one
+	gap *keywordGap
+}
+
+// handleReviewCommand handles /review <ticket_id>, which opens the review
+// form of a ticket, e.g. one whose notification has no 📋 button.
+func (b *Bot) handleReviewCommand(args string) {
+	ticketID, err := parseTicketID(args)
+	if err != nil {
+		msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("❌ %v\n\nUsage: /review <ticket_id>", err))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).Error("Failed to send review form reply")
+		}
+		return
+	}
+
+	b.startReviewForm(ticketID)
+}
+
+func reviewFormButton(ticketID int) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData("📋 Review form", fmt.Sprintf("review:%d", ticketID))
+}
+
+// handleReviewFormCallback processes the buttons of the review form:
+// "review:<ticket_id>" opens it, "review:skip" leaves out a rubric field,
+// "review:score:<n>" sets the overall score and "review:cancel" drops it.
+func (b *Bot) handleReviewFormCallback(callback *tgbotapi.CallbackQuery) {
+	value := strings.TrimPrefix(callback.Data, "review:")
+	switch {
+	case value == "cancel":
+		if b.reviewForm != nil {
+			b.sendReviewFormReply(fmt.Sprintf("🗑 Review form for ticket #%d discarded", b.reviewForm.ticketID))
+		}
+		b.reviewForm = nil
+	case value == "skip":
+		b.recordReviewComment("")
+	case strings.HasPrefix(value, "score:"):
+		score, err := strconv.Atoi(strings.TrimPrefix(value, "score:"))
+		if err != nil || score < 1 || score > reviewMaxScore {
+			b.logger.WithField("callback_data", callback.Data).Error("Malformed review score callback")
+			return
+		}
+		b.finishReviewForm(score)
+	default:
+		ticketID, err := parseTicketID(value)
+		if err != nil {
+			b.logger.WithError(err).WithField("callback_data", callback.Data).Error("Malformed review form callback")
+			return
+		}
+		b.startReviewForm(ticketID)
+	}
+}
+
+func (b *Bot) startReviewForm(ticketID int) {
+	session := b.findOpenTicket(fmt.Sprintf("#%d", ticketID))
+	if session == nil {
+		b.sendReviewFormReply(fmt.Sprintf("❌ No open ticket #%d", ticketID))
+		return
+	}
+
+	b.reviewForm = &reviewForm{ticketID: ticketID, gap: b.analyzeKeywords(session)}
+	b.askReviewFormStep()
+}
+
+// askReviewFormStep asks the admin for the current rubric field, or for the
+// overall score once all fields are done.
+func (b *Bot) askReviewFormStep() {
+	form := b.reviewForm
+	header := fmt.Sprintf("📋 Review of ticket #%d (%d/%d)", form.ticketID, form.step+1, len(reviewRubric)+1)
+
+	var msg tgbotapi.MessageConfig
+	if form.step < len(reviewRubric) {
+		prompt := reviewRubric[form.step].Prompt
+		if reviewRubric[form.step].Heading == "review_ats" && form.gap != nil {
+			prompt += "\n\n" + form.gap.AdminSummary()
+		}
+		msg = tgbotapi.NewMessage(b.adminID, fmt.Sprintf("%s\n\n%s\n\nType your feedback or skip this part.", header, prompt))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("⏭ Skip", "review:skip"),
+				tgbotapi.NewInlineKeyboardButtonData("❌ Cancel", "review:cancel"),
+			),
+		)
+	} else {
+		var rows [][]tgbotapi.InlineKeyboardButton
+		var row []tgbotapi.InlineKeyboardButton
+		for score := 1; score <= reviewMaxScore; score++ {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(score), fmt.Sprintf("review:score:%d", score)))
+			if len(row) == 5 {
+				rows = append(rows, row)
+				row = nil
+			}
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("❌ Cancel", "review:cancel")))
+
+		msg = tgbotapi.NewMessage(b.adminID, fmt.Sprintf("%s\n\n⭐ Overall score out of %d?", header, reviewMaxScore))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.WithError(err).WithField("ticket_id", form.ticketID).Error("Failed to send review form step")
+	}
+}
+
+// recordReviewComment stores the admin's feedback for the current rubric
+// field, "" if they skipped it, and moves on.
+func (b *Bot) recordReviewComment(comment string) {
+	form := b.reviewForm
+	if form == nil || form.step >= len(reviewRubric) {
+		return
+	}
+
+	form.comments = append(form.comments, strings.TrimSpace(comment))
+	form.step++
+	b.askReviewFormStep()
+}
+
+// finishReviewForm compiles the review and hands it to the answer preview,
+// where the admin sends it to the user or discards it.
+func (b *Bot) finishReviewForm(score int) {
+	form := b.reviewForm
+	if form == nil || form.step < len(reviewRubric) {
+		return
+	}
+	b.reviewForm = nil
+
+	session := b.findOpenTicket(fmt.Sprintf("#%d", form.ticketID))
+	if session == nil {
+		b.sendReviewFormReply(fmt.Sprintf("Ticket #%d is already closed, the review was not sent", form.ticketID))
+		return
+	}
+
+	b.answerTicket(session, b.compileReview(session.UserID, form, score), 0)
+}
+
+// compileReview formats a review with headings in the user's language.
+// Skipped rubric fields are left out; keywords of the target role missing
+// from the CV get their own section.
+func (b *Bot) compileReview(userID int64, form *reviewForm, score int) string {
+	var text strings.Builder
+	text.WriteString("**" + b.tr(userID, "review_title") + "**\n")
+	for i, comment := range form.comments {
+		if comment == "" {
+			continue
+		}
+		text.WriteString("\n**" + b.tr(userID, reviewRubric[i].Heading) + "**\n" + comment + "\n")
+	}
+	if gap := form.gap; gap != nil && gap.Err == nil && len(gap.Missing) > 0 {
+		text.WriteString("\n**" + b.tr(userID, "review_keywords") + "**\n" + strings.Join(gap.Missing, ", ") + "\n")
+	}
+	text.WriteString("\n**" + b.tr(userID, "review_score", map[string]interface{}{"Score": score, "Max": reviewMaxScore}) + "**")
+
+	return text.String()
+}
+
+func (b *Bot) sendReviewFormReply(text string) {
+	msg := tgbotapi.NewMessage(b.adminID, text)
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.WithError(err).Error("Failed to send review form reply")
+	}
+}
@@ -0,0 +1,175 @@
+package bot
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	defaultS3Region = "us-east-1"
+	s3HTTPTimeout   = 2 * time.Minute
+)
+
+// S3Archive is a FileArchive in an S3 compatible bucket (AWS S3, MinIO,
+// ...). Objects are named <prefix>tickets/<ticket_id>/<file name>.
+type S3Archive struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+	prefix    string
+	client    *http.Client
+}
+
+// s3ArchiveFromConfig reads S3_BUCKET, S3_ENDPOINT, S3_REGION,
+// S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY and S3_PREFIX. It returns nil when
+// S3_BUCKET is unset. Without S3_ENDPOINT the bucket is on AWS; MinIO and
+// other providers need their URL, e.g. http://localhost:9000. Buckets are
+// always addressed by path, which every provider supports.
+func s3ArchiveFromConfig(config *Config) (*S3Archive, error) {
+	settings := &config.Integrations.S3
+	bucket := settings.Bucket
+	if bucket == "" {
+		return nil, nil
+	}
+
+	region := settings.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+
+	rawEndpoint := settings.Endpoint
+	if rawEndpoint == "" {
+		rawEndpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(rawEndpoint, "/"))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT %q, expected a URL such as http://localhost:9000", rawEndpoint)
+	}
+
+	archive := &S3Archive{
+		endpoint:  endpoint,
+		bucket:    bucket,
+		region:    region,
+		accessKey: settings.AccessKeyID,
+		secretKey: settings.SecretAccessKey,
+		prefix:    settings.Prefix,
+		client:    &http.Client{Timeout: s3HTTPTimeout},
+	}
+	if archive.accessKey == "" || archive.secretKey == "" {
+		return nil, errors.New("S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required when S3_BUCKET is set")
+	}
+
+	return archive, nil
+}
+
+func (a *S3Archive) TicketKey(userID int64, ticketID int, fileName string) string {
+	return fmt.Sprintf("%stickets/%d/%s", a.prefix, ticketID, safeFileName(fileName))
+}
+
+func (a *S3Archive) Put(key string, data []byte) error {
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	_, err := a.do(http.MethodPut, key, data, contentType)
+	return err
+}
+
+func (a *S3Archive) Get(key string) ([]byte, error) {
+	return a.do(http.MethodGet, key, nil, "")
+}
+
+func (a *S3Archive) Delete(key string) error {
+	_, err := a.do(http.MethodDelete, key, nil, "")
+	return err
+}
+
+func (a *S3Archive) do(method, key string, body []byte, contentType string) ([]byte, error) {
+	objectURL := *a.endpoint
+	objectURL.Path = a.endpoint.Path + "/" + a.bucket + "/" + key
+
+	req, err := http.NewRequest(method, objectURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	a.sign(req, body, time.Now().UTC())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("s3 %s %s: %w", method, key, fs.ErrNotExist)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", method, key, resp.Status, truncateText(strings.TrimSpace(string(data)), 200))
+	}
+
+	return data, nil
+}
+
+// sign adds an AWS Signature Version 4 to req.
+func (a *S3Archive) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + hex.EncodeToString(payloadHash[:]),
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + a.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + a.secretKey)
+	for _, part := range []string{date, a.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
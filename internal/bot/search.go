@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const searchResultLimit = 10
+
+// searchTickets finds answered tickets for /search <keywords> [7d|30d|all],
+// e.g. "/search ielts deadline 30d" for last month's question about IELTS
+// deadlines.
+func (b *Bot) searchTickets(args string) {
+	words := strings.Fields(args)
+	from := time.Time{}
+	if len(words) > 0 {
+		if window, exists := statsPeriods[words[len(words)-1]]; exists {
+			words = words[:len(words)-1]
+			if window > 0 {
+				from = time.Now().Add(-window)
+			}
+		}
+	}
+	query := strings.Join(words, " ")
+
+	if len(searchTerms(query)) == 0 {
+		msg := tgbotapi.NewMessage(b.adminID, "Usage: /search <keywords> [7d|30d|all]")
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send search usage")
+		}
+		return
+	}
+
+	matches, err := b.searchIndex.search(b.store, query, from, searchResultLimit)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to search tickets")
+		msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("❌ Search failed: %v", err))
+		_, err = b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send search error")
+		}
+		return
+	}
+	if len(matches) == 0 {
+		msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("🔍 No tickets found for %q", query))
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send empty search result")
+		}
+		return
+	}
+
+	var resultText strings.Builder
+	resultText.WriteString(fmt.Sprintf("🔍 %d ticket(s) found for %q:\n\n", len(matches), query))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, ticket := range matches {
+		resultText.WriteString(fmt.Sprintf("#%d (%s)\n❓ %s\n💬 %s\n\n",
+			ticket.ID, ticket.AnsweredAt.Format("2006-01-02"),
+			truncateText(ticket.Question, 150), truncateText(ticket.Answer, 200)))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("♻️ Reuse answer #%d", ticket.ID), fmt.Sprintf("answer:%d", ticket.ID)),
+		))
+	}
+	resultText.WriteString("To reuse an answer, reply to a question with /reuse <ticket_id>")
+
+	_, err = b.api.SendLong(b.adminID, resultText.String(), tgbotapi.NewInlineKeyboardMarkup(rows...))
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send search results")
+	}
+}
+
+func (b *Bot) previousAnswer(ticketID string) (string, error) {
+	id, err := strconv.Atoi(strings.TrimPrefix(ticketID, "#"))
+	if err != nil {
+		return "", fmt.Errorf("invalid ticket ID %q", ticketID)
+	}
+
+	ticket, exists := b.store.Ticket(id)
+	if !exists || ticket.AnsweredAt.IsZero() {
+		return "", fmt.Errorf("ticket #%d has no answer to reuse", id)
+	}
+
+	return ticket.Answer, nil
+}
+
+// handleShowAnswerCallback processes "answer:<ticket_id>" callbacks from
+// search results by sending the full previous answer to the admin.
+func (b *Bot) handleShowAnswerCallback(callback *tgbotapi.CallbackQuery) {
+	ticketID := strings.TrimPrefix(callback.Data, "answer:")
+
+	answer, err := b.previousAnswer(ticketID)
+	if err != nil {
+		msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("❌ %v", err))
+		_, err = b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send reuse error")
+		}
+		return
+	}
+
+	text := fmt.Sprintf("♻️ Answer of ticket #%s:\n\n%s\n\n💡 Reply to a question with /reuse %s to send it", ticketID, answer, ticketID)
+	_, err = b.api.SendLong(b.adminID, text, nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send previous answer")
+	}
+}
@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+// ticketIndex is an SQLite FTS5 index over the questions and answers of
+// answered tickets. It lives in memory and is rebuilt from the store
+// whenever a ticket has changed since the last search.
+type ticketIndex struct {
+	mu       sync.Mutex
+	db       *sql.DB
+	built    bool
+	revision uint64
+	tickets  map[int]storage.TicketRecord
+}
+
+// searchTerms splits text into lower-case words, e.g. "IELTS deadlines?"
+// into "ielts" and "deadlines".
+func searchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// matchQuery turns terms into an FTS5 query matching tickets that contain
+// every one of them as a word prefix, so "deadline" also finds
+// "deadlines". Terms are letters and digits only, so quoting them is
+// enough to keep FTS5 operators out.
+func matchQuery(terms []string) string {
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = `"` + term + `"*`
+	}
+
+	return strings.Join(prefixes, " ")
+}
+
+// open creates the in-memory database on first use. It must be called with
+// idx.mu held.
+func (idx *ticketIndex) open() error {
+	if idx.db != nil {
+		return nil
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return err
+	}
+	// Every connection to :memory: is a database of its own
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE VIRTUAL TABLE tickets USING fts5(question, answer, created_at UNINDEXED)`)
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("create search index: %w", err)
+	}
+
+	idx.db = db
+	return nil
+}
+
+// refresh rebuilds the index if a ticket changed. It must be called with
+// idx.mu held.
+func (idx *ticketIndex) refresh(store *storage.Store) error {
+	revision := store.TicketsRevision()
+	if idx.built && idx.revision == revision {
+		return nil
+	}
+
+	if err := idx.open(); err != nil {
+		return err
+	}
+
+	tx, err := idx.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM tickets`); err != nil {
+		return err
+	}
+	insert, err := tx.Prepare(`INSERT INTO tickets (rowid, question, answer, created_at) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+
+	tickets := make(map[int]storage.TicketRecord)
+	for _, ticket := range store.Tickets() {
+		if ticket.AnsweredAt.IsZero() {
+			continue
+		}
+		if _, err := insert.Exec(ticket.ID, ticket.Question, ticket.Answer, ticket.CreatedAt.Unix()); err != nil {
+			return fmt.Errorf("index ticket #%d: %w", ticket.ID, err)
+		}
+		tickets[ticket.ID] = ticket
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	idx.tickets = tickets
+	idx.built = true
+	idx.revision = revision
+	return nil
+}
+
+// search returns up to limit answered tickets created since from that
+// contain every term of query, ranked by FTS5's bm25; equally good matches
+// are listed newest first. Without terms it returns the newest answered
+// tickets.
+func (idx *ticketIndex) search(store *storage.Store, query string, from time.Time, limit int) ([]storage.TicketRecord, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if err := idx.refresh(store); err != nil {
+		return nil, err
+	}
+
+	since := int64(0)
+	if !from.IsZero() {
+		since = from.Unix()
+	}
+
+	var rows *sql.Rows
+	var err error
+	if terms := searchTerms(query); len(terms) > 0 {
+		rows, err = idx.db.Query(`SELECT rowid FROM tickets WHERE tickets MATCH ? AND created_at >= ? ORDER BY rank, rowid DESC LIMIT ?`,
+			matchQuery(terms), since, limit)
+	} else {
+		rows, err = idx.db.Query(`SELECT rowid FROM tickets WHERE created_at >= ? ORDER BY rowid DESC LIMIT ?`, since, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []storage.TicketRecord
+	for rows.Next() {
+		var ticketID int
+		if err := rows.Scan(&ticketID); err != nil {
+			return nil, err
+		}
+		results = append(results, idx.tickets[ticketID])
+	}
+
+	return results, rows.Err()
+}
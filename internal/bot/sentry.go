@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const sentryFlushTimeout = 2 * time.Second
+
+// SetupSentry initializes Sentry when SENTRY_DSN is set and forwards logrus
+// error, fatal and panic entries to it. The returned function flushes
+// pending events and should run before the process exits.
+func SetupSentry(logger *logrus.Logger, config *Config) (func(), error) {
+	dsn := config.Observability.SentryDSN
+	if dsn == "" {
+		return func() {}, nil
+	}
+
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: config.Observability.SentryEnvironment,
+	})
+	if err != nil {
+		return func() {}, err
+	}
+
+	logger.AddHook(&sentryHook{})
+	return func() { sentry.Flush(sentryFlushTimeout) }, nil
+}
+
+type sentryHook struct{}
+
+func (h *sentryHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+func (h *sentryHook) Fire(entry *logrus.Entry) error {
+	event := sentry.NewEvent()
+	event.Message = entry.Message
+	event.Timestamp = entry.Time
+	event.Level = sentry.LevelError
+	if entry.Level != logrus.ErrorLevel {
+		event.Level = sentry.LevelFatal
+	}
+
+	for key, value := range entry.Data {
+		switch key {
+		case logrus.ErrorKey:
+			if err, ok := value.(error); ok {
+				event.Exception = []sentry.Exception{{
+					Type:  fmt.Sprintf("%T", err),
+					Value: err.Error(),
+				}}
+			}
+		case "user_id":
+			event.User.ID = fmt.Sprint(value)
+		default:
+			event.Extra[key] = value
+		}
+	}
+
+	sentry.CaptureEvent(event)
+	if entry.Level != logrus.ErrorLevel {
+		// Fatal entries exit the process right after the hooks run
+		sentry.Flush(sentryFlushTimeout)
+	}
+
+	return nil
+}
+
+// withSentryScope attaches the update being handled to every event reported
+// until the returned function is called.
+func withSentryScope(update tgbotapi.Update) func() {
+	hub := sentry.CurrentHub()
+	scope := hub.PushScope()
+
+	scope.SetTag("update_id", strconv.Itoa(update.UpdateID))
+	if user := update.SentFrom(); user != nil {
+		scope.SetUser(sentry.User{ID: strconv.FormatInt(user.ID, 10), Username: user.UserName})
+	}
+	if update.CallbackQuery != nil {
+		scope.SetTag("update_type", "callback_query")
+		scope.SetExtra("callback_data", update.CallbackQuery.Data)
+	} else if update.Message != nil {
+		scope.SetTag("update_type", "message")
+		scope.SetExtra("message_text", update.Message.Text)
+	}
+
+	return func() { hub.PopScope() }
+}
+
+// reportPanic sends a recovered panic to Sentry.
+func reportPanic(r interface{}) {
+	sentry.CurrentHub().Recover(r)
+	sentry.Flush(sentryFlushTimeout)
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// showSessions lists the open tickets for /sessions [label], only those of
+// users with the label if one is given. Snoozed tickets are only counted.
+func (b *Bot) showSessions(args string) {
+	now := time.Now()
+	tickets := b.openTickets()
+
+	label := ""
+	if strings.TrimSpace(args) != "" {
+		var err error
+		label, err = parseLabel(args)
+		if err != nil {
+			msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("❌ %v\n\nUsage: /sessions [label]", err))
+			if _, err := b.api.Send(msg); err != nil {
+				b.logger.WithError(err).Error("Failed to send sessions usage")
+			}
+			return
+		}
+		tickets = slices.DeleteFunc(tickets, func(session *UserSession) bool {
+			return !slices.Contains(b.userLabels(session.UserID), label)
+		})
+	}
+
+	snoozed := len(tickets)
+	tickets = slices.DeleteFunc(tickets, func(session *UserSession) bool {
+		return session.Snoozed(now)
+	})
+	snoozed -= len(tickets)
+
+	// Pinned tickets go first, otherwise in queue order
+	sort.SliceStable(tickets, func(i, j int) bool {
+		return tickets[i].Pinned && !tickets[j].Pinned
+	})
+
+	if len(tickets) == 0 {
+		empty := "No active user sessions"
+		if label != "" {
+			empty = fmt.Sprintf("No active sessions of users tagged %s", label)
+		}
+		if snoozed > 0 {
+			empty += fmt.Sprintf("\n\n💤 %d snoozed ticket(s)", snoozed)
+		}
+		msg := tgbotapi.NewMessage(b.adminID, empty)
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send 'no sessions' message")
+		}
+		return
+	}
+
+	var sessionsText strings.Builder
+	sessionsText.WriteString("Active user sessions:\n\n")
+
+	for _, session := range tickets {
+		marker := ""
+		if session.Pinned {
+			marker = "📌 "
+		}
+		if session.Urgent {
+			marker += "🚨 "
+		}
+		if session.AfterHours {
+			marker += "🌙 "
+		}
+		if !session.AcknowledgedAt.IsZero() {
+			marker += "👀 "
+		}
+		if !session.EscalatedAt.IsZero() {
+			marker += "⬆️ "
+		}
+		if session.AssignedTo != 0 {
+			marker += "👤 " + b.assigneeName(session.AssignedTo) + " "
+		}
+		if labels := b.userLabels(session.UserID); len(labels) > 0 {
+			marker += "🏷 " + strings.Join(labels, ", ") + " "
+		}
+
+		waiting := formatDuration(now.Sub(session.CreatedAt))
+		if session.Username != "" {
+			sessionsText.WriteString(fmt.Sprintf("%s#%d @%s (ID: %d), waiting %s: %s\n\n",
+				marker, session.TicketID, session.Username, session.UserID, waiting, session.LastQuestion))
+		} else {
+			sessionsText.WriteString(fmt.Sprintf("%s#%d User ID %d, waiting %s: %s\n\n",
+				marker, session.TicketID, session.UserID, waiting, session.LastQuestion))
+		}
+		if comments := b.ticketCommentLines(session.TicketID); comments != "" {
+			sessionsText.WriteString(comments + "\n\n")
+		}
+	}
+
+	if snoozed > 0 {
+		sessionsText.WriteString(fmt.Sprintf("💤 %d snoozed ticket(s) not shown", snoozed))
+	}
+
+	_, err := b.api.SendLong(b.adminID, sessionsText.String(), nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send sessions list")
+	}
+}
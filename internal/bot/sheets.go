@@ -0,0 +1,207 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2/google"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+const (
+	integrationSheets = "google_sheets"
+
+	defaultSheetsTab = "Sheet1"
+	sheetsAPIBase    = "https://sheets.googleapis.com/v4/spreadsheets"
+	sheetsScope      = "https://www.googleapis.com/auth/spreadsheets"
+	// sheetsStatusColumn holds the ticket status in the row layout written by
+	// AppendTicket: ticket, created, user ID, username, category, question, status.
+	sheetsStatusColumn = "G"
+)
+
+// SheetsClient appends tickets to a Google Sheet so the queue can be tracked
+// outside Telegram.
+type SheetsClient struct {
+	spreadsheetID string
+	tab           string
+	client        *http.Client
+
+	mu   sync.Mutex
+	rows map[int]int
+}
+
+// sheetsClientFromConfig returns nil when GOOGLE_SHEETS_ID is unset.
+func sheetsClientFromConfig(config *Config) (*SheetsClient, error) {
+	settings := &config.Integrations.GoogleSheets
+	spreadsheetID := settings.ID
+	if spreadsheetID == "" {
+		return nil, nil
+	}
+
+	credentialsFile := settings.Credentials
+	if credentialsFile == "" {
+		return nil, fmt.Errorf("GOOGLE_SHEETS_CREDENTIALS must point to a service account key when GOOGLE_SHEETS_ID is set")
+	}
+
+	credentials, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	jwt, err := google.JWTConfigFromJSON(credentials, sheetsScope)
+	if err != nil {
+		return nil, err
+	}
+
+	tab := settings.Tab
+	if tab == "" {
+		tab = defaultSheetsTab
+	}
+
+	client := jwt.Client(context.Background())
+	client.Timeout = 15 * time.Second
+
+	return &SheetsClient{
+		spreadsheetID: spreadsheetID,
+		tab:           tab,
+		client:        client,
+		rows:          make(map[int]int),
+	}, nil
+}
+
+type sheetsValues struct {
+	Values [][]interface{} `json:"values"`
+}
+
+// AppendTicket adds a row for a new ticket and remembers where it landed so
+// the status can be updated later.
+func (s *SheetsClient) AppendTicket(ticket storage.TicketRecord) error {
+	values := sheetsValues{Values: [][]interface{}{{
+		ticket.ID,
+		ticket.CreatedAt.Format(time.RFC3339),
+		strconv.FormatInt(ticket.UserID, 10),
+		ticket.Username,
+		ticket.Category,
+		ticket.Question,
+		"open",
+	}}}
+
+	endpoint := fmt.Sprintf("%s/%s/values/%s:append?valueInputOption=RAW&insertDataOption=INSERT_ROWS",
+		sheetsAPIBase, s.spreadsheetID, url.PathEscape(s.a1Range("A:G")))
+
+	var response struct {
+		Updates struct {
+			UpdatedRange string `json:"updatedRange"`
+		} `json:"updates"`
+	}
+	if err := s.do(http.MethodPost, endpoint, values, &response); err != nil {
+		return err
+	}
+
+	row, err := rowFromRange(response.Updates.UpdatedRange)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.rows[ticket.ID] = row
+	s.mu.Unlock()
+
+	return nil
+}
+
+// UpdateStatus overwrites the status cell of a previously appended ticket.
+func (s *SheetsClient) UpdateStatus(ticketID int, status string) error {
+	s.mu.Lock()
+	row, exists := s.rows[ticketID]
+	s.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("no sheet row recorded for ticket #%d", ticketID)
+	}
+
+	cell := s.a1Range(fmt.Sprintf("%s%d", sheetsStatusColumn, row))
+	endpoint := fmt.Sprintf("%s/%s/values/%s?valueInputOption=RAW",
+		sheetsAPIBase, s.spreadsheetID, url.PathEscape(cell))
+
+	return s.do(http.MethodPut, endpoint, sheetsValues{Values: [][]interface{}{{status}}}, nil)
+}
+
+func (s *SheetsClient) a1Range(cells string) string {
+	return fmt.Sprintf("'%s'!%s", strings.ReplaceAll(s.tab, "'", "''"), cells)
+}
+
+func (s *SheetsClient) do(method, endpoint string, body, result interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sheets API returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// rowFromRange extracts the first row number from an A1 range such as
+// "'Sheet1'!A12:G12".
+func rowFromRange(a1 string) (int, error) {
+	cells := a1[strings.LastIndex(a1, "!")+1:]
+	if before, _, found := strings.Cut(cells, ":"); found {
+		cells = before
+	}
+
+	row, err := strconv.Atoi(strings.TrimLeft(cells, "ABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected updated range %q", a1)
+	}
+
+	return row, nil
+}
+
+func (b *Bot) syncTicketToSheet(ticket storage.TicketRecord) {
+	if b.sheets == nil {
+		return
+	}
+
+	b.runIntegration(integrationSheets, fmt.Sprintf("append ticket #%d to sheet", ticket.ID), func() error {
+		return b.sheets.AppendTicket(ticket)
+	})
+}
+
+func (b *Bot) syncTicketStatusToSheet(ticketID int, status string) {
+	if b.sheets == nil {
+		return
+	}
+
+	b.runIntegration(integrationSheets, fmt.Sprintf("mark ticket #%d %s in sheet", ticketID, status), func() error {
+		return b.sheets.UpdateStatus(ticketID, status)
+	})
+}
@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const slaCheckInterval = 5 * time.Minute
+
+var defaultSLAThresholds = []time.Duration{4 * time.Hour, 24 * time.Hour}
+
+// slaThresholdsFromConfig returns the waiting times after which the admin
+// is reminded about an unanswered ticket, shortest first. Without
+// SLA_THRESHOLDS the defaults apply; disable_sla (or "off") turns the
+// reminders off.
+func slaThresholdsFromConfig(config *Config) []time.Duration {
+	if config.Limits.DisableSLA {
+		return nil
+	}
+	if len(config.Limits.SLAThresholds) == 0 {
+		return defaultSLAThresholds
+	}
+
+	thresholds := make([]time.Duration, 0, len(config.Limits.SLAThresholds))
+	for _, threshold := range config.Limits.SLAThresholds {
+		thresholds = append(thresholds, time.Duration(threshold))
+	}
+	sort.Slice(thresholds, func(i, j int) bool { return thresholds[i] < thresholds[j] })
+
+	return thresholds
+}
+
+func (b *Bot) runSLAReminders() {
+	ticker := time.NewTicker(slaCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		b.mu.Lock()
+		if len(b.slaThresholds) > 0 {
+			b.checkSLA(b.slaThresholds)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// checkSLA pings whoever handles a ticket, the admin or the reviewer it is
+// assigned to, whenever one of their tickets crosses a new threshold. The
+// reminder lists every overdue ticket of theirs, not just the ones that
+// triggered it.
+func (b *Bot) checkSLA(thresholds []time.Duration) {
+	var recipients []int64
+	overdue := make(map[int64][]*UserSession)
+	escalated := make(map[int64]bool)
+
+	for _, session := range b.openTickets() {
+		if session.Snoozed(time.Now()) {
+			continue
+		}
+		waiting := time.Since(session.CreatedAt)
+
+		level := 0
+		for level < len(thresholds) && waiting >= thresholds[level] {
+			level++
+		}
+		if level == 0 {
+			continue
+		}
+
+		recipient := b.adminID
+		if session.AssignedTo != 0 {
+			recipient = session.AssignedTo
+		}
+		if _, listed := overdue[recipient]; !listed {
+			recipients = append(recipients, recipient)
+		}
+		overdue[recipient] = append(overdue[recipient], session)
+		if level > session.SLALevel {
+			session.SLALevel = level
+			escalated[recipient] = true
+		}
+	}
+
+	for _, recipient := range recipients {
+		if escalated[recipient] {
+			b.sendSLAReminder(recipient, overdue[recipient], thresholds)
+		}
+	}
+}
+
+func (b *Bot) sendSLAReminder(recipient int64, overdue []*UserSession, thresholds []time.Duration) {
+	var reminder strings.Builder
+	reminder.WriteString(fmt.Sprintf("⏰ SLA reminder: %d overdue ticket(s)\n\n", len(overdue)))
+	for _, session := range overdue {
+		user := fmt.Sprintf("user ID %d", session.UserID)
+		if session.Username != "" {
+			user = "@" + session.Username
+		}
+		reminder.WriteString(fmt.Sprintf("#%d %s - waiting %s (over %s)\n%s\n\n",
+			session.TicketID, user, formatDuration(time.Since(session.CreatedAt)),
+			formatDuration(thresholds[session.SLALevel-1]), truncateText(session.LastQuestion, 100)))
+	}
+
+	_, err := b.api.SendLong(recipient, reminder.String(), nil)
+	if err != nil {
+		b.logger.WithError(err).WithField("chat_id", recipient).Error("Failed to send SLA reminder")
+	}
+}
@@ -0,0 +1,331 @@
+package bot
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+const (
+	integrationSlack = "slack"
+
+	slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+	slackHTTPTimeout    = 15 * time.Second
+	slackMaxClockSkew   = 5 * time.Minute
+	// slackAnswerPrefix marks a thread reply as the answer to relay, so the
+	// team can discuss a question in its thread without messaging the user.
+	slackAnswerPrefix = "!answer"
+)
+
+// SlackClient mirrors new tickets into a Slack channel. With only a webhook
+// it posts one-way; with a bot token and signing secret, thread replies
+// starting with !answer are relayed back to the user.
+type SlackClient struct {
+	webhookURL    string
+	token         string
+	channel       string
+	signingSecret string
+	eventsAddr    string
+	client        *http.Client
+
+	mu      sync.Mutex
+	threads map[string]int
+	posts   map[int]string
+}
+
+// slackClientFromConfig returns nil when neither SLACK_WEBHOOK_URL nor
+// SLACK_BOT_TOKEN is set.
+func slackClientFromConfig(config *Config) (*SlackClient, error) {
+	settings := &config.Integrations.Slack
+	slack := &SlackClient{
+		webhookURL:    settings.WebhookURL,
+		token:         settings.BotToken,
+		channel:       settings.ChannelID,
+		signingSecret: settings.SigningSecret,
+		eventsAddr:    settings.EventsAddr,
+		client:        &http.Client{Timeout: slackHTTPTimeout},
+		threads:       make(map[string]int),
+		posts:         make(map[int]string),
+	}
+
+	if slack.webhookURL == "" && slack.token == "" {
+		return nil, nil
+	}
+	if slack.token != "" && slack.channel == "" {
+		return nil, fmt.Errorf("SLACK_CHANNEL_ID is required when SLACK_BOT_TOKEN is set")
+	}
+	if slack.eventsAddr != "" && (slack.token == "" || slack.signingSecret == "") {
+		return nil, fmt.Errorf("SLACK_BOT_TOKEN and SLACK_SIGNING_SECRET are required to relay answers from Slack")
+	}
+
+	return slack, nil
+}
+
+// RelayEnabled reports whether answers typed in Slack threads reach users.
+func (s *SlackClient) RelayEnabled() bool {
+	return s.eventsAddr != ""
+}
+
+// PostTicket announces a new ticket in the channel.
+func (s *SlackClient) PostTicket(ticket storage.TicketRecord) error {
+	user := fmt.Sprintf("user %d", ticket.UserID)
+	if ticket.Username != "" {
+		user = "@" + ticket.Username
+	}
+
+	text := fmt.Sprintf("🆕 Ticket #%d from %s (%s)\n\n%s", ticket.ID, user, categoryLabel(ticket.Category), ticket.Question)
+	if s.RelayEnabled() {
+		text += fmt.Sprintf("\n\nReply in this thread with `%s <text>` to answer the user.", slackAnswerPrefix)
+	}
+
+	if s.token == "" {
+		return s.postWebhook(text)
+	}
+
+	ts, err := s.postMessage(text, "")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.threads[ts] = ticket.ID
+	s.posts[ticket.ID] = ts
+	s.mu.Unlock()
+
+	return nil
+}
+
+// PostAnswer notes in the ticket's thread that it has been answered.
+func (s *SlackClient) PostAnswer(ticketID int, answer string) error {
+	s.mu.Lock()
+	ts, exists := s.posts[ticketID]
+	delete(s.posts, ticketID)
+	if exists {
+		delete(s.threads, ts)
+	}
+	s.mu.Unlock()
+
+	if !exists {
+		return nil
+	}
+
+	_, err := s.postMessage("✅ Answered:\n\n"+answer, ts)
+	return err
+}
+
+func (s *SlackClient) ticketForThread(ts string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ticketID, exists := s.threads[ts]
+	return ticketID, exists
+}
+
+func (s *SlackClient) postWebhook(text string) error {
+	payload, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("slack webhook returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
+
+func (s *SlackClient) postMessage(text, threadTS string) (string, error) {
+	body := map[string]string{"channel": s.channel, "text": text}
+	if threadTS != "" {
+		body["thread_ts"] = threadTS
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, slackPostMessageURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		TS    string `json:"ts"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if !result.OK {
+		return "", fmt.Errorf("slack chat.postMessage failed: %s", result.Error)
+	}
+
+	return result.TS, nil
+}
+
+// verifySignature checks the X-Slack-Signature of an Events API request.
+func (s *SlackClient) verifySignature(header http.Header, body []byte) bool {
+	timestamp, err := strconv.ParseInt(header.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return false
+	}
+
+	skew := time.Since(time.Unix(timestamp, 0))
+	if skew > slackMaxClockSkew || skew < -slackMaxClockSkew {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.signingSecret))
+	fmt.Fprintf(mac, "v0:%d:%s", timestamp, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature")))
+}
+
+type slackEvent struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Event     struct {
+		Type     string `json:"type"`
+		Subtype  string `json:"subtype"`
+		BotID    string `json:"bot_id"`
+		User     string `json:"user"`
+		Channel  string `json:"channel"`
+		Text     string `json:"text"`
+		ThreadTS string `json:"thread_ts"`
+	} `json:"event"`
+}
+
+// startSlackEventsServer receives thread replies from the Slack Events API
+// on SLACK_EVENTS_ADDR.
+func (b *Bot) startSlackEventsServer() {
+	if b.slack == nil || !b.slack.RelayEnabled() {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slack/events", b.handleSlackEvents)
+
+	go func() {
+		err := http.ListenAndServe(b.slack.eventsAddr, mux)
+		if err != nil {
+			b.logger.WithError(err).WithField("addr", b.slack.eventsAddr).Error("Slack events server stopped")
+		}
+	}()
+}
+
+func (b *Bot) handleSlackEvents(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !b.slack.verifySignature(r.Header, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var payload slackEvent
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	if payload.Type == "url_verification" {
+		fmt.Fprint(w, payload.Challenge)
+		return
+	}
+
+	// Slack retries deliveries it considers slow; the first one is handled
+	w.WriteHeader(http.StatusOK)
+	if r.Header.Get("X-Slack-Retry-Num") != "" || payload.Type != "event_callback" {
+		return
+	}
+
+	event := payload.Event
+	if event.Type != "message" || event.Subtype != "" || event.BotID != "" ||
+		event.ThreadTS == "" || event.Channel != b.slack.channel {
+		return
+	}
+
+	answer, found := strings.CutPrefix(strings.TrimSpace(event.Text), slackAnswerPrefix)
+	answer = strings.TrimSpace(answer)
+	if !found || answer == "" {
+		return
+	}
+
+	ticketID, exists := b.slack.ticketForThread(event.ThreadTS)
+	if !exists {
+		return
+	}
+
+	go b.relaySlackAnswer(ticketID, event.User, answer)
+}
+
+func (b *Bot) relaySlackAnswer(ticketID int, slackUser, answer string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	session, exists := b.tickets[ticketID]
+	if !exists {
+		return
+	}
+
+	b.logger.WithFields(logrus.Fields{
+		"ticket_id":  ticketID,
+		"slack_user": slackUser,
+	}).Info("Relaying answer from Slack")
+
+	b.deliverAnswer(session, answer)
+}
+
+func (b *Bot) postTicketToSlack(ticket storage.TicketRecord) {
+	if b.slack == nil {
+		return
+	}
+
+	b.runIntegration(integrationSlack, fmt.Sprintf("post ticket #%d to Slack", ticket.ID), func() error {
+		return b.slack.PostTicket(ticket)
+	})
+}
+
+func (b *Bot) postAnswerToSlack(ticketID int, answer string) {
+	if b.slack == nil {
+		return
+	}
+
+	b.runIntegration(integrationSlack, fmt.Sprintf("post answer for ticket #%d to Slack", ticketID), func() error {
+		return b.slack.PostAnswer(ticketID, answer)
+	})
+}
@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const snoozeCheckInterval = time.Minute
+
+// snoozeOptions are the snooze buttons on admin notifications.
+var snoozeOptions = []struct {
+	Label    string
+	Duration time.Duration
+}{
+	{"⏰ 1h", time.Hour},
+	{"⏰ 4h", 4 * time.Hour},
+	{"⏰ 1d", 24 * time.Hour},
+}
+
+func snoozeKeyboard(ticketID int) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, option := range snoozeOptions {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(option.Label,
+			fmt.Sprintf("snooze:%d:%s", ticketID, option.Duration)))
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// Snoozed reports whether the admin put the ticket aside until later.
+func (s *UserSession) Snoozed(now time.Time) bool {
+	return s.SnoozedUntil.After(now)
+}
+
+// handleSnoozeCallback processes "snooze:<ticket_id>:<duration>" callbacks
+// from admin notifications. The ticket is hidden from /sessions and SLA
+// reminders until the snooze is over, then notified again.
+func (b *Bot) handleSnoozeCallback(callback *tgbotapi.CallbackQuery) {
+	idArg, durationArg, _ := strings.Cut(strings.TrimPrefix(callback.Data, "snooze:"), ":")
+	ticketID, err := strconv.Atoi(idArg)
+	if err != nil {
+		b.logger.WithError(err).WithField("callback_data", callback.Data).Error("Malformed snooze callback")
+		return
+	}
+	duration, err := time.ParseDuration(durationArg)
+	if err != nil {
+		b.logger.WithError(err).WithField("callback_data", callback.Data).Error("Malformed snooze callback")
+		return
+	}
+
+	var reply string
+	session := b.findOpenTicket(fmt.Sprintf("#%d", ticketID))
+	if session == nil {
+		reply = fmt.Sprintf("Ticket #%d is already closed", ticketID)
+	} else {
+		until := time.Now().Add(duration)
+		if err := b.store.SnoozeTicket(ticketID, until); err != nil {
+			b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to persist snoozed ticket")
+		}
+		session.SnoozedUntil = until
+
+		b.logger.WithFields(logrus.Fields{
+			"ticket_id": ticketID,
+			"until":     until,
+		}).Info("Ticket snoozed")
+		reply = fmt.Sprintf("⏰ Ticket #%d snoozed until %s, you will be notified again then", ticketID, until.Format("2006-01-02 15:04"))
+	}
+
+	msg := tgbotapi.NewMessage(b.adminID, reply)
+	if callback.Message != nil {
+		msg.ReplyToMessageID = callback.Message.MessageID
+	}
+	_, err = b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send snooze confirmation")
+	}
+}
+
+func (b *Bot) runSnoozes() {
+	ticker := time.NewTicker(snoozeCheckInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		b.mu.Lock()
+		b.wakeSnoozedTickets(now)
+		b.mu.Unlock()
+	}
+}
+
+// wakeSnoozedTickets notifies the admin again about open tickets whose
+// snooze is over. The snooze is kept in the store, so tickets snoozed
+// before a restart come back too. Callers must hold b.mu.
+func (b *Bot) wakeSnoozedTickets(now time.Time) {
+	for _, ticket := range b.store.Tickets() {
+		if ticket.SnoozedUntil.IsZero() || ticket.SnoozedUntil.After(now) {
+			continue
+		}
+		if err := b.store.SnoozeTicket(ticket.ID, time.Time{}); err != nil {
+			b.logger.WithError(err).WithField("ticket_id", ticket.ID).Error("Failed to persist snooze end")
+		}
+
+		session := b.findOpenTicket(fmt.Sprintf("#%d", ticket.ID))
+		if session == nil {
+			continue
+		}
+		session.SnoozedUntil = time.Time{}
+
+		msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("⏰ Snooze of ticket #%d is over", ticket.ID))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("ticket_id", ticket.ID).Error("Failed to send snooze reminder")
+		}
+		if err := b.sendAdminNotification(session); err != nil {
+			b.logger.WithError(err).WithField("ticket_id", ticket.ID).Error("Failed to notify about snoozed ticket")
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+const defaultDailyReportTime = "09:00"
+
+// StatsSummary aggregates persisted history over a time window.
+type StatsSummary struct {
+	Received      int
+	Answered      int
+	CVRequests    int
+	NewUsers      int
+	RatingsUp     int
+	RatingsDown   int
+	ResponseTimes []time.Duration
+	HourCounts    [24]int
+	Categories    map[string]int
+}
+
+func inWindow(t, from, to time.Time) bool {
+	return !t.IsZero() && !t.Before(from) && t.Before(to)
+}
+
+func computeStats(tickets []storage.TicketRecord, users []storage.UserRecord, from, to time.Time) StatsSummary {
+	summary := StatsSummary{Categories: make(map[string]int)}
+
+	for _, ticket := range tickets {
+		if inWindow(ticket.CreatedAt, from, to) {
+			summary.Received++
+			summary.HourCounts[ticket.CreatedAt.Hour()]++
+			if ticket.Kind == string(StateCVReview) {
+				summary.CVRequests++
+			} else if ticket.Category != "" {
+				summary.Categories[ticket.Category]++
+			}
+		}
+
+		if inWindow(ticket.AnsweredAt, from, to) {
+			summary.Answered++
+			if !ticket.CreatedAt.IsZero() {
+				summary.ResponseTimes = append(summary.ResponseTimes, ticket.AnsweredAt.Sub(ticket.CreatedAt))
+			}
+			switch ticket.Rating {
+			case storage.RatingUp:
+				summary.RatingsUp++
+			case storage.RatingDown:
+				summary.RatingsDown++
+			}
+		}
+	}
+
+	for _, user := range users {
+		if inWindow(user.FirstSeen, from, to) {
+			summary.NewUsers++
+		}
+	}
+
+	return summary
+}
+
+func (s StatsSummary) AverageResponseTime() time.Duration {
+	if len(s.ResponseTimes) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, responseTime := range s.ResponseTimes {
+		total += responseTime
+	}
+
+	return total / time.Duration(len(s.ResponseTimes))
+}
+
+// ResponseTimePercentile returns the p-th percentile (0-100) of response
+// times using the nearest-rank method.
+func (s StatsSummary) ResponseTimePercentile(p float64) time.Duration {
+	if len(s.ResponseTimes) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(s.ResponseTimes))
+	copy(sorted, s.ResponseTimes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(float64(len(sorted))*p/100+0.5) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+
+	return sorted[rank]
+}
+
+// BusiestHours returns up to n hours of the day with the most new tickets.
+func (s StatsSummary) BusiestHours(n int) []int {
+	var hours []int
+	for hour, count := range s.HourCounts {
+		if count > 0 {
+			hours = append(hours, hour)
+		}
+	}
+	sort.SliceStable(hours, func(i, j int) bool { return s.HourCounts[hours[i]] > s.HourCounts[hours[j]] })
+
+	return hours[:min(n, len(hours))]
+}
+
+// TopCategories returns up to n question categories ordered by volume.
+func (s StatsSummary) TopCategories(n int) []string {
+	categories := make([]string, 0, len(s.Categories))
+	for category := range s.Categories {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if s.Categories[categories[i]] != s.Categories[categories[j]] {
+			return s.Categories[categories[i]] > s.Categories[categories[j]]
+		}
+		return categories[i] < categories[j]
+	})
+
+	return categories[:min(n, len(categories))]
+}
+
+func (s StatsSummary) satisfactionLine() string {
+	rated := s.RatingsUp + s.RatingsDown
+	if rated == 0 {
+		return "⭐ Satisfaction: no ratings yet\n"
+	}
+
+	return fmt.Sprintf("⭐ Satisfaction: %.0f%% (%d 👍 / %d 👎, %d rated)\n",
+		float64(s.RatingsUp)*100/float64(rated), s.RatingsUp, s.RatingsDown, rated)
+}
+
+func (s StatsSummary) averageResponseLine() string {
+	if len(s.ResponseTimes) == 0 {
+		return "⏱ Average response time: -\n"
+	}
+
+	return fmt.Sprintf("⏱ Average response time: %s\n⏱ Median / p95: %s / %s\n",
+		formatDuration(s.AverageResponseTime()),
+		formatDuration(s.ResponseTimePercentile(50)), formatDuration(s.ResponseTimePercentile(95)))
+}
+
+// statsPeriods maps the /stats argument to the window it covers; zero means
+// all time.
+var statsPeriods = map[string]time.Duration{
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"all": 0,
+}
+
+func (b *Bot) showStats(args string) {
+	if args == "" {
+		args = "all"
+	}
+
+	period, exists := statsPeriods[args]
+	if !exists {
+		msg := tgbotapi.NewMessage(b.adminID, "Usage: /stats [7d|30d|all]")
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send stats usage")
+		}
+		return
+	}
+
+	now := time.Now()
+	from := time.Time{}
+	if period > 0 {
+		from = now.Add(-period)
+	}
+	summary := computeStats(b.store.Tickets(), b.store.Users(), from, now)
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("📊 Bot statistics (%s):\n\n", args))
+	text.WriteString(fmt.Sprintf("📬 Open tickets: %d\n", len(b.tickets)))
+	text.WriteString(fmt.Sprintf("📥 Questions received: %d\n", summary.Received))
+	text.WriteString(fmt.Sprintf("✅ Answered tickets: %d\n", summary.Answered))
+	text.WriteString(fmt.Sprintf("📄 CV reviews requested: %d\n", summary.CVRequests))
+	text.WriteString(fmt.Sprintf("👤 New users: %d\n", summary.NewUsers))
+	text.WriteString(summary.averageResponseLine())
+	text.WriteString(summary.satisfactionLine())
+
+	if hours := summary.BusiestHours(3); len(hours) > 0 {
+		text.WriteString("\n🕐 Busiest hours:\n")
+		for _, hour := range hours {
+			text.WriteString(fmt.Sprintf("• %02d:00-%02d:00 - %d tickets\n", hour, (hour+1)%24, summary.HourCounts[hour]))
+		}
+	}
+
+	if categories := summary.TopCategories(3); len(categories) > 0 {
+		text.WriteString("\n🏷 Top categories:\n")
+		for _, category := range categories {
+			text.WriteString(fmt.Sprintf("• %s - %d\n", categoryLabel(category), summary.Categories[category]))
+		}
+	}
+
+	text.WriteString(experimentLines(computeExperiment(b.store.Tickets(), b.store.Users(), from, now)))
+
+	msg := tgbotapi.NewMessage(b.adminID, text.String())
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send stats")
+	}
+}
+
+// dailyReportTimeFromConfig reads DAILY_REPORT_TIME as "HH:MM" in local
+// time. A value of "off" disables the daily report.
+func dailyReportTimeFromConfig(config *Config) (time.Time, bool, error) {
+	value := strings.TrimSpace(config.Features.DailyReportTime)
+	if value == "off" {
+		return time.Time{}, false, nil
+	}
+	if value == "" {
+		value = defaultDailyReportTime
+	}
+
+	at, err := time.Parse("15:04", value)
+	return at, err == nil, err
+}
+
+func nextDailyRun(now, at time.Time) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
+
+func (b *Bot) runDailyReport(at time.Time) {
+	for {
+		next := nextDailyRun(time.Now(), at)
+		time.Sleep(time.Until(next))
+
+		b.mu.Lock()
+		b.sendDailyReport(next.AddDate(0, 0, -1), next)
+		b.mu.Unlock()
+	}
+}
+
+func (b *Bot) sendDailyReport(from, to time.Time) {
+	summary := computeStats(b.store.Tickets(), b.store.Users(), from, to)
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("🗓 Daily report for %s - %s\n\n", from.Format("Jan 2 15:04"), to.Format("Jan 2 15:04")))
+	text.WriteString(fmt.Sprintf("📥 Questions received: %d\n", summary.Received))
+	text.WriteString(fmt.Sprintf("✅ Answered: %d\n", summary.Answered))
+	text.WriteString(summary.averageResponseLine())
+	text.WriteString(fmt.Sprintf("📄 CV reviews requested: %d\n", summary.CVRequests))
+	text.WriteString(fmt.Sprintf("👤 New users: %d\n", summary.NewUsers))
+	text.WriteString(fmt.Sprintf("📬 Still open: %d\n", len(b.tickets)))
+
+	msg := tgbotapi.NewMessage(b.adminID, text.String())
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send daily report")
+	}
+}
@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// openTickets returns every unanswered session in the order the admin queue
+// is worked through: priority tickets first, then oldest first.
+func (b *Bot) openTickets() []*UserSession {
+	tickets := make([]*UserSession, 0, len(b.tickets))
+	for _, session := range b.tickets {
+		tickets = append(tickets, session)
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		return queuedBefore(tickets[i], tickets[j])
+	})
+
+	return tickets
+}
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	return string(runes[:limit]) + "…"
+}
+
+func (b *Bot) showUserStatus(userID int64) {
+	var statusText strings.Builder
+
+	queue := b.openTickets()
+	for position, session := range queue {
+		if session.UserID != userID {
+			continue
+		}
+
+		if statusText.Len() == 0 {
+			statusText.WriteString(b.trMarkdown(userID, "status_header") + "\n\n")
+		}
+		statusText.WriteString(b.trMarkdown(userID, "status_ticket", map[string]interface{}{
+			"TicketID": session.TicketID,
+			"Waiting":  formatDuration(time.Since(session.CreatedAt)),
+			"Position": position + 1,
+			"Total":    len(queue),
+			"Question": truncateText(session.LastQuestion, 100),
+		}) + "\n\n")
+	}
+
+	if statusText.Len() == 0 {
+		statusText.WriteString(b.trMarkdown(userID, "status_none"))
+	} else {
+		statusText.WriteString(b.trMarkdown(userID, "status_footer"))
+	}
+
+	msg := telegram.NewMarkdownMessage(userID, statusText.String())
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send user status")
+	}
+}
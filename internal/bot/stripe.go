@@ -0,0 +1,342 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const (
+	stripeCheckoutURL    = "https://api.stripe.com/v1/checkout/sessions"
+	stripeHTTPTimeout    = 15 * time.Second
+	stripeMaxClockSkew   = 5 * time.Minute
+	stripeCheckoutExpiry = time.Hour
+)
+
+// StripeClient sells priority CV reviews and subscriptions through Stripe
+// Checkout, for users Telegram Payments does not reach. A payment only
+// counts once Stripe's webhook confirms it.
+type StripeClient struct {
+	secretKey     string
+	webhookSecret string
+	webhookAddr   string
+	client        *http.Client
+}
+
+// stripeClientFromConfig returns nil when STRIPE_SECRET_KEY is unset.
+func stripeClientFromConfig(config *Config) (*StripeClient, error) {
+	settings := &config.Integrations.Payments.Stripe
+	stripe := &StripeClient{
+		secretKey:     settings.SecretKey,
+		webhookSecret: settings.WebhookSecret,
+		webhookAddr:   settings.WebhookAddr,
+		client:        &http.Client{Timeout: stripeHTTPTimeout},
+	}
+
+	if stripe.secretKey == "" {
+		return nil, nil
+	}
+	if stripe.webhookSecret == "" || stripe.webhookAddr == "" {
+		return nil, fmt.Errorf("STRIPE_WEBHOOK_SECRET and STRIPE_WEBHOOK_ADDR are required to confirm Stripe payments")
+	}
+
+	return stripe, nil
+}
+
+// CreateCheckout creates a Checkout Session for one priority review and
+// returns its payment page. reference comes back in the webhook.
+func (s *StripeClient) CreateCheckout(amount int, currency, name, reference, returnURL string) (string, error) {
+	form := url.Values{
+		"mode":                                   {"payment"},
+		"client_reference_id":                    {reference},
+		"success_url":                            {returnURL},
+		"cancel_url":                             {returnURL},
+		"expires_at":                             {strconv.FormatInt(time.Now().Add(stripeCheckoutExpiry).Unix(), 10)},
+		"line_items[0][quantity]":                {"1"},
+		"line_items[0][price_data][currency]":    {strings.ToLower(currency)},
+		"line_items[0][price_data][unit_amount]": {strconv.Itoa(amount)},
+		"line_items[0][price_data][product_data][name]": {name},
+	}
+
+	return s.createSession(form)
+}
+
+// CreateSubscriptionCheckout creates a Checkout Session for a monthly
+// subscription and returns its payment page. Stripe charges it every month
+// until it is canceled; the user ID is kept in the subscription's metadata
+// to credit the renewals.
+func (s *StripeClient) CreateSubscriptionCheckout(userID int64, amount int, currency, name, reference, returnURL string) (string, error) {
+	form := url.Values{
+		"mode":                                   {"subscription"},
+		"client_reference_id":                    {reference},
+		"success_url":                            {returnURL},
+		"cancel_url":                             {returnURL},
+		"expires_at":                             {strconv.FormatInt(time.Now().Add(stripeCheckoutExpiry).Unix(), 10)},
+		"line_items[0][quantity]":                {"1"},
+		"line_items[0][price_data][currency]":    {strings.ToLower(currency)},
+		"line_items[0][price_data][unit_amount]": {strconv.Itoa(amount)},
+		"line_items[0][price_data][recurring][interval]": {"month"},
+		"line_items[0][price_data][product_data][name]":  {name},
+		"subscription_data[metadata][user_id]":           {strconv.FormatInt(userID, 10)},
+	}
+
+	return s.createSession(form)
+}
+
+func (s *StripeClient) createSession(form url.Values) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, stripeCheckoutURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(s.secretKey, "")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		URL   string `json:"url"`
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("stripe returned %s: %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("stripe returned %s: %s", resp.Status, result.Error.Message)
+	}
+
+	return result.URL, nil
+}
+
+// verifySignature checks the Stripe-Signature header of a webhook request:
+// "t=<timestamp>,v1=<signature>[,v1=...]".
+func (s *StripeClient) verifySignature(header string, body []byte) bool {
+	var timestamp int64
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp, _ = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	skew := time.Since(time.Unix(timestamp, 0))
+	if timestamp == 0 || skew > stripeMaxClockSkew || skew < -stripeMaxClockSkew {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.webhookSecret))
+	fmt.Fprintf(mac, "%d.%s", timestamp, body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(expected), []byte(signature)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type stripeEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		Object struct {
+			ID                string `json:"id"`
+			ClientReferenceID string `json:"client_reference_id"`
+			Mode              string `json:"mode"`
+			PaymentStatus     string `json:"payment_status"`
+			PaymentIntent     string `json:"payment_intent"`
+			AmountTotal       int    `json:"amount_total"`
+			AmountPaid        int    `json:"amount_paid"`
+			Currency          string `json:"currency"`
+			// Invoices of a subscription, in the API versions before and
+			// after 2025-03-31
+			Subscription        string                    `json:"subscription"`
+			SubscriptionDetails stripeSubscriptionDetails `json:"subscription_details"`
+			Parent              struct {
+				SubscriptionDetails stripeSubscriptionDetails `json:"subscription_details"`
+			} `json:"parent"`
+			Lines struct {
+				Data []struct {
+					Period struct {
+						End int64 `json:"end"`
+					} `json:"period"`
+				} `json:"data"`
+			} `json:"lines"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+type stripeSubscriptionDetails struct {
+	Subscription string `json:"subscription"`
+	Metadata     struct {
+		UserID string `json:"user_id"`
+	} `json:"metadata"`
+}
+
+// startStripeWebhookServer receives payment confirmations from Stripe on
+// STRIPE_WEBHOOK_ADDR.
+func (b *Bot) startStripeWebhookServer() {
+	if b.stripe == nil {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stripe/webhook", b.handleStripeWebhook)
+
+	go func() {
+		err := http.ListenAndServe(b.stripe.webhookAddr, mux)
+		if err != nil {
+			b.logger.WithError(err).WithField("addr", b.stripe.webhookAddr).Error("Stripe webhook server stopped")
+		}
+	}()
+}
+
+func (b *Bot) handleStripeWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !b.stripe.verifySignature(r.Header.Get("Stripe-Signature"), body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var event stripeEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	switch event.Type {
+	case "invoice.paid":
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.handleStripeInvoice(event)
+		w.WriteHeader(http.StatusOK)
+		return
+	case "customer.subscription.deleted":
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.cancelStripeSubscription(event.Data.Object.ID)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// Delayed payment methods complete the session before the money
+	// arrives; those are confirmed by the async_payment_succeeded event.
+	// Subscriptions are confirmed by their invoices instead.
+	checkout := event.Data.Object
+	confirmed := (event.Type == "checkout.session.completed" && checkout.PaymentStatus == "paid") ||
+		event.Type == "checkout.session.async_payment_succeeded"
+	if !confirmed {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	userID, ok := priorityPayloadUser(checkout.ClientReferenceID)
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	chargeID := checkout.PaymentIntent
+	if chargeID == "" {
+		chargeID = checkout.ID
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.confirmPayment(checkout.ClientReferenceID, storage.PaymentRecord{
+		ChargeID: chargeID,
+		Provider: "stripe",
+		UserID:   userID,
+		Amount:   checkout.AmountTotal,
+		Currency: strings.ToUpper(checkout.Currency),
+		PaidAt:   time.Now(),
+	})
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// sendStripeCheckout sends the user a link to a Stripe payment page for a
+// priority review. Callers must hold b.mu, which is released while Stripe
+// creates the session; the link is only sent if the user is still choosing
+// a tier for the same intake by then.
+func (b *Bot) sendStripeCheckout(userID int64, intake *CVIntake) {
+	if intake.creatingCheckout {
+		return
+	}
+	intake.creatingCheckout = true
+	b.sendChatAction(userID, tgbotapi.ChatTyping)
+
+	payload := newPriorityPayload(userID)
+	returnURL := "https://t.me/" + b.api.Self().UserName
+	price := b.priorityPrice(userID)
+	currency := b.priorityReview.Currency
+	title := b.tr(userID, "invoice_title")
+
+	b.mu.Unlock()
+	link, err := b.stripe.CreateCheckout(price, currency, title, payload, returnURL)
+	b.mu.Lock()
+
+	intake.creatingCheckout = false
+	if b.cvForms[userID] != intake || b.userStates[userID] != StateCVTier {
+		b.logger.WithField("user_id", userID).Info("User left the tier choice while the Stripe checkout was created")
+		return
+	}
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to create Stripe checkout")
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "payment_link_failed"))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send payment link error")
+		}
+		return
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(b.tr(userID, "button_pay", map[string]interface{}{"Price": formatPrice(price, currency)}), link),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_standard_review"), "cv_tier:standard"),
+		),
+	)
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "payment_link"))
+	msg.ReplyMarkup = keyboard
+	_, err = b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send Stripe payment link")
+		return
+	}
+
+	intake.Payment = PaymentPending
+	intake.invoicePayload = payload
+	intake.price = price
+}
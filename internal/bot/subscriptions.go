@@ -0,0 +1,370 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const (
+	subscriptionPayloadPrefix   = "subscription:"
+	defaultFreeQuestions        = 3
+	subscriptionQuotaPeriodDays = 30
+)
+
+// SubscriptionPlan is the monthly mentorship subscription: unlimited
+// questions and the flows reserved for subscribers, at Price in the smallest
+// unit of Currency per month. It is sold through the same Telegram Payments
+// provider and Stripe account as the priority review.
+type SubscriptionPlan struct {
+	ProviderToken string
+	Price         int
+	Currency      string
+	// FreeQuestions is how many questions users without a subscription may
+	// ask in 30 days
+	FreeQuestions int
+	// SubscriberOnly names the flows only subscribers may start
+	SubscriberOnly map[string]bool
+}
+
+// subscriptionPlanFromConfig reads SUBSCRIPTION_PRICE, FREE_QUESTIONS_PER_MONTH
+// (default 3) and SUBSCRIBER_ONLY_FLOWS, a comma-separated list of flow
+// names. It returns nil when SUBSCRIPTION_PRICE is unset, i.e. every flow is
+// free.
+func subscriptionPlanFromConfig(config *Config) (*SubscriptionPlan, error) {
+	payments := &config.Integrations.Payments
+	if payments.Subscription.Price == nil {
+		return nil, nil
+	}
+
+	token := payments.ProviderToken
+	if token == "" && payments.Stripe.SecretKey == "" {
+		return nil, fmt.Errorf("SUBSCRIPTION_PRICE needs PAYMENT_PROVIDER_TOKEN or STRIPE_SECRET_KEY to take payments")
+	}
+
+	price := *payments.Subscription.Price
+	if price <= 0 {
+		return nil, fmt.Errorf("SUBSCRIPTION_PRICE must be a positive amount in the smallest currency unit (e.g. 2000 for 20.00), got %d", price)
+	}
+
+	plan := &SubscriptionPlan{
+		ProviderToken:  token,
+		Price:          price,
+		Currency:       strings.ToUpper(payments.Currency),
+		FreeQuestions:  intOr(payments.Subscription.FreeQuestions, defaultFreeQuestions),
+		SubscriberOnly: make(map[string]bool),
+	}
+	if plan.Currency == "" {
+		plan.Currency = defaultPaymentCurrency
+	}
+
+	for _, name := range payments.Subscription.SubscriberOnlyFlows {
+		plan.SubscriberOnly[name] = true
+	}
+
+	return plan, nil
+}
+
+// subscribed reports whether the user has an active subscription.
+func (b *Bot) subscribed(userID int64) bool {
+	subscription, exists := b.store.Subscription(userID)
+	return exists && subscription.Active(time.Now())
+}
+
+// requireEntitlement wraps the start of a flow with the subscription check:
+// subscriber-only flows need a subscription and questions are limited to
+// the free quota without one. Users who are refused get the subscription
+// offer instead.
+func (b *Bot) requireEntitlement(flowName string, start func(userID int64)) func(userID int64) {
+	return func(userID int64) {
+		if b.subscriptionPlan == nil || b.subscribed(userID) {
+			start(userID)
+			return
+		}
+
+		if b.subscriptionPlan.SubscriberOnly[flowName] {
+			b.offerSubscription(userID, "subscription_required")
+			return
+		}
+
+		if flowName == string(StateQuestion) {
+			since := time.Now().AddDate(0, 0, -subscriptionQuotaPeriodDays)
+			if b.store.CountUserTickets(userID, string(StateQuestion), since) >= b.subscriptionPlan.FreeQuestions {
+				b.offerSubscription(userID, "subscription_quota_reached")
+				return
+			}
+		}
+
+		start(userID)
+	}
+}
+
+// showSubscription handles /subscribe: the user's subscription, and the
+// checkout buttons unless Stripe already renews it.
+func (b *Bot) showSubscription(userID int64) {
+	if b.subscriptionPlan == nil {
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "subscription_unavailable"))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send subscription reply")
+		}
+		return
+	}
+
+	subscription, exists := b.store.Subscription(userID)
+	if !exists || !subscription.Active(time.Now()) {
+		b.offerSubscription(userID, "subscription_offer")
+		return
+	}
+
+	data := map[string]interface{}{"Until": subscription.ActiveUntil.Format("2006-01-02")}
+	if subscription.StripeID != "" && subscription.CanceledAt.IsZero() {
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "subscription_renewing", data))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send subscription status")
+		}
+		return
+	}
+
+	b.offerSubscription(userID, "subscription_active_until", data)
+}
+
+// offerSubscription sends a text about the subscription with the buttons
+// to pay for a month.
+func (b *Bot) offerSubscription(userID int64, messageID string, data ...map[string]interface{}) {
+	plan := b.subscriptionPlan
+	price := formatPrice(plan.Price, plan.Currency)
+
+	values := map[string]interface{}{"Price": price, "Free": plan.FreeQuestions}
+	for _, extra := range data {
+		for key, value := range extra {
+			values[key] = value
+		}
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if plan.ProviderToken != "" {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_subscribe", map[string]interface{}{"Price": price}), "sub:telegram"),
+		))
+	}
+	if b.stripe != nil {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_subscribe_stripe", map[string]interface{}{"Price": price}), "sub:stripe"),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_back_to_menu"), "back_to_menu"),
+	))
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, messageID, values))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send subscription offer")
+	}
+}
+
+func (b *Bot) handleSubscriptionCallback(callback *tgbotapi.CallbackQuery) {
+	userID := callback.From.ID
+	plan := b.subscriptionPlan
+
+	switch {
+	case callback.Data == "sub:telegram" && plan != nil && plan.ProviderToken != "":
+		b.sendSubscriptionInvoice(userID)
+	case callback.Data == "sub:stripe" && plan != nil && b.stripe != nil:
+		b.sendSubscriptionCheckout(userID)
+	default:
+		b.showSubscription(userID)
+	}
+}
+
+// sendSubscriptionInvoice sends the Telegram invoice for a month of the
+// subscription. Telegram payments do not renew by themselves; the user pays
+// again for the next month.
+func (b *Bot) sendSubscriptionInvoice(userID int64) {
+	plan := b.subscriptionPlan
+	payload := fmt.Sprintf("%s%d:%d", subscriptionPayloadPrefix, userID, time.Now().UnixNano())
+	prices := []tgbotapi.LabeledPrice{{Label: b.tr(userID, "subscription_invoice_title"), Amount: plan.Price}}
+
+	invoice := tgbotapi.NewInvoice(userID, b.tr(userID, "subscription_invoice_title"), b.tr(userID, "subscription_invoice_description"),
+		payload, plan.ProviderToken, "", plan.Currency, prices)
+	// A nil slice is sent as null, which Telegram rejects
+	invoice.SuggestedTipAmounts = []int{}
+
+	_, err := b.api.Send(invoice)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send subscription invoice")
+		return
+	}
+
+	b.subscriptionInvoices[userID] = payload
+}
+
+// sendSubscriptionCheckout sends the user a link to a Stripe payment page
+// for a subscription that renews every month.
+func (b *Bot) sendSubscriptionCheckout(userID int64) {
+	b.sendChatAction(userID, tgbotapi.ChatTyping)
+
+	plan := b.subscriptionPlan
+	reference := fmt.Sprintf("%s%d:%d", subscriptionPayloadPrefix, userID, time.Now().UnixNano())
+	returnURL := "https://t.me/" + b.api.Self().UserName
+	link, err := b.stripe.CreateSubscriptionCheckout(userID, plan.Price, plan.Currency, b.tr(userID, "subscription_invoice_title"), reference, returnURL)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to create Stripe subscription checkout")
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "payment_link_failed"))
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send payment link error")
+		}
+		return
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(b.tr(userID, "button_pay", map[string]interface{}{"Price": formatPrice(plan.Price, plan.Currency)}), link),
+		),
+	)
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "subscription_link"))
+	msg.ReplyMarkup = keyboard
+	_, err = b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send Stripe subscription link")
+	}
+}
+
+// validSubscriptionCheckout reports whether a Telegram checkout is for the
+// latest subscription invoice of the user, at the current price.
+func (b *Bot) validSubscriptionCheckout(query *tgbotapi.PreCheckoutQuery) bool {
+	plan := b.subscriptionPlan
+	return plan != nil && b.subscriptionInvoices[query.From.ID] == query.InvoicePayload &&
+		query.TotalAmount == plan.Price && query.Currency == plan.Currency
+}
+
+// handleStripeInvoice extends the subscription an invoice paid for, the
+// first month as well as the renewals. Callers must hold b.mu.
+func (b *Bot) handleStripeInvoice(event stripeEvent) {
+	invoice := event.Data.Object
+
+	details := invoice.Parent.SubscriptionDetails
+	if details.Subscription == "" {
+		details = invoice.SubscriptionDetails
+		details.Subscription = invoice.Subscription
+	}
+	userID, err := strconv.ParseInt(details.Metadata.UserID, 10, 64)
+	if details.Subscription == "" || err != nil {
+		return
+	}
+
+	var until time.Time
+	if len(invoice.Lines.Data) > 0 && invoice.Lines.Data[0].Period.End > 0 {
+		until = time.Unix(invoice.Lines.Data[0].Period.End, 0)
+	}
+
+	b.confirmSubscription(storage.PaymentRecord{
+		ChargeID: invoice.ID,
+		Provider: "stripe",
+		UserID:   userID,
+		Amount:   invoice.AmountPaid,
+		Currency: strings.ToUpper(invoice.Currency),
+		PaidAt:   time.Now(),
+	}, details.Subscription, until)
+}
+
+// confirmSubscription records a subscription payment and tells the user
+// until when they are subscribed. Callers must hold b.mu.
+func (b *Bot) confirmSubscription(payment storage.PaymentRecord, stripeID string, until time.Time) {
+	userID := payment.UserID
+
+	subscription, added, err := b.store.RenewSubscription(payment, stripeID, until)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist subscription payment")
+	}
+	if !added {
+		return
+	}
+	delete(b.subscriptionInvoices, userID)
+
+	b.audit.Record(AuditSubscriptionPaid, userID, logrus.Fields{
+		"provider":     payment.Provider,
+		"charge_id":    payment.ChargeID,
+		"amount":       payment.Amount,
+		"currency":     payment.Currency,
+		"active_until": subscription.ActiveUntil,
+	})
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "subscription_paid", map[string]interface{}{
+		"Until": subscription.ActiveUntil.Format("2006-01-02"),
+	}))
+	_, err = b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send subscription confirmation")
+	}
+}
+
+// cancelStripeSubscription stops the renewals of a subscription canceled in
+// Stripe. The month paid for stays active. Callers must hold b.mu.
+func (b *Bot) cancelStripeSubscription(stripeID string) {
+	subscription, found, err := b.store.CancelSubscription(stripeID, time.Now())
+	if err != nil {
+		b.logger.WithError(err).WithField("subscription", stripeID).Error("Failed to persist subscription cancellation")
+	}
+	if !found {
+		return
+	}
+
+	b.audit.Record(AuditSubscriptionCanceled, subscription.UserID, logrus.Fields{
+		"subscription": stripeID,
+		"active_until": subscription.ActiveUntil,
+	})
+}
+
+// showSubscribers lists the subscriptions for the admin, the active ones
+// first.
+func (b *Bot) showSubscribers() {
+	subscriptions := b.store.Subscriptions()
+	now := time.Now()
+
+	var text strings.Builder
+	if len(subscriptions) == 0 {
+		text.WriteString("No subscribers yet.")
+	} else {
+		active := 0
+		for _, subscription := range subscriptions {
+			if subscription.Active(now) {
+				active++
+			}
+		}
+		fmt.Fprintf(&text, "Subscribers: %d active, %d expired\n\n", active, len(subscriptions)-active)
+
+		for _, subscription := range subscriptions {
+			name := strconv.FormatInt(subscription.UserID, 10)
+			if user, exists := b.store.User(subscription.UserID); exists && len(user.Usernames) > 0 {
+				name = "@" + user.Usernames[len(user.Usernames)-1]
+			}
+
+			status := "renews"
+			switch {
+			case !subscription.Active(now):
+				status = "expired"
+			case subscription.StripeID == "" || !subscription.CanceledAt.IsZero():
+				status = "ends"
+			}
+
+			fmt.Fprintf(&text, "• %s: %s %s (%s), %d payments, %s\n", name, status,
+				subscription.ActiveUntil.Format("2006-01-02"), subscription.Provider,
+				subscription.Payments, formatPrice(subscription.Paid, subscription.Currency))
+		}
+	}
+
+	_, err := b.api.SendLong(b.adminID, text.String(), nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send subscriber list")
+	}
+}
@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const (
+	defaultSurveyDelay  = 24 * time.Hour
+	surveyCheckInterval = 10 * time.Minute
+)
+
+func (b *Bot) runFollowUpSurveys() {
+	ticker := time.NewTicker(surveyCheckInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		b.mu.Lock()
+		delay := b.surveyDelay
+		b.mu.Unlock()
+		if delay <= 0 {
+			continue
+		}
+
+		for _, ticket := range b.store.TicketsAwaitingSurvey(now.Add(-delay)) {
+			b.sendFollowUpSurvey(ticket)
+		}
+	}
+}
+
+func (b *Bot) sendFollowUpSurvey(ticket storage.TicketRecord) {
+	userID := ticket.UserID
+	surveyText := b.trMarkdown(userID, "survey_prompt", map[string]interface{}{"Question": ticket.Question})
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_resolved"), fmt.Sprintf("survey:%d:yes", ticket.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_still_need_help"), fmt.Sprintf("survey:%d:no", ticket.ID)),
+		),
+	)
+
+	msg := telegram.NewMarkdownMessage(userID, surveyText)
+	msg.ReplyMarkup = keyboard
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":   ticket.UserID,
+			"ticket_id": ticket.ID,
+		}).Error("Failed to send follow-up survey")
+	}
+
+	// Mark as sent even on failure so blocked users are not retried forever
+	if err := b.store.MarkSurveySent(ticket.ID); err != nil {
+		b.logger.WithError(err).WithField("ticket_id", ticket.ID).Error("Failed to persist survey status")
+	}
+}
+
+// handleSurveyCallback processes "survey:<ticket_id>:<yes|no>" callbacks.
+// Unresolved answers are routed back to the admin as a new ticket.
+func (b *Bot) handleSurveyCallback(callback *tgbotapi.CallbackQuery) {
+	userID := callback.From.ID
+
+	parts := strings.Split(callback.Data, ":")
+	if len(parts) != 3 || (parts[2] != "yes" && parts[2] != "no") {
+		b.logger.WithField("callback_data", callback.Data).Error("Malformed survey callback")
+		return
+	}
+
+	ticketID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		b.logger.WithError(err).WithField("callback_data", callback.Data).Error("Malformed survey ticket ID")
+		return
+	}
+
+	resolved := parts[2] == "yes"
+	ticket, updated, err := b.store.ResolveTicket(ticketID, userID, resolved)
+	if err != nil {
+		b.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":   userID,
+			"ticket_id": ticketID,
+		}).Error("Failed to persist survey answer")
+	}
+	if !updated {
+		return
+	}
+
+	if callback.Message != nil {
+		removeButtons := tgbotapi.NewEditMessageReplyMarkup(callback.Message.Chat.ID, callback.Message.MessageID,
+			tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+		_, err = b.api.Request(removeButtons)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to remove survey buttons")
+		}
+	}
+
+	if resolved {
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "survey_resolved_thanks"))
+		_, err = b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send survey acknowledgement")
+		}
+		return
+	}
+
+	questionText := fmt.Sprintf("Follow-up to ticket #%d: the issue is not resolved.\n\nOriginal question: %s", ticket.ID, ticket.Question)
+	b.createUserSession(userID, callback.From.UserName, questionText, 0, false, "", StateQuestion)
+}
@@ -0,0 +1,164 @@
+package bot
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// mockTelegram is a TelegramClient that records what the bot sends instead
+// of calling the Bot API.
+type mockTelegram struct {
+	sent     []tgbotapi.Chattable
+	requests []tgbotapi.Chattable
+	files    map[string]tgbotapi.File
+	// downloads are the contents of files by ID
+	downloads map[string][]byte
+	// sendErr, when set, fails every Send, chatErrs every Send to a chat
+	sendErr  error
+	chatErrs map[int64]error
+	// beforeSend, when set, runs at the start of every Send
+	beforeSend func(tgbotapi.Chattable)
+	lastID     int
+}
+
+var _ TelegramClient = (*mockTelegram)(nil)
+
+func newMockTelegram() *mockTelegram {
+	return &mockTelegram{files: make(map[string]tgbotapi.File), downloads: make(map[string][]byte), chatErrs: make(map[int64]error)}
+}
+
+func (m *mockTelegram) Send(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if m.beforeSend != nil {
+		m.beforeSend(chattable)
+	}
+	if m.sendErr != nil {
+		return tgbotapi.Message{}, m.sendErr
+	}
+	if msg, ok := chattable.(tgbotapi.MessageConfig); ok && m.chatErrs[msg.ChatID] != nil {
+		return tgbotapi.Message{}, m.chatErrs[msg.ChatID]
+	}
+
+	m.sent = append(m.sent, chattable)
+	m.lastID++
+
+	message := tgbotapi.Message{MessageID: m.lastID}
+	if msg, ok := chattable.(tgbotapi.MessageConfig); ok {
+		message.Chat = &tgbotapi.Chat{ID: msg.ChatID}
+		message.Text = msg.Text
+	}
+
+	return message, nil
+}
+
+func (m *mockTelegram) SendLong(chatID int64, text string, markup interface{}) ([]tgbotapi.Message, error) {
+	return m.sendLong(chatID, text, "", markup)
+}
+
+func (m *mockTelegram) SendLongMarkdown(chatID int64, text string, markup interface{}) ([]tgbotapi.Message, error) {
+	return m.sendLong(chatID, text, tgbotapi.ModeMarkdownV2, markup)
+}
+
+func (m *mockTelegram) sendLong(chatID int64, text, parseMode string, markup interface{}) ([]tgbotapi.Message, error) {
+	parts := telegram.SplitMessage(text, telegram.MessageLimit)
+	if parseMode == tgbotapi.ModeMarkdownV2 {
+		parts = telegram.SplitMarkdown(text, telegram.MessageLimit)
+	}
+
+	sent := make([]tgbotapi.Message, 0, len(parts))
+	for i, part := range parts {
+		msg := tgbotapi.NewMessage(chatID, part)
+		msg.ParseMode = parseMode
+		if i == len(parts)-1 && markup != nil {
+			msg.ReplyMarkup = markup
+		}
+
+		message, err := m.Send(msg)
+		if err != nil {
+			return sent, err
+		}
+		sent = append(sent, message)
+	}
+
+	return sent, nil
+}
+
+func (m *mockTelegram) Request(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	m.requests = append(m.requests, chattable)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func (m *mockTelegram) GetFile(config tgbotapi.FileConfig) (tgbotapi.File, error) {
+	file, exists := m.files[config.FileID]
+	if !exists {
+		return tgbotapi.File{}, fmt.Errorf("file %q not found", config.FileID)
+	}
+
+	return file, nil
+}
+
+func (m *mockTelegram) DownloadFile(fileID string) ([]byte, error) {
+	data, exists := m.downloads[fileID]
+	if !exists {
+		return nil, fmt.Errorf("file %q not found", fileID)
+	}
+
+	return data, nil
+}
+
+func (m *mockTelegram) Self() tgbotapi.User {
+	return tgbotapi.User{ID: 1, IsBot: true, UserName: "faq_test_bot"}
+}
+
+func (m *mockTelegram) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return make(chan tgbotapi.Update)
+}
+
+// messages returns the text messages sent to chatID, oldest first.
+func (m *mockTelegram) messages(chatID int64) []tgbotapi.MessageConfig {
+	var messages []tgbotapi.MessageConfig
+	for _, chattable := range m.sent {
+		if msg, ok := chattable.(tgbotapi.MessageConfig); ok && msg.ChatID == chatID {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages
+}
+
+// lastMessage returns the most recent text message sent to chatID.
+func (m *mockTelegram) lastMessage(t *testing.T, chatID int64) tgbotapi.MessageConfig {
+	t.Helper()
+
+	messages := m.messages(chatID)
+	if len(messages) == 0 {
+		t.Fatalf("no message was sent to chat %d", chatID)
+	}
+
+	return messages[len(messages)-1]
+}
+
+var markdownEscape = regexp.MustCompile(`\\(.)`)
+
+// lastText returns the most recent text sent to chatID as the user reads it,
+// without MarkdownV2 escapes.
+func (m *mockTelegram) lastText(t *testing.T, chatID int64) string {
+	t.Helper()
+
+	msg := m.lastMessage(t, chatID)
+	if msg.ParseMode != tgbotapi.ModeMarkdownV2 {
+		return msg.Text
+	}
+
+	return markdownEscape.ReplaceAllString(msg.Text, "$1")
+}
+
+// reset forgets everything sent so far.
+func (m *mockTelegram) reset() {
+	m.sent = nil
+	m.requests = nil
+}
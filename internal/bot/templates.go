@@ -0,0 +1,121 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// templateData is the set of placeholders available to saved answer
+// templates, e.g. {{.Username}}, {{.TicketID}} and {{.Question}}.
+type templateData struct {
+	Username string
+	UserID   int64
+	TicketID int
+	Question string
+}
+
+func newTemplateData(session *UserSession) templateData {
+	return templateData{
+		Username: session.Username,
+		UserID:   session.UserID,
+		TicketID: session.TicketID,
+		Question: session.LastQuestion,
+	}
+}
+
+func renderTemplate(text string, session *UserSession) (string, error) {
+	tmpl, err := template.New("answer").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, newTemplateData(session)); err != nil {
+		return "", err
+	}
+
+	return rendered.String(), nil
+}
+
+func (b *Bot) renderSavedTemplate(name string, session *UserSession) (string, error) {
+	text, exists := b.store.Template(name)
+	if !exists {
+		return "", fmt.Errorf("template %q not found, see /templates", name)
+	}
+
+	return renderTemplate(text, session)
+}
+
+func (b *Bot) handleTemplateCommand(args string) {
+	fields := strings.Fields(args)
+
+	var reply string
+	switch {
+	case len(fields) >= 3 && fields[0] == "add":
+		name := fields[1]
+		text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(args), "add"))
+		text = strings.TrimSpace(strings.TrimPrefix(text, name))
+
+		// Validate placeholders against a sample session before saving
+		sample := &UserSession{Username: "username", UserID: 1, TicketID: 1, LastQuestion: "question"}
+		if _, err := renderTemplate(text, sample); err != nil {
+			reply = fmt.Sprintf("❌ Invalid template: %v\n\nAvailable placeholders: {{.Username}}, {{.UserID}}, {{.TicketID}}, {{.Question}}", err)
+			break
+		}
+
+		if err := b.store.SaveTemplate(name, text); err != nil {
+			b.logger.WithError(err).Error("Failed to save template")
+			reply = fmt.Sprintf("❌ Failed to save template: %v", err)
+			break
+		}
+		reply = fmt.Sprintf("✅ Template %q saved. Reply to a question with /t %s to use it.", name, name)
+
+	case len(fields) == 2 && fields[0] == "delete":
+		deleted, err := b.store.DeleteTemplate(fields[1])
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to delete template")
+			reply = fmt.Sprintf("❌ Failed to delete template: %v", err)
+		} else if !deleted {
+			reply = fmt.Sprintf("Template %q not found", fields[1])
+		} else {
+			reply = fmt.Sprintf("🗑 Template %q deleted", fields[1])
+		}
+
+	default:
+		reply = `Usage:
+/template add <name> <text>
+/template delete <name>
+
+Placeholders: {{.Username}}, {{.UserID}}, {{.TicketID}}, {{.Question}}`
+	}
+
+	msg := tgbotapi.NewMessage(b.adminID, reply)
+	_, err := b.api.Send(msg)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send template command reply")
+	}
+}
+
+func (b *Bot) showTemplates() {
+	names := b.store.TemplateNames()
+
+	var text strings.Builder
+	if len(names) == 0 {
+		text.WriteString("No saved templates. Add one with /template add <name> <text>")
+	} else {
+		text.WriteString("Saved templates:\n\n")
+		for _, name := range names {
+			body, _ := b.store.Template(name)
+			text.WriteString(fmt.Sprintf("• %s: %s\n", name, body))
+		}
+		text.WriteString("\nReply to a question with /t <name> to use a template")
+	}
+
+	_, err := b.api.SendLong(b.adminID, text.String(), nil)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to send templates list")
+	}
+}
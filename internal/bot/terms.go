@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// Terms is the privacy notice or terms of service users accept before
+// their first submission.
+type Terms struct {
+	// Text may use the same markup as FAQ answers
+	Text    string
+	Version string
+}
+
+// termsFromConfig loads TERMS_FILE, the terms users have to accept before they
+// start a flow, and TERMS_VERSION. Users who accepted another version are
+// asked again; without TERMS_VERSION the version is derived from the text,
+// so any edit asks everyone again. Without TERMS_FILE there is no gate.
+func termsFromConfig(config *Config) (*Terms, error) {
+	path := config.Texts.TermsFile
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+
+	version := strings.TrimSpace(config.Texts.TermsVersion)
+	if version == "" {
+		sum := sha256.Sum256([]byte(text))
+		version = hex.EncodeToString(sum[:4])
+	}
+
+	return &Terms{Text: text, Version: version}, nil
+}
+
+// requireTerms wraps the start of a flow with the terms gate: users who
+// have not accepted the current terms are shown them first, and the flow
+// starts once they accept.
+func (b *Bot) requireTerms(flowName string, start func(userID int64)) func(userID int64) {
+	return func(userID int64) {
+		if b.terms == nil {
+			start(userID)
+			return
+		}
+
+		user, _ := b.store.User(userID)
+		if user.TermsVersion == b.terms.Version {
+			start(userID)
+			return
+		}
+
+		b.showTerms(userID, flowName, user.TermsVersion != "")
+	}
+}
+
+func (b *Bot) showTerms(userID int64, flowName string, updated bool) {
+	promptID := "terms_prompt"
+	if updated {
+		promptID = "terms_updated"
+	}
+
+	text := b.trMarkdown(userID, promptID) + "\n\n" + telegram.FormatMarkdown(b.terms.Text)
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_terms_accept"), "terms:accept:"+flowName),
+			tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_terms_decline"), "terms:decline"),
+		),
+	)
+
+	if _, err := b.api.SendLongMarkdown(userID, text, keyboard); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send terms")
+	}
+}
+
+// handleTermsCallback processes "terms:accept:<flow>", which records that
+// the user accepted the current terms and starts the flow they asked for,
+// and "terms:decline".
+func (b *Bot) handleTermsCallback(callback *tgbotapi.CallbackQuery) {
+	userID := callback.From.ID
+
+	flowName, accepted := strings.CutPrefix(callback.Data, "terms:accept:")
+	if !accepted || b.terms == nil {
+		msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, "terms_declined"))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_back_to_menu"), "back_to_menu"),
+			),
+		)
+		if _, err := b.api.Send(msg); err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send terms declined message")
+		}
+		return
+	}
+
+	if err := b.store.AcceptTerms(userID, b.terms.Version, time.Now()); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist terms acceptance")
+		return
+	}
+	b.audit.Record(AuditTermsAccepted, userID, logrus.Fields{"version": b.terms.Version})
+
+	if flow, exists := b.flows.Flow(flowName); exists {
+		flow.Start(userID)
+		return
+	}
+	b.showWelcomeMenu(userID)
+}
@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "faq_bot"
+
+var tracer = otel.Tracer("github.com/DilmurodYangiboev/faq_bot")
+
+// SetupTracing installs an OTLP/HTTP trace exporter when an endpoint is
+// configured (otlp_endpoint, or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT). The
+// exporter reads the remaining standard OTEL_* variables itself. Without an
+// endpoint the global no-op tracer is kept.
+func SetupTracing(ctx context.Context, config *Config) (func(context.Context) error, error) {
+	endpoint := config.Observability.OTLPEndpoint
+	tracesEndpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" && tracesEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// The traces endpoint wins over the base one, as in the exporter itself
+	var options []otlptracehttp.Option
+	if tracesEndpoint == "" {
+		options = append(options, otlptracehttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/")+"/v1/traces"))
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	name := serviceName
+	if config.Observability.ServiceName != "" {
+		name = config.Observability.ServiceName
+	}
+
+	// OTEL_RESOURCE_ATTRIBUTES adds to the defaults
+	res, err := resource.New(ctx,
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithFromEnv(),
+		resource.WithAttributes(semconv.ServiceName(name)),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+type traceContextHolder struct {
+	ctx context.Context
+}
+
+func (b *Bot) traceContext() context.Context {
+	if holder, ok := b.traceCtx.Load().(traceContextHolder); ok {
+		return holder.ctx
+	}
+
+	return context.Background()
+}
+
+// startSpan starts a child of the current span and makes it current until the
+// returned function is called. Updates are handled one at a time under b.mu,
+// so the current span is tracked on the Bot instead of threading a context
+// through every handler.
+func (b *Bot) startSpan(name string, attrs ...attribute.KeyValue) func() {
+	parent := b.traceContext()
+	ctx, span := tracer.Start(parent, name, trace.WithAttributes(attrs...))
+	b.traceCtx.Store(traceContextHolder{ctx: ctx})
+
+	return func() {
+		span.End()
+		b.traceCtx.Store(traceContextHolder{ctx: parent})
+	}
+}
+
+// tracingHTTPClient records a client span for every Telegram Bot API call
+// made while handling an update.
+type tracingHTTPClient struct {
+	bot  *Bot
+	next tgbotapi.HTTPClient
+}
+
+func (c *tracingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	// The request path embeds the bot token, so only the method name is recorded
+	method := path.Base(req.URL.Path)
+	if method == "getUpdates" {
+		return c.next.Do(req)
+	}
+
+	ctx, span := tracer.Start(c.bot.traceContext(), "telegram."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("telegram.method", method)))
+	defer span.End()
+
+	resp, err := c.next.Do(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	return resp, nil
+}
@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+const trackerHTTPTimeout = 15 * time.Second
+
+// IssueTracker mirrors tickets into an external tracker such as Trello or Jira
+// for teams that already run their workflow there.
+type IssueTracker interface {
+	// Name is the integration name shown in /features.
+	Name() string
+	// CreateIssue opens a card/issue for a ticket and returns its reference.
+	CreateIssue(ticket storage.TicketRecord) (string, error)
+	// CloseIssue records the answer and moves the card/issue to done.
+	CloseIssue(ref, answer string) error
+}
+
+// ticketTracker remembers which card/issue belongs to which ticket.
+type ticketTracker struct {
+	IssueTracker
+
+	mu   sync.Mutex
+	refs map[int]string
+}
+
+// trackerFromConfig selects the tracker named by TRACKER and returns nil when it
+// is unset.
+func trackerFromConfig(config *Config) (*ticketTracker, error) {
+	var tracker IssueTracker
+	var err error
+
+	switch name := config.Integrations.Tracker.Type; name {
+	case "":
+		return nil, nil
+	case "trello":
+		tracker, err = trelloFromConfig(config)
+	case "jira":
+		tracker, err = jiraFromConfig(config)
+	default:
+		return nil, fmt.Errorf("unknown TRACKER %q, expected trello or jira", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ticketTracker{IssueTracker: tracker, refs: make(map[int]string)}, nil
+}
+
+func (t *ticketTracker) create(ticket storage.TicketRecord) error {
+	ref, err := t.CreateIssue(ticket)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.refs[ticket.ID] = ref
+	t.mu.Unlock()
+
+	return nil
+}
+
+func (t *ticketTracker) close(ticketID int, answer string) error {
+	t.mu.Lock()
+	ref, exists := t.refs[ticketID]
+	t.mu.Unlock()
+	if !exists {
+		return fmt.Errorf("no %s issue recorded for ticket #%d", t.Name(), ticketID)
+	}
+
+	if err := t.CloseIssue(ref, answer); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	delete(t.refs, ticketID)
+	t.mu.Unlock()
+
+	return nil
+}
+
+func trackerTitle(ticket storage.TicketRecord) string {
+	user := fmt.Sprintf("user %d", ticket.UserID)
+	if ticket.Username != "" {
+		user = "@" + ticket.Username
+	}
+
+	return fmt.Sprintf("#%d %s: %s", ticket.ID, user, truncateText(ticket.Question, 80))
+}
+
+func trackerDescription(ticket storage.TicketRecord) string {
+	return fmt.Sprintf("Ticket #%d (%s, %s)\nUser ID: %d\nCreated: %s\n\n%s",
+		ticket.ID, ticket.Kind, categoryLabel(ticket.Category), ticket.UserID,
+		ticket.CreatedAt.Format(time.RFC3339), ticket.Question)
+}
+
+func (b *Bot) createTrackerIssue(ticket storage.TicketRecord) {
+	if b.tracker == nil {
+		return
+	}
+
+	b.runIntegration(b.tracker.Name(), fmt.Sprintf("create issue for ticket #%d", ticket.ID), func() error {
+		return b.tracker.create(ticket)
+	})
+}
+
+func (b *Bot) closeTrackerIssue(ticketID int, answer string) {
+	if b.tracker == nil {
+		return
+	}
+
+	b.runIntegration(b.tracker.Name(), fmt.Sprintf("close issue for ticket #%d", ticketID), func() error {
+		return b.tracker.close(ticketID, answer)
+	})
+}
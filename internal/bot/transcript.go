@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+// userTranscript compiles the conversation history with a user as plain
+// text: what the bot knows about them, then every ticket, oldest first,
+// with its question and answer.
+func userTranscript(user storage.UserRecord, tickets []storage.TicketRecord, now time.Time) string {
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("Transcript of user ID %d\n", user.ID))
+	if len(user.Usernames) > 0 {
+		text.WriteString("Usernames: @" + strings.Join(user.Usernames, ", @") + "\n")
+	}
+	if !user.FirstSeen.IsZero() {
+		text.WriteString("First seen: " + user.FirstSeen.Format("2006-01-02 15:04") + "\n")
+	}
+	if !user.TermsAcceptedAt.IsZero() {
+		text.WriteString(fmt.Sprintf("Terms accepted: version %s on %s\n", user.TermsVersion, user.TermsAcceptedAt.Format("2006-01-02 15:04")))
+	}
+	if len(user.Labels) > 0 {
+		text.WriteString("Labels: " + strings.Join(user.Labels, ", ") + "\n")
+	}
+	for _, note := range user.Notes {
+		text.WriteString(fmt.Sprintf("Note (%s): %s\n", note.AddedAt.Format("2006-01-02"), note.Text))
+	}
+	text.WriteString(fmt.Sprintf("Generated: %s, %d ticket(s)\n", now.Format("2006-01-02 15:04"), len(tickets)))
+
+	for _, ticket := range tickets {
+		text.WriteString(fmt.Sprintf("\n=== Ticket #%d · %s", ticket.ID, ticket.Kind))
+		if ticket.Category != "" {
+			text.WriteString(" · " + ticket.Category)
+		}
+		text.WriteString(" ===\n")
+
+		text.WriteString(fmt.Sprintf("[%s] User:\n%s\n", ticket.CreatedAt.Format("2006-01-02 15:04"), ticket.Question))
+		if ticket.AnsweredAt.IsZero() {
+			text.WriteString("(not answered yet)\n")
+			continue
+		}
+		text.WriteString(fmt.Sprintf("[%s] Admin:\n%s\n", ticket.AnsweredAt.Format("2006-01-02 15:04"), ticket.Answer))
+
+		var outcome []string
+		if ticket.Rating != "" {
+			outcome = append(outcome, "rated "+ticket.Rating)
+		}
+		if ticket.Resolved != nil && *ticket.Resolved {
+			outcome = append(outcome, "resolved")
+		} else if ticket.Resolved != nil {
+			outcome = append(outcome, "not resolved")
+		}
+		if len(outcome) > 0 {
+			text.WriteString("(" + strings.Join(outcome, ", ") + ")\n")
+		}
+	}
+
+	return text.String()
+}
+
+// sendTranscript sends the admin the conversation history with a user as a
+// text file for /transcript <user_id>.
+func (b *Bot) sendTranscript(args string) {
+	userID, err := strconv.ParseInt(strings.TrimSpace(args), 10, 64)
+	if err != nil {
+		msg := tgbotapi.NewMessage(b.adminID, "Usage: /transcript <user_id>")
+		_, err = b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send transcript usage")
+		}
+		return
+	}
+
+	var tickets []storage.TicketRecord
+	for _, ticket := range b.store.Tickets() {
+		if ticket.UserID == userID {
+			tickets = append(tickets, ticket)
+		}
+	}
+
+	user, exists := b.store.User(userID)
+	if !exists && len(tickets) == 0 {
+		msg := tgbotapi.NewMessage(b.adminID, fmt.Sprintf("User ID %d has never used the bot", userID))
+		_, err = b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to send unknown transcript user")
+		}
+		return
+	}
+	user.ID = userID
+
+	b.sendChatAction(b.adminID, tgbotapi.ChatUploadDocument)
+
+	now := time.Now()
+	document := tgbotapi.NewDocument(b.adminID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("transcript-%d-%s.txt", userID, now.Format("2006-01-02")),
+		Bytes: []byte(userTranscript(user, tickets, now)),
+	})
+	document.Caption = fmt.Sprintf("📜 Transcript of user ID %d: %d ticket(s)", userID, len(tickets))
+
+	_, err = b.api.Send(document)
+	if err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send transcript")
+	}
+}
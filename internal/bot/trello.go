@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+const trelloAPIBase = "https://api.trello.com/1"
+
+// trelloTracker creates a card per ticket in TRELLO_LIST_ID and, when the
+// ticket is answered, comments the answer and moves the card to
+// TRELLO_DONE_LIST_ID (or archives it when no done list is configured).
+type trelloTracker struct {
+	key        string
+	token      string
+	listID     string
+	doneListID string
+	client     *http.Client
+}
+
+func trelloFromConfig(config *Config) (*trelloTracker, error) {
+	settings := &config.Integrations.Tracker.Trello
+	tracker := &trelloTracker{
+		key:        settings.Key,
+		token:      settings.Token,
+		listID:     settings.ListID,
+		doneListID: settings.DoneListID,
+		client:     &http.Client{Timeout: trackerHTTPTimeout},
+	}
+	if tracker.key == "" || tracker.token == "" || tracker.listID == "" {
+		return nil, fmt.Errorf("TRELLO_KEY, TRELLO_TOKEN and TRELLO_LIST_ID are required when TRACKER=trello")
+	}
+
+	return tracker, nil
+}
+
+func (t *trelloTracker) Name() string {
+	return "trello"
+}
+
+func (t *trelloTracker) CreateIssue(ticket storage.TicketRecord) (string, error) {
+	params := url.Values{}
+	params.Set("idList", t.listID)
+	params.Set("name", trackerTitle(ticket))
+	params.Set("desc", trackerDescription(ticket))
+
+	var card struct {
+		ID string `json:"id"`
+	}
+	if err := t.do(http.MethodPost, "/cards", params, &card); err != nil {
+		return "", err
+	}
+
+	return card.ID, nil
+}
+
+func (t *trelloTracker) CloseIssue(cardID, answer string) error {
+	comment := url.Values{}
+	comment.Set("text", "Answer sent:\n\n"+answer)
+	if err := t.do(http.MethodPost, "/cards/"+cardID+"/actions/comments", comment, nil); err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	if t.doneListID != "" {
+		params.Set("idList", t.doneListID)
+	} else {
+		params.Set("closed", "true")
+	}
+
+	return t.do(http.MethodPut, "/cards/"+cardID, params, nil)
+}
+
+func (t *trelloTracker) do(method, path string, params url.Values, result interface{}) error {
+	params.Set("key", t.key)
+	params.Set("token", t.token)
+
+	req, err := http.NewRequest(method, trelloAPIBase+path, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("trello API returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
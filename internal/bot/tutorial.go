@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// tutorialSteps are the message IDs of the first-run tutorial, in order.
+var tutorialSteps = []string{"tutorial_intro", "tutorial_question", "tutorial_cv_review"}
+
+// startTutorial shows the first step of the tutorial to users who have not
+// seen it and never opened a ticket, and reports whether it did. The
+// tutorial is marked as shown right away, so skipping it or pressing /start
+// again leads to the welcome menu.
+func (b *Bot) startTutorial(userID int64) bool {
+	if !b.onboardingTutorial {
+		return false
+	}
+
+	user, exists := b.store.User(userID)
+	if !exists || !user.TutorialShownAt.IsZero() || user.Questions > 0 {
+		return false
+	}
+
+	if err := b.store.MarkTutorialShown(userID, time.Now()); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist tutorial")
+	}
+	b.showTutorialStep(userID, 0)
+
+	return true
+}
+
+func (b *Bot) showTutorialStep(userID int64, step int) {
+	var keyboard tgbotapi.InlineKeyboardMarkup
+	if step < len(tutorialSteps)-1 {
+		keyboard = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_tutorial_next"), fmt.Sprintf("tutorial:%d", step+1)),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_tutorial_skip"), "tutorial:done"),
+			),
+		)
+	} else {
+		keyboard = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(b.tr(userID, "button_tutorial_done"), "tutorial:done"),
+			),
+		)
+	}
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, tutorialSteps[step]))
+	msg.ReplyMarkup = keyboard
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send tutorial step")
+		return
+	}
+
+	b.userStates[userID] = StateWelcome
+}
+
+// handleTutorialCallback processes the "tutorial:<step>" buttons, and
+// "tutorial:done", which ends the tutorial with the welcome menu.
+func (b *Bot) handleTutorialCallback(callback *tgbotapi.CallbackQuery) {
+	userID := callback.From.ID
+
+	value := strings.TrimPrefix(callback.Data, "tutorial:")
+	if value == "done" {
+		b.showWelcomeMenu(userID)
+		return
+	}
+
+	step, err := strconv.Atoi(value)
+	if err != nil || step < 0 || step >= len(tutorialSteps) {
+		b.logger.WithField("callback_data", callback.Data).Error("Malformed tutorial callback")
+		b.showWelcomeMenu(userID)
+		return
+	}
+
+	b.showTutorialStep(userID, step)
+}
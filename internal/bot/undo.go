@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// undoWindow is how long after answering the admin can take the answer back.
+const undoWindow = 2 * time.Minute
+
+func undoKeyboard(ticketID int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("↩️ Undo", fmt.Sprintf("undo:%d", ticketID)),
+		),
+	)
+}
+
+// handleUndoCallback retracts an answer shortly after it was sent: the
+// delivered messages are deleted from the user's chat and the ticket is
+// opened again. The confirmation the button was on becomes the message the
+// admin replies to for the new answer.
+func (b *Bot) handleUndoCallback(callback *tgbotapi.CallbackQuery) {
+	ticketID, err := strconv.Atoi(strings.TrimPrefix(callback.Data, "undo:"))
+	if err != nil {
+		b.logger.WithError(err).WithField("callback_data", callback.Data).Error("Malformed undo callback")
+		return
+	}
+
+	ticket, exists := b.store.Ticket(ticketID)
+	var text string
+	switch {
+	case !exists || ticket.AnsweredAt.IsZero():
+		text = fmt.Sprintf("Ticket #%d is not answered", ticketID)
+	case time.Since(ticket.AnsweredAt) > undoWindow:
+		text = fmt.Sprintf("⌛ The answer to ticket #%d can only be undone within %s of sending it", ticketID, formatDuration(undoWindow))
+	}
+	if text != "" {
+		b.editUndoConfirmation(callback, text)
+		return
+	}
+
+	failed := 0
+	for _, messageID := range ticket.DeliveredMessageIDs {
+		_, err := b.api.Request(tgbotapi.NewDeleteMessage(ticket.ChatID(), messageID))
+		if err != nil {
+			failed++
+			b.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":    ticket.UserID,
+				"message_id": messageID,
+			}).Error("Failed to delete retracted answer")
+		}
+	}
+
+	_, _, err = b.store.ReopenTicket(ticketID)
+	if err != nil {
+		b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to persist reopened ticket")
+	}
+	b.syncTicketStatusToSheet(ticketID, "open")
+
+	session := sessionFromTicket(ticket)
+	b.trackOpenTicket(session)
+	if callback.Message != nil {
+		session.AdminMsgID = callback.Message.MessageID
+		b.adminMessages[callback.Message.MessageID] = session
+		err = b.store.MapAdminMessages(ticketID, []int{callback.Message.MessageID})
+		if err != nil {
+			b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to persist admin notification messages")
+		}
+	}
+
+	b.audit.Record(AuditAnswerUndone, b.adminID, logrus.Fields{
+		"user_id":   ticket.UserID,
+		"ticket_id": ticketID,
+	})
+
+	text = fmt.Sprintf("↩️ Answer to ticket #%d undone, the ticket is open again.\n\n💡 Reply to this message to answer it", ticketID)
+	if failed > 0 {
+		text = fmt.Sprintf("↩️ Ticket #%d is open again, but %d message(s) could not be deleted from the user's chat.\n\n💡 Reply to this message to answer it", ticketID, failed)
+	}
+	b.editUndoConfirmation(callback, text)
+}
+
+// editUndoConfirmation replaces the confirmation the undo button was on,
+// which also removes the button.
+func (b *Bot) editUndoConfirmation(callback *tgbotapi.CallbackQuery, text string) {
+	if callback.Message == nil {
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(callback.Message.Chat.ID, callback.Message.MessageID, text)
+	_, err := b.api.Request(edit)
+	if err != nil {
+		b.logger.WithError(err).Error("Failed to update answer confirmation")
+	}
+}
@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+const integrationFileArchive = "file_archive"
+
+// FileArchive keeps the files users upload with their tickets, so they
+// stay retrievable after Telegram stops serving them.
+type FileArchive interface {
+	// TicketKey returns the key a file uploaded with a ticket is stored
+	// under.
+	TicketKey(userID int64, ticketID int, fileName string) string
+	// Put stores data under key.
+	Put(key string, data []byte) error
+	// Get returns the file stored under key, an error wrapping
+	// fs.ErrNotExist if there is none.
+	Get(key string) ([]byte, error)
+	// Delete removes the file stored under key.
+	Delete(key string) error
+}
+
+// fileArchiveFromConfig returns the archive in an S3 bucket (S3_BUCKET) or in
+// a local directory (ARCHIVE_DIR), nil when neither is set.
+func fileArchiveFromConfig(config *Config) (FileArchive, error) {
+	s3, err := s3ArchiveFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	local, err := localArchiveFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case s3 != nil && local != nil:
+		return nil, errors.New("S3_BUCKET and ARCHIVE_DIR are alternatives, set only one")
+	case s3 != nil:
+		return s3, nil
+	case local != nil:
+		return local, nil
+	default:
+		return nil, nil
+	}
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// safeFileName reduces a file name to characters that are safe in object
+// keys and paths.
+func safeFileName(fileName string) string {
+	name := strings.Trim(unsafeFileNameChars.ReplaceAllString(fileName, "_"), "_.")
+	if name == "" {
+		return "file"
+	}
+
+	return name
+}
+
+// archiveTicketFile copies the file uploaded with a new ticket, the
+// attachment of a question or the CV of a review request, from Telegram
+// to the file archive in the background.
+func (b *Bot) archiveTicketFile(session *UserSession) {
+	if b.fileArchive == nil {
+		return
+	}
+
+	fileID, fileName := session.FileID, session.FileName
+	if intake := session.CVIntake; intake != nil {
+		fileID, fileName = intake.CVFileID, intake.CVFileName
+	}
+	if fileID == "" {
+		return
+	}
+
+	archive := b.fileArchive
+	key := archive.TicketKey(session.UserID, session.TicketID, fileName)
+	ticketID := session.TicketID
+	b.runIntegration(integrationFileArchive, fmt.Sprintf("archive %s of ticket #%d", fileName, ticketID), func() error {
+		data, err := b.api.DownloadFile(fileID)
+		if err != nil {
+			return err
+		}
+		if err := archive.Put(key, data); err != nil {
+			return err
+		}
+
+		return b.store.AddTicketFile(ticketID, key)
+	})
+}
+
+// sendTicketFiles handles /files <ticket_id>, which sends the admin the
+// archived files of a ticket, also long after it was answered.
+func (b *Bot) sendTicketFiles(args string) {
+	ticketID, err := parseTicketID(args)
+	if err != nil {
+		b.sendTicketFilesReply(fmt.Sprintf("❌ %v\n\nUsage: /files <ticket_id>", err))
+		return
+	}
+	if b.fileArchive == nil {
+		b.sendTicketFilesReply("The file archive is not set up, see S3_BUCKET or ARCHIVE_DIR")
+		return
+	}
+
+	ticket, exists := b.store.Ticket(ticketID)
+	switch {
+	case !exists:
+		b.sendTicketFilesReply(fmt.Sprintf("❌ Ticket #%d not found", ticketID))
+		return
+	case len(ticket.Files) == 0:
+		b.sendTicketFilesReply(fmt.Sprintf("Ticket #%d has no archived files", ticketID))
+		return
+	}
+
+	b.sendChatAction(b.adminID, tgbotapi.ChatUploadDocument)
+	for _, key := range ticket.Files {
+		data, err := b.fileArchive.Get(key)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			b.sendTicketFilesReply(fmt.Sprintf("📭 %s of ticket #%d is no longer in the archive, it may have been pruned to stay within the quota", path.Base(key), ticketID))
+			continue
+		case err != nil:
+			b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to fetch archived file")
+			b.sendTicketFilesReply(fmt.Sprintf("❌ Failed to fetch %s: %v", path.Base(key), err))
+			continue
+		}
+
+		document := tgbotapi.NewDocument(b.adminID, tgbotapi.FileBytes{Name: path.Base(key), Bytes: data})
+		document.Caption = fmt.Sprintf("📎 File of ticket #%d", ticketID)
+		if _, err := b.api.Send(document); err != nil {
+			b.logger.WithError(err).WithField("ticket_id", ticketID).Error("Failed to send archived file")
+		}
+	}
+}
+
+func (b *Bot) sendTicketFilesReply(text string) {
+	msg := tgbotapi.NewMessage(b.adminID, text)
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.WithError(err).Error("Failed to send ticket files reply")
+	}
+}
+
+// deleteArchivedFiles removes the archived files of purged tickets and
+// returns how many were removed.
+func (b *Bot) deleteArchivedFiles(tickets []storage.TicketRecord) int {
+	deleted := 0
+	for _, ticket := range tickets {
+		for _, key := range ticket.Files {
+			if err := b.fileArchive.Delete(key); err != nil {
+				b.logger.WithError(err).WithField("ticket_id", ticket.ID).Error("Failed to delete archived file")
+				continue
+			}
+			deleted++
+		}
+	}
+
+	return deleted
+}
@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"time"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+const defaultUrgentCooldown = 24 * time.Hour
+
+func (b *Bot) submitUrgentQuestionDraft(userID int64) {
+	draft, exists := b.drafts[userID]
+	if !exists {
+		b.showWelcomeMenu(userID)
+		return
+	}
+
+	if last, marked := b.lastUrgent[userID]; marked && time.Since(last) < b.urgentCooldown {
+		limitText := b.trMarkdown(userID, "urgent_limit", map[string]interface{}{
+			"Cooldown":   formatDuration(b.urgentCooldown),
+			"Remaining":  formatDuration(b.urgentCooldown - time.Since(last)),
+			"SendButton": b.tr(userID, "button_send"),
+		})
+
+		msg := telegram.NewMarkdownMessage(userID, limitText)
+		_, err := b.api.Send(msg)
+		if err != nil {
+			b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send urgent limit message")
+		}
+		return
+	}
+
+	draft.Urgent = true
+	b.lastUrgent[userID] = time.Now()
+	b.submitQuestionDraft(userID)
+}
@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/telegram"
+)
+
+// verificationOptions is the number of answer buttons of a challenge.
+const verificationOptions = 4
+
+// verification is the challenge a user has to solve, with the message that
+// triggered it so it can be handled once they pass, e.g. a /start deep
+// link.
+type verification struct {
+	answer  int
+	pending *tgbotapi.Message
+}
+
+// verifyNewUsers holds back updates of users who have not passed the
+// verification yet and asks them a simple question instead. The admin,
+// group chats and payment updates are never held back.
+func (b *Bot) verifyNewUsers(next UpdateHandler) UpdateHandler {
+	return func(update tgbotapi.Update) {
+		user := update.SentFrom()
+		if !b.verifyUsers || user == nil || user.ID == b.adminID || b.isReviewer(user.ID) {
+			next(update)
+			return
+		}
+
+		message, callback := update.Message, update.CallbackQuery
+		if message == nil && callback == nil {
+			next(update)
+			return
+		}
+		if message != nil && isGroupChat(message.Chat) {
+			next(update)
+			return
+		}
+		if record, exists := b.store.User(user.ID); exists && !record.VerifiedAt.IsZero() {
+			next(update)
+			return
+		}
+
+		if callback != nil {
+			if _, err := b.api.Request(tgbotapi.NewCallback(callback.ID, "")); err != nil {
+				b.logger.WithError(err).Error("Failed to answer callback query")
+			}
+			if answer, ok := strings.CutPrefix(callback.Data, "verify:"); ok {
+				b.checkVerification(user.ID, answer, next)
+				return
+			}
+		}
+
+		challenge, exists := b.verifications[user.ID]
+		if !exists {
+			challenge = &verification{}
+			b.verifications[user.ID] = challenge
+		}
+		if message != nil && challenge.pending == nil {
+			challenge.pending = message
+		}
+		b.sendVerification(user.ID, "verification_prompt")
+	}
+}
+
+// sendVerification asks the user a new sum with one right and several
+// wrong answers to pick from.
+func (b *Bot) sendVerification(userID int64, messageID string) {
+	x, y := rand.IntN(9)+1, rand.IntN(9)+1
+	answer := x + y
+
+	options := []int{answer}
+	for len(options) < verificationOptions {
+		option := rand.IntN(18) + 1
+		if !slices.Contains(options, option) {
+			options = append(options, option)
+		}
+	}
+	rand.Shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, option := range options {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(strconv.Itoa(option), fmt.Sprintf("verify:%d", option)))
+	}
+
+	challenge, exists := b.verifications[userID]
+	if !exists {
+		challenge = &verification{}
+		b.verifications[userID] = challenge
+	}
+	challenge.answer = answer
+
+	msg := telegram.NewMarkdownMessage(userID, b.trMarkdown(userID, messageID, map[string]interface{}{
+		"Question": fmt.Sprintf("%d + %d", x, y),
+	}))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+	if _, err := b.api.Send(msg); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to send verification")
+	}
+}
+
+// checkVerification handles the answer of "verify:<answer>". A right answer
+// verifies the user and handles the message that was held back; a wrong or
+// outdated one gets a new question.
+func (b *Bot) checkVerification(userID int64, answer string, next UpdateHandler) {
+	challenge, exists := b.verifications[userID]
+	if !exists || answer != strconv.Itoa(challenge.answer) {
+		b.sendVerification(userID, "verification_failed")
+		return
+	}
+
+	if err := b.store.MarkVerified(userID, time.Now()); err != nil {
+		b.logger.WithError(err).WithField("user_id", userID).Error("Failed to persist verification")
+		return
+	}
+	delete(b.verifications, userID)
+	b.audit.Record(AuditUserVerified, userID, nil)
+
+	if challenge.pending != nil {
+		next(tgbotapi.Update{Message: challenge.pending})
+	} else {
+		b.showWelcomeMenu(userID)
+	}
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DilmurodYangiboev/faq_bot/internal/storage"
+)
+
+const (
+	integrationWebhook = "webhook"
+	webhookHTTPTimeout = 10 * time.Second
+)
+
+// WebhookEvent names an event delivered to WEBHOOK_URL.
+type WebhookEvent string
+
+const (
+	WebhookNewQuestion WebhookEvent = "new_question"
+	WebhookAnswered    WebhookEvent = "answered"
+	WebhookCVRequested WebhookEvent = "cv_requested"
+	// WebhookUserBanned is reserved for user moderation; the bot has no ban
+	// command yet, so it is never emitted.
+	WebhookUserBanned WebhookEvent = "user_banned"
+)
+
+// Webhook posts bot events as JSON to an external URL. When WEBHOOK_SECRET is
+// set every request carries X-Webhook-Signature: sha256=<hex HMAC of the body>.
+type Webhook struct {
+	url    string
+	secret string
+	events map[WebhookEvent]bool
+	client *http.Client
+}
+
+type webhookPayload struct {
+	Event     WebhookEvent `json:"event"`
+	Timestamp time.Time    `json:"timestamp"`
+	Data      interface{}  `json:"data"`
+}
+
+// webhookFromConfig returns nil when WEBHOOK_URL is unset. WEBHOOK_EVENTS
+// optionally limits delivery to a comma-separated list of events.
+func webhookFromConfig(config *Config) (*Webhook, error) {
+	settings := &config.Integrations.Webhook
+	url := settings.URL
+	if url == "" {
+		return nil, nil
+	}
+
+	webhook := &Webhook{
+		url:    url,
+		secret: settings.Secret,
+		client: &http.Client{Timeout: webhookHTTPTimeout},
+	}
+
+	if len(settings.Events) > 0 {
+		webhook.events = make(map[WebhookEvent]bool)
+		for _, name := range settings.Events {
+			event := WebhookEvent(name)
+			switch event {
+			case WebhookNewQuestion, WebhookAnswered, WebhookCVRequested, WebhookUserBanned:
+				webhook.events[event] = true
+			default:
+				return nil, fmt.Errorf("unknown webhook event %q", event)
+			}
+		}
+	}
+
+	return webhook, nil
+}
+
+func (w *Webhook) Wants(event WebhookEvent) bool {
+	return w.events == nil || w.events[event]
+}
+
+func (w *Webhook) Deliver(payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", string(payload.Event))
+	if w.secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.secret))
+		mac.Write(body)
+		req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	return nil
+}
+
+// emitWebhook delivers an event in the background. The timestamp is taken
+// now so retries report when the event happened, not when it was sent.
+func (b *Bot) emitWebhook(event WebhookEvent, data interface{}) {
+	if b.webhook == nil || !b.webhook.Wants(event) {
+		return
+	}
+
+	payload := webhookPayload{Event: event, Timestamp: time.Now().UTC(), Data: data}
+	b.runIntegration(integrationWebhook, fmt.Sprintf("deliver %s webhook", event), func() error {
+		return b.webhook.Deliver(payload)
+	})
+}
+
+func (b *Bot) emitTicketCreatedWebhook(ticket storage.TicketRecord) {
+	event := WebhookNewQuestion
+	if ticket.Kind == string(StateCVReview) {
+		event = WebhookCVRequested
+	}
+
+	b.emitWebhook(event, ticket)
+}
@@ -0,0 +1,202 @@
+// Package commands routes command messages such as "/reply 42 Thanks!" to
+// their handlers and lists the commands each role may run.
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Role is who sent a command. A command names the roles allowed to run it.
+type Role int
+
+const (
+	User Role = 1 << iota
+	Admin
+)
+
+// Request is a command message split into the command and its arguments.
+type Request struct {
+	Message *tgbotapi.Message
+	// Command is the name the command is registered under, even when it was
+	// sent through an alias
+	Command string
+	// Args is the text after the command with surrounding whitespace
+	// removed, e.g. "42 Thanks!" for "/reply 42 Thanks!"
+	Args string
+}
+
+// SplitArgs splits Args on whitespace into at most n parts. The last part
+// keeps the rest of the text as sent, including line breaks.
+func (r Request) SplitArgs(n int) []string {
+	var parts []string
+	rest := r.Args
+	for rest != "" {
+		if len(parts) == n-1 {
+			return append(parts, rest)
+		}
+
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			return append(parts, rest)
+		}
+		parts = append(parts, rest[:end])
+		rest = strings.TrimLeftFunc(rest[end:], unicode.IsSpace)
+	}
+
+	return parts
+}
+
+type Handler func(req Request)
+
+// Command is a command users or the admin can send.
+type Command struct {
+	// Name is the command as listed, e.g. "/reply"
+	Name string
+	// Aliases also run the command. Aliases starting with "/" take
+	// arguments like the name; plain words such as "main menu" only match
+	// the whole message.
+	Aliases []string
+	// Usage describes the arguments, e.g. "<ticket_id> <text>"
+	Usage string
+	// Description is shown in command lists. The bot translates user
+	// command descriptions, admin ones are English like every admin text.
+	Description string
+	// MinArgs is the number of arguments the command needs
+	MinArgs int
+	// Roles may run the command, e.g. User|Admin
+	Roles   Role
+	Handler Handler
+}
+
+// Names returns the name and the aliases starting with "/".
+func (c *Command) Names() []string {
+	names := []string{c.Name}
+	for _, alias := range c.Aliases {
+		if strings.HasPrefix(alias, "/") {
+			names = append(names, alias)
+		}
+	}
+
+	return names
+}
+
+// UsageError is returned by Route when a command was sent with fewer
+// arguments than it needs. The handler is not called.
+type UsageError struct {
+	Command *Command
+}
+
+func (e *UsageError) Error() string {
+	return fmt.Sprintf("usage: %s %s", e.Command.Name, e.Command.Usage)
+}
+
+// Router maps command names and aliases to commands.
+type Router struct {
+	commands []*Command
+	byName   map[string][]*Command
+}
+
+func NewRouter() *Router {
+	return &Router{byName: make(map[string][]*Command)}
+}
+
+// Register adds a command. A name or alias can be shared by several
+// commands as long as they are meant for different roles, such as the
+// user and the admin /help.
+func (r *Router) Register(command *Command) error {
+	if !strings.HasPrefix(command.Name, "/") {
+		return fmt.Errorf("command %q must start with /", command.Name)
+	}
+	if command.Roles == 0 {
+		return fmt.Errorf("command %q has no roles", command.Name)
+	}
+
+	keys := append([]string{command.Name}, command.Aliases...)
+	for _, key := range keys {
+		for _, other := range r.byName[strings.ToLower(key)] {
+			if other.Roles&command.Roles != 0 {
+				return fmt.Errorf("command %q: %q is already used by %q", command.Name, key, other.Name)
+			}
+		}
+	}
+
+	r.commands = append(r.commands, command)
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		r.byName[key] = append(r.byName[key], command)
+	}
+
+	return nil
+}
+
+// Route runs the command in message if role may run it. It reports whether
+// the message was a command; commands of other roles are treated as
+// ordinary text so they are not revealed.
+func (r *Router) Route(role Role, message *tgbotapi.Message) (bool, error) {
+	text := strings.TrimSpace(message.Text)
+
+	// Plain word aliases such as "main menu" only match the whole message
+	command, exists := r.lookup(role, strings.ToLower(text))
+	args := ""
+	if !exists {
+		var name string
+		name, args = cutCommand(text)
+		if !strings.HasPrefix(name, "/") {
+			return false, nil
+		}
+		if command, exists = r.lookup(role, name); !exists {
+			return false, nil
+		}
+	}
+
+	req := Request{Message: message, Command: command.Name, Args: args}
+	if command.MinArgs > 0 && len(req.SplitArgs(command.MinArgs)) < command.MinArgs {
+		return true, &UsageError{Command: command}
+	}
+
+	command.Handler(req)
+	return true, nil
+}
+
+// lookup finds the command of role registered under key.
+func (r *Router) lookup(role Role, key string) (*Command, bool) {
+	for _, command := range r.byName[key] {
+		if command.Roles&role != 0 {
+			return command, true
+		}
+	}
+
+	return nil, false
+}
+
+// cutCommand splits text into the lower-cased command, without a
+// "@botname" suffix, and its arguments.
+func cutCommand(text string) (string, string) {
+	end := strings.IndexFunc(text, unicode.IsSpace)
+	if end < 0 {
+		end = len(text)
+	}
+
+	name := strings.ToLower(text[:end])
+	if at := strings.Index(name, "@"); at > 0 {
+		name = name[:at]
+	}
+
+	return name, strings.TrimSpace(text[end:])
+}
+
+// Commands returns the commands role may run in registration order.
+func (r *Router) Commands(role Role) []*Command {
+	var commands []*Command
+	for _, command := range r.commands {
+		if command.Roles&role != 0 {
+			commands = append(commands, command)
+		}
+	}
+
+	return commands
+}
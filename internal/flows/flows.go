@@ -0,0 +1,126 @@
+// Package flows lets conversation flows (asking a question, CV review, ...)
+// register the commands, menu buttons and user states they own.
+package flows
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// State is the conversation state of a user. Each state belongs to at most
+// one flow.
+type State string
+
+// StateHandler handles a user message while the user is in a flow state.
+type StateHandler func(message *tgbotapi.Message, userID int64, username string)
+
+// Flow is a conversation users start from the welcome menu, such as asking
+// a question or requesting a CV review. New flows (interview prep,
+// mentorship, ...) only need to be registered with a Registry.
+type Flow struct {
+	// Name is the callback data of the welcome menu button
+	Name string
+	// Button is the message ID of the welcome menu button label
+	Button string
+	// Commands start the flow, e.g. "/ask". The first one is listed in
+	// /commands with Description, the message ID of its description.
+	Commands    []string
+	Description string
+	// Keywords start the flow from the welcome state when the message
+	// contains one of them
+	Keywords []string
+	Start    func(userID int64)
+	// States are the user states owned by the flow and their handlers
+	States map[State]StateHandler
+}
+
+// Registry maps commands, menu buttons and user states to flows.
+type Registry struct {
+	flows    []*Flow
+	byName   map[string]*Flow
+	commands map[string]*Flow
+	states   map[State]StateHandler
+	reserved map[State]bool
+}
+
+// NewRegistry creates an empty registry. Reserved states, such as the
+// welcome menu, are handled by the bot itself and cannot be claimed by a
+// flow.
+func NewRegistry(reserved ...State) *Registry {
+	r := &Registry{
+		byName:   make(map[string]*Flow),
+		commands: make(map[string]*Flow),
+		states:   make(map[State]StateHandler),
+		reserved: make(map[State]bool),
+	}
+	for _, state := range reserved {
+		r.reserved[state] = true
+	}
+
+	return r
+}
+
+// Register adds a flow. Names, commands and states must be unique across
+// flows.
+func (r *Registry) Register(flow *Flow) error {
+	if _, exists := r.byName[flow.Name]; exists {
+		return fmt.Errorf("flow %q is already registered", flow.Name)
+	}
+	for _, command := range flow.Commands {
+		if other, exists := r.commands[command]; exists {
+			return fmt.Errorf("flow %q: command %q is already used by flow %q", flow.Name, command, other.Name)
+		}
+	}
+	for state := range flow.States {
+		if _, exists := r.states[state]; exists || r.reserved[state] {
+			return fmt.Errorf("flow %q: state %q is already handled", flow.Name, state)
+		}
+	}
+
+	r.flows = append(r.flows, flow)
+	r.byName[flow.Name] = flow
+	for _, command := range flow.Commands {
+		r.commands[command] = flow
+	}
+	for state, handler := range flow.States {
+		r.states[state] = handler
+	}
+
+	return nil
+}
+
+func (r *Registry) Flow(name string) (*Flow, bool) {
+	flow, exists := r.byName[name]
+	return flow, exists
+}
+
+// ForWelcomeText picks the flow a user asked for from the welcome menu,
+// either by its number in the menu ("1", "2", ...) or by keyword.
+func (r *Registry) ForWelcomeText(text string) (*Flow, bool) {
+	if number, err := strconv.Atoi(text); err == nil && number >= 1 && number <= len(r.flows) {
+		return r.flows[number-1], true
+	}
+
+	for _, flow := range r.flows {
+		for _, keyword := range flow.Keywords {
+			if strings.Contains(text, keyword) {
+				return flow, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (r *Registry) StateHandler(state State) (StateHandler, bool) {
+	handler, exists := r.states[state]
+	return handler, exists
+}
+
+// Flows returns the registered flows in menu order.
+func (r *Registry) Flows() []*Flow {
+	return r.flows
+}
@@ -0,0 +1,1895 @@
+// Package storage persists tickets, users and settings of the FAQ bot in a
+// single JSON file.
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// DefaultDataFile is used when DATA_FILE is unset.
+const DefaultDataFile = "data/faq_bot.json"
+
+// lastActiveResolution is how far apart updates of a user's last activity
+// are persisted.
+const lastActiveResolution = time.Minute
+
+// Store persists bot data as a single JSON document that is rewritten
+// atomically on every change. The ID of the last handled update changes
+// with every update, so it is kept in a small file of its own next to it.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	data storeData
+	// lastUpdateID is persisted in the offset file
+	lastUpdateID int
+	// ticketsRevision counts the changes to tickets since the store was
+	// opened
+	ticketsRevision uint64
+}
+
+type storeData struct {
+	LastTicketID int                   `json:"last_ticket_id"`
+	Templates    map[string]string     `json:"templates"`
+	Tickets      []TicketRecord        `json:"tickets"`
+	Digest       DigestSettings        `json:"digest"`
+	Users        map[int64]*UserRecord `json:"users"`
+	LastOutboxID int                   `json:"last_outbox_id,omitempty"`
+	// LastUpdateID is only read from stores written before the offset
+	// file
+	LastUpdateID int             `json:"last_update_id,omitempty"`
+	Outbox       []OutboxMessage `json:"outbox,omitempty"`
+	// AdminMessages maps the admin's notification messages to the open
+	// tickets they announce, so replies to them work after a restart
+	AdminMessages map[int]int     `json:"admin_messages,omitempty"`
+	Payments      []PaymentRecord `json:"payments,omitempty"`
+	// PromoCodes are keyed by their upper-case code
+	PromoCodes    map[string]*PromoCode   `json:"promo_codes,omitempty"`
+	Subscriptions map[int64]*Subscription `json:"subscriptions,omitempty"`
+	// Availability is the admin's weekly availability for consultations
+	Availability  []AvailabilityWindow `json:"availability,omitempty"`
+	LastBookingID int                  `json:"last_booking_id,omitempty"`
+	Bookings      []Booking            `json:"bookings,omitempty"`
+	// PendingScans are the uploads being scanned for malware
+	PendingScans []PendingScan `json:"pending_scans,omitempty"`
+}
+
+// PendingScan is an upload held back while it is scanned for malware. The
+// update it came with is already confirmed to Telegram, so one still on
+// file after a restart was lost and its sender is asked to send it again.
+type PendingScan struct {
+	ChatID    int64     `json:"chat_id"`
+	MessageID int       `json:"message_id"`
+	UserID    int64     `json:"user_id"`
+	FileName  string    `json:"file_name"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// UserRecord is the persisted profile of a user who talked to the bot.
+type UserRecord struct {
+	ID         int64     `json:"id"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastActive time.Time `json:"last_active,omitzero"`
+	// Usernames are the Telegram usernames the user went by, oldest first
+	Usernames []string `json:"usernames,omitempty"`
+	// Questions counts the tickets the user opened, including ones removed
+	// from the store since
+	Questions int `json:"questions,omitempty"`
+	// TelegramLanguage is the language code reported by the user's client
+	TelegramLanguage string `json:"telegram_language,omitempty"`
+	// Language is the language picked with /language and wins over
+	// TelegramLanguage
+	Language string `json:"language,omitempty"`
+	// ReferredBy is the user whose referral link brought this user in
+	ReferredBy int64     `json:"referred_by,omitempty"`
+	ReferredAt time.Time `json:"referred_at,omitzero"`
+	// PromoCodes are the codes the user redeemed, each only once
+	PromoCodes []string `json:"promo_codes,omitempty"`
+	// PromoDiscount is the discount in percent of a redeemed code that
+	// applies to the user's next paid review
+	PromoDiscount int `json:"promo_discount,omitempty"`
+	// StartedFlow is the question or CV review flow the user started last
+	StartedFlow   string    `json:"started_flow,omitempty"`
+	StartedFlowAt time.Time `json:"started_flow_at,omitzero"`
+	// ReengagedAt is when the user was last asked to come back to a flow
+	// they abandoned, ReturnedAt when they submitted a ticket after it
+	ReengagedAt    time.Time `json:"reengaged_at,omitzero"`
+	ReturnedAt     time.Time `json:"returned_at,omitzero"`
+	NoReengagement bool      `json:"no_reengagement,omitempty"`
+	// VerifiedAt is when the user passed the new-user verification
+	VerifiedAt time.Time `json:"verified_at,omitzero"`
+	// WelcomeVariant is the welcome menu text the user was shown in the
+	// onboarding experiment, WelcomeVariantAt when it was picked
+	WelcomeVariant   string    `json:"welcome_variant,omitempty"`
+	WelcomeVariantAt time.Time `json:"welcome_variant_at,omitzero"`
+	// TutorialShownAt is when the user was shown the first-run tutorial
+	TutorialShownAt time.Time `json:"tutorial_shown_at,omitzero"`
+	// TermsVersion is the version of the terms the user accepted last,
+	// TermsAcceptedAt when
+	TermsVersion    string    `json:"terms_version,omitempty"`
+	TermsAcceptedAt time.Time `json:"terms_accepted_at,omitzero"`
+	// Notes are the admin's private notes about the user, oldest first
+	Notes []UserNote `json:"notes,omitempty"`
+	// Labels are the admin's tags for the user, e.g. "student"
+	Labels []string `json:"labels,omitempty"`
+}
+
+// UserNote is a private note of the admin about a user.
+type UserNote struct {
+	Text    string    `json:"text"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// PromoCode discounts priority CV reviews. A Discount of 100 makes them
+// free. MaxUses and ExpiresAt are unlimited when zero.
+type PromoCode struct {
+	Code      string    `json:"code"`
+	Discount  int       `json:"discount"`
+	MaxUses   int       `json:"max_uses,omitempty"`
+	Uses      int       `json:"uses,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Reasons RedeemPromoCode refuses a code.
+var (
+	ErrPromoNotFound = errors.New("promo code not found")
+	ErrPromoExpired  = errors.New("promo code expired")
+	ErrPromoUsedUp   = errors.New("promo code used up")
+	ErrPromoRedeemed = errors.New("promo code already redeemed")
+)
+
+// OutboxMessage is a message that must reach the user even across Telegram
+// outages and restarts, such as an admin's answer. It stays in the store
+// until Telegram accepted every part of it.
+type OutboxMessage struct {
+	ID        int                            `json:"id"`
+	ChatID    int64                          `json:"chat_id"`
+	TicketID  int                            `json:"ticket_id,omitempty"`
+	Text      string                         `json:"text"`
+	ParseMode string                         `json:"parse_mode,omitempty"`
+	ReplyTo   int                            `json:"reply_to,omitempty"`
+	Markup    *tgbotapi.InlineKeyboardMarkup `json:"markup,omitempty"`
+	// Broadcast marks an announcement to many users, whose failed
+	// deliveries are not reported one by one
+	Broadcast bool `json:"broadcast,omitempty"`
+	// PartsSent counts the parts of a split message already delivered, so
+	// a retry continues where the last attempt stopped. MessageIDs are the
+	// IDs of those parts.
+	PartsSent   int       `json:"parts_sent,omitempty"`
+	MessageIDs  []int     `json:"message_ids,omitempty"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	NextAttempt time.Time `json:"next_attempt"`
+	// ClaimedUntil is set while an attempt is under way, so the message is
+	// not sent twice. It expires on its own if the bot stopped mid-attempt.
+	ClaimedUntil time.Time `json:"claimed_until,omitzero"`
+	// StatusMessageID is the admin's confirmation of a queued answer,
+	// updated once it is delivered or given up on
+	StatusMessageID int `json:"status_message_id,omitempty"`
+}
+
+// DigestSettings controls batching of new-ticket notifications.
+type DigestSettings struct {
+	Enabled  bool   `json:"enabled"`
+	Interval string `json:"interval,omitempty"`
+}
+
+const (
+	RatingUp   = "up"
+	RatingDown = "down"
+)
+
+// TicketRecord is the persisted history entry of a ticket. Kind is the user
+// state the ticket was created in (e.g. "cv_review"); AnsweredAt is zero
+// while the ticket is still open.
+type TicketRecord struct {
+	ID         int       `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Username   string    `json:"username,omitempty"`
+	Kind       string    `json:"kind"`
+	Category   string    `json:"category,omitempty"`
+	Question   string    `json:"question"`
+	Answer     string    `json:"answer,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	AnsweredAt time.Time `json:"answered_at"`
+	Rating     string    `json:"rating,omitempty"`
+	SurveySent bool      `json:"survey_sent,omitempty"`
+	Resolved   *bool     `json:"resolved,omitempty"`
+	// AnswerMessageID is the admin's message that answered the ticket, so
+	// edits of it can be passed on to the user
+	AnswerMessageID int `json:"answer_message_id,omitempty"`
+	// DeliveredMessageIDs are the messages in the answer's chat that carry
+	// the answer, so it can be retracted
+	DeliveredMessageIDs []int `json:"delivered_message_ids,omitempty"`
+	// GroupChatID is set for questions asked in a group; the answer is
+	// posted there as a reply to GroupMessageID
+	GroupChatID    int64 `json:"group_chat_id,omitempty"`
+	GroupMessageID int   `json:"group_message_id,omitempty"`
+	// Paid is set for priority CV reviews the user paid for
+	Paid bool `json:"paid,omitempty"`
+	// Pinned tickets are listed first in /sessions
+	Pinned bool `json:"pinned,omitempty"`
+	// SnoozedUntil is when a ticket the admin snoozed is notified again
+	SnoozedUntil time.Time `json:"snoozed_until,omitzero"`
+	// AssignedTo is the reviewer the ticket is assigned to, zero for the
+	// admin. AssigneeMessageIDs are the messages that forwarded the ticket
+	// to them; Assignments logs every assignment, oldest first.
+	AssignedTo         int64              `json:"assigned_to,omitempty"`
+	AssigneeMessageIDs []int              `json:"assignee_message_ids,omitempty"`
+	Assignments        []TicketAssignment `json:"assignments,omitempty"`
+	// EscalatedAt is when the ticket was escalated to the senior reviewer
+	EscalatedAt time.Time `json:"escalated_at,omitzero"`
+	// AcknowledgedAt is when the admin acknowledged the ticket
+	AcknowledgedAt time.Time `json:"acknowledged_at,omitzero"`
+	// Comments are the admin's and reviewers' internal comments, oldest
+	// first; the user never sees them
+	Comments []TicketComment `json:"comments,omitempty"`
+	// ArchivedAt is when the admin archived the ticket; archived tickets
+	// leave the active lists but are never purged
+	ArchivedAt time.Time `json:"archived_at,omitzero"`
+	// Files are the keys of the files uploaded with the ticket in the file
+	// archive
+	Files []string `json:"files,omitempty"`
+}
+
+// TicketAssignment records who a ticket was assigned to and when.
+type TicketAssignment struct {
+	To int64     `json:"to"`
+	At time.Time `json:"at"`
+}
+
+// TicketComment is an internal comment on a ticket.
+type TicketComment struct {
+	By   int64     `json:"by"`
+	Text string    `json:"text"`
+	At   time.Time `json:"at"`
+}
+
+// PaymentRecord is a payment for a priority CV review or, with Purpose
+// PaymentSubscription, a month of a subscription. TicketID stays zero until
+// the review it paid for is submitted.
+type PaymentRecord struct {
+	// ChargeID is the payment provider's ID of the charge, used for refunds
+	ChargeID string    `json:"charge_id"`
+	Provider string    `json:"provider"`
+	UserID   int64     `json:"user_id"`
+	Amount   int       `json:"amount"`
+	Currency string    `json:"currency"`
+	PaidAt   time.Time `json:"paid_at"`
+	TicketID int       `json:"ticket_id,omitempty"`
+	Purpose  string    `json:"purpose,omitempty"`
+}
+
+// PaymentSubscription is the Purpose of subscription payments.
+const PaymentSubscription = "subscription"
+
+// Subscription is a user's monthly mentorship subscription, active until
+// ActiveUntil. Stripe renews it by itself; Telegram payments cover one
+// month and are renewed by paying again.
+type Subscription struct {
+	UserID   int64  `json:"user_id"`
+	Provider string `json:"provider"`
+	// StripeID is the Stripe subscription that renews it
+	StripeID    string    `json:"stripe_id,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	ActiveUntil time.Time `json:"active_until"`
+	// Payments counts the months paid, Paid sums them up in the smallest
+	// unit of Currency
+	Payments   int       `json:"payments"`
+	Paid       int       `json:"paid"`
+	Currency   string    `json:"currency"`
+	CanceledAt time.Time `json:"canceled_at,omitzero"`
+}
+
+// Active reports whether the subscription covers now.
+func (s Subscription) Active(now time.Time) bool {
+	return now.Before(s.ActiveUntil)
+}
+
+// ChatID is the chat the answer of the ticket goes to: the group it was
+// asked in or the user's private chat.
+func (t TicketRecord) ChatID() int64 {
+	if t.GroupChatID != 0 {
+		return t.GroupChatID
+	}
+
+	return t.UserID
+}
+
+func OpenStore(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &s.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.data.Templates == nil {
+		s.data.Templates = make(map[string]string)
+	}
+	if s.data.Users == nil {
+		s.data.Users = make(map[int64]*UserRecord)
+	}
+	if s.data.AdminMessages == nil {
+		s.data.AdminMessages = make(map[int]int)
+	}
+	if s.data.PromoCodes == nil {
+		s.data.PromoCodes = make(map[string]*PromoCode)
+	}
+	if s.data.Subscriptions == nil {
+		s.data.Subscriptions = make(map[int64]*Subscription)
+	}
+
+	offset, err := os.ReadFile(s.offsetPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(offset) > 0 {
+		if s.lastUpdateID, err = strconv.Atoi(strings.TrimSpace(string(offset))); err != nil {
+			return nil, fmt.Errorf("invalid offset file %s: %w", s.offsetPath(), err)
+		}
+	}
+	// Stores written before the offset file move their offset there
+	if s.data.LastUpdateID > 0 {
+		if s.data.LastUpdateID > s.lastUpdateID {
+			if err := s.saveOffset(s.data.LastUpdateID); err != nil {
+				return nil, err
+			}
+		}
+		s.data.LastUpdateID = 0
+	}
+
+	// Stores written before question counts were kept start from the
+	// tickets on file
+	counted := make(map[int64]bool)
+	for _, user := range s.data.Users {
+		counted[user.ID] = user.Questions > 0
+	}
+	for _, ticket := range s.data.Tickets {
+		if user, exists := s.data.Users[ticket.UserID]; exists && !counted[user.ID] {
+			user.Questions++
+		}
+	}
+
+	return s, nil
+}
+
+// save must be called with s.mu held.
+func (s *Store) save() error {
+	content, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// saveTickets is save for changes to tickets. It must be called with s.mu
+// held.
+func (s *Store) saveTickets() error {
+	s.ticketsRevision++
+
+	return s.save()
+}
+
+// TicketsRevision changes whenever a ticket is added, changed or removed,
+// so derived data such as a search index knows when to rebuild. Changes to
+// other data, e.g. the offset saved for every update, leave it as is.
+func (s *Store) TicketsRevision() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ticketsRevision
+}
+
+// Ping verifies that the data directory is still writable.
+func (s *Store) Ping() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	probe, err := os.CreateTemp(filepath.Dir(s.path), ".ping-*")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+
+	return os.Remove(probe.Name())
+}
+
+func (s *Store) NextTicketID() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.LastTicketID++
+	return s.data.LastTicketID, s.save()
+}
+
+func (s *Store) SaveTemplate(name, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Templates[name] = text
+	return s.save()
+}
+
+func (s *Store) DeleteTemplate(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.data.Templates[name]; !exists {
+		return false, nil
+	}
+
+	delete(s.data.Templates, name)
+	return true, s.save()
+}
+
+func (s *Store) Template(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	text, exists := s.data.Templates[name]
+	return text, exists
+}
+
+func (s *Store) TemplateNames() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names := make([]string, 0, len(s.data.Templates))
+	for name := range s.data.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (s *Store) AddTicket(record TicketRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Tickets = append(s.data.Tickets, record)
+	if user, exists := s.data.Users[record.UserID]; exists {
+		user.Questions++
+	}
+
+	return s.saveTickets()
+}
+
+func (s *Store) AnswerTicket(ticketID int, answer string, answeredAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		if s.data.Tickets[i].ID == ticketID {
+			s.data.Tickets[i].Answer = answer
+			s.data.Tickets[i].AnsweredAt = answeredAt
+			s.forgetAdminMessages(ticketID)
+			return s.saveTickets()
+		}
+	}
+
+	return nil
+}
+
+// UpdateQuestion replaces the question of a ticket the user edited.
+func (s *Store) UpdateQuestion(ticketID int, question string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		if s.data.Tickets[i].ID == ticketID {
+			s.data.Tickets[i].Question = question
+			return s.saveTickets()
+		}
+	}
+
+	return nil
+}
+
+// AddTicketFile records the archive key of a file uploaded with a ticket.
+func (s *Store) AddTicketFile(ticketID int, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		ticket := &s.data.Tickets[i]
+		if ticket.ID != ticketID {
+			continue
+		}
+		for _, existing := range ticket.Files {
+			if existing == key {
+				return nil
+			}
+		}
+		ticket.Files = append(ticket.Files, key)
+		return s.saveTickets()
+	}
+
+	return nil
+}
+
+// SetTicketPinned pins or unpins a ticket.
+func (s *Store) SetTicketPinned(ticketID int, pinned bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		if s.data.Tickets[i].ID == ticketID {
+			s.data.Tickets[i].Pinned = pinned
+			return s.saveTickets()
+		}
+	}
+
+	return nil
+}
+
+// SnoozeTicket sets when a snoozed ticket is notified again; the zero time
+// ends the snooze.
+func (s *Store) SnoozeTicket(ticketID int, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		if s.data.Tickets[i].ID == ticketID {
+			s.data.Tickets[i].SnoozedUntil = until
+			return s.saveTickets()
+		}
+	}
+
+	return nil
+}
+
+// AssignTicket assigns a ticket to a reviewer, or back to the admin with a
+// zero assignee, and logs the assignment.
+func (s *Store) AssignTicket(ticketID int, assignee int64, messageIDs []int, assignment TicketAssignment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		ticket := &s.data.Tickets[i]
+		if ticket.ID == ticketID {
+			ticket.AssignedTo = assignee
+			ticket.AssigneeMessageIDs = messageIDs
+			ticket.Assignments = append(ticket.Assignments, assignment)
+			return s.saveTickets()
+		}
+	}
+
+	return nil
+}
+
+// AcknowledgeTicket records when the admin acknowledged a ticket.
+func (s *Store) AcknowledgeTicket(ticketID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		if s.data.Tickets[i].ID == ticketID {
+			s.data.Tickets[i].AcknowledgedAt = at
+			return s.saveTickets()
+		}
+	}
+
+	return nil
+}
+
+// EscalateTicket records when a ticket was escalated.
+func (s *Store) EscalateTicket(ticketID int, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		if s.data.Tickets[i].ID == ticketID {
+			s.data.Tickets[i].EscalatedAt = at
+			return s.saveTickets()
+		}
+	}
+
+	return nil
+}
+
+// AddTicketComment attaches an internal comment to a ticket. It reports
+// false for an unknown ticket.
+func (s *Store) AddTicketComment(ticketID int, comment TicketComment) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		if s.data.Tickets[i].ID == ticketID {
+			s.data.Tickets[i].Comments = append(s.data.Tickets[i].Comments, comment)
+			return true, s.saveTickets()
+		}
+	}
+
+	return false, nil
+}
+
+// AssignedTicket returns the open ticket assigned to a reviewer that one of
+// their messages forwarded.
+func (s *Store) AssignedTicket(reviewerID int64, messageID int) (TicketRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ticket := range s.data.Tickets {
+		if ticket.AssignedTo == reviewerID && ticket.AnsweredAt.IsZero() && ticket.ArchivedAt.IsZero() &&
+			slices.Contains(ticket.AssigneeMessageIDs, messageID) {
+			return ticket, true
+		}
+	}
+
+	return TicketRecord{}, false
+}
+
+// SetAnswerMessage records the admin's message that answered a ticket.
+func (s *Store) SetAnswerMessage(ticketID, messageID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		if s.data.Tickets[i].ID == ticketID {
+			s.data.Tickets[i].AnswerMessageID = messageID
+			return s.saveTickets()
+		}
+	}
+
+	return nil
+}
+
+// AddDeliveredMessages records messages in the user's chat that carry the
+// answer of a ticket.
+func (s *Store) AddDeliveredMessages(ticketID int, messageIDs []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		if s.data.Tickets[i].ID == ticketID {
+			s.data.Tickets[i].DeliveredMessageIDs = append(s.data.Tickets[i].DeliveredMessageIDs, messageIDs...)
+			return s.saveTickets()
+		}
+	}
+
+	return nil
+}
+
+// ReopenTicket removes the answer of a ticket, and everything that followed
+// it such as the rating, and returns the ticket as it was before. It reports
+// false when the ticket does not exist or is not answered.
+func (s *Store) ReopenTicket(ticketID int) (TicketRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		ticket := &s.data.Tickets[i]
+		if ticket.ID != ticketID || ticket.AnsweredAt.IsZero() {
+			continue
+		}
+
+		answered := *ticket
+		ticket.Answer = ""
+		ticket.AnsweredAt = time.Time{}
+		ticket.Rating = ""
+		ticket.SurveySent = false
+		ticket.Resolved = nil
+		ticket.AnswerMessageID = 0
+		ticket.DeliveredMessageIDs = nil
+		return answered, true, s.saveTickets()
+	}
+
+	return TicketRecord{}, false, nil
+}
+
+// TicketByAnswerMessage returns the ticket answered by the admin's message.
+func (s *Store) TicketByAnswerMessage(messageID int) (TicketRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ticket := range s.data.Tickets {
+		if ticket.AnswerMessageID == messageID && !ticket.AnsweredAt.IsZero() {
+			return ticket, true
+		}
+	}
+
+	return TicketRecord{}, false
+}
+
+// UpdateAnswer replaces the answer of an answered ticket, keeping the time
+// it was first answered.
+func (s *Store) UpdateAnswer(ticketID int, answer string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		if s.data.Tickets[i].ID == ticketID {
+			s.data.Tickets[i].Answer = answer
+			return s.saveTickets()
+		}
+	}
+
+	return nil
+}
+
+// RateTicket records a rating on a ticket owned by userID. It reports false
+// when no such ticket exists.
+func (s *Store) RateTicket(ticketID int, userID int64, rating string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		ticket := &s.data.Tickets[i]
+		if ticket.ID == ticketID && ticket.UserID == userID {
+			ticket.Rating = rating
+			return true, s.saveTickets()
+		}
+	}
+
+	return false, nil
+}
+
+// PurgeAnsweredTickets removes tickets answered before cutoff and returns
+// them. With dryRun the tickets are only returned. Open and archived
+// tickets are always kept.
+func (s *Store) PurgeAnsweredTickets(cutoff time.Time, dryRun bool) ([]TicketRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := make([]TicketRecord, 0, len(s.data.Tickets))
+	var purged []TicketRecord
+	for _, ticket := range s.data.Tickets {
+		if ticket.AnsweredAt.IsZero() || !ticket.AnsweredAt.Before(cutoff) || !ticket.ArchivedAt.IsZero() {
+			kept = append(kept, ticket)
+		} else {
+			purged = append(purged, ticket)
+		}
+	}
+
+	if dryRun || len(purged) == 0 {
+		return purged, nil
+	}
+
+	s.data.Tickets = kept
+	return purged, s.saveTickets()
+}
+
+func (s *Store) Tickets() []TicketRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tickets := make([]TicketRecord, len(s.data.Tickets))
+	copy(tickets, s.data.Tickets)
+
+	return tickets
+}
+
+// TicketsAwaitingSurvey returns answered tickets older than answeredBefore
+// that have not received a follow-up survey yet.
+func (s *Store) TicketsAwaitingSurvey(answeredBefore time.Time) []TicketRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []TicketRecord
+	for _, ticket := range s.data.Tickets {
+		// Questions asked in a group were answered in public, the asker
+		// gets no survey in private
+		if !ticket.SurveySent && ticket.GroupChatID == 0 && !ticket.AnsweredAt.IsZero() && ticket.AnsweredAt.Before(answeredBefore) {
+			due = append(due, ticket)
+		}
+	}
+
+	return due
+}
+
+func (s *Store) MarkSurveySent(ticketID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		if s.data.Tickets[i].ID == ticketID {
+			s.data.Tickets[i].SurveySent = true
+			return s.saveTickets()
+		}
+	}
+
+	return nil
+}
+
+// ResolveTicket stores the survey answer for a ticket owned by userID. It
+// reports false when no such ticket exists or it was already answered.
+func (s *Store) ResolveTicket(ticketID int, userID int64, resolved bool) (TicketRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		ticket := &s.data.Tickets[i]
+		if ticket.ID == ticketID && ticket.UserID == userID {
+			if ticket.Resolved != nil {
+				return *ticket, false, nil
+			}
+			ticket.Resolved = &resolved
+			return *ticket, true, s.saveTickets()
+		}
+	}
+
+	return TicketRecord{}, false, nil
+}
+
+// UserTickets returns the answered tickets of a user, newest first.
+func (s *Store) UserTickets(userID int64) []TicketRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tickets []TicketRecord
+	for i := len(s.data.Tickets) - 1; i >= 0; i-- {
+		if s.data.Tickets[i].UserID == userID && !s.data.Tickets[i].AnsweredAt.IsZero() {
+			tickets = append(tickets, s.data.Tickets[i])
+		}
+	}
+
+	return tickets
+}
+
+// CountUserTickets counts the tickets of a kind a user opened since a
+// time, answered or not.
+func (s *Store) CountUserTickets(userID int64, kind string, since time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, ticket := range s.data.Tickets {
+		if ticket.UserID == userID && ticket.Kind == kind && !ticket.CreatedAt.Before(since) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// OpenTickets returns the tickets that are neither answered nor archived,
+// oldest first.
+func (s *Store) OpenTickets() []TicketRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var tickets []TicketRecord
+	for _, ticket := range s.data.Tickets {
+		if ticket.AnsweredAt.IsZero() && ticket.ArchivedAt.IsZero() {
+			tickets = append(tickets, ticket)
+		}
+	}
+
+	return tickets
+}
+
+func (s *Store) DigestSettings() DigestSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.data.Digest
+}
+
+func (s *Store) SetDigestSettings(settings DigestSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Digest = settings
+	return s.save()
+}
+
+// RecordUser remembers when a user was first and last seen, the usernames
+// they went by and the language their client reports. It reports whether
+// the user is new.
+func (s *Store) RecordUser(userID int64, username, languageCode string, seenAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		user = &UserRecord{ID: userID, FirstSeen: seenAt, LastActive: seenAt, TelegramLanguage: languageCode}
+		if username != "" {
+			user.Usernames = []string{username}
+		}
+		s.data.Users[userID] = user
+		return true, s.save()
+	}
+
+	changed := false
+	if languageCode != "" && user.TelegramLanguage != languageCode {
+		user.TelegramLanguage = languageCode
+		changed = true
+	}
+	if username != "" && (len(user.Usernames) == 0 || user.Usernames[len(user.Usernames)-1] != username) {
+		user.Usernames = append(user.Usernames, username)
+		changed = true
+	}
+	// Activity is kept to the minute so a chatty user does not rewrite
+	// the store on every message
+	if seenAt.Sub(user.LastActive) >= lastActiveResolution {
+		user.LastActive = seenAt
+		changed = true
+	}
+
+	if !changed {
+		return false, nil
+	}
+	return false, s.save()
+}
+
+// UserLanguage returns the language a user picked, falling back to the one
+// their client reports, or "" when unknown.
+func (s *Store) UserLanguage(userID int64) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		return ""
+	}
+	if user.Language != "" {
+		return user.Language
+	}
+
+	return user.TelegramLanguage
+}
+
+func (s *Store) SetUserLanguage(userID int64, language string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		user = &UserRecord{ID: userID, FirstSeen: now}
+		s.data.Users[userID] = user
+	}
+	user.Language = language
+
+	return s.save()
+}
+
+func (s *Store) Users() []UserRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	users := make([]UserRecord, 0, len(s.data.Users))
+	for _, user := range s.data.Users {
+		users = append(users, *user)
+	}
+
+	return users
+}
+
+func (s *Store) User(userID int64) (UserRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		return UserRecord{}, false
+	}
+
+	return *user, true
+}
+
+// SetReferrer records who invited a user. A user is only ever credited to
+// the first referrer; it reports whether the referral was recorded.
+func (s *Store) SetReferrer(userID, referrerID int64, at time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists || user.ReferredBy != 0 {
+		return false, nil
+	}
+	user.ReferredBy = referrerID
+	user.ReferredAt = at
+
+	return true, s.save()
+}
+
+func (s *Store) Ticket(ticketID int) (TicketRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ticket := range s.data.Tickets {
+		if ticket.ID == ticketID {
+			return ticket, true
+		}
+	}
+
+	return TicketRecord{}, false
+}
+
+// MapAdminMessages records the admin notification messages of an open
+// ticket.
+func (s *Store) MapAdminMessages(ticketID int, messageIDs []int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, messageID := range messageIDs {
+		s.data.AdminMessages[messageID] = ticketID
+	}
+
+	return s.save()
+}
+
+// AdminMessageTicket returns the ID of the open ticket an admin
+// notification message belongs to.
+func (s *Store) AdminMessageTicket(messageID int) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ticketID, exists := s.data.AdminMessages[messageID]
+	return ticketID, exists
+}
+
+// AdminMessages returns which open ticket each admin notification message
+// announces.
+func (s *Store) AdminMessages() map[int]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return maps.Clone(s.data.AdminMessages)
+}
+
+// forgetAdminMessages must be called with s.mu held.
+func (s *Store) forgetAdminMessages(ticketID int) {
+	for messageID, candidate := range s.data.AdminMessages {
+		if candidate == ticketID {
+			delete(s.data.AdminMessages, messageID)
+		}
+	}
+}
+
+// EnqueueOutbox persists message and returns the ID assigned to it.
+func (s *Store) EnqueueOutbox(message OutboxMessage) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.LastOutboxID++
+	message.ID = s.data.LastOutboxID
+	s.data.Outbox = append(s.data.Outbox, message)
+
+	return message.ID, s.save()
+}
+
+// DueOutbox returns the queued messages whose next attempt is due and that
+// no attempt has claimed.
+func (s *Store) DueOutbox(now time.Time) []OutboxMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []OutboxMessage
+	for _, message := range s.data.Outbox {
+		if message.due(now) {
+			due = append(due, message)
+		}
+	}
+
+	return due
+}
+
+// ClaimDueOutbox returns the same messages as DueOutbox and claims them
+// until now+lease, so they are not picked up again while being sent.
+func (s *Store) ClaimDueOutbox(now time.Time, lease time.Duration) ([]OutboxMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []OutboxMessage
+	for i := range s.data.Outbox {
+		if s.data.Outbox[i].due(now) {
+			s.data.Outbox[i].ClaimedUntil = now.Add(lease)
+			due = append(due, s.data.Outbox[i])
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	return due, s.save()
+}
+
+// due reports whether the next attempt at m is due and unclaimed at now.
+func (m OutboxMessage) due(now time.Time) bool {
+	return !now.Before(m.NextAttempt) && !now.Before(m.ClaimedUntil)
+}
+
+func (s *Store) UpdateOutbox(message OutboxMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Outbox {
+		if s.data.Outbox[i].ID == message.ID {
+			s.data.Outbox[i] = message
+			return s.save()
+		}
+	}
+
+	return nil
+}
+
+// SetOutboxStatusMessage records the admin's message about a queued
+// outbox message.
+func (s *Store) SetOutboxStatusMessage(id, messageID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Outbox {
+		if s.data.Outbox[i].ID == id {
+			s.data.Outbox[i].StatusMessageID = messageID
+			return s.save()
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) RemoveOutbox(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Outbox {
+		if s.data.Outbox[i].ID == id {
+			s.data.Outbox = append(s.data.Outbox[:i], s.data.Outbox[i+1:]...)
+			return s.save()
+		}
+	}
+
+	return nil
+}
+
+// AddPendingScan records an upload whose scan started.
+func (s *Store) AddPendingScan(scan PendingScan) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.PendingScans = append(s.data.PendingScans, scan)
+	return s.save()
+}
+
+// RemovePendingScan forgets the scan of the upload in message messageID of
+// chatID once it finished.
+func (s *Store) RemovePendingScan(chatID int64, messageID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, scan := range s.data.PendingScans {
+		if scan.ChatID == chatID && scan.MessageID == messageID {
+			s.data.PendingScans = slices.Delete(s.data.PendingScans, i, i+1)
+			return s.save()
+		}
+	}
+
+	return nil
+}
+
+// TakePendingScans returns the scans still on file and forgets them.
+func (s *Store) TakePendingScans() ([]PendingScan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scans := s.data.PendingScans
+	if len(scans) == 0 {
+		return nil, nil
+	}
+	s.data.PendingScans = nil
+
+	return scans, s.save()
+}
+
+func (s *Store) OutboxSize() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.data.Outbox)
+}
+
+// LastUpdateID is the ID of the last Telegram update the bot finished
+// handling, so updates are not handled twice across restarts.
+func (s *Store) LastUpdateID() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastUpdateID
+}
+
+// SetLastUpdateID persists the ID of a handled update to the offset file,
+// without rewriting the data file.
+func (s *Store) SetLastUpdateID(updateID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if updateID <= s.lastUpdateID {
+		return nil
+	}
+
+	return s.saveOffset(updateID)
+}
+
+// offsetPath is the file next to the data file that keeps the ID of the
+// last handled update.
+func (s *Store) offsetPath() string {
+	return s.path + ".offset"
+}
+
+// saveOffset must be called with s.mu held, or before the store is shared.
+func (s *Store) saveOffset(updateID int) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := s.offsetPath() + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(updateID)+"\n"), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.offsetPath()); err != nil {
+		return err
+	}
+
+	s.lastUpdateID = updateID
+	return nil
+}
+
+// AddPayment records a payment. It reports false for a charge that is
+// already on file, e.g. from a redelivered notification.
+func (s *Store) AddPayment(record PaymentRecord) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, payment := range s.data.Payments {
+		if payment.ChargeID == record.ChargeID {
+			return false, nil
+		}
+	}
+
+	s.data.Payments = append(s.data.Payments, record)
+	return true, s.save()
+}
+
+// UnusedPayment returns the oldest payment of a user that no ticket used yet.
+func (s *Store) UnusedPayment(userID int64) (PaymentRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, payment := range s.data.Payments {
+		if payment.UserID == userID && payment.TicketID == 0 && payment.Purpose == "" {
+			return payment, true
+		}
+	}
+
+	return PaymentRecord{}, false
+}
+
+// UsePayment assigns the oldest unused payment of a user to a ticket. It
+// reports false if the user has none.
+func (s *Store) UsePayment(userID int64, ticketID int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Payments {
+		if s.data.Payments[i].UserID == userID && s.data.Payments[i].TicketID == 0 && s.data.Payments[i].Purpose == "" {
+			s.data.Payments[i].TicketID = ticketID
+			return true, s.save()
+		}
+	}
+
+	return false, nil
+}
+
+// SavePromoCode creates or replaces a promo code. Replacing keeps the uses
+// counted so far.
+func (s *Store) SavePromoCode(promo PromoCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	promo.Code = strings.ToUpper(promo.Code)
+	if existing, exists := s.data.PromoCodes[promo.Code]; exists {
+		promo.Uses = existing.Uses
+	}
+	s.data.PromoCodes[promo.Code] = &promo
+
+	return s.save()
+}
+
+func (s *Store) DeletePromoCode(code string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code = strings.ToUpper(code)
+	if _, exists := s.data.PromoCodes[code]; !exists {
+		return false, nil
+	}
+
+	delete(s.data.PromoCodes, code)
+	return true, s.save()
+}
+
+// PromoCodes returns all promo codes sorted by code.
+func (s *Store) PromoCodes() []PromoCode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	codes := make([]PromoCode, 0, len(s.data.PromoCodes))
+	for _, promo := range s.data.PromoCodes {
+		codes = append(codes, *promo)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+
+	return codes
+}
+
+// RedeemPromoCode counts a use of a code by a user and makes its discount
+// the user's pending discount. Each user can redeem a code once.
+func (s *Store) RedeemPromoCode(userID int64, code string, now time.Time) (PromoCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	promo, exists := s.data.PromoCodes[strings.ToUpper(code)]
+	if !exists {
+		return PromoCode{}, ErrPromoNotFound
+	}
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		user = &UserRecord{ID: userID, FirstSeen: now}
+		s.data.Users[userID] = user
+	}
+	for _, redeemed := range user.PromoCodes {
+		if redeemed == promo.Code {
+			return PromoCode{}, ErrPromoRedeemed
+		}
+	}
+
+	if !promo.ExpiresAt.IsZero() && !now.Before(promo.ExpiresAt) {
+		return PromoCode{}, ErrPromoExpired
+	}
+	if promo.MaxUses > 0 && promo.Uses >= promo.MaxUses {
+		return PromoCode{}, ErrPromoUsedUp
+	}
+
+	promo.Uses++
+	user.PromoCodes = append(user.PromoCodes, promo.Code)
+	if promo.Discount > user.PromoDiscount {
+		user.PromoDiscount = promo.Discount
+	}
+
+	return *promo, s.save()
+}
+
+// ClearPromoDiscount drops a user's pending discount once a review was paid
+// with it.
+func (s *Store) ClearPromoDiscount(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists || user.PromoDiscount == 0 {
+		return nil
+	}
+	user.PromoDiscount = 0
+
+	return s.save()
+}
+
+// RenewSubscription records a subscription payment of a user and extends
+// the subscription to until, or by a month from its current end if until is
+// zero. It reports false for a payment that is already on file.
+func (s *Store) RenewSubscription(payment PaymentRecord, stripeID string, until time.Time) (Subscription, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.data.Payments {
+		if existing.ChargeID == payment.ChargeID {
+			return Subscription{}, false, nil
+		}
+	}
+	payment.Purpose = PaymentSubscription
+	s.data.Payments = append(s.data.Payments, payment)
+
+	subscription, exists := s.data.Subscriptions[payment.UserID]
+	if !exists || !subscription.Active(payment.PaidAt) {
+		subscription = &Subscription{UserID: payment.UserID, StartedAt: payment.PaidAt, ActiveUntil: payment.PaidAt}
+		s.data.Subscriptions[payment.UserID] = subscription
+	}
+	subscription.Provider = payment.Provider
+	subscription.Currency = payment.Currency
+	subscription.CanceledAt = time.Time{}
+	if stripeID != "" {
+		subscription.StripeID = stripeID
+	}
+	subscription.Payments++
+	subscription.Paid += payment.Amount
+
+	if until.IsZero() {
+		until = subscription.ActiveUntil.AddDate(0, 1, 0)
+	}
+	if until.After(subscription.ActiveUntil) {
+		subscription.ActiveUntil = until
+	}
+
+	return *subscription, true, s.save()
+}
+
+func (s *Store) Subscription(userID int64) (Subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription, exists := s.data.Subscriptions[userID]
+	if !exists {
+		return Subscription{}, false
+	}
+
+	return *subscription, true
+}
+
+// Subscriptions returns all subscriptions, the ones ending last first.
+func (s *Store) Subscriptions() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriptions := make([]Subscription, 0, len(s.data.Subscriptions))
+	for _, subscription := range s.data.Subscriptions {
+		subscriptions = append(subscriptions, *subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].ActiveUntil.After(subscriptions[j].ActiveUntil)
+	})
+
+	return subscriptions
+}
+
+// CancelSubscription records that a Stripe subscription will not renew. It
+// stays active until the end of the paid month.
+func (s *Store) CancelSubscription(stripeID string, at time.Time) (Subscription, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, subscription := range s.data.Subscriptions {
+		if subscription.StripeID == stripeID {
+			subscription.CanceledAt = at
+			return *subscription, true, s.save()
+		}
+	}
+
+	return Subscription{}, false, nil
+}
+
+// AvailabilityWindow is a weekly recurring time the admin takes calls, e.g.
+// Mondays from "10:00" to "12:00" in the booking time zone.
+type AvailabilityWindow struct {
+	Weekday time.Weekday `json:"weekday"`
+	Start   string       `json:"start"`
+	End     string       `json:"end"`
+}
+
+// Booking is a consultation call a user booked.
+type Booking struct {
+	ID         int       `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Username   string    `json:"username,omitempty"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	CreatedAt  time.Time `json:"created_at"`
+	CanceledAt time.Time `json:"canceled_at,omitzero"`
+	// Reminders are the reminders already sent, by how long before the
+	// call they were due, e.g. "1h0m0s"
+	Reminders []string `json:"reminders,omitempty"`
+}
+
+// ErrSlotTaken is returned for a booking that overlaps another one.
+var ErrSlotTaken = errors.New("slot is already booked")
+
+// Availability returns the weekly availability, by weekday and start.
+func (s *Store) Availability() []AvailabilityWindow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	windows := append([]AvailabilityWindow(nil), s.data.Availability...)
+	sort.Slice(windows, func(i, j int) bool {
+		if windows[i].Weekday != windows[j].Weekday {
+			return windows[i].Weekday < windows[j].Weekday
+		}
+		return windows[i].Start < windows[j].Start
+	})
+
+	return windows
+}
+
+// SetAvailability replaces the windows of the given weekdays.
+func (s *Store) SetAvailability(days []time.Weekday, windows []AvailabilityWindow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replaced := make(map[time.Weekday]bool, len(days))
+	for _, day := range days {
+		replaced[day] = true
+	}
+
+	kept := windows
+	for _, window := range s.data.Availability {
+		if !replaced[window.Weekday] {
+			kept = append(kept, window)
+		}
+	}
+	s.data.Availability = kept
+
+	return s.save()
+}
+
+// AddBooking numbers and saves a booking unless it overlaps an upcoming
+// booking that is not canceled.
+func (s *Store) AddBooking(booking Booking) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.data.Bookings {
+		if existing.CanceledAt.IsZero() && existing.Start.Before(booking.End) && booking.Start.Before(existing.End) {
+			return Booking{}, ErrSlotTaken
+		}
+	}
+
+	s.data.LastBookingID++
+	booking.ID = s.data.LastBookingID
+	s.data.Bookings = append(s.data.Bookings, booking)
+
+	return booking, s.save()
+}
+
+// UpcomingBookings returns the bookings that are not canceled and have not
+// ended at now, soonest first.
+func (s *Store) UpcomingBookings(now time.Time) []Booking {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var bookings []Booking
+	for _, booking := range s.data.Bookings {
+		if booking.CanceledAt.IsZero() && booking.End.After(now) {
+			bookings = append(bookings, booking)
+		}
+	}
+	sort.Slice(bookings, func(i, j int) bool {
+		return bookings[i].Start.Before(bookings[j].Start)
+	})
+
+	return bookings
+}
+
+// CancelBooking cancels an upcoming booking. It reports false for an
+// unknown or already canceled booking.
+func (s *Store) CancelBooking(id int, at time.Time) (Booking, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Bookings {
+		booking := &s.data.Bookings[i]
+		if booking.ID == id && booking.CanceledAt.IsZero() {
+			booking.CanceledAt = at
+			return *booking, true, s.save()
+		}
+	}
+
+	return Booking{}, false, nil
+}
+
+// MarkBookingReminded records that a reminder of a booking was sent.
+func (s *Store) MarkBookingReminded(id int, reminder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Bookings {
+		if s.data.Bookings[i].ID == id {
+			s.data.Bookings[i].Reminders = append(s.data.Bookings[i].Reminders, reminder)
+			return s.save()
+		}
+	}
+
+	return nil
+}
+
+// RecordFlowStart remembers that a user started a question or CV review.
+func (s *Store) RecordFlowStart(userID int64, flow string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		return nil
+	}
+	user.StartedFlow = flow
+	user.StartedFlowAt = at
+
+	return s.save()
+}
+
+// AbandonedUsers returns the users who started a flow between from and to
+// and have not opened a ticket since. Users who opted out, or were already
+// asked to come back after that start or since notBefore, are left out.
+func (s *Store) AbandonedUsers(from, to, notBefore time.Time) []UserRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var users []UserRecord
+	for _, user := range s.data.Users {
+		if user.NoReengagement || user.StartedFlowAt.Before(from) || !user.StartedFlowAt.Before(to) {
+			continue
+		}
+		if user.ReengagedAt.After(user.StartedFlowAt) || user.ReengagedAt.After(notBefore) {
+			continue
+		}
+
+		submitted := false
+		for _, ticket := range s.data.Tickets {
+			if ticket.UserID == user.ID && !ticket.CreatedAt.Before(user.StartedFlowAt) {
+				submitted = true
+				break
+			}
+		}
+		if !submitted {
+			users = append(users, *user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return users
+}
+
+// MarkReengaged records that a user was asked to come back.
+func (s *Store) MarkReengaged(userID int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		return nil
+	}
+	user.ReengagedAt = at
+
+	return s.save()
+}
+
+// RecordReturn records that a user opened a ticket at a time. It reports
+// whether this is the first ticket since they were asked to come back
+// within window, i.e. the re-engagement worked.
+func (s *Store) RecordReturn(userID int64, at time.Time, window time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists || user.ReengagedAt.IsZero() || user.ReturnedAt.After(user.ReengagedAt) || at.Sub(user.ReengagedAt) > window {
+		return false, nil
+	}
+	user.ReturnedAt = at
+
+	return true, s.save()
+}
+
+// SetReengagementOptOut stops or allows messages asking a user to come
+// back.
+func (s *Store) SetReengagementOptOut(userID int64, optOut bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		return nil
+	}
+	user.NoReengagement = optOut
+
+	return s.save()
+}
+
+// AssignWelcomeVariant records the welcome menu variant a user was given.
+func (s *Store) AssignWelcomeVariant(userID int64, variant string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		return nil
+	}
+	user.WelcomeVariant = variant
+	user.WelcomeVariantAt = at
+
+	return s.save()
+}
+
+// MarkTutorialShown records that a user was shown the first-run tutorial.
+func (s *Store) MarkTutorialShown(userID int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		return nil
+	}
+	user.TutorialShownAt = at
+
+	return s.save()
+}
+
+// AcceptTerms records that a user accepted a version of the terms.
+func (s *Store) AcceptTerms(userID int64, version string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		return nil
+	}
+	user.TermsVersion = version
+	user.TermsAcceptedAt = at
+
+	return s.save()
+}
+
+// MarkVerified records that a user passed the new-user verification.
+func (s *Store) MarkVerified(userID int64, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		return nil
+	}
+	user.VerifiedAt = at
+
+	return s.save()
+}
+
+// AddUserNote attaches a note to a user. It reports false for a user the
+// bot has never seen.
+func (s *Store) AddUserNote(userID int64, note UserNote) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists {
+		return false, nil
+	}
+	user.Notes = append(user.Notes, note)
+
+	return true, s.save()
+}
+
+// ClearUserNotes deletes all notes about a user and returns how many there
+// were.
+func (s *Store) ClearUserNotes(userID int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists || len(user.Notes) == 0 {
+		return 0, nil
+	}
+	cleared := len(user.Notes)
+	user.Notes = nil
+
+	return cleared, s.save()
+}
+
+// TagUser adds a label to a user. It reports false for a user the bot has
+// never seen or who already has the label.
+func (s *Store) TagUser(userID int64, label string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists || slices.Contains(user.Labels, label) {
+		return false, nil
+	}
+	user.Labels = append(user.Labels, label)
+
+	return true, s.save()
+}
+
+// UntagUser removes a label from a user. It reports false if the user did
+// not have it.
+func (s *Store) UntagUser(userID int64, label string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.data.Users[userID]
+	if !exists || !slices.Contains(user.Labels, label) {
+		return false, nil
+	}
+	// A new slice, the old one may be shared with copies of the record
+	var labels []string
+	for _, candidate := range user.Labels {
+		if candidate != label {
+			labels = append(labels, candidate)
+		}
+	}
+	user.Labels = labels
+
+	return true, s.save()
+}
+
+// ArchiveTicket marks a ticket archived at the given time. It reports false
+// for an unknown or already archived ticket.
+func (s *Store) ArchiveTicket(ticketID int, at time.Time) (TicketRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		ticket := &s.data.Tickets[i]
+		if ticket.ID == ticketID {
+			if !ticket.ArchivedAt.IsZero() {
+				return *ticket, false, nil
+			}
+			ticket.ArchivedAt = at
+			s.forgetAdminMessages(ticketID)
+			return *ticket, true, s.saveTickets()
+		}
+	}
+
+	return TicketRecord{}, false, nil
+}
+
+// UnarchiveTicket brings an archived ticket back. It reports false for an
+// unknown ticket or one that is not archived.
+func (s *Store) UnarchiveTicket(ticketID int) (TicketRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.data.Tickets {
+		ticket := &s.data.Tickets[i]
+		if ticket.ID == ticketID {
+			if ticket.ArchivedAt.IsZero() {
+				return *ticket, false, nil
+			}
+			ticket.ArchivedAt = time.Time{}
+			return *ticket, true, s.saveTickets()
+		}
+	}
+
+	return TicketRecord{}, false, nil
+}
+
+// ArchivedTickets returns the archived tickets, most recently archived
+// first.
+func (s *Store) ArchivedTickets() []TicketRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var archived []TicketRecord
+	for _, ticket := range s.data.Tickets {
+		if !ticket.ArchivedAt.IsZero() {
+			archived = append(archived, ticket)
+		}
+	}
+	sort.SliceStable(archived, func(i, j int) bool {
+		return archived[i].ArchivedAt.After(archived[j].ArchivedAt)
+	})
+
+	return archived
+}
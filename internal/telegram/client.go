@@ -0,0 +1,289 @@
+// Package telegram wraps the Bot API client with the delivery guarantees the
+// bot relies on: retries with backoff, per-chat rate limit pauses and
+// splitting of long messages.
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxAttempts    = 4
+	retryBaseDelay = 500 * time.Millisecond
+	// maxRetryDelay bounds a single wait, since most sends happen while
+	// the bot's state is locked
+	maxRetryDelay = 10 * time.Second
+
+	downloadTimeout = 30 * time.Second
+	// MaxDownloadSize is the largest file the Bot API lets bots download
+	MaxDownloadSize = 20 << 20
+)
+
+// Client sends messages through the Bot API on behalf of the bot.
+type Client struct {
+	api    *tgbotapi.BotAPI
+	logger *logrus.Logger
+	pauses chatPauses
+}
+
+func NewClient(api *tgbotapi.BotAPI, logger *logrus.Logger) *Client {
+	return &Client{api: api, logger: logger}
+}
+
+// Self is the bot's own Telegram user.
+func (c *Client) Self() tgbotapi.User {
+	return c.api.Self
+}
+
+// Request calls a Bot API method that does not return a message, such as
+// answering a callback query.
+func (c *Client) Request(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	return c.api.Request(chattable)
+}
+
+// GetFile looks up a file sent to the bot so it can be downloaded.
+func (c *Client) GetFile(config tgbotapi.FileConfig) (tgbotapi.File, error) {
+	return c.api.GetFile(config)
+}
+
+// DownloadFile fetches the content of a file sent to the bot.
+func (c *Client) DownloadFile(fileID string) ([]byte, error) {
+	link, err := c.api.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: downloadTimeout}
+	resp, err := client.Get(link)
+	if err != nil {
+		// The link contains the bot token, keep it out of logs
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("download file %s: %w", fileID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download file %s: %s", fileID, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxDownloadSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("download file %s: %w", fileID, err)
+	}
+	if len(data) > MaxDownloadSize {
+		return nil, fmt.Errorf("file %s is larger than %d MB", fileID, MaxDownloadSize>>20)
+	}
+
+	return data, nil
+}
+
+func (c *Client) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return c.api.GetUpdatesChan(config)
+}
+
+// chatPauses remembers the chats Telegram rate limited with retry_after, so
+// further sends to them fail fast instead of hitting the API again.
+type chatPauses struct {
+	mu    sync.Mutex
+	until map[int64]time.Time
+}
+
+func (p *chatPauses) Pause(chatID int64, delay time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.until == nil {
+		p.until = make(map[int64]time.Time)
+	}
+	until := time.Now().Add(delay)
+	if until.After(p.until[chatID]) {
+		p.until[chatID] = until
+	}
+}
+
+// Remaining returns how long sends to chatID must still wait.
+func (p *chatPauses) Remaining(chatID int64) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	until, paused := p.until[chatID]
+	if !paused {
+		return 0
+	}
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		delete(p.until, chatID)
+		return 0
+	}
+
+	return remaining
+}
+
+// chattableChatID returns the chat a message is addressed to, or 0 for
+// configs the bot does not send through Client.Send.
+func chattableChatID(c tgbotapi.Chattable) int64 {
+	switch config := c.(type) {
+	case tgbotapi.MessageConfig:
+		return config.ChatID
+	case tgbotapi.DocumentConfig:
+		return config.ChatID
+	case tgbotapi.EditMessageTextConfig:
+		return config.ChatID
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return config.ChatID
+	default:
+		return 0
+	}
+}
+
+// RateLimitError is returned by Send for a chat Telegram paused with a 429
+// and retry_after. Nothing was sent; callers that must deliver the message
+// retry once RetryAfter has passed, e.g. through the bot's outbox.
+type RateLimitError struct {
+	ChatID     int64
+	RetryAfter time.Duration
+	// Err is the 429 itself, nil when the chat was already paused
+	Err error
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("chat %d is rate limited for another %s", e.ChatID, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter reports how long err asks the caller to wait before sending
+// to the chat again, if it is a RateLimitError.
+func RetryAfter(err error) (time.Duration, bool) {
+	var limited *RateLimitError
+	if !errors.As(err, &limited) {
+		return 0, false
+	}
+
+	return limited.RetryAfter, true
+}
+
+// Send delivers a message through the Bot API, retrying network errors and
+// Telegram server errors with exponential backoff and jitter. Permanent
+// errors such as a user who blocked the bot or an unknown chat are returned
+// right away.
+//
+// A 429 with retry_after pauses only the affected chat. Send never waits
+// out a pause: while it lasts, sends to the chat fail fast with a
+// RateLimitError without calling the API.
+func (c *Client) Send(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	chatID := chattableChatID(chattable)
+
+	for attempt := 1; ; attempt++ {
+		if wait := c.pauses.Remaining(chatID); wait > 0 {
+			return tgbotapi.Message{}, &RateLimitError{ChatID: chatID, RetryAfter: wait}
+		}
+
+		message, err := c.api.Send(chattable)
+		if err == nil {
+			return message, nil
+		}
+
+		var apiErr *tgbotapi.Error
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			wait := time.Duration(apiErr.RetryAfter) * time.Second
+			c.pauses.Pause(chatID, wait)
+			return message, &RateLimitError{ChatID: chatID, RetryAfter: wait, Err: err}
+		}
+
+		delay, retryable := retryDelay(err, attempt)
+		if !retryable || attempt >= maxAttempts {
+			return message, err
+		}
+
+		c.logger.WithError(err).WithFields(logrus.Fields{
+			"attempt": attempt,
+			"delay":   delay,
+		}).Warn("Telegram send failed, retrying")
+		time.Sleep(delay)
+	}
+}
+
+// retryDelay reports whether err is worth retrying and how long to
+// wait before the given attempt is repeated.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	backoff := retryBaseDelay << (attempt - 1)
+	// Jitter keeps concurrent senders from retrying in lockstep
+	delay := backoff/2 + rand.N(backoff/2+1)
+
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		// No API response at all: connection reset, timeout, DNS, ...
+		return min(delay, maxRetryDelay), true
+	}
+
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests:
+		// Only a 429 without retry_after gets here, Send pauses the chat
+		// for the others
+		return min(delay, maxRetryDelay), true
+	case apiErr.Code >= http.StatusInternalServerError:
+		return min(delay, maxRetryDelay), true
+	default:
+		// 400 bad request / chat not found, 403 bot blocked, ...
+		return 0, false
+	}
+}
+
+// PermanentError reports errors that no retry will fix: Telegram rejected
+// the request itself (blocked bot, unknown chat, bad markup).
+func PermanentError(err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.Code >= http.StatusBadRequest && apiErr.Code < http.StatusInternalServerError &&
+		apiErr.Code != http.StatusTooManyRequests
+}
+
+// DeliveryFailure explains for the admin why a message did not reach a
+// chat, e.g. "the user blocked the bot".
+func DeliveryFailure(err error) string {
+	if _, limited := RetryAfter(err); limited {
+		return "Telegram kept rate limiting the bot"
+	}
+
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return fmt.Sprintf("Telegram could not be reached (%v)", err)
+	}
+
+	description := strings.ToLower(apiErr.Message)
+	switch {
+	case strings.Contains(description, "blocked by the user"):
+		return "the user blocked the bot"
+	case strings.Contains(description, "user is deactivated"):
+		return "the user deleted their Telegram account"
+	case strings.Contains(description, "chat not found"):
+		return "the chat does not exist, the user never started the bot or deleted the chat"
+	case strings.Contains(description, "kicked from"):
+		return "the bot was removed from the chat"
+	case apiErr.Code == http.StatusTooManyRequests:
+		return "Telegram kept rate limiting the bot"
+	case apiErr.Code >= http.StatusInternalServerError:
+		return fmt.Sprintf("Telegram had an internal error (%s)", apiErr.Message)
+	default:
+		return fmt.Sprintf("Telegram rejected the message (%s)", apiErr.Message)
+	}
+}
@@ -0,0 +1,95 @@
+package telegram
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// markdownEscaper escapes every character MarkdownV2 treats as markup. Unlike
+// tgbotapi.EscapeText it also escapes the backslash, so text ending in one
+// cannot swallow the next character.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// EscapeMarkdown makes text safe to embed in a MarkdownV2 message: it is
+// shown exactly as written, whatever characters it contains. Everything a
+// user or the admin typed must go through it.
+func EscapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+// FormatMarkdown converts the lightweight markup used in the bot's texts to
+// MarkdownV2 and escapes everything else:
+//
+//	**bold**, `code` and [label](https://example.com)
+//
+// Markup that is not closed is shown as written.
+func FormatMarkdown(text string) string {
+	var out strings.Builder
+
+	for text != "" {
+		if inner, rest, ok := cutDelimited(text, "**", "**"); ok && inner != "" {
+			out.WriteString("*" + EscapeMarkdown(inner) + "*")
+			text = rest
+			continue
+		}
+
+		if inner, rest, ok := cutDelimited(text, "`", "`"); ok && inner != "" {
+			out.WriteString("`" + escapeCode(inner) + "`")
+			text = rest
+			continue
+		}
+
+		if label, rest, ok := cutDelimited(text, "[", "]("); ok && label != "" {
+			if url, rest, ok := strings.Cut(rest, ")"); ok && url != "" && !strings.ContainsAny(url, " \n") {
+				out.WriteString("[" + EscapeMarkdown(label) + "](" + strings.ReplaceAll(url, `\`, `\\`) + ")")
+				text = rest
+				continue
+			}
+		}
+
+		// Plain text up to the next possible markup
+		end := strings.IndexAny(text[1:], "*`[")
+		if end < 0 {
+			end = len(text)
+		} else {
+			end++
+		}
+		out.WriteString(EscapeMarkdown(text[:end]))
+		text = text[end:]
+	}
+
+	return out.String()
+}
+
+// cutDelimited returns the text between open at the start of text and the
+// next close, and what follows close.
+func cutDelimited(text, open, close string) (string, string, bool) {
+	if !strings.HasPrefix(text, open) {
+		return "", "", false
+	}
+
+	inner, rest, ok := strings.Cut(text[len(open):], close)
+	if !ok || strings.Contains(inner, "\n\n") {
+		return "", "", false
+	}
+
+	return inner, rest, true
+}
+
+// escapeCode escapes the characters MarkdownV2 treats as markup inside code.
+func escapeCode(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(text)
+}
+
+// NewMarkdownMessage creates a message whose text is MarkdownV2, e.g. the
+// result of FormatMarkdown.
+func NewMarkdownMessage(chatID int64, text string) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	return msg
+}
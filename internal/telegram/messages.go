@@ -0,0 +1,280 @@
+package telegram
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MessageLimit is the maximum message length accepted by Telegram, measured
+// in UTF-16 code units.
+const MessageLimit = 4096
+
+func utf16Len(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+// SplitMessage chunks text into parts no longer than limit, preferring to cut
+// at paragraph breaks, then line breaks, then spaces.
+func SplitMessage(text string, limit int) []string {
+	var parts []string
+
+	for utf16Len(text) > limit {
+		// Find the longest rune prefix that fits the limit
+		cut, size := 0, 0
+		for i, r := range text {
+			width := len(utf16.Encode([]rune{r}))
+			if size+width > limit {
+				break
+			}
+			size += width
+			cut = i + len(string(r))
+		}
+
+		head := text[:cut]
+		for _, separator := range []string{"\n\n", "\n", " "} {
+			if index := strings.LastIndex(head, separator); index > 0 {
+				head = head[:index]
+				break
+			}
+		}
+
+		parts = append(parts, strings.TrimRight(head, " \n"))
+		text = strings.TrimLeft(text[len(head):], " \n")
+	}
+
+	if text != "" || len(parts) == 0 {
+		parts = append(parts, text)
+	}
+
+	return parts
+}
+
+// markdownToken is a piece of MarkdownV2 text that must stay in one part:
+// a character, an escape, a formatting marker, inline code or a link.
+type markdownToken struct {
+	text string
+	// marker is set for the markers that open and close bold, italic,
+	// underline, strikethrough and spoiler spans
+	marker bool
+}
+
+// markdownMarkers are the span markers, longest first.
+var markdownMarkers = []string{"||", "__", "*", "_", "~"}
+
+// tokenizeMarkdown splits MarkdownV2 text into tokens.
+func tokenizeMarkdown(text string) []markdownToken {
+	var tokens []markdownToken
+	for text != "" {
+		size := 0
+		marker := false
+		switch {
+		case text[0] == '\\' && len(text) > 1:
+			_, width := utf8.DecodeRuneInString(text[1:])
+			size = 1 + width
+		case strings.HasPrefix(text, "```"):
+			size = closingIndex(text, 3, "```")
+		case text[0] == '`':
+			size = closingIndex(text, 1, "`")
+		case text[0] == '[':
+			if end := strings.Index(text, "]("); end > 0 {
+				size = closingIndex(text, end+2, ")")
+			}
+		}
+		if size == 0 {
+			for _, candidate := range markdownMarkers {
+				if strings.HasPrefix(text, candidate) {
+					size, marker = len(candidate), true
+					break
+				}
+			}
+		}
+		if size == 0 {
+			_, size = utf8.DecodeRuneInString(text)
+		}
+
+		tokens = append(tokens, markdownToken{text: text[:size], marker: marker})
+		text = text[size:]
+	}
+
+	return tokens
+}
+
+// closingIndex returns the end of close, searched from start and skipping
+// escapes, or the length of text when it is not closed.
+func closingIndex(text string, start int, close string) int {
+	for i := start; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(text[i:], close) {
+			return i + len(close)
+		}
+	}
+
+	return len(text)
+}
+
+// SplitMarkdown is SplitMessage for MarkdownV2 text. Parts are never cut
+// inside an escape, inline code or a link, and prefer cuts outside
+// formatting. A bold, italic, underline, strikethrough or spoiler span that
+// has to be cut is closed at the end of one part and reopened at the start
+// of the next, so every part is valid on its own.
+func SplitMarkdown(text string, limit int) []string {
+	tokens := tokenizeMarkdown(text)
+
+	var parts []string
+	var open []string
+	for len(tokens) > 0 {
+		reopen := strings.Join(open, "")
+		end, stack := markdownCut(tokens, open, limit-utf16Len(reopen))
+
+		var head strings.Builder
+		head.WriteString(reopen)
+		last := end
+		for last > 0 && isSpaceToken(tokens[last-1]) {
+			last--
+		}
+		for _, token := range tokens[:last] {
+			head.WriteString(token.text)
+		}
+		for i := len(stack) - 1; i >= 0; i-- {
+			head.WriteString(stack[i])
+		}
+		if end == len(tokens) {
+			// The last part needs no closing markers of its own
+			parts = append(parts, strings.TrimRight(head.String(), " \n"))
+			break
+		}
+		parts = append(parts, head.String())
+
+		tokens = tokens[end:]
+		for len(tokens) > 0 && isSpaceToken(tokens[0]) {
+			tokens = tokens[1:]
+		}
+		open = stack
+	}
+
+	if len(parts) == 0 {
+		parts = append(parts, "")
+	}
+
+	return parts
+}
+
+// markdownCut returns how many tokens go into a part that has room for
+// limit UTF-16 code units, with the closing markers of the spans still open
+// at the cut, and those spans. Like SplitMessage it prefers paragraph
+// breaks, then line breaks, then spaces, outside formatting first.
+func markdownCut(tokens []markdownToken, open []string, limit int) (int, []string) {
+	type cut struct {
+		end   int
+		stack []string
+		// separator is 3 before a paragraph break, 2 before a line break, 1
+		// before a space and 0 elsewhere
+		separator int
+	}
+
+	var cuts []cut
+	stack := append([]string(nil), open...)
+	size, closing := 0, utf16Len(strings.Join(stack, ""))
+	for i, token := range tokens {
+		opening := false
+		if token.marker {
+			if index := slices.Index(stack, token.text); index >= 0 {
+				stack = slices.Delete(stack, index, index+1)
+				closing -= utf16Len(token.text)
+			} else {
+				stack = append(stack, token.text)
+				closing += utf16Len(token.text)
+				opening = true
+			}
+		}
+		size += utf16Len(token.text)
+		if size+closing > limit && len(cuts) > 0 {
+			break
+		}
+		// A cut right inside a span would leave it empty in one part
+		if opening || i+1 < len(tokens) && tokens[i+1].marker && slices.Contains(stack, tokens[i+1].text) {
+			continue
+		}
+
+		separator := 0
+		if i+1 < len(tokens) {
+			switch next := tokens[i+1].text; {
+			case next == "\n" && i+2 < len(tokens) && tokens[i+2].text == "\n":
+				separator = 3
+			case next == "\n":
+				separator = 2
+			case next == " ":
+				separator = 1
+			}
+		}
+		cuts = append(cuts, cut{end: i + 1, stack: append([]string(nil), stack...), separator: separator})
+		if size+closing > limit {
+			// A single token longer than the limit goes alone
+			break
+		}
+	}
+
+	if size+closing <= limit || len(cuts) == 0 {
+		return len(tokens), nil
+	}
+
+	for _, outside := range []bool{true, false} {
+		for separator := 3; separator > 0; separator-- {
+			for i := len(cuts) - 1; i >= 0; i-- {
+				if cuts[i].separator == separator && (!outside || len(cuts[i].stack) == 0) {
+					return cuts[i].end, cuts[i].stack
+				}
+			}
+		}
+	}
+	last := cuts[len(cuts)-1]
+
+	return last.end, last.stack
+}
+
+func isSpaceToken(token markdownToken) bool {
+	return token.text == " " || token.text == "\n"
+}
+
+// SendLong sends text to chatID, split into several messages when it exceeds
+// Telegram's limit. The reply markup is attached to the last part.
+func (c *Client) SendLong(chatID int64, text string, markup interface{}) ([]tgbotapi.Message, error) {
+	return c.sendLong(chatID, text, "", markup)
+}
+
+// SendLongMarkdown is SendLong for MarkdownV2 text, split with
+// SplitMarkdown.
+func (c *Client) SendLongMarkdown(chatID int64, text string, markup interface{}) ([]tgbotapi.Message, error) {
+	return c.sendLong(chatID, text, tgbotapi.ModeMarkdownV2, markup)
+}
+
+func (c *Client) sendLong(chatID int64, text, parseMode string, markup interface{}) ([]tgbotapi.Message, error) {
+	parts := SplitMessage(text, MessageLimit)
+	if parseMode == tgbotapi.ModeMarkdownV2 {
+		parts = SplitMarkdown(text, MessageLimit)
+	}
+
+	sent := make([]tgbotapi.Message, 0, len(parts))
+	for i, part := range parts {
+		msg := tgbotapi.NewMessage(chatID, part)
+		msg.ParseMode = parseMode
+		if i == len(parts)-1 && markup != nil {
+			msg.ReplyMarkup = markup
+		}
+
+		message, err := c.Send(msg)
+		if err != nil {
+			return sent, err
+		}
+		sent = append(sent, message)
+	}
+
+	return sent, nil
+}